@@ -0,0 +1,176 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/bbolt"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// APIRootService implements a bolt version of the APIRootService interface
+type APIRootService struct {
+	DataStore *DataStore
+}
+
+type apiRootRecord struct {
+	Path             string    `json:"path"`
+	Title            string    `json:"title"`
+	Description      string    `json:"description"`
+	Versions         []string  `json:"versions"`
+	MaxContentLength int64     `json:"max_content_length"`
+	DefaultPageSize  int64     `json:"default_page_size,omitempty"`
+	MaxPageSize      int64     `json:"max_page_size,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func (r *apiRootRecord) apiRoot() cabby.APIRoot {
+	return cabby.APIRoot{
+		Path:             r.Path,
+		Title:            r.Title,
+		Description:      r.Description,
+		Versions:         r.Versions,
+		MaxContentLength: r.MaxContentLength,
+		DefaultPageSize:  r.DefaultPageSize,
+		MaxPageSize:      r.MaxPageSize,
+		UpdatedAt:        r.UpdatedAt}
+}
+
+// APIRoot will read from the data store and return the resource
+func (s APIRootService) APIRoot(ctx context.Context, path string) (cabby.APIRoot, error) {
+	resource, action := "APIRoot", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.apiRoot(path)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s APIRootService) apiRoot(path string) (cabby.APIRoot, error) {
+	a := cabby.APIRoot{}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		var r apiRootRecord
+		ok, err := get(tx.Bucket(apiRootsBucket), []byte(path), &r)
+		if ok {
+			a = r.apiRoot()
+		}
+		return err
+	})
+	return a, err
+}
+
+// APIRoots will read from the data store and return the resource
+func (s APIRootService) APIRoots(ctx context.Context) ([]cabby.APIRoot, error) {
+	resource, action := "APIRoots", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.apiRoots()
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s APIRootService) apiRoots() ([]cabby.APIRoot, error) {
+	as := []cabby.APIRoot{}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(apiRootsBucket).ForEach(func(k, v []byte) error {
+			var r apiRootRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			as = append(as, r.apiRoot())
+			return nil
+		})
+	})
+	return as, err
+}
+
+// CreateAPIRoot creates a user in the data store
+func (s APIRootService) CreateAPIRoot(ctx context.Context, a cabby.APIRoot) error {
+	resource, action := "APIRoot", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := a.Validate()
+	if err == nil {
+		err = s.createAPIRoot(a)
+	} else {
+		log.WithFields(log.Fields{"api_root": a, "error": err}).Error("Invalid API Root")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s APIRootService) createAPIRoot(a cabby.APIRoot) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(apiRootsBucket)
+		if b.Get([]byte(a.Path)) != nil {
+			return fmt.Errorf("API root %s already exists", a.Path)
+		}
+
+		return put(b, []byte(a.Path), apiRootRecord{
+			Path:             a.Path,
+			Title:            a.Title,
+			Description:      a.Description,
+			Versions:         a.Versions,
+			MaxContentLength: a.MaxContentLength,
+			DefaultPageSize:  a.DefaultPageSize,
+			MaxPageSize:      a.MaxPageSize,
+			UpdatedAt:        now()})
+	})
+}
+
+// DeleteAPIRoot creates a user in the data store
+func (s APIRootService) DeleteAPIRoot(ctx context.Context, path string) error {
+	resource, action := "APIRoot", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.deleteAPIRoot(path)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s APIRootService) deleteAPIRoot(path string) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(apiRootsBucket).Delete([]byte(path))
+	})
+}
+
+// UpdateAPIRoot creates a user in the data store
+func (s APIRootService) UpdateAPIRoot(ctx context.Context, a cabby.APIRoot) error {
+	resource, action := "APIRoot", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := a.Validate()
+	if err == nil {
+		err = s.updateAPIRoot(a)
+	} else {
+		log.WithFields(log.Fields{"api_root": a, "error": err}).Error("Invalid API Root")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s APIRootService) updateAPIRoot(a cabby.APIRoot) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(apiRootsBucket)
+
+		var r apiRootRecord
+		ok, err := get(b, []byte(a.Path), &r)
+		if !ok || err != nil {
+			return err
+		}
+
+		r.Title = a.Title
+		r.Description = a.Description
+		r.Versions = a.Versions
+		r.MaxContentLength = a.MaxContentLength
+		r.DefaultPageSize = a.DefaultPageSize
+		r.MaxPageSize = a.MaxPageSize
+		r.UpdatedAt = now()
+		return put(b, []byte(a.Path), r)
+	})
+}
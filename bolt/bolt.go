@@ -0,0 +1,263 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// addedFormat is a fixed width time format so added times sort in index keys
+	addedFormat = "2006-01-02T15:04:05.000Z"
+	// modifiedFormat is a fixed width time format so versions of an object sort in keys
+	modifiedFormat = "2006-01-02T15:04:05.000000000Z"
+	// openTimeout is how long Open waits for another process to let go of the file
+	openTimeout = 5 * time.Second
+	// timeFormat matches the format the sqlite data store has for status created_at times
+	timeFormat = "2006-01-02 15:04:05.000"
+)
+
+// keys in bucket and index keys are separated by a byte that can't be in an id, type or time
+const keySeparator = "\x00"
+
+var (
+	apiRootsBucket         = []byte("api_roots")
+	collectionsBucket      = []byte("collections")
+	discoveryBucket        = []byte("discovery")
+	objectsBucket          = []byte("objects")
+	objectBodiesBucket     = []byte("object_bodies")
+	objectsAddedBucket     = []byte("objects_added")
+	objectsTypeBucket      = []byte("objects_type")
+	objectTombstonesBucket = []byte("object_tombstones")
+	statusesBucket         = []byte("statuses")
+	userCollectionsBucket  = []byte("user_collections")
+	usersBucket            = []byte("users")
+
+	buckets = [][]byte{
+		apiRootsBucket,
+		collectionsBucket,
+		discoveryBucket,
+		objectsBucket,
+		objectBodiesBucket,
+		objectsAddedBucket,
+		objectsTypeBucket,
+		objectTombstonesBucket,
+		statusesBucket,
+		userCollectionsBucket,
+		usersBucket,
+	}
+
+	discoveryKey = []byte("discovery")
+)
+
+// DataStore keeps resources in an embedded bbolt file; it needs no cgo and no server
+type DataStore struct {
+	DB   *bbolt.DB
+	Path string
+}
+
+// NewDataStore returns a data store for the bbolt file at the path; the file and its buckets are created if they
+// don't exist
+func NewDataStore(path string) (*DataStore, error) {
+	s := DataStore{Path: path}
+	if s.Path == "" {
+		return &s, errors.New("No database location specfied in config")
+	}
+
+	err := s.Open()
+	return &s, err
+}
+
+// APIRootService returns a service for api root resources
+func (s *DataStore) APIRootService() cabby.APIRootService {
+	return APIRootService{DataStore: s}
+}
+
+// Close the data store file
+func (s *DataStore) Close() {
+	s.DB.Close()
+}
+
+// CollectionService returns a service for collection resources
+func (s *DataStore) CollectionService() cabby.CollectionService {
+	return CollectionService{DataStore: s}
+}
+
+// DiscoveryService returns a service for discovery resources
+func (s *DataStore) DiscoveryService() cabby.DiscoveryService {
+	return DiscoveryService{DataStore: s}
+}
+
+// ManifestService returns a service for object resources
+func (s *DataStore) ManifestService() cabby.ManifestService {
+	return ManifestService{DataStore: s}
+}
+
+// ObjectService returns a service for object resources
+func (s *DataStore) ObjectService() cabby.ObjectService {
+	return ObjectService{DataStore: s}
+}
+
+// Open the data store file and create any buckets it doesn't have
+func (s *DataStore) Open() (err error) {
+	s.DB, err = bbolt.Open(s.Path, 0600, &bbolt.Options{Timeout: openTimeout})
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "path": s.Path}).Error("Failed to open data store")
+		return
+	}
+
+	err = s.DB.Update(func(tx *bbolt.Tx) error {
+		for _, b := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "path": s.Path}).Error("Failed to create buckets")
+	}
+	return
+}
+
+// StatusService returns service for status resources
+func (s *DataStore) StatusService() cabby.StatusService {
+	return StatusService{DataStore: s}
+}
+
+// UserService returns a service for user resources
+func (s *DataStore) UserService() cabby.UserService {
+	return UserService{DataStore: s}
+}
+
+/* helpers */
+
+// get decodes the record at a key; it returns false if there isn't one
+func get(b *bbolt.Bucket, key []byte, v interface{}) (bool, error) {
+	data := b.Get(key)
+	if data == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// key joins parts into a bucket key
+func key(parts ...string) []byte {
+	return []byte(strings.Join(parts, keySeparator))
+}
+
+// keyParts splits a bucket key into its parts
+func keyParts(k []byte) []string {
+	return strings.Split(string(k), keySeparator)
+}
+
+// now is truncated to milliseconds, the precision the sql data stores keep
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// prefix is a key prefix that matches keys starting with the parts
+func prefix(parts ...string) []byte {
+	return append(key(parts...), keySeparator...)
+}
+
+func put(b *bbolt.Bucket, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
+
+// scan calls fn with each key and value in the bucket that starts with the prefix
+func scan(b *bbolt.Bucket, p []byte, fn func(k, v []byte) error) error {
+	return scanFrom(b, p, p, fn)
+}
+
+// scanFrom is like scan but starts at the seek key
+func scanFrom(b *bbolt.Bucket, seek, p []byte, fn func(k, v []byte) error) error {
+	c := b.Cursor()
+	for k, v := c.Seek(seek); k != nil && bytes.HasPrefix(k, p); k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* pagination helpers */
+
+// position is where a page of records is in the records read
+type position struct {
+	total, remaining int64
+	last             cabby.Cursor
+}
+
+// set sets the total of a range, and where a cursored range is in the records read
+func (p position) set(cr *cabby.Range) {
+	if !cr.Cursored {
+		cr.Total = p.total
+		return
+	}
+	cr.SetPage(p.total, p.remaining, p.last)
+}
+
+// page returns the records in a range and where they are in the records; a cursored page is in the order the records
+// were added and other pages are in the order of the range's sort
+func page(data []objectRecord, cr *cabby.Range) ([]objectRecord, position) {
+	p := position{total: int64(len(data))}
+	sorted := append([]objectRecord(nil), data...)
+
+	if !cr.Cursored {
+		sort.SliceStable(sorted, func(i, j int) bool { return cr.Sort.Less(sorted[i].sortKey(), sorted[j].sortKey()) })
+		r := Range{cr}
+		first, last := r.Bounds(len(sorted))
+		return sorted[first:last], p
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].cursor().Before(sorted[j].cursor()) })
+
+	var after []objectRecord
+	for _, r := range sorted {
+		if cr.After.Before(r.cursor()) {
+			after = append(after, r)
+		}
+	}
+	p.remaining = int64(len(after))
+
+	if cr.Limit > 0 && int64(len(after)) > cr.Limit {
+		after = after[:cr.Limit]
+	}
+	if len(after) > 0 {
+		p.last = after[len(after)-1].cursor()
+	}
+	return after, p
+}
+
+// Range implementation for the bolt data store
+type Range struct {
+	*cabby.Range
+}
+
+// Bounds returns the start and end indexes of the range in a list of the given length
+func (r *Range) Bounds(length int) (start, end int) {
+	if !r.Valid() {
+		return 0, length
+	}
+
+	start, end = int(r.First), int(r.Last)+1
+	if start > length {
+		start = length
+	}
+	if end > length {
+		end = length
+	}
+	return
+}
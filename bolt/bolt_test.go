@@ -0,0 +1,124 @@
+package bolt
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/datastoretest"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestDataStoreConformance(t *testing.T) {
+	var cleanups []func()
+	defer func() {
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+	}()
+
+	datastoretest.Run(t, func(t *testing.T) cabby.DataStore {
+		ds, cleanup := newDataStore(t)
+		cleanups = append(cleanups, cleanup)
+		return ds
+	})
+}
+
+func TestNewDataStore(t *testing.T) {
+	_, cleanup := newDataStore(t)
+	cleanup()
+}
+
+func TestNewDataStoreNoPath(t *testing.T) {
+	_, err := NewDataStore("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestNewDataStoreInvalidPath(t *testing.T) {
+	_, err := NewDataStore(filepath.Join("testdata", "missing", "cabby.db"))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// resources are kept when the data store is closed and opened again
+func TestDataStoreReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cabby-bolt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cabby.db")
+	ds, err := NewDataStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createTestObject(t, ds, tester.Object)
+	ds.Close()
+
+	ds, err = NewDataStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ds.Close()
+
+	results, err := ds.ObjectService().Object(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{})
+	if err != nil {
+		t.Error("Got:", err)
+	}
+	if len(results) != 1 {
+		t.Fatal("Got:", len(results), "Expected:", 1)
+	}
+	if string(results[0].Object) != string(tester.Object.Object) {
+		t.Error("Got:", string(results[0].Object), "Expected:", string(tester.Object.Object))
+	}
+}
+
+func TestRangeBounds(t *testing.T) {
+	tests := []struct {
+		cabbyRange cabby.Range
+		length     int
+		start      int
+		end        int
+	}{
+		{cabby.Range{First: -1, Last: -1}, 10, 0, 10},
+		{cabby.Range{First: 0, Last: 5}, 10, 0, 6},
+		{cabby.Range{First: 8, Last: 12}, 10, 8, 10},
+		{cabby.Range{First: 12, Last: 15}, 10, 10, 10},
+	}
+
+	for _, test := range tests {
+		r := Range{&test.cabbyRange}
+		start, end := r.Bounds(test.length)
+
+		if start != test.start || end != test.end {
+			t.Error("Got:", start, end, "Expected:", test.start, test.end)
+		}
+	}
+}
+
+func TestVersionKey(t *testing.T) {
+	tests := []struct {
+		modified string
+		expected string
+	}{
+		{"2016-04-06T20:07:09Z", "2016-04-06T20:07:09.000000000Z"},
+		{"2016-04-06T20:07:09.1Z", "2016-04-06T20:07:09.100000000Z"},
+		{"2016-04-06T22:07:09.000+02:00", "2016-04-06T20:07:09.000000000Z"},
+		{"not a time", "not a time"},
+	}
+
+	for _, test := range tests {
+		result := versionKey(test.modified)
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/bbolt"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// CollectionService implements a bolt version of the CollectionService interface
+type CollectionService struct {
+	DataStore *DataStore
+}
+
+type collectionRecord struct {
+	ID             string        `json:"id"`
+	APIRootPath    string        `json:"api_root_path"`
+	Title          string        `json:"title"`
+	Description    string        `json:"description"`
+	MediaTypes     []string      `json:"media_types"`
+	VersionPolicy  string        `json:"version_policy,omitempty"`
+	RetainVersions int           `json:"retain_versions,omitempty"`
+	RetainWindow   time.Duration `json:"retain_window,omitempty"`
+	ExpireBy       string        `json:"expire_by,omitempty"`
+	MaxAge         time.Duration `json:"max_age,omitempty"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// collection returns the collection with a user's access to it
+func (r *collectionRecord) collection(uc *userCollectionRecord) (cabby.Collection, error) {
+	id, err := cabby.IDFromString(r.ID)
+	return cabby.Collection{
+		ID:             id,
+		Title:          r.Title,
+		Description:    r.Description,
+		CanRead:        uc.CanRead,
+		CanWrite:       uc.CanWrite,
+		MediaTypes:     r.MediaTypes,
+		VersionPolicy:  r.VersionPolicy,
+		RetainVersions: r.RetainVersions,
+		RetainWindow:   r.RetainWindow,
+		ExpireBy:       r.ExpireBy,
+		MaxAge:         r.MaxAge,
+		UpdatedAt:      cabby.LaterTime(r.UpdatedAt, uc.UpdatedAt)}, err
+}
+
+// Collection will read from the data store and return the resource
+func (s CollectionService) Collection(ctx context.Context, apiRootPath, collectionID string) (cabby.Collection, error) {
+	resource, action := "Collection", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.collection(cabby.TakeUser(ctx).Email, apiRootPath, collectionID)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s CollectionService) collection(user, apiRootPath, collectionID string) (cabby.Collection, error) {
+	c := cabby.Collection{}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		var r collectionRecord
+		ok, err := get(tx.Bucket(collectionsBucket), []byte(collectionID), &r)
+		if !ok || err != nil || r.APIRootPath != apiRootPath {
+			return err
+		}
+
+		var uc userCollectionRecord
+		ok, err = get(tx.Bucket(userCollectionsBucket), key(user, collectionID), &uc)
+		if !ok || err != nil || !uc.CanRead {
+			return err
+		}
+
+		c, err = r.collection(&uc)
+		return err
+	})
+	return c, err
+}
+
+// Collections will read from the data store and return the resource
+func (s CollectionService) Collections(ctx context.Context, apiRootPath string, cr *cabby.Range) (cabby.Collections, error) {
+	resource, action := "Collections", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.collections(cabby.TakeUser(ctx).Email, apiRootPath, cr)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// like the sql data stores, the range total and latest update are only set if the range has collections
+func (s CollectionService) collections(user, apiRootPath string, cr *cabby.Range) (cabby.Collections, error) {
+	var data []cabby.Collection
+	var lastUpdatedAt time.Time
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		ucs := tx.Bucket(userCollectionsBucket)
+
+		return tx.Bucket(collectionsBucket).ForEach(func(k, v []byte) error {
+			var r collectionRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if r.APIRootPath != apiRootPath {
+				return nil
+			}
+
+			var uc userCollectionRecord
+			ok, err := get(ucs, key(user, r.ID), &uc)
+			if !ok || err != nil || !(uc.CanRead || uc.CanWrite) {
+				return err
+			}
+
+			c, err := r.collection(&uc)
+			if err != nil {
+				return err
+			}
+
+			lastUpdatedAt = cabby.LaterTime(lastUpdatedAt, c.UpdatedAt)
+			data = append(data, c)
+			return nil
+		})
+	})
+
+	cs := cabby.Collections{}
+	if err != nil {
+		return cs, err
+	}
+
+	sort.SliceStable(data, func(i, j int) bool { return cr.Sort.Less(collectionSortKey(data[i]), collectionSortKey(data[j])) })
+
+	r := Range{cr}
+	first, last := r.Bounds(len(data))
+
+	if first < last {
+		cr.Total = int64(len(data))
+		cs.UpdatedAt = lastUpdatedAt
+		cs.Collections = append(cs.Collections, data[first:last]...)
+	}
+	return cs, nil
+}
+
+func collectionSortKey(c cabby.Collection) cabby.SortKey {
+	return cabby.SortKey{ID: c.ID.String(), Title: c.Title}
+}
+
+// CollectionsInAPIRoot return collections in a given api root
+func (s CollectionService) CollectionsInAPIRoot(ctx context.Context, apiRootPath string) (cabby.CollectionsInAPIRoot, error) {
+	resource, action := "CollectionsInAPIRoot", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.collectionsInAPIRoot(apiRootPath)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s CollectionService) collectionsInAPIRoot(apiRootPath string) (cabby.CollectionsInAPIRoot, error) {
+	ac := cabby.CollectionsInAPIRoot{}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(collectionsBucket).ForEach(func(k, v []byte) error {
+			var r collectionRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if r.APIRootPath != apiRootPath {
+				return nil
+			}
+
+			id, err := cabby.IDFromString(r.ID)
+			if err != nil {
+				return err
+			}
+
+			ac.Path = r.APIRootPath
+			ac.CollectionIDs = append(ac.CollectionIDs, id)
+			return nil
+		})
+	})
+	return ac, err
+}
+
+// CreateCollection creates a user in the data store
+func (s CollectionService) CreateCollection(ctx context.Context, c cabby.Collection) error {
+	resource, action := "Collection", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := c.Validate()
+	if err == nil {
+		err = s.createCollection(c)
+	} else {
+		log.WithFields(log.Fields{"collection": c, "error": err}).Error("Invalid Collection")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s CollectionService) createCollection(c cabby.Collection) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(collectionsBucket)
+		id := c.ID.String()
+
+		if b.Get([]byte(id)) != nil {
+			return fmt.Errorf("Collection %s already exists", id)
+		}
+
+		return put(b, []byte(id), collectionRecord{
+			ID:             id,
+			APIRootPath:    c.APIRootPath,
+			Title:          c.Title,
+			Description:    c.Description,
+			MediaTypes:     c.MediaTypes,
+			VersionPolicy:  c.VersionPolicy,
+			RetainVersions: c.RetainVersions,
+			RetainWindow:   c.RetainWindow,
+			ExpireBy:       c.ExpireBy,
+			MaxAge:         c.MaxAge,
+			UpdatedAt:      now()})
+	})
+}
+
+// DeleteCollection creates a user in the data store
+func (s CollectionService) DeleteCollection(ctx context.Context, id string) error {
+	resource, action := "Collection", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.deleteCollection(id)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s CollectionService) deleteCollection(id string) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(collectionsBucket).Delete([]byte(id))
+	})
+}
+
+// UpdateCollection creates a user in the data store
+func (s CollectionService) UpdateCollection(ctx context.Context, c cabby.Collection) error {
+	resource, action := "Collection", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := c.Validate()
+	if err == nil {
+		err = s.updateCollection(c)
+	} else {
+		log.WithFields(log.Fields{"collection": c, "error": err}).Error("Invalid Collection")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s CollectionService) updateCollection(c cabby.Collection) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(collectionsBucket)
+		id := []byte(c.ID.String())
+
+		var r collectionRecord
+		ok, err := get(b, id, &r)
+		if !ok || err != nil {
+			return err
+		}
+
+		r.APIRootPath = c.APIRootPath
+		r.Title = c.Title
+		r.Description = c.Description
+		r.VersionPolicy = c.VersionPolicy
+		r.RetainVersions = c.RetainVersions
+		r.RetainWindow = c.RetainWindow
+		r.ExpireBy = c.ExpireBy
+		r.MaxAge = c.MaxAge
+		r.UpdatedAt = now()
+		return put(b, id, r)
+	})
+}
@@ -0,0 +1,134 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/bbolt"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// DiscoveryService implements a bolt version of the DiscoveryService interface
+type DiscoveryService struct {
+	DataStore *DataStore
+}
+
+// discoveryRecord has the fields a discovery keeps; its api roots come from the api roots in the data store
+type discoveryRecord struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Contact     string    `json:"contact"`
+	Default     string    `json:"default"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func newDiscoveryRecord(d cabby.Discovery) discoveryRecord {
+	return discoveryRecord{Title: d.Title, Description: d.Description, Contact: d.Contact, Default: d.Default, UpdatedAt: now()}
+}
+
+// CreateDiscovery creates a user in the data store
+func (s DiscoveryService) CreateDiscovery(ctx context.Context, d cabby.Discovery) error {
+	resource, action := "Discovery", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := d.Validate()
+	if err == nil {
+		err = s.createDiscovery(d)
+	} else {
+		log.WithFields(log.Fields{"discovery": d, "error": err}).Error("Invalid Discovery")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s DiscoveryService) createDiscovery(d cabby.Discovery) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(discoveryBucket)
+		if b.Get(discoveryKey) != nil {
+			return errors.New("Only one discovery can be defined")
+		}
+		return put(b, discoveryKey, newDiscoveryRecord(d))
+	})
+}
+
+// DeleteDiscovery creates a user in the data store
+func (s DiscoveryService) DeleteDiscovery(ctx context.Context) error {
+	resource, action := "Discovery", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.deleteDiscovery()
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s DiscoveryService) deleteDiscovery() error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(discoveryBucket).Delete(discoveryKey)
+	})
+}
+
+// Discovery will read from the data store and return the resource
+func (s DiscoveryService) Discovery(ctx context.Context) (cabby.Discovery, error) {
+	resource, action := "Discovery", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.discovery()
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// every api root belongs to the discovery
+func (s DiscoveryService) discovery() (cabby.Discovery, error) {
+	d := cabby.Discovery{}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		var r discoveryRecord
+		ok, err := get(tx.Bucket(discoveryBucket), discoveryKey, &r)
+		if !ok || err != nil {
+			return err
+		}
+
+		d = cabby.Discovery{Title: r.Title, Description: r.Description, Contact: r.Contact, Default: r.Default, UpdatedAt: r.UpdatedAt}
+
+		return tx.Bucket(apiRootsBucket).ForEach(func(k, v []byte) error {
+			var a apiRootRecord
+			if err := json.Unmarshal(v, &a); err != nil {
+				return err
+			}
+
+			d.UpdatedAt = cabby.LaterTime(d.UpdatedAt, a.UpdatedAt)
+			d.APIRoots = append(d.APIRoots, a.Path)
+			return nil
+		})
+	})
+	return d, err
+}
+
+// UpdateDiscovery creates a user in the data store
+func (s DiscoveryService) UpdateDiscovery(ctx context.Context, d cabby.Discovery) error {
+	resource, action := "Discovery", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := d.Validate()
+	if err == nil {
+		err = s.updateDiscovery(d)
+	} else {
+		log.WithFields(log.Fields{"discovery": d, "error": err}).Error("Invalid Discovery")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s DiscoveryService) updateDiscovery(d cabby.Discovery) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(discoveryBucket)
+		if b.Get(discoveryKey) == nil {
+			return nil
+		}
+		return put(b, discoveryKey, newDiscoveryRecord(d))
+	})
+}
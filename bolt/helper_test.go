@@ -0,0 +1,91 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+	"github.com/pladdy/stones"
+)
+
+/* helpers */
+
+func createTestObject(t *testing.T, ds *DataStore, o cabby.Object) {
+	err := ds.ObjectService().CreateObject(context.Background(), o)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createTypedObject creates a version of an object with its own id and type
+func createTypedObject(t *testing.T, ds *DataStore, objectType, modified string) string {
+	id, _ := stones.NewStixID(objectType)
+
+	o := tester.Object
+	o.ID = stones.ID(id.String())
+	o.Type = objectType
+	o.Modified = modified
+	createTestObject(t, ds, o)
+
+	return id.String()
+}
+
+func objectsToChannel(objects []json.RawMessage) <-chan []byte {
+	c := make(chan []byte, len(objects))
+	for _, o := range objects {
+		c <- o
+	}
+	close(c)
+	return c
+}
+
+// newDataStore returns an empty data store in a temporary directory and a function to remove it
+func newDataStore(t *testing.T) (*DataStore, func()) {
+	dir, err := ioutil.TempDir("", "cabby-bolt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := NewDataStore(filepath.Join(dir, "cabby.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	return ds, func() {
+		ds.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// setupBolt returns a data store with the tester resources in it and a function to remove it
+func setupBolt(t *testing.T) (*DataStore, func()) {
+	ds, cleanup := newDataStore(t)
+	ctx := context.Background()
+
+	if err := ds.UserService().CreateUser(ctx, tester.User, tester.UserPassword); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.DiscoveryService().CreateDiscovery(ctx, tester.Discovery); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.APIRootService().CreateAPIRoot(ctx, tester.APIRoot); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.CollectionService().CreateCollection(ctx, tester.Collection); err != nil {
+		t.Fatal(err)
+	}
+
+	ca := cabby.CollectionAccess{ID: tester.Collection.ID, CanRead: true, CanWrite: true}
+	if err := ds.UserService().CreateUserCollection(ctx, tester.UserEmail, ca); err != nil {
+		t.Fatal(err)
+	}
+
+	createTestObject(t, ds, tester.Object)
+	return ds, cleanup
+}
@@ -0,0 +1,125 @@
+package bolt
+
+import (
+	"context"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// ManifestService implements a bolt version of the ManifestService interface
+type ManifestService struct {
+	DataStore *DataStore
+}
+
+// IterateManifest will read from the data store and return an iterator over the resource; the range total is
+// set once Next is called
+func (s ManifestService) IterateManifest(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+	resource, action := "Manifest", "iterate"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.iterateManifest(collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// like the sql data stores, each version of an object is an entry in the manifest; entries don't read the objects
+func (s ManifestService) iterateManifest(collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+	it := manifestIterator{cr: cr}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		data, err := s.DataStore.objects(tx, collectionID, f)
+		if err != nil {
+			return err
+		}
+
+		var records []objectRecord
+		records, it.position = page(data, cr)
+		for _, r := range data {
+			it.lastUpdatedAt = cabby.LaterTime(it.lastUpdatedAt, r.UpdatedAt)
+		}
+
+		it.firstAdded, it.lastAdded = dateAdded(records)
+
+		for _, r := range records {
+			it.entries = append(it.entries, cabby.ManifestEntry{
+				ID:         r.ID,
+				DateAdded:  r.CreatedAt.Format(cabby.TimestampFormat),
+				Versions:   []string{r.Modified},
+				MediaTypes: []string{cabby.StixContentType}})
+		}
+		return nil
+	})
+	return &it, err
+}
+
+// Manifest will read from the data store and return the resource
+func (s ManifestService) Manifest(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error) {
+	resource, action := "Manifest", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.manifest(collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ManifestService) manifest(collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error) {
+	m := cabby.Manifest{}
+
+	mr, err := s.iterateManifest(collectionID, cr, f)
+	if err != nil {
+		return m, err
+	}
+	defer mr.Close()
+
+	for mr.Next() {
+		m.Objects = append(m.Objects, mr.Entry())
+	}
+
+	err = mr.Err()
+	return m, err
+}
+
+// manifestIterator implements a cabby.ManifestIterator over entries read from the data store
+type manifestIterator struct {
+	entries       []cabby.ManifestEntry
+	cr            *cabby.Range
+	position      position
+	entry         cabby.ManifestEntry
+	lastUpdatedAt time.Time
+	updatedAt     time.Time
+	firstAdded    time.Time
+	lastAdded     time.Time
+}
+
+func (m *manifestIterator) Next() bool {
+	if len(m.entries) == 0 {
+		return false
+	}
+
+	m.entry, m.entries = m.entries[0], m.entries[1:]
+	m.position.set(m.cr)
+	m.updatedAt = m.lastUpdatedAt
+	return true
+}
+
+func (m *manifestIterator) Entry() cabby.ManifestEntry {
+	return m.entry
+}
+
+func (m *manifestIterator) UpdatedAt() time.Time {
+	return m.updatedAt
+}
+
+func (m *manifestIterator) DateAdded() (time.Time, time.Time) {
+	return m.firstAdded, m.lastAdded
+}
+
+func (m *manifestIterator) Err() error {
+	return nil
+}
+
+func (m *manifestIterator) Close() error {
+	m.entries = nil
+	return nil
+}
@@ -0,0 +1,966 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/stones"
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/bbolt"
+)
+
+// the status of a bundle is updated, and checked for a cancel, every progressInterval objects; objects are written
+// in a transaction per interval
+const progressInterval = 50
+
+// ObjectService implements a bolt version of the ObjectService interface
+type ObjectService struct {
+	DataStore *DataStore
+}
+
+// objectRecord is what's kept in the objects bucket; the object itself is in the bodies bucket so filters don't have
+// to read it
+type objectRecord struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	Created      string    `json:"created"`
+	Modified     string    `json:"modified"`
+	CollectionID string    `json:"collection_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	// Row orders versions added at the same time; versions written before rows were numbered are all row 0
+	Row int64 `json:"row,omitempty"`
+
+	key []byte
+}
+
+func (r objectRecord) cursor() cabby.Cursor {
+	return cabby.Cursor{DateAdded: r.CreatedAt, Row: r.Row}
+}
+
+func (r objectRecord) sortKey() cabby.SortKey {
+	return cabby.SortKey{DateAdded: r.CreatedAt, ID: r.ID, Modified: r.Modified, Row: r.Row}
+}
+
+// tombstoneRecord is what's kept in the tombstones bucket; it's keyed by collection and when it was deleted so
+// tombstones deleted after a time can be scanned
+type tombstoneRecord struct {
+	ID           string    `json:"id"`
+	Modified     string    `json:"modified"`
+	CollectionID string    `json:"collection_id"`
+	DeletedAt    time.Time `json:"deleted_at"`
+}
+
+// CompactObjects will delete the object versions collections don't retain
+func (s ObjectService) CompactObjects(ctx context.Context) (int64, error) {
+	resource, action := "Objects", "compact"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.DataStore.compactObjects(now())
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s *DataStore) compactObjects(t time.Time) (int64, error) {
+	var compacted int64
+
+	err := s.DB.Update(func(tx *bbolt.Tx) error {
+		var collections []collectionRecord
+
+		err := tx.Bucket(collectionsBucket).ForEach(func(k, v []byte) error {
+			var r collectionRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if r.RetainVersions > 0 || r.RetainWindow > 0 {
+				collections = append(collections, r)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, c := range collections {
+			deleted, err := compactCollection(tx, c, t)
+			if err != nil {
+				return err
+			}
+			compacted += deleted
+		}
+		return nil
+	})
+	return compacted, err
+}
+
+// compactCollection deletes the versions of each object in a collection that it doesn't retain
+func compactCollection(tx *bbolt.Tx, c collectionRecord, t time.Time) (int64, error) {
+	versions, err := collectionObjects(tx, c.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	collection := cabby.Collection{RetainVersions: c.RetainVersions, RetainWindow: c.RetainWindow}
+	var compacted int64
+
+	for _, records := range versions {
+		var modified []string
+		for _, r := range records {
+			modified = append(modified, r.Modified)
+		}
+
+		expired := map[string]bool{}
+		for _, m := range collection.ExpiredVersions(modified, t) {
+			expired[m] = true
+		}
+
+		for _, r := range records {
+			if !expired[r.Modified] {
+				continue
+			}
+			if err := deleteObject(tx, r); err != nil {
+				return compacted, err
+			}
+			compacted++
+		}
+	}
+	return compacted, nil
+}
+
+// collectionObjects returns the records of every object version in a collection by object id, in version order.  The
+// records are read before any are deleted so the cursor isn't moved by a delete.
+func collectionObjects(tx *bbolt.Tx, collectionID string) (map[string][]objectRecord, error) {
+	versions := map[string][]objectRecord{}
+
+	err := scan(tx.Bucket(objectsBucket), prefix(collectionID), func(k, v []byte) error {
+		var r objectRecord
+		if err := json.Unmarshal(v, &r); err != nil {
+			return err
+		}
+		r.key = append([]byte(nil), k...)
+		versions[r.ID] = append(versions[r.ID], r)
+		return nil
+	})
+	return versions, err
+}
+
+// CreateBundle will write objects to the data store as they're received and update the status when done
+func (s ObjectService) CreateBundle(ctx context.Context, objects <-chan []byte, collectionID string, st cabby.Status, ss cabby.StatusService) {
+	resource, action := "Bundle", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	s.createBundle(ctx, objects, collectionID, st, ss)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+}
+
+func (s ObjectService) createBundle(ctx context.Context, objects <-chan []byte, collectionID string, st cabby.Status, ss cabby.StatusService) {
+	var batch []cabby.Object
+	var failures []cabby.StatusFailure
+	var successes []string
+	total, canceled := int64(0), int64(0)
+
+	// the channel is always drained so the sender doesn't block, even after a cancel
+	for object := range objects {
+		total++
+
+		if canceled > 0 {
+			canceled++
+			continue
+		}
+
+		if total > 1 && (total-1)%progressInterval == 0 {
+			failed, ignored := s.DataStore.writeObjects(batch, collectionID)
+			failures, successes = append(failures, failed...), append(successes, ignored...)
+			batch = nil
+
+			if err := s.DataStore.updateStatusProgress(st, total-1); err != nil {
+				log.WithFields(log.Fields{"error": err, "status_id": st.ID}).Error("Failed to update status progress")
+			}
+
+			if cabby.StatusCanceled(ctx, st, ss) {
+				canceled++
+				log.WithFields(log.Fields{"status_id": st.ID}).Warn("Status canceled, stopping bundle")
+				continue
+			}
+		}
+
+		o, err := cabby.NewObject(object)
+		if err != nil {
+			failures = append(failures, cabby.StatusFailure{ID: string(o.ID), Version: o.Modified, Message: err.Error()})
+			continue
+		}
+		batch = append(batch, o)
+	}
+	failed, ignored := s.DataStore.writeObjects(batch, collectionID)
+	failures, successes = append(failures, failed...), append(successes, ignored...)
+
+	st.TotalCount = total
+	st.Failures = failures
+	st.Successes = successes
+	cabby.UpdateStatusCounts(ctx, st, canceled, ss)
+}
+
+// CreateObject will read from the data store and return the resource
+func (s ObjectService) CreateObject(ctx context.Context, object cabby.Object) error {
+	resource, action := "Object", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		action, err := resolveObject(tx, object, object.CollectionID.String())
+		if err != nil {
+			return err
+		}
+		return writeObject(tx, object, object.CollectionID.String(), action)
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "id": object.ID}).Error("Failed to write object")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+// DeleteObject will delete the versions of an object in the filter and record a tombstone for each
+func (s ObjectService) DeleteObject(ctx context.Context, collectionID, objectID string, f cabby.Filter) (int64, error) {
+	resource, action := "Object", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.DataStore.deleteObjectVersions(collectionID, objectID, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s *DataStore) deleteObjectVersions(collectionID, objectID string, f cabby.Filter) (int64, error) {
+	var deleted int64
+
+	err := s.DB.Update(func(tx *bbolt.Tx) error {
+		keys, err := objectKeys(tx, collectionID, objectID)
+		if err != nil {
+			return err
+		}
+
+		data, err := filterObjects(tx, collectionID, keys, f)
+		if err != nil {
+			return err
+		}
+
+		t := now()
+		for _, r := range data {
+			if err := deleteObject(tx, r); err != nil {
+				return err
+			}
+
+			tombstone := tombstoneRecord{ID: r.ID, Modified: r.Modified, CollectionID: collectionID, DeletedAt: t}
+			k := key(collectionID, t.Format(addedFormat), r.ID, versionKey(r.Modified))
+			if err := put(tx.Bucket(objectTombstonesBucket), k, tombstone); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		deleted = 0
+	}
+	return deleted, err
+}
+
+// Object will read from the data store and return the resource
+func (s ObjectService) Object(ctx context.Context, collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
+	resource, action := "Object", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.object(collectionID, objectID, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ObjectService) object(collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
+	objects := []cabby.Object{}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		keys, err := objectKeys(tx, collectionID, objectID)
+		if err != nil {
+			return err
+		}
+
+		data, err := filterObjects(tx, collectionID, keys, f)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range data {
+			objects = append(objects, r.read(tx))
+		}
+		return nil
+	})
+	return objects, err
+}
+
+// IterateObjects will read from the data store and return an iterator over the resource; the range total is
+// set once Next is called
+func (s ObjectService) IterateObjects(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+	resource, action := "Objects", "iterate"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.iterateObjects(collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// the objects in range are read when the iterator is created so it doesn't hold a transaction open while it's read
+func (s ObjectService) iterateObjects(collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+	it := objectIterator{cr: cr}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		data, err := s.DataStore.objects(tx, collectionID, f)
+		if err != nil {
+			return err
+		}
+
+		var records []objectRecord
+		records, it.position = page(data, cr)
+		for _, r := range data {
+			it.lastUpdatedAt = cabby.LaterTime(it.lastUpdatedAt, r.UpdatedAt)
+		}
+
+		it.firstAdded, it.lastAdded = dateAdded(records)
+
+		for _, r := range records {
+			it.objects = append(it.objects, r.read(tx))
+		}
+		return nil
+	})
+	return &it, err
+}
+
+// Objects will read from the data store and return the resource
+func (s ObjectService) Objects(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
+	resource, action := "Objects", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.objects(collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ObjectService) objects(collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
+	objects := []cabby.Object{}
+
+	or, err := s.iterateObjects(collectionID, cr, f)
+	if err != nil {
+		return objects, err
+	}
+	defer or.Close()
+
+	for or.Next() {
+		objects = append(objects, or.Object())
+	}
+
+	err = or.Err()
+	return objects, err
+}
+
+// PurgeObjects will delete every version of the objects that have expired in their collections
+func (s ObjectService) PurgeObjects(ctx context.Context) (int64, error) {
+	resource, action := "Objects", "purge"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.DataStore.purgeObjects(now())
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s *DataStore) purgeObjects(t time.Time) (int64, error) {
+	var purged int64
+
+	err := s.DB.Update(func(tx *bbolt.Tx) error {
+		var collections []string
+
+		err := tx.Bucket(collectionsBucket).ForEach(func(k, v []byte) error {
+			var r collectionRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if r.ExpireBy != "" {
+				collections = append(collections, r.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, collectionID := range collections {
+			deleted, err := purgeCollection(tx, collectionID, t)
+			if err != nil {
+				return err
+			}
+			purged += deleted
+		}
+		return nil
+	})
+	return purged, err
+}
+
+// purgeCollection deletes every version of the objects in a collection whose latest version has expired
+func purgeCollection(tx *bbolt.Tx, collectionID string, t time.Time) (int64, error) {
+	versions, err := collectionObjects(tx, collectionID)
+	if err != nil {
+		return 0, err
+	}
+
+	expiry := collectionExpiry(tx, collectionID)
+	var purged int64
+
+	for _, records := range versions {
+		latest := records[len(records)-1]
+		if !expiry.Expired(latest.read(tx), t) {
+			continue
+		}
+
+		for _, r := range records {
+			if err := deleteObject(tx, r); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Tombstones will read the object versions deleted from a collection; the filter's added after is compared to when
+// they were deleted
+func (s ObjectService) Tombstones(ctx context.Context, collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+	resource, action := "Tombstones", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.DataStore.tombstones(collectionID, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// like the other data stores, an added after that can't be parsed matches nothing
+func (s *DataStore) tombstones(collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+	tombstones := []cabby.Tombstone{}
+
+	seek := prefix(collectionID)
+	if len(f.AddedAfter) > 0 {
+		t, err := time.Parse(time.RFC3339Nano, f.AddedAfter)
+		if err != nil {
+			return tombstones, nil
+		}
+		seek = key(collectionID, t.UTC().Truncate(time.Millisecond).Add(time.Millisecond).Format(addedFormat))
+	}
+
+	err := s.DB.View(func(tx *bbolt.Tx) error {
+		return scanFrom(tx.Bucket(objectTombstonesBucket), seek, prefix(collectionID), func(k, v []byte) error {
+			var r tombstoneRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+
+			tombstones = append(tombstones,
+				cabby.Tombstone{ID: r.ID, Version: r.Modified, DateDeleted: r.DeletedAt.Format(cabby.TimestampFormat)})
+			return nil
+		})
+	})
+	return tombstones, err
+}
+
+// Versions will read the modified times of an object's versions in a collection, earliest first; the range total is
+// set
+func (s ObjectService) Versions(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+	resource, action := "Versions", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.versions(collectionID, objectID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ObjectService) versions(collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+	versions := []string{}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		keys, err := objectKeys(tx, collectionID, objectID)
+		if err != nil {
+			return err
+		}
+
+		data, err := filterObjects(tx, collectionID, keys, f)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range data {
+			versions = append(versions, r.Modified)
+		}
+		return nil
+	})
+	cabby.SortVersions(versions)
+
+	cr.Total = int64(len(versions))
+	r := Range{cr}
+	first, last := r.Bounds(len(versions))
+	return versions[first:last], err
+}
+
+// objectIterator implements a cabby.ObjectIterator over objects read from the data store
+type objectIterator struct {
+	objects       []cabby.Object
+	cr            *cabby.Range
+	position      position
+	object        cabby.Object
+	lastUpdatedAt time.Time
+	updatedAt     time.Time
+	firstAdded    time.Time
+	lastAdded     time.Time
+}
+
+func (o *objectIterator) Next() bool {
+	if len(o.objects) == 0 {
+		return false
+	}
+
+	o.object, o.objects = o.objects[0], o.objects[1:]
+	o.position.set(o.cr)
+	o.updatedAt = o.lastUpdatedAt
+	return true
+}
+
+func (o *objectIterator) UpdatedAt() time.Time {
+	return o.updatedAt
+}
+
+func (o *objectIterator) DateAdded() (time.Time, time.Time) {
+	return o.firstAdded, o.lastAdded
+}
+
+func (o *objectIterator) Object() cabby.Object {
+	return o.object
+}
+
+func (o *objectIterator) Err() error {
+	return nil
+}
+
+func (o *objectIterator) Close() error {
+	o.objects = nil
+	return nil
+}
+
+/* helpers */
+
+// read returns the object as it's stored in a collection
+func (r *objectRecord) read(tx *bbolt.Tx) cabby.Object {
+	o := cabby.Object{
+		ID:        stones.ID(r.ID),
+		Type:      r.Type,
+		Created:   r.Created,
+		Modified:  r.Modified,
+		Object:    append([]byte(nil), tx.Bucket(objectBodiesBucket).Get(r.key)...),
+		DateAdded: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt}
+
+	o.CollectionID, _ = cabby.IDFromString(r.CollectionID)
+	return o
+}
+
+// dateAdded returns when the first and last of the objects were added
+func dateAdded(data []objectRecord) (first, last time.Time) {
+	for _, r := range data {
+		if first.IsZero() || r.CreatedAt.Before(first) {
+			first = r.CreatedAt
+		}
+		if r.CreatedAt.After(last) {
+			last = r.CreatedAt
+		}
+	}
+	return
+}
+
+// createObject writes an object and its index entries; resolve it first
+func createObject(tx *bbolt.Tx, o cabby.Object, collectionID string) error {
+	id, version, t := string(o.ID), versionKey(o.Modified), now()
+
+	row, err := tx.Bucket(objectsBucket).NextSequence()
+	if err != nil {
+		return err
+	}
+
+	k := key(collectionID, id, version)
+	r := objectRecord{
+		ID:           id,
+		Type:         o.Type,
+		Created:      o.Created,
+		Modified:     o.Modified,
+		CollectionID: collectionID,
+		CreatedAt:    t,
+		UpdatedAt:    t,
+		Row:          int64(row)}
+
+	if err := put(tx.Bucket(objectsBucket), k, r); err != nil {
+		return err
+	}
+
+	writes := []struct {
+		bucket []byte
+		key    []byte
+		value  []byte
+	}{
+		{objectBodiesBucket, k, o.Object},
+		{objectsAddedBucket, key(collectionID, t.Format(addedFormat), id, version), []byte{}},
+		{objectsTypeBucket, key(collectionID, o.Type, id, version), []byte{}},
+	}
+
+	for _, w := range writes {
+		if err := tx.Bucket(w.bucket).Put(w.key, w.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteObject deletes an object version and its index entries
+func deleteObject(tx *bbolt.Tx, r objectRecord) error {
+	version := versionKey(r.Modified)
+
+	deletes := []struct {
+		bucket []byte
+		key    []byte
+	}{
+		{objectsBucket, r.key},
+		{objectBodiesBucket, r.key},
+		{objectsAddedBucket, key(r.CollectionID, r.CreatedAt.Format(addedFormat), r.ID, version)},
+		{objectsTypeBucket, key(r.CollectionID, r.Type, r.ID, version)},
+	}
+
+	for _, d := range deletes {
+		if err := tx.Bucket(d.bucket).Delete(d.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterObjects returns the objects at the keys that are in the filter, in the order they were added; keys are
+// expected to be in the collection
+func filterObjects(tx *bbolt.Tx, collectionID string, keys [][]byte, f cabby.Filter) ([]objectRecord, error) {
+	objects := tx.Bucket(objectsBucket)
+	versions := map[string]objectVersions{}
+	expiry, expired := collectionExpiry(tx, collectionID), map[string]bool{}
+	t := now()
+	var matched []objectRecord
+
+	for _, k := range keys {
+		var r objectRecord
+		ok, err := get(objects, k, &r)
+		if err != nil {
+			return matched, err
+		}
+		if !ok {
+			continue
+		}
+		r.key = k
+
+		v, ok := versions[r.ID]
+		if !ok {
+			v = firstAndLastVersions(objects, collectionID, r.ID)
+			versions[r.ID] = v
+			expired[r.ID] = objectExpired(tx, expiry, collectionID, r.ID, v.last, t)
+		}
+		if expired[r.ID] {
+			continue
+		}
+
+		o := cabby.Object{ID: stones.ID(r.ID), Type: r.Type, Modified: r.Modified}
+		if len(f.Properties) > 0 {
+			o.Object = tx.Bucket(objectBodiesBucket).Get(k)
+		}
+		if f.Match(o, objectVersion(versionKey(r.Modified), v.first, v.last), r.CreatedAt) {
+			matched = append(matched, r)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return string(matched[i].key) < string(matched[j].key)
+		}
+		return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+	})
+	return matched, nil
+}
+
+// collectionExpiry returns a collection with only its expiry read
+func collectionExpiry(tx *bbolt.Tx, collectionID string) cabby.Collection {
+	var r collectionRecord
+	if _, err := get(tx.Bucket(collectionsBucket), []byte(collectionID), &r); err != nil {
+		return cabby.Collection{}
+	}
+	return cabby.Collection{ExpireBy: r.ExpireBy, MaxAge: r.MaxAge}
+}
+
+// objectExpired returns whether the latest version of an object has expired in the collection
+func objectExpired(tx *bbolt.Tx, c cabby.Collection, collectionID, id, last string, t time.Time) bool {
+	if c.ExpireBy == "" {
+		return false
+	}
+
+	r := objectRecord{key: key(collectionID, id, last)}
+	if ok, err := get(tx.Bucket(objectsBucket), r.key, &r); !ok || err != nil {
+		return false
+	}
+	return c.Expired(r.read(tx), t)
+}
+
+type objectVersions struct{ first, last string }
+
+// firstAndLastVersions reads the versions of an object in a collection; they're in order in the objects bucket
+func firstAndLastVersions(objects *bbolt.Bucket, collectionID, id string) objectVersions {
+	p := prefix(collectionID, id)
+	c := objects.Cursor()
+	v := objectVersions{}
+
+	k, _ := c.Seek(p)
+	if k == nil || !strings.HasPrefix(string(k), string(p)) {
+		return v
+	}
+	v.first = string(k[len(p):])
+
+	k, _ = c.Seek(append(p, 0xff))
+	if k == nil {
+		k, _ = c.Last()
+	} else {
+		k, _ = c.Prev()
+	}
+	v.last = string(k[len(p):])
+	return v
+}
+
+// indexKeys returns the object keys for index entries with the prefix; index keys end with the object id and version
+func indexKeys(b *bbolt.Bucket, collectionID string, seek, p []byte) ([][]byte, error) {
+	var keys [][]byte
+
+	err := scanFrom(b, seek, p, func(k, v []byte) error {
+		parts := keyParts(k)
+		if len(parts) < 2 {
+			return fmt.Errorf("Invalid index key: %q", k)
+		}
+
+		keys = append(keys, key(collectionID, parts[len(parts)-2], parts[len(parts)-1]))
+		return nil
+	})
+	return keys, err
+}
+
+// objectKeys returns the keys of each version of an object in a collection
+func objectKeys(tx *bbolt.Tx, collectionID, id string) ([][]byte, error) {
+	var keys [][]byte
+
+	err := scan(tx.Bucket(objectsBucket), prefix(collectionID, id), func(k, v []byte) error {
+		keys = append(keys, append([]byte(nil), k...))
+		return nil
+	})
+	return keys, err
+}
+
+// objects returns the objects in a collection that are in the filter; the index read depends on the filter: ids,
+// then types, then added after, then every object in the collection
+func (s *DataStore) objects(tx *bbolt.Tx, collectionID string, f cabby.Filter) ([]objectRecord, error) {
+	var keys [][]byte
+	var err error
+
+	switch {
+	case len(f.IDs) > 0:
+		for _, id := range uniqueValues(f.IDs) {
+			idKeys, err := objectKeys(tx, collectionID, id)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, idKeys...)
+		}
+	case len(f.Types) > 0:
+		for _, t := range uniqueValues(f.Types) {
+			p := prefix(collectionID, t)
+			typeKeys, err := indexKeys(tx.Bucket(objectsTypeBucket), collectionID, p, p)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, typeKeys...)
+		}
+	case len(f.AddedAfter) > 0:
+		t, err := time.Parse(time.RFC3339Nano, f.AddedAfter)
+		if err != nil {
+			return nil, nil
+		}
+
+		seek := key(collectionID, t.UTC().Truncate(time.Millisecond).Add(time.Millisecond).Format(addedFormat))
+		keys, err = indexKeys(tx.Bucket(objectsAddedBucket), collectionID, seek, prefix(collectionID))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		keys, err = indexKeys(tx.Bucket(objectsAddedBucket), collectionID, prefix(collectionID), prefix(collectionID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return filterObjects(tx, collectionID, keys, f)
+}
+
+// objectVersion places a version among the first and last versions of an object
+func objectVersion(modified, first, last string) string {
+	switch {
+	case modified == first && modified == last:
+		return "only"
+	case modified == last:
+		return "last"
+	case modified == first:
+		return "first"
+	}
+	return ""
+}
+
+func uniqueValues(raw string) []string {
+	seen := map[string]bool{}
+	var values []string
+
+	for _, v := range strings.Split(raw, ",") {
+		if !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// resolveObject checks an object can be written and applies the collection's version policy to it; like the sql
+// data stores an object's id and version are unique in a collection, the same version can be in other collections
+func resolveObject(tx *bbolt.Tx, o cabby.Object, collectionID string) (cabby.VersionAction, error) {
+	if !json.Valid(o.Object) {
+		return cabby.WriteVersion, fmt.Errorf("Object %s isn't valid JSON", o.ID)
+	}
+
+	stored, err := storedVersions(tx, o, collectionID)
+	if err != nil {
+		return cabby.WriteVersion, err
+	}
+	return cabby.ResolveVersion(versionPolicy(tx, collectionID), o, stored)
+}
+
+// writeObject does what the version policy resolved for an object
+func writeObject(tx *bbolt.Tx, o cabby.Object, collectionID string, action cabby.VersionAction) error {
+	switch action {
+	case cabby.WriteVersion:
+		return createObject(tx, o, collectionID)
+	case cabby.OverwriteVersion:
+		return overwriteObject(tx, o, collectionID)
+	}
+	return nil
+}
+
+// overwriteObject replaces the stored version of an object; the date it was added doesn't change
+func overwriteObject(tx *bbolt.Tx, o cabby.Object, collectionID string) error {
+	id, version := string(o.ID), versionKey(o.Modified)
+	k := key(collectionID, id, version)
+
+	var r objectRecord
+	ok, err := get(tx.Bucket(objectsBucket), k, &r)
+	if !ok || err != nil {
+		return err
+	}
+
+	if err := tx.Bucket(objectsTypeBucket).Delete(key(collectionID, r.Type, id, version)); err != nil {
+		return err
+	}
+
+	r.Type, r.Created, r.UpdatedAt = o.Type, o.Created, now()
+	if err := put(tx.Bucket(objectsBucket), k, r); err != nil {
+		return err
+	}
+
+	if err := tx.Bucket(objectBodiesBucket).Put(k, o.Object); err != nil {
+		return err
+	}
+	return tx.Bucket(objectsTypeBucket).Put(key(collectionID, o.Type, id, version), []byte{})
+}
+
+// storedVersions returns the versions of an object in a collection
+func storedVersions(tx *bbolt.Tx, o cabby.Object, collectionID string) ([]cabby.Object, error) {
+	keys, err := objectKeys(tx, collectionID, string(o.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []cabby.Object
+	for _, k := range keys {
+		var r objectRecord
+		if _, err := get(tx.Bucket(objectsBucket), k, &r); err != nil {
+			return versions, err
+		}
+
+		versions = append(versions, cabby.Object{
+			ID:       o.ID,
+			Modified: r.Modified,
+			Object:   tx.Bucket(objectBodiesBucket).Get(k)})
+	}
+	return versions, nil
+}
+
+// versionPolicy returns a collection's version policy
+func versionPolicy(tx *bbolt.Tx, collectionID string) string {
+	var r collectionRecord
+	if _, err := get(tx.Bucket(collectionsBucket), []byte(collectionID), &r); err != nil {
+		return ""
+	}
+	return r.VersionPolicy
+}
+
+// versionKey is the modified time of an object in a fixed width format so versions sort; a time that can't be
+// parsed is used as is
+func versionKey(modified string) string {
+	t, err := time.Parse(time.RFC3339Nano, modified)
+	if err != nil {
+		return modified
+	}
+	return t.UTC().Format(modifiedFormat)
+}
+
+// writeObjects writes objects in one transaction and returns the ones that failed and the ones ignored by the
+// collection's version policy; if the transaction fails they all fail
+func (s *DataStore) writeObjects(objects []cabby.Object, collectionID string) (
+	failures []cabby.StatusFailure, ignored []string) {
+	if len(objects) == 0 {
+		return
+	}
+
+	err := s.DB.Update(func(tx *bbolt.Tx) error {
+		failures, ignored = nil, nil
+
+		for _, o := range objects {
+			action, err := resolveObject(tx, o, collectionID)
+			if err != nil {
+				log.WithFields(log.Fields{"error": err, "id": o.ID}).Error("Failed to write object")
+				failures = append(failures, cabby.StatusFailure{ID: string(o.ID), Version: o.Modified, Message: err.Error()})
+				continue
+			}
+
+			if action == cabby.IgnoreVersion {
+				ignored = append(ignored, string(o.ID))
+			}
+
+			if err := writeObject(tx, o, collectionID, action); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "objects": len(objects)}).Error("Failed to write objects")
+
+		failures, ignored = nil, nil
+		for _, o := range objects {
+			failures = append(failures, cabby.StatusFailure{ID: string(o.ID), Version: o.Modified, Message: err.Error()})
+		}
+	}
+	return
+}
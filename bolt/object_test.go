@@ -0,0 +1,218 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+	"github.com/pladdy/stones"
+	"go.etcd.io/bbolt"
+)
+
+func TestObjectServiceCreateBundle(t *testing.T) {
+	ds, cleanup := setupBolt(t)
+	defer cleanup()
+
+	content, err := ioutil.ReadFile("testdata/malware_bundle.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bundle stones.Bundle
+	if err := json.Unmarshal(content, &bundle); err != nil {
+		t.Fatal(err)
+	}
+
+	st, _ := cabby.NewStatus(len(bundle.Objects))
+	ds.StatusService().CreateStatus(context.Background(), st)
+	ds.ObjectService().CreateBundle(context.Background(), objectsToChannel(bundle.Objects), tester.CollectionID, st, ds.StatusService())
+
+	// objects are kept as they're posted
+	for _, object := range bundle.Objects {
+		expected, _ := cabby.NewObject(object)
+		results, _ := ds.ObjectService().Object(context.Background(), tester.CollectionID, string(expected.ID), cabby.Filter{})
+
+		if len(results) != 1 {
+			t.Fatal("Got:", len(results), "Expected:", 1)
+		}
+		if string(results[0].Object) != string(object) {
+			t.Error("Got:", string(results[0].Object), "Expected:", string(object))
+		}
+	}
+
+	status, _ := ds.StatusService().Status(context.Background(), st.ID.String())
+	if status.SuccessCount != int64(len(bundle.Objects)) {
+		t.Error("Got:", status.SuccessCount, "Expected:", len(bundle.Objects))
+	}
+}
+
+// objects are written a batch at a time; a bad object fails on its own
+func TestObjectServiceCreateBundleBatches(t *testing.T) {
+	ds, cleanup := setupBolt(t)
+	defer cleanup()
+
+	bundle, _ := stones.NewBundle()
+	for i := 0; i < progressInterval*2+10; i++ {
+		id, _ := stones.NewStixID("malware")
+		object := fmt.Sprintf(
+			`{"type": "malware", "id": "%s", "created": "2016-04-06T20:07:09.000Z", "modified": "2016-04-06T20:07:09.000Z"}`,
+			id.String())
+		bundle.Objects = append(bundle.Objects, []byte(object))
+	}
+
+	// an invalid object and a version that exists
+	bundle.Objects = append(bundle.Objects, []byte(`{"type": "malware"}`), tester.Object.Object)
+
+	st, _ := cabby.NewStatus(len(bundle.Objects))
+	ds.StatusService().CreateStatus(context.Background(), st)
+	ds.ObjectService().CreateBundle(context.Background(), objectsToChannel(bundle.Objects), tester.CollectionID, st, ds.StatusService())
+
+	status, _ := ds.StatusService().Status(context.Background(), st.ID.String())
+	if status.SuccessCount != int64(progressInterval*2+10) {
+		t.Error("Got:", status.SuccessCount, "Expected:", progressInterval*2+10)
+	}
+	if status.FailureCount != 2 || len(status.Failures) != 2 {
+		t.Error("Got:", status.FailureCount, status.Failures, "Expected:", 2)
+	}
+
+	cr := cabby.Range{First: -1, Last: -1}
+	results, _ := ds.ObjectService().Objects(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+	if len(results) != progressInterval*2+11 {
+		t.Error("Got:", len(results), "Expected:", progressInterval*2+11)
+	}
+}
+
+func TestObjectServiceCreateBundleCanceled(t *testing.T) {
+	ds, cleanup := newDataStore(t)
+	defer cleanup()
+
+	bundle, _ := stones.NewBundle()
+	for i := 0; i < progressInterval*2; i++ {
+		id, _ := stones.NewStixID("malware")
+		object := fmt.Sprintf(
+			`{"type": "malware", "id": "%s", "created": "2016-04-06T20:07:09.000Z", "modified": "2016-04-06T20:07:09.000Z"}`,
+			id.String())
+		bundle.Objects = append(bundle.Objects, []byte(object))
+	}
+
+	ssv := ds.StatusService()
+	st, _ := cabby.NewStatus(len(bundle.Objects))
+	ssv.CreateStatus(context.Background(), st)
+	ssv.CancelStatus(context.Background(), st.ID.String())
+
+	ds.ObjectService().CreateBundle(context.Background(), objectsToChannel(bundle.Objects), tester.CollectionID, st, ssv)
+
+	// the first batch is written before the cancel is checked
+	cr := cabby.Range{First: -1, Last: -1}
+	results, _ := ds.ObjectService().Objects(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+	if len(results) != progressInterval {
+		t.Error("Got:", len(results), "Expected:", progressInterval)
+	}
+
+	status, _ := ssv.Status(context.Background(), st.ID.String())
+	if status.Status != "canceled" {
+		t.Error("Got:", status.Status, "Expected:", "canceled")
+	}
+	if status.FailureCount != int64(progressInterval) {
+		t.Error("Got:", status.FailureCount, "Expected:", progressInterval)
+	}
+}
+
+// each object version has an entry in every index
+func TestObjectServiceCreateObjectIndexes(t *testing.T) {
+	ds, cleanup := setupBolt(t)
+	defer cleanup()
+
+	err := ds.DB.View(func(tx *bbolt.Tx) error {
+		for _, b := range [][]byte{objectsBucket, objectBodiesBucket, objectsAddedBucket, objectsTypeBucket} {
+			if n := tx.Bucket(b).Stats().KeyN; n != 1 {
+				t.Error("Got:", n, "Expected:", 1, "Bucket:", string(b))
+			}
+		}
+
+		version := versionKey(tester.Object.Modified)
+		if tx.Bucket(objectsTypeBucket).Get(key(tester.CollectionID, "malware", tester.ObjectID, version)) == nil {
+			t.Error("Expected a type index entry")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// filters read from the index that fits them; the results are the same as filtering every object
+func TestObjectServiceObjectsIndexes(t *testing.T) {
+	ds, cleanup := setupBolt(t)
+	defer cleanup()
+
+	indicator := createTypedObject(t, ds, "indicator", "2018-01-01T00:00:00.000Z")
+	time.Sleep(10 * time.Millisecond)
+	addedAfter := time.Now().UTC().Format(time.RFC3339Nano)
+	time.Sleep(10 * time.Millisecond)
+
+	createTypedObject(t, ds, "indicator", "2018-01-01T00:00:00.000Z")
+	o := tester.Object
+	o.ID = stones.ID(indicator)
+	o.Type = "indicator"
+	o.Modified = "2018-01-02T00:00:00.000Z"
+	createTestObject(t, ds, o)
+
+	tests := []struct {
+		filter   cabby.Filter
+		expected int
+	}{
+		{cabby.Filter{IDs: indicator}, 2},
+		{cabby.Filter{IDs: indicator + "," + indicator}, 2},
+		{cabby.Filter{IDs: indicator, Versions: "first"}, 1},
+		{cabby.Filter{IDs: indicator, Types: "malware"}, 0},
+		{cabby.Filter{Types: "indicator"}, 3},
+		{cabby.Filter{Types: "indicator,malware"}, 4},
+		{cabby.Filter{Types: "indicator", Versions: "last"}, 2},
+		{cabby.Filter{Types: "indicator", AddedAfter: addedAfter}, 2},
+		{cabby.Filter{AddedAfter: addedAfter}, 2},
+		{cabby.Filter{AddedAfter: addedAfter, Versions: "first"}, 1},
+		{cabby.Filter{AddedAfter: "not a time"}, 0},
+	}
+
+	for _, test := range tests {
+		cr := cabby.Range{First: -1, Last: -1}
+		results, err := ds.ObjectService().Objects(context.Background(), tester.CollectionID, &cr, test.filter)
+		if err != nil {
+			t.Error("Got:", err, "Filter:", test.filter)
+		}
+
+		if len(results) != test.expected {
+			t.Error("Got:", len(results), "Expected:", test.expected, "Filter:", test.filter)
+		}
+	}
+}
+
+// objects are listed in the order they're added
+func TestObjectServiceObjectsOrder(t *testing.T) {
+	ds, cleanup := setupBolt(t)
+	defer cleanup()
+
+	expected := []string{tester.ObjectID}
+	for i := 0; i < 5; i++ {
+		time.Sleep(2 * time.Millisecond)
+		expected = append(expected, createTypedObject(t, ds, "malware", "2018-01-01T00:00:00.000Z"))
+	}
+
+	cr := cabby.Range{First: -1, Last: -1}
+	results, _ := ds.ObjectService().Objects(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+	if len(results) != len(expected) {
+		t.Fatal("Got:", len(results), "Expected:", len(expected))
+	}
+
+	for i, o := range results {
+		if string(o.ID) != expected[i] {
+			t.Error("Got:", o.ID, "Expected:", expected[i], "Index:", i)
+		}
+	}
+}
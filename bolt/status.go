@@ -0,0 +1,336 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// StatusService implements a bolt version of the StatusService interface
+type StatusService struct {
+	DataStore *DataStore
+}
+
+type statusRecord struct {
+	ID           string                `json:"id"`
+	Status       string                `json:"status"`
+	TotalCount   int64                 `json:"total_count"`
+	SuccessCount int64                 `json:"success_count"`
+	FailureCount int64                 `json:"failure_count"`
+	Failures     []cabby.StatusFailure `json:"failures"`
+	Successes    []string              `json:"successes"`
+	PendingCount int64                 `json:"pending_count"`
+	User         string                `json:"user"`
+	CollectionID string                `json:"collection_id"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+	// Row orders statuses created at the same time; statuses created before rows were numbered are all row 0
+	Row int64 `json:"row,omitempty"`
+}
+
+// read returns the fields a status read has; like the sql data stores, pendings aren't kept
+func (r *statusRecord) read() (cabby.Status, error) {
+	id, err := cabby.IDFromString(r.ID)
+	return cabby.Status{
+		ID:           id,
+		Status:       r.Status,
+		TotalCount:   r.TotalCount,
+		SuccessCount: r.SuccessCount,
+		FailureCount: r.FailureCount,
+		Failures:     r.Failures,
+		Successes:    r.Successes,
+		PendingCount: r.PendingCount,
+		User:         r.User,
+		CollectionID: r.CollectionID,
+		CreatedAt:    r.CreatedAt.Format(timeFormat)}, err
+}
+
+// CancelStatus will mark a pending status as canceled
+func (s StatusService) CancelStatus(ctx context.Context, statusID string) error {
+	resource, action := "Status", "cancel"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.cancelStatus(statusID)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s StatusService) cancelStatus(statusID string) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(statusesBucket)
+
+		var r statusRecord
+		ok, err := get(b, []byte(statusID), &r)
+		if err != nil {
+			return err
+		}
+		if !ok || r.Status != "pending" {
+			return errors.New("No pending status found for this id")
+		}
+
+		r.Status = "canceled"
+		r.UpdatedAt = now()
+		return put(b, []byte(statusID), r)
+	})
+}
+
+// CreateStatus will read from the data store and return the resource
+func (s StatusService) CreateStatus(ctx context.Context, status cabby.Status) error {
+	resource, action := "Status", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.createStatus(status)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s StatusService) createStatus(st cabby.Status) error {
+	t := now()
+
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		row, err := tx.Bucket(statusesBucket).NextSequence()
+		if err != nil {
+			return err
+		}
+
+		return put(tx.Bucket(statusesBucket), []byte(st.ID.String()), statusRecord{
+			ID:           st.ID.String(),
+			Status:       st.Status,
+			TotalCount:   st.TotalCount,
+			SuccessCount: st.SuccessCount,
+			FailureCount: st.FailureCount,
+			PendingCount: st.PendingCount,
+			User:         st.User,
+			CollectionID: st.CollectionID,
+			CreatedAt:    t,
+			UpdatedAt:    t,
+			Row:          int64(row)})
+	})
+}
+
+// PurgeStatuses will delete statuses created before a given time that are no longer pending
+func (s StatusService) PurgeStatuses(ctx context.Context, before time.Time) (int64, error) {
+	resource, action := "Statuses", "purge"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.purgeStatuses(before)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s StatusService) purgeStatuses(before time.Time) (int64, error) {
+	before = before.Truncate(time.Millisecond)
+	var purged int64
+
+	err := s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(statusesBucket)
+		var keys [][]byte
+
+		err := b.ForEach(func(k, v []byte) error {
+			var r statusRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+
+			if r.CreatedAt.Before(before) && r.Status != "pending" {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		purged = int64(len(keys))
+		return nil
+	})
+	return purged, err
+}
+
+// Status will read from the data store and return the resource
+func (s StatusService) Status(ctx context.Context, statusID string) (cabby.Status, error) {
+	resource, action := "Status", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.status(statusID)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s StatusService) status(statusID string) (cabby.Status, error) {
+	st := cabby.Status{}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		var r statusRecord
+		ok, err := get(tx.Bucket(statusesBucket), []byte(statusID), &r)
+		if !ok || err != nil {
+			return err
+		}
+
+		st, err = r.read()
+		return err
+	})
+	return st, err
+}
+
+// Statuses will read from the data store and return the resource
+func (s StatusService) Statuses(ctx context.Context, cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+	resource, action := "Statuses", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.statuses(cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// statuses are listed newest first; like the sql data stores, statuses in a list leave out their failures and successes
+func (s StatusService) statuses(cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+	filter := StatusFilter{f}
+	var data []statusRecord
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(statusesBucket).ForEach(func(k, v []byte) error {
+			var r statusRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+
+			if filter.Match(cabby.Status{User: r.User, CollectionID: r.CollectionID, Status: r.Status}, r.CreatedAt) {
+				data = append(data, r)
+			}
+			return nil
+		})
+	})
+
+	statuses := []cabby.Status{}
+	if err != nil {
+		return statuses, err
+	}
+
+	sort.SliceStable(data, func(i, j int) bool {
+		if data[i].CreatedAt.Equal(data[j].CreatedAt) {
+			return data[i].Row > data[j].Row
+		}
+		return data[i].CreatedAt.After(data[j].CreatedAt)
+	})
+
+	r := Range{cr}
+	first, last := r.Bounds(len(data))
+
+	for _, d := range data[first:last] {
+		st, err := d.read()
+		if err != nil {
+			return statuses, err
+		}
+
+		st.Failures = nil
+		st.Successes = nil
+		statuses = append(statuses, st)
+		cr.Total = int64(len(data))
+	}
+	return statuses, nil
+}
+
+// UpdateStatus will read from the data store and return the resource
+func (s StatusService) UpdateStatus(ctx context.Context, status cabby.Status) error {
+	resource, action := "Status", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.updateStatus(status)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s StatusService) updateStatus(st cabby.Status) error {
+	st.PendingCount = st.TotalCount - st.SuccessCount - st.FailureCount
+
+	if st.PendingCount == 0 {
+		st.SuccessCount = st.TotalCount - st.FailureCount
+		st.Status = "complete"
+	}
+
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(statusesBucket)
+		k := []byte(st.ID.String())
+
+		var r statusRecord
+		ok, err := get(b, k, &r)
+		if !ok || err != nil {
+			return err
+		}
+
+		// a canceled status stays canceled
+		if r.Status != "canceled" {
+			r.Status = st.Status
+		}
+		r.TotalCount = st.TotalCount
+		r.SuccessCount = st.SuccessCount
+		r.FailureCount = st.FailureCount
+		r.Failures = st.Failures
+		r.Successes = st.Successes
+		r.PendingCount = st.PendingCount
+		r.UpdatedAt = now()
+		return put(b, k, r)
+	})
+}
+
+// updateStatusProgress sets the counts of a pending status to what's been received
+func (s *DataStore) updateStatusProgress(st cabby.Status, received int64) error {
+	return s.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(statusesBucket)
+		k := []byte(st.ID.String())
+
+		var r statusRecord
+		ok, err := get(b, k, &r)
+		if !ok || err != nil || r.Status != "pending" {
+			return err
+		}
+
+		r.TotalCount = received
+		r.PendingCount = received
+		r.UpdatedAt = now()
+		return put(b, k, r)
+	})
+}
+
+// StatusFilter implementation for the bolt data store
+type StatusFilter struct {
+	cabby.StatusFilter
+}
+
+// Match returns whether a status created at the given time is in the filter
+func (f *StatusFilter) Match(st cabby.Status, createdAt time.Time) bool {
+	fields := []struct {
+		field string
+		value string
+	}{
+		{st.User, f.User},
+		{st.CollectionID, f.CollectionID},
+		{st.Status, f.Status},
+	}
+
+	for _, field := range fields {
+		if len(field.value) > 0 && field.field != field.value {
+			return false
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, f.CreatedAfter); err == nil {
+		if !createdAt.After(t.Truncate(time.Millisecond)) {
+			return false
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, f.CreatedBefore); err == nil {
+		if !createdAt.Before(t.Truncate(time.Millisecond)) {
+			return false
+		}
+	}
+
+	return true
+}
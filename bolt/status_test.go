@@ -0,0 +1,71 @@
+package bolt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"go.etcd.io/bbolt"
+)
+
+func TestStatusServicePurgeStatuses(t *testing.T) {
+	ds, cleanup := newDataStore(t)
+	defer cleanup()
+
+	s := ds.StatusService()
+	old := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	statuses := []struct {
+		status    string
+		createdAt time.Time
+	}{
+		{"complete", old},
+		{"canceled", old},
+		{"pending", old},
+		{"complete", now()},
+	}
+
+	for _, st := range statuses {
+		status, _ := cabby.NewStatus(3)
+		status.Status = st.status
+
+		if err := s.CreateStatus(context.Background(), status); err != nil {
+			t.Fatal(err)
+		}
+		setStatusCreatedAt(t, ds, status.ID.String(), st.createdAt)
+	}
+
+	purged, err := s.PurgeStatuses(context.Background(), time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Error("Got:", err)
+	}
+
+	// pending statuses aren't purged
+	if purged != 2 {
+		t.Error("Got:", purged, "Expected:", 2)
+	}
+
+	cr := cabby.Range{First: -1, Last: -1}
+	results, _ := s.Statuses(context.Background(), &cr, cabby.StatusFilter{})
+	if len(results) != 2 {
+		t.Error("Got:", len(results), "Expected:", 2)
+	}
+}
+
+func setStatusCreatedAt(t *testing.T, ds *DataStore, id string, createdAt time.Time) {
+	err := ds.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(statusesBucket)
+
+		var r statusRecord
+		if _, err := get(b, []byte(id), &r); err != nil {
+			return err
+		}
+
+		r.CreatedAt = createdAt
+		return put(b, []byte(id), r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,265 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/bbolt"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// UserService implements a bolt version of the servce
+type UserService struct {
+	DataStore *DataStore
+}
+
+type userRecord struct {
+	Email     string    `json:"email"`
+	CanAdmin  bool      `json:"can_admin"`
+	Pass      string    `json:"pass"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// userCollectionRecord is keyed by the user's email and the collection id
+type userCollectionRecord struct {
+	CollectionID string    `json:"collection_id"`
+	CanRead      bool      `json:"can_read"`
+	CanWrite     bool      `json:"can_write"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CreateUser creates a user in the data store
+func (s UserService) CreateUser(ctx context.Context, user cabby.User, password string) error {
+	resource, action := "User", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := cabby.ValidateUserPassword(user, password)
+	if err == nil {
+		err = s.createUser(user, password)
+	} else {
+		log.WithFields(log.Fields{"error": err, "password": password, "user": user}).Error("Invalid user and/or password")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s UserService) createUser(u cabby.User, password string) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		if b.Get([]byte(u.Email)) != nil {
+			return fmt.Errorf("User %s already exists", u.Email)
+		}
+		return put(b, []byte(u.Email), userRecord{Email: u.Email, CanAdmin: u.CanAdmin, Pass: cabby.HashPassword(password), UpdatedAt: now()})
+	})
+}
+
+// CreateUserCollection creates an association of a user to a collection
+func (s UserService) CreateUserCollection(ctx context.Context, user string, ca cabby.CollectionAccess) error {
+	resource, action := "UserCollection", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := cabby.ValidateUserCollection(user, ca)
+	if err == nil {
+		err = s.createUserCollection(user, ca)
+	} else {
+		log.WithFields(log.Fields{"error": err, "collection_access": ca, "user": user}).Error("Invalid user and/or collection")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+// like the sql data stores, an association that already exists is left as is
+func (s UserService) createUserCollection(user string, ca cabby.CollectionAccess) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(usersBucket).Get([]byte(user)) == nil {
+			return fmt.Errorf("User %s doesn't exist", user)
+		}
+
+		b := tx.Bucket(userCollectionsBucket)
+		k := key(user, ca.ID.String())
+		if b.Get(k) != nil {
+			return nil
+		}
+
+		return put(b, k, userCollectionRecord{
+			CollectionID: ca.ID.String(), CanRead: ca.CanRead, CanWrite: ca.CanWrite, UpdatedAt: now()})
+	})
+}
+
+// DeleteUserCollection deletes a collection from a user
+func (s UserService) DeleteUserCollection(ctx context.Context, user, id string) error {
+	resource, action := "UserCollection", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.deleteUserCollection(user, id)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s UserService) deleteUserCollection(user, id string) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(userCollectionsBucket).Delete(key(user, id))
+	})
+}
+
+// DeleteUser creates a user in the data store
+func (s UserService) DeleteUser(ctx context.Context, user string) error {
+	resource, action := "User", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.deleteUser(user)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+// a user's collection associations are deleted with it
+func (s UserService) deleteUser(user string) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(usersBucket).Delete([]byte(user)); err != nil {
+			return err
+		}
+
+		b := tx.Bucket(userCollectionsBucket)
+		var keys [][]byte
+
+		err := scan(b, prefix(user), func(k, v []byte) error {
+			keys = append(keys, append([]byte(nil), k...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateUser creates a user in the data store
+func (s UserService) UpdateUser(ctx context.Context, user cabby.User) error {
+	resource, action := "User", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := user.Validate()
+	if err == nil {
+		err = s.updateUser(user)
+	} else {
+		log.WithFields(log.Fields{"error": err, "user": user}).Error("Invalid user")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s UserService) updateUser(u cabby.User) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+
+		var r userRecord
+		ok, err := get(b, []byte(u.Email), &r)
+		if !ok || err != nil {
+			return err
+		}
+
+		r.CanAdmin = u.CanAdmin
+		r.UpdatedAt = now()
+		return put(b, []byte(u.Email), r)
+	})
+}
+
+// UpdateUserCollection update a users access to a specfific collection
+func (s UserService) UpdateUserCollection(ctx context.Context, user string, ca cabby.CollectionAccess) error {
+	resource, action := "UserCollection", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := cabby.ValidateUserCollection(user, ca)
+	if err == nil {
+		err = s.updateUserCollection(user, ca)
+	} else {
+		log.WithFields(log.Fields{"error": err, "collection_access": ca, "user": user}).Error("Invalid user and/or collection")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s UserService) updateUserCollection(user string, ca cabby.CollectionAccess) error {
+	return s.DataStore.DB.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(userCollectionsBucket)
+		k := key(user, ca.ID.String())
+
+		var r userCollectionRecord
+		ok, err := get(b, k, &r)
+		if !ok || err != nil {
+			return err
+		}
+
+		r.CanRead = ca.CanRead
+		r.CanWrite = ca.CanWrite
+		r.UpdatedAt = now()
+		return put(b, k, r)
+	})
+}
+
+// User will read from the data store and populate the result with a resource
+func (s UserService) User(ctx context.Context, user, password string) (cabby.User, error) {
+	resource, action := "User", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.user(user, password)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s UserService) user(user, password string) (cabby.User, error) {
+	u := cabby.User{}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		var r userRecord
+		ok, err := get(tx.Bucket(usersBucket), []byte(user), &r)
+		if ok && err == nil && r.Pass == cabby.HashPassword(password) {
+			u.Email = r.Email
+			u.CanAdmin = r.CanAdmin
+		}
+		return err
+	})
+	return u, err
+}
+
+// UserCollections will read from the data store and populate the result with a resource
+func (s UserService) UserCollections(ctx context.Context, user string) (cabby.UserCollectionList, error) {
+	resource, action := "UserCollectionList", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.userCollections(user)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s UserService) userCollections(user string) (cabby.UserCollectionList, error) {
+	ucl := cabby.UserCollectionList{Email: user, CollectionAccessList: map[cabby.ID]cabby.CollectionAccess{}}
+
+	err := s.DataStore.DB.View(func(tx *bbolt.Tx) error {
+		return scan(tx.Bucket(userCollectionsBucket), prefix(user), func(k, v []byte) error {
+			var r userCollectionRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+
+			id, err := cabby.IDFromString(r.CollectionID)
+			if err != nil {
+				return err
+			}
+
+			ucl.CollectionAccessList[id] = cabby.CollectionAccess{ID: id, CanRead: r.CanRead, CanWrite: r.CanWrite}
+			return nil
+		})
+	})
+	return ucl, err
+}
@@ -2,6 +2,7 @@ package cabby
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/pladdy/stones"
@@ -33,6 +35,8 @@ const (
 	TaxiiContentType = "application/vnd.oasis.taxii+json"
 	// TaxiiVersion notes the supported version of the server
 	TaxiiVersion = "taxii-2.0"
+	// TimestampFormat is the format of timestamps the server assigns, like the date an object was added
+	TimestampFormat = "2006-01-02T15:04:05.000Z"
 )
 
 // APIRoot resource
@@ -42,6 +46,12 @@ type APIRoot struct {
 	Description      string   `json:"description,omitempty"`
 	Versions         []string `json:"versions"`
 	MaxContentLength int64    `json:"max_content_length"`
+	// internal
+	UpdatedAt time.Time `json:"-"`
+	// DefaultPageSize is how many items a page of a listing has when a request doesn't ask for a range; 0 is every item
+	DefaultPageSize int64 `json:"-"`
+	// MaxPageSize is the most items a request can ask for in a page; 0 doesn't limit it
+	MaxPageSize int64 `json:"-"`
 }
 
 // IncludesMinVersion checks if minimum taxii version is included in list
@@ -70,6 +80,12 @@ func (a *APIRoot) Validate() error {
 	if !a.IncludesMinVersion(a.Versions) {
 		return fmt.Errorf("Minimum TAXII version %s must be included in 'Versions'", TaxiiVersion)
 	}
+	if a.DefaultPageSize < 0 || a.MaxPageSize < 0 {
+		return fmt.Errorf("Invalid page sizes: %d default, %d max", a.DefaultPageSize, a.MaxPageSize)
+	}
+	if a.MaxPageSize > 0 && a.DefaultPageSize > a.MaxPageSize {
+		return errors.New("The default page size can't be more than the max page size")
+	}
 
 	return nil
 }
@@ -92,6 +108,17 @@ type Collection struct {
 	Title       string   `json:"title"`
 	Description string   `json:"description,omitempty"`
 	MediaTypes  []string `json:"media_types,omitempty"`
+	// internal
+	UpdatedAt     time.Time `json:"-"`
+	VersionPolicy string    `json:"-"`
+	// RetainVersions is how many of an object's latest versions are kept; 0 keeps them all
+	RetainVersions int `json:"-"`
+	// RetainWindow is how long versions are kept after they're modified; 0 keeps them forever
+	RetainWindow time.Duration `json:"-"`
+	// ExpireBy is what an object's age is measured from; empty never expires objects
+	ExpireBy string `json:"-"`
+	// MaxAge is how old an object gets before it expires
+	MaxAge time.Duration `json:"-"`
 }
 
 // NewCollection returns a collection resource; it takes an optional id string
@@ -121,6 +148,30 @@ func (c *Collection) Validate() (err error) {
 		return fmt.Errorf("Invalid title: %s", c.Title)
 	}
 
+	if !ValidVersionPolicy(c.VersionPolicy) {
+		return fmt.Errorf("Invalid version policy: %s", c.VersionPolicy)
+	}
+
+	if c.RetainVersions < 0 || c.RetainWindow < 0 {
+		return fmt.Errorf("Invalid retention: %d versions, %s", c.RetainVersions, c.RetainWindow)
+	}
+
+	if c.RetainVersions > 0 && c.RetainWindow > 0 {
+		return errors.New("A collection can retain a number of versions or a window of versions, not both")
+	}
+
+	if !ValidExpiry(c.ExpireBy) || c.MaxAge < 0 {
+		return fmt.Errorf("Invalid expiry: %s, %s", c.ExpireBy, c.MaxAge)
+	}
+
+	if c.ExpireBy == ExpireByAdded && c.MaxAge == 0 {
+		return errors.New("A collection that expires objects by the date added needs a max age")
+	}
+
+	if c.ExpireBy == "" && c.MaxAge > 0 {
+		return errors.New("A collection with a max age needs an expiry")
+	}
+
 	return
 }
 
@@ -134,6 +185,8 @@ type CollectionAccess struct {
 // Collections resource
 type Collections struct {
 	Collections []Collection `json:"collections"`
+	// internal; the latest update to any collection, not just the ones in a requested range
+	UpdatedAt time.Time `json:"-"`
 }
 
 // CollectionsInAPIRoot associated a list of collection IDs that belong to a API Root Path
@@ -154,11 +207,17 @@ type CollectionService interface {
 
 // Config for a server
 type Config struct {
-	Host      string
-	Port      int
-	SSLCert   string            `json:"ssl_cert"`
-	SSLKey    string            `json:"ssl_key"`
-	DataStore map[string]string `json:"data_store"`
+	Host                string
+	Port                int
+	SSLCert             string            `json:"ssl_cert"`
+	SSLKey              string            `json:"ssl_key"`
+	DataStore           map[string]string `json:"data_store"`
+	MigrateOnStart      bool              `json:"migrate_on_start"`
+	StatusRetention     string            `json:"status_retention"`
+	StatusPurgeInterval string            `json:"status_purge_interval"`
+	CompactionInterval  string            `json:"compaction_interval"`
+	ObjectPurgeInterval string            `json:"object_purge_interval"`
+	RequestTimeout      string            `json:"request_timeout"`
 }
 
 // Parse takes a path to a config file and converts to Configs
@@ -175,6 +234,95 @@ func (c Config) Parse(file string) (initializedConfig Config) {
 	return
 }
 
+// Validate returns an error if a duration in the config can't be parsed or a background job's interval isn't
+// positive; a job ticks at its interval, which can't be zero or negative
+func (c Config) Validate() error {
+	durations := []struct {
+		name     string
+		value    string
+		interval bool
+	}{
+		{"status_retention", c.StatusRetention, false},
+		{"status_purge_interval", c.StatusPurgeInterval, true},
+		{"compaction_interval", c.CompactionInterval, true},
+		{"object_purge_interval", c.ObjectPurgeInterval, true},
+		{"request_timeout", c.RequestTimeout, false},
+	}
+
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("Invalid %s: %v", d.name, err)
+		}
+		if d.interval && duration <= 0 {
+			return fmt.Errorf("Invalid %s: %s, it has to be positive", d.name, d.value)
+		}
+	}
+	return nil
+}
+
+// Cursor is the position of an object version in a collection: when the server added it and its row in the data
+// store, which orders versions added at the same time.  It's opaque to clients; they pass back the cursor of a page
+type Cursor struct {
+	DateAdded time.Time
+	Row       int64
+}
+
+// NewCursor returns the cursor a string from Cursor.String represents; an empty string is the start of a collection
+func NewCursor(s string) (c Cursor, err error) {
+	if s == "" {
+		return c, err
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errors.New("Invalid cursor")
+	}
+
+	tokens := strings.Split(string(b), ".")
+	if len(tokens) != 2 {
+		return c, errors.New("Invalid cursor")
+	}
+
+	nanos, err := strconv.ParseInt(tokens[0], 10, 64)
+	if err != nil {
+		return c, errors.New("Invalid cursor")
+	}
+
+	c.Row, err = strconv.ParseInt(tokens[1], 10, 64)
+	if err != nil {
+		return Cursor{}, errors.New("Invalid cursor")
+	}
+
+	c.DateAdded = time.Unix(0, nanos).UTC()
+	return c, err
+}
+
+// Before returns whether the cursor is before another: it was added earlier, or at the same time in an earlier row
+func (c Cursor) Before(other Cursor) bool {
+	if c.DateAdded.Equal(other.DateAdded) {
+		return c.Row < other.Row
+	}
+	return c.DateAdded.Before(other.DateAdded)
+}
+
+// IsZero returns whether the cursor is the start of a collection
+func (c Cursor) IsZero() bool {
+	return c.DateAdded.IsZero() && c.Row == 0
+}
+
+func (c Cursor) String() string {
+	if c.IsZero() {
+		return ""
+	}
+	s := strconv.FormatInt(c.DateAdded.UnixNano(), 10) + "." + strconv.FormatInt(c.Row, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
 // DataStore interface for backend implementations
 type DataStore interface {
 	APIRootService() APIRootService
@@ -195,6 +343,8 @@ type Discovery struct {
 	Contact     string   `json:"contact,omitempty"`
 	Default     string   `json:"default,omitempty"`
 	APIRoots    []string `json:"api_roots,omitempty"`
+	// internal
+	UpdatedAt time.Time `json:"-"`
 }
 
 // Validate a discovery resource
@@ -231,6 +381,9 @@ type Filter struct {
 	IDs        string
 	Types      string
 	Versions   string
+	// Properties are match filters on the other MatchProperties, by property; like ids and types the values are
+	// comma separated
+	Properties map[string]string
 }
 
 // ID for taxii resources
@@ -284,11 +437,31 @@ type ManifestEntry struct {
 	MediaTypes []string `json:"media_types"`
 }
 
+// ManifestIterator reads manifest entries one at a time; like sql.Rows, call Next before each Entry and Close when done.
+// UpdatedAt is the latest update to any entry the read matched, not just the ones in a requested range.
+// DateAdded is when the first and last entries in the requested range were added.
+type ManifestIterator interface {
+	Next() bool
+	Entry() ManifestEntry
+	Err() error
+	Close() error
+	UpdatedAt() time.Time
+	DateAdded() (first, last time.Time)
+}
+
 // ManifestService provides manifest data
 type ManifestService interface {
+	IterateManifest(ctx context.Context, collectionID string, cr *Range, f Filter) (ManifestIterator, error)
 	Manifest(ctx context.Context, collectionID string, cr *Range, f Filter) (Manifest, error)
 }
 
+// Migrator is implemented by data stores with a versioned schema
+type Migrator interface {
+	Migrate(ctx context.Context) (int, error)
+	SchemaVersion(ctx context.Context) (int, error)
+	VerifySchema(ctx context.Context) error
+}
+
 // Object for STIX 2 object data
 // TODO: this should be in stones; needs validation too (in stones)
 type Object struct {
@@ -298,21 +471,69 @@ type Object struct {
 	Modified     string    `json:"modified"`
 	Object       []byte
 	CollectionID ID
+	// DateAdded is when the server added this version of the object to its collection; it never changes
+	DateAdded time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+// NewObject returns an object given its STIX JSON; it's an error if the JSON doesn't have an id
+func NewObject(b []byte) (Object, error) {
+	var o Object
+	err := json.Unmarshal(b, &o)
+	if err != nil {
+		return o, err
+	}
+
+	if o.ID == "" {
+		err = errors.New("Invalid ID")
+	}
+
+	o.Object = b
+	return o, err
+}
+
+// ObjectIterator reads objects one at a time; like sql.Rows, call Next before each Object and Close when done.
+// UpdatedAt is the latest update to any object the read matched, not just the ones in a requested range.
+// DateAdded is when the first and last objects in the requested range were added.
+type ObjectIterator interface {
+	Next() bool
+	Object() Object
+	Err() error
+	Close() error
+	UpdatedAt() time.Time
+	DateAdded() (first, last time.Time)
 }
 
 // ObjectService provides Object data
 type ObjectService interface {
-	CreateBundle(ctx context.Context, b stones.Bundle, collectionID string, s Status, ss StatusService)
+	CompactObjects(ctx context.Context) (int64, error)
+	CreateBundle(ctx context.Context, objects <-chan []byte, collectionID string, s Status, ss StatusService)
 	CreateObject(ctx context.Context, o Object) error
+	DeleteObject(ctx context.Context, collectionID, objectID string, f Filter) (int64, error)
+	IterateObjects(ctx context.Context, collectionID string, cr *Range, f Filter) (ObjectIterator, error)
 	Object(ctx context.Context, collectionID, objectID string, f Filter) ([]Object, error)
 	Objects(ctx context.Context, collectionID string, cr *Range, f Filter) ([]Object, error)
+	PurgeObjects(ctx context.Context) (int64, error)
+	Tombstones(ctx context.Context, collectionID string, f Filter) ([]Tombstone, error)
+	Versions(ctx context.Context, collectionID, objectID string, cr *Range, f Filter) ([]string, error)
 }
 
-// Range is used for paginated requests to represent the requested data range
+// Range is used for paginated requests to represent the requested data range.  A cursored range pages by cursor
+// instead of by item: it's the Limit items added after the After cursor, or all of them when there's no limit.
+// Reading a cursored range sets Offset, how many items are before it, and Next, the cursor of its last item when more
+// items follow it.  Items are read in the order of the range's Sort; a cursored range is always in the order its
+// items were added.
 type Range struct {
-	First int64
-	Last  int64
-	Total int64
+	First  int64
+	Last   int64
+	Total  int64
+	After  Cursor
+	Limit  int64
+	Offset int64
+	Next   Cursor
+	Sort   Sort
+	// Cursored is set for a range that pages by cursor
+	Cursored bool
 }
 
 // NewRange returns a Range given a string from the 'Range' HTTP header string
@@ -352,6 +573,44 @@ func (r *Range) String() string {
 	return s
 }
 
+// Bound limits the range to page sizes: a range without items, or a cursored range without a limit, is given the
+// default size, or the max size when there's no default, and a larger range is cut to the max size.  A size of 0
+// doesn't limit the range.
+func (r *Range) Bound(defaultSize, maxSize int64) {
+	if r.Cursored {
+		if r.Limit == 0 {
+			r.Limit = defaultSize
+		}
+		if maxSize > 0 && (r.Limit == 0 || r.Limit > maxSize) {
+			r.Limit = maxSize
+		}
+		return
+	}
+
+	if !r.Valid() && defaultSize > 0 {
+		r.First, r.Last = 0, defaultSize-1
+	}
+	if !r.Valid() && maxSize > 0 {
+		r.First, r.Last = 0, maxSize-1
+	}
+
+	if maxSize > 0 && r.Last-r.First+1 > maxSize {
+		r.Last = r.First + maxSize - 1
+	}
+}
+
+// SetPage sets where a cursored range is in the items read: how many there are, how many of them are after the
+// range's cursor, and the cursor of the last item in the range
+func (r *Range) SetPage(total, remaining int64, last Cursor) {
+	r.Total = total
+	r.Offset = total - remaining
+	r.Next = Cursor{}
+
+	if r.Limit > 0 && remaining > r.Limit {
+		r.Next = last
+	}
+}
+
 // Valid returns whether the range is valid or not
 func (r *Range) Valid() bool {
 	if r.First < 0 || r.Last < 0 {
@@ -367,16 +626,20 @@ func (r *Range) Valid() bool {
 
 // Status represents a TAXII status object
 type Status struct {
-	ID               ID       `json:"id"`
-	Status           string   `json:"status"`
-	RequestTimestamp string   `json:"request_timestamp"`
-	TotalCount       int64    `json:"total_count"`
-	SuccessCount     int64    `json:"success_count"`
-	Successes        []string `json:"successes"`
-	FailureCount     int64    `json:"failure_count"`
-	Failures         []string `json:"failures"`
-	PendingCount     int64    `json:"pending_count"`
-	Pendings         []string `json:"pendings"`
+	ID               ID              `json:"id"`
+	Status           string          `json:"status"`
+	RequestTimestamp string          `json:"request_timestamp"`
+	TotalCount       int64           `json:"total_count"`
+	SuccessCount     int64           `json:"success_count"`
+	Successes        []string        `json:"successes"`
+	FailureCount     int64           `json:"failure_count"`
+	Failures         []StatusFailure `json:"failures"`
+	PendingCount     int64           `json:"pending_count"`
+	Pendings         []string        `json:"pendings"`
+	// internal fields; they aren't part of the TAXII status resource
+	User         string `json:"-"`
+	CollectionID string `json:"-"`
+	CreatedAt    string `json:"-"`
 }
 
 // NewStatus returns a status struct
@@ -394,13 +657,45 @@ func NewStatus(objects int) (Status, error) {
 	return Status{ID: id, Status: "pending", TotalCount: count, PendingCount: count}, err
 }
 
+// StatusFailure is an object that failed to be added and why
+type StatusFailure struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+	Message string `json:"message,omitempty"`
+}
+
+// StatusFilter for filtering a list of statuses
+type StatusFilter struct {
+	User          string
+	CollectionID  string
+	Status        string
+	CreatedAfter  string
+	CreatedBefore string
+}
+
 // StatusService for status structs
 type StatusService interface {
+	CancelStatus(ctx context.Context, statusID string) error
 	CreateStatus(ctx context.Context, s Status) error
+	PurgeStatuses(ctx context.Context, before time.Time) (int64, error)
 	Status(ctx context.Context, statusID string) (Status, error)
+	Statuses(ctx context.Context, cr *Range, f StatusFilter) ([]Status, error)
 	UpdateStatus(ctx context.Context, s Status) error
 }
 
+// Tombstone records an object version deleted from a collection so clients replicating the collection can delete it
+// too
+type Tombstone struct {
+	ID          string `json:"id"`
+	Version     string `json:"version"`
+	DateDeleted string `json:"date_deleted"`
+}
+
+// Tombstones resource lists the object versions deleted from a collection
+type Tombstones struct {
+	Objects []Tombstone `json:"objects,omitempty"`
+}
+
 // User represents a cabby user
 // should User and UserCollectionList be combined?
 type User struct {
@@ -444,3 +739,10 @@ type UserService interface {
 	User(ctx context.Context, user, password string) (User, error)
 	UserCollections(ctx context.Context, user string) (UserCollectionList, error)
 }
+
+// Versions resource lists the versions of an object in a collection, earliest first; more is true when a range was
+// requested and there are versions after it
+type Versions struct {
+	More     bool     `json:"more"`
+	Versions []string `json:"versions,omitempty"`
+}
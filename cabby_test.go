@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid"
 	log "github.com/sirupsen/logrus"
@@ -20,6 +21,10 @@ func TestAPIRootValidate(t *testing.T) {
 		{APIRoot{Path: "foo", Title: "title"}, true},
 		{APIRoot{Path: "foo", Title: "title", Versions: []string{"taxii-2.1"}}, true},
 		{APIRoot{Path: "foo", Title: "title", Versions: []string{TaxiiVersion}}, false},
+		{APIRoot{Path: "foo", Title: "title", Versions: []string{TaxiiVersion}, DefaultPageSize: 10, MaxPageSize: 100}, false},
+		{APIRoot{Path: "foo", Title: "title", Versions: []string{TaxiiVersion}, DefaultPageSize: -1}, true},
+		{APIRoot{Path: "foo", Title: "title", Versions: []string{TaxiiVersion}, MaxPageSize: -1}, true},
+		{APIRoot{Path: "foo", Title: "title", Versions: []string{TaxiiVersion}, DefaultPageSize: 101, MaxPageSize: 100}, true},
 	}
 
 	for _, test := range tests {
@@ -28,6 +33,9 @@ func TestAPIRootValidate(t *testing.T) {
 		if test.expectError && result == nil {
 			t.Error("Got:", result, "Expected:", test.expectError)
 		}
+		if !test.expectError && result != nil {
+			t.Error("Got:", result, "Expected no error", "API Root:", test.apiRoot)
+		}
 	}
 }
 
@@ -90,6 +98,30 @@ func TestConfigParseInvalidJSON(t *testing.T) {
 	t.Error("Failed to panic with an unknown resource")
 }
 
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		config      Config
+		expectError bool
+	}{
+		{Config{}, false},
+		{Config{StatusRetention: "720h", StatusPurgeInterval: "1h", CompactionInterval: "30m", ObjectPurgeInterval: "1h",
+			RequestTimeout: "30s"}, false},
+		{Config{StatusRetention: "a month"}, true},
+		{Config{StatusPurgeInterval: "0s"}, true},
+		{Config{CompactionInterval: "-1h"}, true},
+		{Config{ObjectPurgeInterval: "0"}, true},
+		{Config{ObjectPurgeInterval: "hourly"}, true},
+		{Config{RequestTimeout: "30"}, true},
+	}
+
+	for _, test := range tests {
+		err := test.config.Validate()
+		if test.expectError != (err != nil) {
+			t.Error("Got:", err, "Expected error:", test.expectError, "Config:", test.config)
+		}
+	}
+}
+
 func TestDiscoveryValidate(t *testing.T) {
 	tests := []struct {
 		discovery   Discovery
@@ -145,6 +177,19 @@ func TestCollectionValidate(t *testing.T) {
 		expectError bool
 	}{
 		{Collection{ID: validID, Title: validTitle}, false},
+		{Collection{ID: validID, Title: validTitle, VersionPolicy: VersionPolicyOverwrite}, false},
+		{Collection{ID: validID, Title: validTitle, VersionPolicy: "keep"}, true},
+		{Collection{ID: validID, Title: validTitle, RetainVersions: 3}, false},
+		{Collection{ID: validID, Title: validTitle, RetainWindow: time.Hour}, false},
+		{Collection{ID: validID, Title: validTitle, RetainVersions: -1}, true},
+		{Collection{ID: validID, Title: validTitle, RetainWindow: -time.Hour}, true},
+		{Collection{ID: validID, Title: validTitle, RetainVersions: 3, RetainWindow: time.Hour}, true},
+		{Collection{ID: validID, Title: validTitle, ExpireBy: ExpireByAdded, MaxAge: time.Hour}, false},
+		{Collection{ID: validID, Title: validTitle, ExpireBy: ExpireByValidUntil}, false},
+		{Collection{ID: validID, Title: validTitle, ExpireBy: ExpireByAdded}, true},
+		{Collection{ID: validID, Title: validTitle, ExpireBy: "modified", MaxAge: time.Hour}, true},
+		{Collection{ID: validID, Title: validTitle, ExpireBy: ExpireByValidUntil, MaxAge: -time.Hour}, true},
+		{Collection{ID: validID, Title: validTitle, MaxAge: time.Hour}, true},
 		{Collection{Title: validTitle}, true},
 		{Collection{ID: validID}, true},
 		{Collection{}, true},
@@ -159,6 +204,106 @@ func TestCollectionValidate(t *testing.T) {
 	}
 }
 
+func TestNewCursor(t *testing.T) {
+	c := Cursor{DateAdded: time.Date(2018, 10, 30, 12, 3, 48, 123456789, time.UTC), Row: 42}
+
+	result, err := NewCursor(c.String())
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+	if result != c {
+		t.Error("Got:", result, "Expected:", c)
+	}
+
+	result, err = NewCursor("")
+	if err != nil || !result.IsZero() {
+		t.Error("Got:", result, err, "Expected a zero cursor")
+	}
+}
+
+func TestNewCursorInvalid(t *testing.T) {
+	for _, s := range []string{"not a cursor", "MTIz", "YS5i", "MTIzLmI"} {
+		if _, err := NewCursor(s); err == nil {
+			t.Error("Got no error", "Expected an error", "Cursor:", s)
+		}
+	}
+}
+
+func TestCursorBefore(t *testing.T) {
+	added := time.Date(2018, 10, 30, 12, 3, 48, 0, time.UTC)
+
+	tests := []struct {
+		cursor   Cursor
+		other    Cursor
+		expected bool
+	}{
+		{Cursor{}, Cursor{DateAdded: added, Row: 1}, true},
+		{Cursor{DateAdded: added, Row: 1}, Cursor{DateAdded: added, Row: 2}, true},
+		{Cursor{DateAdded: added, Row: 2}, Cursor{DateAdded: added, Row: 2}, false},
+		{Cursor{DateAdded: added, Row: 9}, Cursor{DateAdded: added.Add(time.Millisecond), Row: 1}, true},
+		{Cursor{DateAdded: added.Add(time.Millisecond), Row: 1}, Cursor{DateAdded: added, Row: 9}, false},
+	}
+
+	for _, test := range tests {
+		if result := test.cursor.Before(test.other); result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "Cursors:", test.cursor, test.other)
+		}
+	}
+}
+
+func TestRangeBound(t *testing.T) {
+	tests := []struct {
+		cabbyRange  Range
+		defaultSize int64
+		maxSize     int64
+		expected    Range
+	}{
+		{Range{First: -1, Last: -1}, 0, 0, Range{First: -1, Last: -1}},
+		{Range{First: -1, Last: -1}, 10, 100, Range{First: 0, Last: 9}},
+		{Range{First: -1, Last: -1}, 0, 100, Range{First: 0, Last: 99}},
+		{Range{First: 5, Last: 9}, 10, 100, Range{First: 5, Last: 9}},
+		{Range{First: 5, Last: 500}, 10, 100, Range{First: 5, Last: 104}},
+		{Range{First: 5, Last: 500}, 10, 0, Range{First: 5, Last: 500}},
+		{Range{First: -1, Last: -1, Cursored: true}, 10, 100, Range{First: -1, Last: -1, Limit: 10, Cursored: true}},
+		{Range{First: -1, Last: -1, Cursored: true}, 0, 100, Range{First: -1, Last: -1, Limit: 100, Cursored: true}},
+		{Range{First: -1, Last: -1, Limit: 500, Cursored: true}, 10, 100, Range{First: -1, Last: -1, Limit: 100, Cursored: true}},
+		{Range{First: -1, Last: -1, Limit: 50, Cursored: true}, 10, 100, Range{First: -1, Last: -1, Limit: 50, Cursored: true}},
+	}
+
+	for _, test := range tests {
+		r := test.cabbyRange
+		r.Bound(test.defaultSize, test.maxSize)
+
+		if r != test.expected {
+			t.Error("Got:", r, "Expected:", test.expected, "Sizes:", test.defaultSize, test.maxSize)
+		}
+	}
+}
+
+func TestRangeSetPage(t *testing.T) {
+	last := Cursor{DateAdded: time.Date(2018, 10, 30, 12, 3, 48, 0, time.UTC), Row: 3}
+
+	tests := []struct {
+		limit     int64
+		remaining int64
+		offset    int64
+		next      Cursor
+	}{
+		{2, 5, 5, last},
+		{2, 2, 8, Cursor{}},
+		{0, 5, 5, Cursor{}},
+	}
+
+	for _, test := range tests {
+		r := Range{Limit: test.limit, Cursored: true}
+		r.SetPage(10, test.remaining, last)
+
+		if r.Total != 10 || r.Offset != test.offset || r.Next != test.next {
+			t.Error("Got:", r, "Expected offset:", test.offset, "next:", test.next)
+		}
+	}
+}
+
 func TestNewRange(t *testing.T) {
 	invalidRange := Range{First: -1, Last: -1}
 
@@ -294,6 +439,36 @@ func TestNewStatusError(t *testing.T) {
 	}
 }
 
+func TestNewObject(t *testing.T) {
+	o, err := NewObject([]byte(`{
+		"type": "malware",
+		"id": "malware--31b940d4-6f7f-459a-80ea-9c1f17b5891b",
+		"created": "2016-04-06T20:07:09.000Z",
+		"modified": "2016-04-06T20:07:09.000Z",
+		"name": "Poison Ivy"}`))
+
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+	if o.ID != "malware--31b940d4-6f7f-459a-80ea-9c1f17b5891b" || o.Modified != "2016-04-06T20:07:09.000Z" {
+		t.Error("Got:", o)
+	}
+}
+
+func TestNewObjectInvalidObject(t *testing.T) {
+	_, err := NewObject([]byte(`{"foo": "bar"}`))
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestNewObjectInvalidJSON(t *testing.T) {
+	o, err := NewObject([]byte(`{"foo": "bar"`))
+	if err == nil {
+		t.Error("Expected error for object:", o)
+	}
+}
+
 func TestUserDefined(t *testing.T) {
 	tests := []struct {
 		user     User
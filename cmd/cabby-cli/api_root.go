@@ -26,7 +26,9 @@ func cmdCreateAPIRoot() *cobra.Command {
 				Title:            apiRootTitle,
 				Description:      apiRootDescription,
 				Versions:         strings.Split(apiRootVersions, ","),
-				MaxContentLength: maxContentLength}
+				MaxContentLength: maxContentLength,
+				DefaultPageSize:  apiRootDefaultPageSize,
+				MaxPageSize:      apiRootMaxPageSize}
 
 			err = ds.APIRootService().CreateAPIRoot(context.Background(), newAPIRoot)
 			if err != nil {
@@ -85,7 +87,9 @@ func cmdUpdateAPIRoot() *cobra.Command {
 				Title:            apiRootTitle,
 				Description:      apiRootDescription,
 				Versions:         strings.Split(apiRootVersions, ","),
-				MaxContentLength: maxContentLength}
+				MaxContentLength: maxContentLength,
+				DefaultPageSize:  apiRootDefaultPageSize,
+				MaxPageSize:      apiRootMaxPageSize}
 
 			err = ds.APIRootService().CreateAPIRoot(context.Background(), newAPIRoot)
 			if err != nil {
@@ -25,10 +25,15 @@ func cmdCreateCollection() *cobra.Command {
 				log.WithFields(log.Fields{"error": err, "id": collectionID}).Error("Failed to create ID")
 			}
 			newCollection := cabby.Collection{
-				APIRootPath: apiRootPath,
-				ID:          id,
-				Title:       collectionTitle,
-				Description: collectionDescription}
+				APIRootPath:    apiRootPath,
+				ID:             id,
+				Title:          collectionTitle,
+				Description:    collectionDescription,
+				VersionPolicy:  collectionVersionPolicy,
+				RetainVersions: collectionRetainVersions,
+				RetainWindow:   collectionRetainWindow,
+				ExpireBy:       collectionExpireBy,
+				MaxAge:         collectionMaxAge}
 
 			err = ds.CollectionService().CreateCollection(context.Background(), newCollection)
 			if err != nil {
@@ -87,10 +92,15 @@ func cmdUpdateCollection() *cobra.Command {
 				log.WithFields(log.Fields{"error": err, "id": id}).Error("Failed to create ID")
 			}
 			newCollection := cabby.Collection{
-				APIRootPath: apiRootPath,
-				ID:          id,
-				Title:       collectionTitle,
-				Description: collectionDescription}
+				APIRootPath:    apiRootPath,
+				ID:             id,
+				Title:          collectionTitle,
+				Description:    collectionDescription,
+				VersionPolicy:  collectionVersionPolicy,
+				RetainVersions: collectionRetainVersions,
+				RetainWindow:   collectionRetainWindow,
+				ExpireBy:       collectionExpireBy,
+				MaxAge:         collectionMaxAge}
 
 			err = ds.CollectionService().UpdateCollection(context.Background(), newCollection)
 			if err != nil {
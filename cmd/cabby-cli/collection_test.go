@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"testing"
+	"time"
 
 	cabby "github.com/pladdy/cabby2"
 	"github.com/pladdy/cabby2/tester"
@@ -16,6 +17,9 @@ func TestCreateCollection(t *testing.T) {
 
 	command, resource := "create", "collection"
 	expected := tester.Collection
+	expected.VersionPolicy = cabby.VersionPolicyIgnoreIdentical
+	expected.RetainVersions = 3
+	expected.ExpireBy, expected.MaxAge = cabby.ExpireByAdded, 720*time.Hour
 	createTestUser(tester.Collection.ID.String())
 
 	tests := []struct {
@@ -29,7 +33,11 @@ func TestCreateCollection(t *testing.T) {
 			"-a", expected.APIRootPath,
 			"-i", expected.ID.String(),
 			"-t", expected.Title,
-			"-d", expected.Description}, false},
+			"-d", expected.Description,
+			"-p", expected.VersionPolicy,
+			"-n", "3",
+			"-e", expected.ExpireBy,
+			"-m", "720h"}, false},
 	}
 
 	for _, test := range tests {
@@ -119,6 +127,7 @@ func TestUpdateCollection(t *testing.T) {
 	expected.APIRootPath = "/updated/api/root/path/"
 	expected.Title = "an updated title"
 	expected.Description = "an updated description"
+	expected.RetainWindow = 24 * time.Hour
 
 	command, resource := "update", "collection"
 
@@ -132,7 +141,8 @@ func TestUpdateCollection(t *testing.T) {
 			"-a", expected.APIRootPath,
 			"-i", expected.ID.String(),
 			"-t", expected.Title,
-			"-d", expected.Description}, false},
+			"-d", expected.Description,
+			"-w", "24h"}, false},
 	}
 
 	for _, test := range tests {
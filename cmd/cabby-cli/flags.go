@@ -12,6 +12,7 @@ const eightMB = 8388608
 func withAPIRootFlags(cmd *cobra.Command) *cobra.Command {
 	cmd = withAPIRootDescriptionFlag(cmd)
 	cmd = withAPIRootMaxContentLengthFlag(cmd)
+	cmd = withAPIRootPageSizeFlags(cmd)
 	cmd = withAPIRootPathFlag(cmd)
 	cmd = withAPIRootTitleFlag(cmd)
 	return withAPIRootVersionsFlag(cmd)
@@ -28,6 +29,14 @@ func withAPIRootMaxContentLengthFlag(cmd *cobra.Command) *cobra.Command {
 	return cmd
 }
 
+func withAPIRootPageSizeFlags(cmd *cobra.Command) *cobra.Command {
+	cmd.PersistentFlags().Int64VarP(
+		&apiRootDefaultPageSize, "page_size", "s", 0, "items in a page when a request has no range (0 is every item)")
+	cmd.PersistentFlags().Int64VarP(
+		&apiRootMaxPageSize, "max_page_size", "x", 0, "most items a request can ask for in a page (0 doesn't limit it)")
+	return cmd
+}
+
 func withAPIRootPathFlag(cmd *cobra.Command) *cobra.Command {
 	cmd.PersistentFlags().StringVarP(&apiRootPath, "api_root_path", "a", "", "path for the api root")
 	cmd.MarkFlagRequired("api_root_path")
@@ -52,6 +61,9 @@ func withCollectionFlags(cmd *cobra.Command) *cobra.Command {
 	cmd = withAPIRootPathFlag(cmd)
 	cmd = withCollectionIDFlag(cmd)
 	cmd = withCollectionTitleFlag(cmd)
+	cmd = withCollectionVersionPolicyFlag(cmd)
+	cmd = withCollectionRetentionFlags(cmd)
+	cmd = withCollectionExpiryFlags(cmd)
 	return withCollectionDescriptionFlag(cmd)
 }
 
@@ -60,18 +72,39 @@ func withCollectionDescriptionFlag(cmd *cobra.Command) *cobra.Command {
 	return cmd
 }
 
+func withCollectionExpiryFlags(cmd *cobra.Command) *cobra.Command {
+	cmd.PersistentFlags().StringVarP(
+		&collectionExpireBy, "expire_by", "e", "", "what object age is measured from (added, valid_until)")
+	cmd.PersistentFlags().DurationVarP(&collectionMaxAge, "max_age", "m", 0, "how old objects get before they expire")
+	return cmd
+}
+
 func withCollectionIDFlag(cmd *cobra.Command) *cobra.Command {
 	cmd.PersistentFlags().StringVarP(&collectionID, "id", "i", "", "collection id")
 	cmd.MarkFlagRequired("id")
 	return cmd
 }
 
+func withCollectionRetentionFlags(cmd *cobra.Command) *cobra.Command {
+	cmd.PersistentFlags().IntVarP(
+		&collectionRetainVersions, "retain_versions", "n", 0, "latest versions of each object to keep (0 keeps all)")
+	cmd.PersistentFlags().DurationVarP(
+		&collectionRetainWindow, "retain_window", "w", 0, "keep versions modified within this duration (0 keeps all)")
+	return cmd
+}
+
 func withCollectionTitleFlag(cmd *cobra.Command) *cobra.Command {
 	cmd.PersistentFlags().StringVarP(&collectionTitle, "title", "t", "", "collection title")
 	cmd.MarkFlagRequired("title")
 	return cmd
 }
 
+func withCollectionVersionPolicyFlag(cmd *cobra.Command) *cobra.Command {
+	cmd.PersistentFlags().StringVarP(
+		&collectionVersionPolicy, "version_policy", "p", "", "collection version policy (reject, ignore-identical, overwrite)")
+	return cmd
+}
+
 /* discovery flags */
 
 func withDiscoveryContactFlag(cmd *cobra.Command) *cobra.Command {
@@ -95,6 +128,23 @@ func withDiscoveryTitleFlag(cmd *cobra.Command) *cobra.Command {
 	return cmd
 }
 
+/* status flags */
+
+func withStatusFilterFlags(cmd *cobra.Command) *cobra.Command {
+	cmd.PersistentFlags().StringVarP(&statusUser, "user", "u", "", "user that created the status")
+	cmd.PersistentFlags().StringVarP(&statusCollectionID, "collection", "c", "", "collection id the status is for")
+	cmd.PersistentFlags().StringVarP(&statusState, "status", "s", "", "state of the status (pending, complete, canceled)")
+	cmd.PersistentFlags().StringVarP(&statusCreatedAfter, "created_after", "a", "", "RFC 3339 time the status was created after")
+	cmd.PersistentFlags().StringVarP(&statusCreatedBefore, "created_before", "b", "", "RFC 3339 time the status was created before")
+	return cmd
+}
+
+func withStatusIDFlag(cmd *cobra.Command) *cobra.Command {
+	cmd.PersistentFlags().StringVarP(&statusID, "id", "i", "", "status id")
+	cmd.MarkFlagRequired("id")
+	return cmd
+}
+
 /* user flags */
 
 func withAdminFlag(cmd *cobra.Command) *cobra.Command {
@@ -1,34 +1,59 @@
 package main
 
 import (
+	"fmt"
+	"time"
+
 	cabby "github.com/pladdy/cabby2"
-	"github.com/pladdy/cabby2/sqlite"
+	"github.com/pladdy/cabby2/bolt"
+	"github.com/pladdy/cabby2/postgres"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 var (
-	apiRootDescription     string
-	apiRootPath            string
-	apiRootTitle           string
-	apiRootVersions        string
-	cabbyEnv               string
-	configPath             string
-	collectionID           string
-	collectionTitle        string
-	collectionDescription  string
-	discoveryContact       string
-	discoveryDefault       string
-	discoveryDescription   string
-	discoveryTitle         string
-	maxContentLength       int64
-	userAdmin              bool
-	userCollectionCanRead  bool
-	userCollectionCanWrite bool
-	userName               string
-	userPassword           string
+	apiRootDefaultPageSize   int64
+	apiRootDescription       string
+	apiRootMaxPageSize       int64
+	apiRootPath              string
+	apiRootTitle             string
+	apiRootVersions          string
+	cabbyEnv                 string
+	configPath               string
+	collectionID             string
+	collectionTitle          string
+	collectionDescription    string
+	collectionVersionPolicy  string
+	collectionRetainVersions int
+	collectionRetainWindow   time.Duration
+	collectionExpireBy       string
+	collectionMaxAge         time.Duration
+	discoveryContact         string
+	discoveryDefault         string
+	discoveryDescription     string
+	discoveryTitle           string
+	maxContentLength         int64
+	statusCollectionID       string
+	statusCreatedAfter       string
+	statusCreatedBefore      string
+	statusID                 string
+	statusState              string
+	statusUser               string
+	userAdmin                bool
+	userCollectionCanRead    bool
+	userCollectionCanWrite   bool
+	userName                 string
+	userPassword             string
 )
 
+func cmdCancel() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cancel [command/resource]",
+		Short: "Cancel a resource",
+		Args:  cobra.MinimumNArgs(1),
+	}
+}
+
 func cmdCreate() *cobra.Command {
 	return &cobra.Command{
 		Use:   "create [command/resource]",
@@ -45,6 +70,14 @@ func cmdDelete() *cobra.Command {
 	}
 }
 
+func cmdList() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [command/resource]",
+		Short: "List resources",
+		Args:  cobra.MinimumNArgs(1),
+	}
+}
+
 func cmdUpdate() *cobra.Command {
 	return &cobra.Command{
 		Use:   "update [command/resource]",
@@ -55,7 +88,16 @@ func cmdUpdate() *cobra.Command {
 
 func dataStoreFromConfig(path string) (cabby.DataStore, error) {
 	config := cabby.Config{}.Parse(path)
-	return sqlite.NewDataStore(config.DataStore["path"])
+
+	switch config.DataStore["name"] {
+	case "", "sqlite":
+		return newSQLiteDataStore(config.DataStore)
+	case "bolt":
+		return bolt.NewDataStore(config.DataStore["path"])
+	case "postgres":
+		return postgres.NewDataStore(config.DataStore["url"])
+	}
+	return nil, fmt.Errorf("Unknown data store: %s", config.DataStore["name"])
 }
 
 func main() {
@@ -66,10 +108,15 @@ func main() {
 	rootCmd.PersistentFlags().StringVar(&configPath, "config", cabby.DefaultProductionConfig, "path to cabby config file")
 	rootCmd.MarkFlagRequired("config")
 
+	cmdCancel := cmdCancel()
 	cmdCreate := cmdCreate()
 	cmdDelete := cmdDelete()
+	cmdList := cmdList()
+	cmdMigrate := cmdMigrate()
 	cmdUpdate := cmdUpdate()
-	rootCmd.AddCommand(cmdCreate, cmdDelete, cmdUpdate)
+	rootCmd.AddCommand(cmdCancel, cmdCreate, cmdDelete, cmdList, cmdMigrate, cmdUpdate)
+
+	cmdCancel.AddCommand(cmdCancelStatus())
 
 	cmdCreate.AddCommand(
 		cmdCreateAPIRoot(),
@@ -85,6 +132,10 @@ func main() {
 		cmdDeleteUser(),
 		cmdDeleteUserCollection())
 
+	cmdList.AddCommand(cmdListStatus())
+
+	cmdMigrate.AddCommand(cmdMigrateUp())
+
 	cmdUpdate.AddCommand(
 		cmdUpdateAPIRoot(),
 		cmdUpdateCollection(),
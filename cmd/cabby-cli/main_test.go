@@ -8,10 +8,6 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-const (
-	RelativeSchemaPath = "../../sqlite/schema.sql"
-)
-
 var (
 	commands    = []string{"create", "delete", "update"}
 	subCommands = []string{"apiRoot", "collection", "discovery", "user", "userCollection"}
@@ -42,7 +38,7 @@ func setUp() {
 		args         []string
 		expectedFile string
 	}{
-		{"sqlite3", []string{"cabby-cli.db", ".read " + RelativeSchemaPath}, "cabby-cli.db"},
+		{CLICommand, []string{"migrate", "up", "--config", CLIConfig}, "cabby-cli.db"},
 	}
 
 	for _, command := range commandsToRun {
@@ -57,12 +53,13 @@ func setUp() {
 	}
 }
 
+// the data store's WAL files are removed with it; a WAL left behind would be replayed into the next database
 func tearDown() {
-	filesToRemove := []string{"cabby-cli.db"}
+	filesToRemove := []string{"cabby-cli.db", "cabby-cli.db-wal", "cabby-cli.db-shm"}
 
 	for _, file := range filesToRemove {
 		err := os.Remove(file)
-		if err != nil {
+		if err != nil && !os.IsNotExist(err) {
 			log.Warn(err)
 		}
 	}
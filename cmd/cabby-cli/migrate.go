@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	cabby "github.com/pladdy/cabby2"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func cmdMigrate() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate [command]",
+		Short: "Manage the data store schema",
+		Args:  cobra.MinimumNArgs(1),
+	}
+}
+
+func cmdMigrateUp() *cobra.Command {
+	return &cobra.Command{
+		Use:   "up",
+		Short: "Apply pending schema migrations",
+		Long:  `migrate up is used to create a data store or bring its schema up to the version this build expects`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ds, err := dataStoreFromConfig(configPath)
+			if err != nil {
+				log.WithFields(log.Fields{"error": err}).Panic("Can't connect to data store")
+			}
+			defer ds.Close()
+
+			m, ok := ds.(cabby.Migrator)
+			if !ok {
+				fmt.Println("Data store has no schema to migrate")
+				return
+			}
+
+			// a fatal log exits without running deferred calls; the data store is closed first so it's checkpointed
+			applied, err := m.Migrate(context.Background())
+			if err != nil {
+				ds.Close()
+				log.WithFields(log.Fields{"error": err}).Fatal("Failed to migrate")
+			}
+
+			version, err := m.SchemaVersion(context.Background())
+			if err != nil {
+				ds.Close()
+				log.WithFields(log.Fields{"error": err}).Fatal("Failed to read schema version")
+			}
+
+			fmt.Printf("Applied %d migration(s), schema is at version %d\n", applied, version)
+		},
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/pladdy/cabby2/sqlite"
+)
+
+func TestMigrateUp(t *testing.T) {
+	setUp()
+	defer tearDown()
+
+	// set up already migrated; running again is a no-op
+	out, err := exec.Command(CLICommand, "migrate", "up", "--config", CLIConfig).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(out), "Applied 0 migration(s)") {
+		t.Error("Got:", string(out), "Expected: no migrations applied")
+	}
+
+	ds, err := sqlite.NewDataStore("cabby-cli.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ds.Close()
+
+	version, err := ds.SchemaVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != sqlite.LatestSchemaVersion() {
+		t.Error("Got:", version, "Expected:", sqlite.LatestSchemaVersion())
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	cabby "github.com/pladdy/cabby2"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func cmdCancelStatus() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Cancel a pending status",
+		Long:  `cancel status is used to stop a pending ingestion job; objects not yet written are counted as failures`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ds, err := dataStoreFromConfig(configPath)
+			if err != nil {
+				log.WithFields(log.Fields{"error": err}).Panic("Can't connect to data store")
+			}
+			defer ds.Close()
+
+			err = ds.StatusService().CancelStatus(context.Background(), statusID)
+			if err != nil {
+				log.WithFields(log.Fields{"error": err, "id": statusID}).Error("Failed to cancel")
+			}
+		},
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if statusID == "" {
+				log.Fatal("ID required")
+			}
+		},
+	}
+
+	return withStatusIDFlag(cmd)
+}
+
+func cmdListStatus() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "List statuses",
+		Long:  `list status is used to browse statuses by user, collection, state and creation time`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ds, err := dataStoreFromConfig(configPath)
+			if err != nil {
+				log.WithFields(log.Fields{"error": err}).Panic("Can't connect to data store")
+			}
+			defer ds.Close()
+
+			f := cabby.StatusFilter{
+				User:          statusUser,
+				CollectionID:  statusCollectionID,
+				Status:        statusState,
+				CreatedAfter:  statusCreatedAfter,
+				CreatedBefore: statusCreatedBefore}
+
+			cr, _ := cabby.NewRange("")
+			statuses, err := ds.StatusService().Statuses(context.Background(), &cr, f)
+			if err != nil {
+				log.WithFields(log.Fields{"error": err, "filter": f}).Error("Failed to list")
+				return
+			}
+
+			printStatuses(statuses)
+		},
+	}
+
+	return withStatusFilterFlags(cmd)
+}
+
+func printStatuses(statuses []cabby.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tUSER\tCOLLECTION\tCREATED\tTOTAL\tSUCCESS\tFAILURE\tPENDING")
+
+	for _, s := range statuses {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\n",
+			s.ID.String(), s.Status, s.User, s.CollectionID, s.CreatedAt,
+			s.TotalCount, s.SuccessCount, s.FailureCount, s.PendingCount)
+	}
+	w.Flush()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestCancelStatus(t *testing.T) {
+	setUp()
+	defer tearDown()
+
+	command, resource := "cancel", "status"
+	expected := tester.Status
+	expected.Status = "pending"
+
+	ds := testDataStore()
+	err := ds.StatusService().CreateStatus(context.Background(), expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args        []string
+		expectError bool
+	}{
+		{[]string{command, resource}, true},
+		{[]string{command, resource, "--config", CLIConfig}, true},
+		{[]string{command, resource, "--config", CLIConfig, "-i", expected.ID.String()}, false},
+	}
+
+	for _, test := range tests {
+		cmd := exec.Command(CLICommand, test.args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stdout
+
+		err := cmd.Run()
+		if test.expectError && err == nil {
+			t.Error("Expected error: no parameters set")
+		}
+
+		if !test.expectError {
+			result, _ := ds.StatusService().Status(context.Background(), expected.ID.String())
+
+			if result.Status != "canceled" {
+				t.Error("Got:", result.Status, "Expected:", "canceled")
+			}
+		}
+	}
+}
+
+func TestListStatus(t *testing.T) {
+	setUp()
+	defer tearDown()
+
+	ds := testDataStore()
+
+	pending := tester.Status
+	pending.Status = "pending"
+	pending.User = tester.UserEmail
+
+	complete := tester.Status
+	complete.ID, _ = cabby.NewID()
+	complete.Status = "complete"
+	complete.User = "other@cabby.com"
+
+	for _, s := range []cabby.Status{pending, complete} {
+		err := ds.StatusService().CreateStatus(context.Background(), s)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		args     []string
+		included []string
+		excluded []string
+	}{
+		{[]string{}, []string{pending.ID.String(), complete.ID.String()}, []string{}},
+		{[]string{"-u", tester.UserEmail}, []string{pending.ID.String()}, []string{complete.ID.String()}},
+		{[]string{"-s", "complete"}, []string{complete.ID.String()}, []string{pending.ID.String()}},
+	}
+
+	for _, test := range tests {
+		args := append([]string{"list", "status", "--config", CLIConfig}, test.args...)
+		cmd := exec.Command(CLICommand, args...)
+		cmd.Stderr = os.Stdout
+
+		out, err := cmd.Output()
+		if err != nil {
+			t.Error("Got:", err, "Expected: nil")
+		}
+
+		for _, id := range test.included {
+			if !strings.Contains(string(out), id) {
+				t.Error("Got:", string(out), "Expected to include:", id)
+			}
+		}
+		for _, id := range test.excluded {
+			if strings.Contains(string(out), id) {
+				t.Error("Got:", string(out), "Expected to exclude:", id)
+			}
+		}
+	}
+}
@@ -1,14 +1,25 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"time"
 
 	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/bolt"
 	"github.com/pladdy/cabby2/http"
-	"github.com/pladdy/cabby2/sqlite"
+	"github.com/pladdy/cabby2/memory"
+	"github.com/pladdy/cabby2/postgres"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	defaultStatusPurgeInterval = time.Hour
+	defaultCompactionInterval  = time.Hour
+	defaultObjectPurgeInterval = time.Hour
+)
+
 func main() {
 	log.SetLevel(log.InfoLevel)
 
@@ -16,12 +27,106 @@ func main() {
 	flag.Parse()
 
 	c := cabby.Config{}.Parse(*configPath)
+	if err := c.Validate(); err != nil {
+		log.WithFields(log.Fields{"error": err}).Panic("Invalid config")
+	}
 
-	ds, err := sqlite.NewDataStore(c.DataStore["path"])
+	ds, err := newDataStore(c)
 	if err != nil {
 		log.WithFields(log.Fields{"error": err}).Panic("Can't start server")
 	}
 
+	migrateSchema(context.Background(), ds, c)
+
+	startStatusPurge(context.Background(), ds, c)
+	startObjectCompaction(context.Background(), ds, c)
+	startObjectPurge(context.Background(), ds, c)
+
 	server := http.NewCabby(ds, c)
 	log.Fatal(server.ListenAndServeTLS(c.SSLCert, c.SSLKey))
 }
+
+// the data store is chosen by name in the config; sqlite is the default
+func newDataStore(c cabby.Config) (cabby.DataStore, error) {
+	switch c.DataStore["name"] {
+	case "", "sqlite":
+		return newSQLiteDataStore(c.DataStore)
+	case "bolt":
+		return bolt.NewDataStore(c.DataStore["path"])
+	case "memory":
+		return memory.NewDataStore(c.DataStore["seed_path"])
+	case "postgres":
+		return postgres.NewDataStore(c.DataStore["url"])
+	}
+	return nil, fmt.Errorf("Unknown data store: %s", c.DataStore["name"])
+}
+
+// the schema is migrated on start if configured; either way the server won't run against a schema it doesn't expect
+func migrateSchema(ctx context.Context, ds cabby.DataStore, c cabby.Config) {
+	m, ok := ds.(cabby.Migrator)
+	if !ok {
+		return
+	}
+
+	if c.MigrateOnStart {
+		applied, err := m.Migrate(ctx)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Panic("Can't migrate schema")
+		}
+		log.WithFields(log.Fields{"applied": applied}).Info("Schema migrated")
+	}
+
+	if err := m.VerifySchema(ctx); err != nil {
+		log.WithFields(log.Fields{"error": err}).Panic("Can't start server")
+	}
+}
+
+// statuses are kept forever unless a retention is configured
+func startStatusPurge(ctx context.Context, ds cabby.DataStore, c cabby.Config) {
+	if c.StatusRetention == "" {
+		return
+	}
+
+	maxAge, err := time.ParseDuration(c.StatusRetention)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "status_retention": c.StatusRetention}).Panic("Invalid status retention")
+	}
+
+	interval := defaultStatusPurgeInterval
+	if c.StatusPurgeInterval != "" {
+		interval, err = time.ParseDuration(c.StatusPurgeInterval)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "status_purge_interval": c.StatusPurgeInterval}).Panic("Invalid status purge interval")
+		}
+	}
+
+	go cabby.RunJob(ctx, "status purge", interval, cabby.PurgeStatusesJob(ds.StatusService(), maxAge))
+}
+
+// compaction always runs; collections without a retention keep every version so it doesn't delete anything for them
+func startObjectCompaction(ctx context.Context, ds cabby.DataStore, c cabby.Config) {
+	interval := defaultCompactionInterval
+	if c.CompactionInterval != "" {
+		var err error
+		interval, err = time.ParseDuration(c.CompactionInterval)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "compaction_interval": c.CompactionInterval}).Panic("Invalid compaction interval")
+		}
+	}
+
+	go cabby.RunJob(ctx, "object compaction", interval, cabby.CompactObjectsJob(ds.ObjectService()))
+}
+
+// the object purge always runs; collections without an expiry don't have objects to purge
+func startObjectPurge(ctx context.Context, ds cabby.DataStore, c cabby.Config) {
+	interval := defaultObjectPurgeInterval
+	if c.ObjectPurgeInterval != "" {
+		var err error
+		interval, err = time.ParseDuration(c.ObjectPurgeInterval)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "object_purge_interval": c.ObjectPurgeInterval}).Panic("Invalid object purge interval")
+		}
+	}
+
+	go cabby.RunJob(ctx, "object purge", interval, cabby.PurgeObjectsJob(ds.ObjectService()))
+}
@@ -0,0 +1,15 @@
+//go:build nosqlite
+// +build nosqlite
+
+package main
+
+import (
+	"errors"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// builds tagged nosqlite leave out sqlite (and cgo); use the bolt or postgres data store instead
+func newSQLiteDataStore(config map[string]string) (cabby.DataStore, error) {
+	return nil, errors.New("This build doesn't include the sqlite data store")
+}
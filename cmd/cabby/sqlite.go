@@ -0,0 +1,18 @@
+//go:build !nosqlite
+// +build !nosqlite
+
+package main
+
+import (
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/sqlite"
+)
+
+// the data store config has the path to the database and optionally its busy timeout and read pool size
+func newSQLiteDataStore(config map[string]string) (cabby.DataStore, error) {
+	o, err := sqlite.NewOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	return sqlite.NewDataStoreWithOptions(config["path"], o)
+}
@@ -17,6 +17,12 @@ const (
 	KeyUser Key = 1
 )
 
+// JobContext returns a context for work that outlives a request, like ingesting a bundle: it has the request's
+// transaction id and user but isn't canceled when the request is done
+func JobContext(ctx context.Context) context.Context {
+	return WithUser(WithTransactionID(context.Background(), TakeTransactionID(ctx)), TakeUser(ctx))
+}
+
 // TakeTransactionID returns the transaction id stored in a context
 func TakeTransactionID(ctx context.Context) uuid.UUID {
 	id, ok := ctx.Value(KeyTransactionID).(uuid.UUID)
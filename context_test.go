@@ -7,6 +7,25 @@ import (
 	"github.com/gofrs/uuid"
 )
 
+func TestJobContext(t *testing.T) {
+	transactionID := uuid.Must(uuid.NewV4())
+	u := User{Email: "foo"}
+
+	ctx, cancel := context.WithCancel(WithUser(WithTransactionID(context.Background(), transactionID), u))
+	job := JobContext(ctx)
+	cancel()
+
+	if job.Err() != nil {
+		t.Error("Got:", job.Err(), "Expected no error")
+	}
+	if TakeTransactionID(job) != transactionID {
+		t.Error("Got:", TakeTransactionID(job), "Expected:", transactionID)
+	}
+	if TakeUser(job).Email != u.Email {
+		t.Error("Got:", TakeUser(job).Email, "Expected:", u.Email)
+	}
+}
+
 func TestContextTransactionID(t *testing.T) {
 	ctx := context.Background()
 
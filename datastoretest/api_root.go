@@ -0,0 +1,138 @@
+package datastoretest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+var apiRootTests = []test{
+	{"APIRoot", testAPIRoot},
+	{"APIRootMissing", testAPIRootMissing},
+	{"APIRoots", testAPIRoots},
+	{"CreateAPIRootDuplicate", testCreateAPIRootDuplicate},
+	{"CreateAPIRootInvalid", testCreateAPIRootInvalid},
+	{"DeleteAPIRoot", testDeleteAPIRoot},
+	{"UpdateAPIRoot", testUpdateAPIRoot},
+}
+
+func testAPIRoot(t *testing.T, ds cabby.DataStore) {
+	result, err := ds.APIRootService().APIRoot(context.Background(), tester.APIRootPath)
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	compareAPIRoot(t, result, tester.APIRoot)
+	if result.UpdatedAt.IsZero() {
+		t.Error("Expected an update time")
+	}
+}
+
+func testAPIRootMissing(t *testing.T, ds cabby.DataStore) {
+	result, err := ds.APIRootService().APIRoot(context.Background(), "missing")
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+	if result.Path != "" {
+		t.Error("Got:", result, "Expected no api root")
+	}
+}
+
+func testAPIRoots(t *testing.T, ds cabby.DataStore) {
+	s := ds.APIRootService()
+
+	other := tester.APIRoot
+	other.Path = "other_api_root"
+	other.DefaultPageSize, other.MaxPageSize = 100, 1000
+
+	if err := s.CreateAPIRoot(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := s.APIRoots(context.Background())
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	paths := map[string]bool{}
+	for _, a := range results {
+		paths[a.Path] = true
+		if a.Path == other.Path {
+			compareAPIRoot(t, a, other)
+		}
+	}
+
+	if len(results) != 2 || !paths[tester.APIRootPath] || !paths[other.Path] {
+		t.Error("Got:", results, "Expected:", tester.APIRootPath, other.Path)
+	}
+}
+
+func testCreateAPIRootDuplicate(t *testing.T, ds cabby.DataStore) {
+	err := ds.APIRootService().CreateAPIRoot(context.Background(), tester.APIRoot)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func testCreateAPIRootInvalid(t *testing.T, ds cabby.DataStore) {
+	err := ds.APIRootService().CreateAPIRoot(context.Background(), cabby.APIRoot{})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func testDeleteAPIRoot(t *testing.T, ds cabby.DataStore) {
+	s := ds.APIRootService()
+
+	if err := s.DeleteAPIRoot(context.Background(), tester.APIRootPath); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	result, _ := s.APIRoot(context.Background(), tester.APIRootPath)
+	if result.Path != "" {
+		t.Error("Got:", result, "Expected no api root")
+	}
+}
+
+func testUpdateAPIRoot(t *testing.T, ds cabby.DataStore) {
+	s := ds.APIRootService()
+
+	expected := tester.APIRoot
+	expected.Title = "an updated title"
+	expected.Description = "an updated description"
+	expected.MaxContentLength = 1024
+	expected.DefaultPageSize, expected.MaxPageSize = 50, 500
+
+	if err := s.UpdateAPIRoot(context.Background(), expected); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	result, _ := s.APIRoot(context.Background(), tester.APIRootPath)
+	compareAPIRoot(t, result, expected)
+}
+
+func compareAPIRoot(t *testing.T, result, expected cabby.APIRoot) {
+	t.Helper()
+
+	if result.Path != expected.Path {
+		t.Error("Got:", result.Path, "Expected:", expected.Path)
+	}
+	if result.Title != expected.Title {
+		t.Error("Got:", result.Title, "Expected:", expected.Title)
+	}
+	if result.Description != expected.Description {
+		t.Error("Got:", result.Description, "Expected:", expected.Description)
+	}
+	if strings.Join(result.Versions, ",") != strings.Join(expected.Versions, ",") {
+		t.Error("Got:", result.Versions, "Expected:", expected.Versions)
+	}
+	if result.MaxContentLength != expected.MaxContentLength {
+		t.Error("Got:", result.MaxContentLength, "Expected:", expected.MaxContentLength)
+	}
+	if result.DefaultPageSize != expected.DefaultPageSize || result.MaxPageSize != expected.MaxPageSize {
+		t.Error("Got:", result.DefaultPageSize, result.MaxPageSize, "Expected:", expected.DefaultPageSize, expected.MaxPageSize)
+	}
+}
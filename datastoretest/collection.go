@@ -0,0 +1,352 @@
+package datastoretest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+var collectionTests = []test{
+	{"Collection", testCollection},
+	{"CollectionAccess", testCollectionAccess},
+	{"CollectionOtherAPIRoot", testCollectionOtherAPIRoot},
+	{"Collections", testCollections},
+	{"CollectionsInAPIRoot", testCollectionsInAPIRoot},
+	{"CollectionsSort", testCollectionsSort},
+	{"CollectionExpiry", testCollectionExpiry},
+	{"CollectionRetention", testCollectionRetention},
+	{"CollectionVersionPolicy", testCollectionVersionPolicy},
+	{"CreateCollectionDuplicate", testCreateCollectionDuplicate},
+	{"CreateCollectionInvalid", testCreateCollectionInvalid},
+	{"DeleteCollection", testDeleteCollection},
+	{"UpdateCollection", testUpdateCollection},
+}
+
+func testCollection(t *testing.T, ds cabby.DataStore) {
+	result, err := ds.CollectionService().Collection(tester.Context, tester.APIRootPath, tester.CollectionID)
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	compareCollection(t, result, tester.Collection)
+	if result.UpdatedAt.IsZero() {
+		t.Error("Expected an update time")
+	}
+}
+
+// a collection is read if the user can read it; it's listed if the user can read or write it
+func testCollectionAccess(t *testing.T, ds cabby.DataStore) {
+	s := ds.CollectionService()
+	us := ds.UserService()
+
+	c := tester.Collection
+	c.ID = newID(t)
+
+	if err := s.CreateCollection(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		access   *cabby.CollectionAccess
+		readable bool
+		listed   int
+	}{
+		{nil, false, 1},
+		{&cabby.CollectionAccess{ID: c.ID, CanRead: false, CanWrite: false}, false, 1},
+		{&cabby.CollectionAccess{ID: c.ID, CanRead: false, CanWrite: true}, false, 2},
+		{&cabby.CollectionAccess{ID: c.ID, CanRead: true, CanWrite: false}, true, 2},
+	}
+
+	for _, test := range tests {
+		if test.access != nil {
+			if err := us.DeleteUserCollection(context.Background(), tester.UserEmail, c.ID.String()); err != nil {
+				t.Fatal(err)
+			}
+			if err := us.CreateUserCollection(context.Background(), tester.UserEmail, *test.access); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		result, err := s.Collection(tester.Context, tester.APIRootPath, c.ID.String())
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+		if result.ID.IsEmpty() == test.readable {
+			t.Error("Got:", result, "Expected readable:", test.readable, "Access:", test.access)
+		}
+
+		results, err := s.Collections(tester.Context, tester.APIRootPath, allItems())
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+		if len(results.Collections) != test.listed {
+			t.Error("Got:", len(results.Collections), "Expected:", test.listed, "Access:", test.access)
+		}
+	}
+}
+
+func testCollectionOtherAPIRoot(t *testing.T, ds cabby.DataStore) {
+	s := ds.CollectionService()
+
+	result, err := s.Collection(tester.Context, "other_api_root", tester.CollectionID)
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+	if !result.ID.IsEmpty() {
+		t.Error("Got:", result, "Expected no collection")
+	}
+
+	results, err := s.Collections(tester.Context, "other_api_root", allItems())
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+	if len(results.Collections) != 0 {
+		t.Error("Got:", len(results.Collections), "Expected:", 0)
+	}
+}
+
+func testCollections(t *testing.T, ds cabby.DataStore) {
+	for i := 0; i < 10; i++ {
+		createCollection(t, ds, newID(t))
+	}
+
+	tests := []struct {
+		cabbyRange  cabby.Range
+		collections int
+	}{
+		{cabby.Range{First: -1, Last: -1}, 11},
+		{cabby.Range{First: 0, Last: 5}, 6},
+		{cabby.Range{First: 8, Last: 20}, 3},
+	}
+
+	for _, test := range tests {
+		results, err := ds.CollectionService().Collections(tester.Context, tester.APIRootPath, &test.cabbyRange)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+
+		if len(results.Collections) != test.collections {
+			t.Error("Got:", len(results.Collections), "Expected:", test.collections, "Range:", test.cabbyRange)
+		}
+		if test.cabbyRange.Total != 11 {
+			t.Error("Got:", test.cabbyRange.Total, "Expected:", 11, "Range:", test.cabbyRange)
+		}
+		if results.UpdatedAt.IsZero() {
+			t.Error("Expected an update time")
+		}
+	}
+}
+
+// collections are in id order unless they're sorted on another field
+func testCollectionsSort(t *testing.T, ds cabby.DataStore) {
+	for _, title := range []string{"c", "a", "b"} {
+		c := tester.Collection
+		c.ID, c.Title = newID(t), title
+		createUserCollection(t, ds, c)
+	}
+
+	tests := []struct {
+		sort cabby.Sort
+	}{
+		{cabby.Sort{}},
+		{cabby.Sort{Field: cabby.SortID, Descending: true}},
+		{cabby.Sort{Field: cabby.SortTitle}},
+		{cabby.Sort{Field: cabby.SortTitle, Descending: true}},
+	}
+
+	for _, test := range tests {
+		cr := cabby.Range{First: -1, Last: -1, Sort: test.sort}
+		results, err := ds.CollectionService().Collections(tester.Context, tester.APIRootPath, &cr)
+		if err != nil || len(results.Collections) != 4 {
+			t.Fatal("Got:", results, err, "Expected 4 collections")
+		}
+
+		for i := 1; i < len(results.Collections); i++ {
+			previous, current := results.Collections[i-1], results.Collections[i]
+			if !test.sort.Less(collectionSortKey(previous), collectionSortKey(current)) {
+				t.Error("Got:", previous.ID, previous.Title, current.ID, current.Title, "Expected them sorted by:", test.sort)
+			}
+		}
+	}
+}
+
+func collectionSortKey(c cabby.Collection) cabby.SortKey {
+	return cabby.SortKey{ID: c.ID.String(), Title: c.Title}
+}
+
+func testCollectionsInAPIRoot(t *testing.T, ds cabby.DataStore) {
+	other := createCollection(t, ds, newID(t))
+
+	result, err := ds.CollectionService().CollectionsInAPIRoot(context.Background(), tester.APIRootPath)
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	if result.Path != tester.APIRootPath {
+		t.Error("Got:", result.Path, "Expected:", tester.APIRootPath)
+	}
+
+	ids := map[string]bool{}
+	for _, id := range result.CollectionIDs {
+		ids[id.String()] = true
+	}
+	if len(ids) != 2 || !ids[tester.CollectionID] || !ids[other.ID.String()] {
+		t.Error("Got:", result.CollectionIDs, "Expected:", tester.CollectionID, other.ID.String())
+	}
+}
+
+func testCollectionExpiry(t *testing.T, ds cabby.DataStore) {
+	added := tester.Collection
+	added.ID, added.ExpireBy, added.MaxAge = newID(t), cabby.ExpireByAdded, 720*time.Hour
+
+	validUntil := tester.Collection
+	validUntil.ID, validUntil.ExpireBy = newID(t), cabby.ExpireByValidUntil
+
+	for _, expected := range []cabby.Collection{added, validUntil} {
+		createUserCollection(t, ds, expected)
+
+		result, err := ds.CollectionService().Collection(tester.Context, tester.APIRootPath, expected.ID.String())
+		if err != nil {
+			t.Fatal("Got:", err, "Expected no error")
+		}
+		compareCollection(t, result, expected)
+	}
+}
+
+func testCollectionRetention(t *testing.T, ds cabby.DataStore) {
+	versions := tester.Collection
+	versions.ID, versions.RetainVersions = newID(t), 3
+
+	window := tester.Collection
+	window.ID, window.RetainWindow = newID(t), 36*time.Hour
+
+	for _, expected := range []cabby.Collection{versions, window} {
+		createUserCollection(t, ds, expected)
+
+		result, err := ds.CollectionService().Collection(tester.Context, tester.APIRootPath, expected.ID.String())
+		if err != nil {
+			t.Fatal("Got:", err, "Expected no error")
+		}
+		compareCollection(t, result, expected)
+	}
+}
+
+func testCollectionVersionPolicy(t *testing.T, ds cabby.DataStore) {
+	expected := createPolicyCollection(t, ds, cabby.VersionPolicyIgnoreIdentical)
+
+	result, err := ds.CollectionService().Collection(tester.Context, tester.APIRootPath, expected.ID.String())
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	compareCollection(t, result, expected)
+}
+
+func testCreateCollectionDuplicate(t *testing.T, ds cabby.DataStore) {
+	err := ds.CollectionService().CreateCollection(context.Background(), tester.Collection)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func testCreateCollectionInvalid(t *testing.T, ds cabby.DataStore) {
+	err := ds.CollectionService().CreateCollection(context.Background(), cabby.Collection{})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	c := tester.Collection
+	c.ID = newID(t)
+	c.VersionPolicy = "keep"
+
+	err = ds.CollectionService().CreateCollection(context.Background(), c)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	c.VersionPolicy, c.RetainVersions, c.RetainWindow = "", 3, time.Hour
+
+	err = ds.CollectionService().CreateCollection(context.Background(), c)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	c.RetainVersions, c.RetainWindow, c.ExpireBy = 0, 0, "modified"
+
+	err = ds.CollectionService().CreateCollection(context.Background(), c)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func testDeleteCollection(t *testing.T, ds cabby.DataStore) {
+	s := ds.CollectionService()
+
+	if err := s.DeleteCollection(context.Background(), tester.CollectionID); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	result, _ := s.Collection(tester.Context, tester.APIRootPath, tester.CollectionID)
+	if !result.ID.IsEmpty() {
+		t.Error("Got:", result, "Expected no collection")
+	}
+}
+
+func testUpdateCollection(t *testing.T, ds cabby.DataStore) {
+	s := ds.CollectionService()
+
+	expected := tester.Collection
+	expected.Title = "an updated title"
+	expected.Description = "an updated description"
+	expected.VersionPolicy = cabby.VersionPolicyOverwrite
+	expected.RetainVersions = 5
+	expected.ExpireBy, expected.MaxAge = cabby.ExpireByValidUntil, time.Hour
+
+	if err := s.UpdateCollection(context.Background(), expected); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	result, _ := s.Collection(tester.Context, tester.APIRootPath, tester.CollectionID)
+	compareCollection(t, result, expected)
+}
+
+func compareCollection(t *testing.T, result, expected cabby.Collection) {
+	t.Helper()
+
+	if result.ID.String() != expected.ID.String() {
+		t.Error("Got:", result.ID.String(), "Expected:", expected.ID.String())
+	}
+	if result.Title != expected.Title {
+		t.Error("Got:", result.Title, "Expected:", expected.Title)
+	}
+	if result.Description != expected.Description {
+		t.Error("Got:", result.Description, "Expected:", expected.Description)
+	}
+	if result.CanRead != expected.CanRead {
+		t.Error("Got:", result.CanRead, "Expected:", expected.CanRead)
+	}
+	if result.CanWrite != expected.CanWrite {
+		t.Error("Got:", result.CanWrite, "Expected:", expected.CanWrite)
+	}
+	if strings.Join(result.MediaTypes, ",") != strings.Join(expected.MediaTypes, ",") {
+		t.Error("Got:", result.MediaTypes, "Expected:", expected.MediaTypes)
+	}
+	if result.VersionPolicy != expected.VersionPolicy {
+		t.Error("Got:", result.VersionPolicy, "Expected:", expected.VersionPolicy)
+	}
+	if result.RetainVersions != expected.RetainVersions {
+		t.Error("Got:", result.RetainVersions, "Expected:", expected.RetainVersions)
+	}
+	if result.RetainWindow != expected.RetainWindow {
+		t.Error("Got:", result.RetainWindow, "Expected:", expected.RetainWindow)
+	}
+	if result.ExpireBy != expected.ExpireBy {
+		t.Error("Got:", result.ExpireBy, "Expected:", expected.ExpireBy)
+	}
+	if result.MaxAge != expected.MaxAge {
+		t.Error("Got:", result.MaxAge, "Expected:", expected.MaxAge)
+	}
+}
@@ -0,0 +1,128 @@
+// Package datastoretest is a suite of tests for cabby.DataStore implementations.  A data store runs it from its own
+// tests to check that it behaves like the others:
+//
+//	func TestDataStoreConformance(t *testing.T) {
+//		datastoretest.Run(t, func(t *testing.T) cabby.DataStore {
+//			// return a new, empty data store
+//		})
+//	}
+package datastoretest
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+// Factory returns a new data store that's empty and ready to use; the suite closes it when a test is done
+type Factory func(t *testing.T) cabby.DataStore
+
+type test struct {
+	name string
+	run  func(t *testing.T, ds cabby.DataStore)
+}
+
+// Run the suite; each test gets a data store from the factory with the tester resources created in it
+func Run(t *testing.T, factory Factory) {
+	var tests []test
+	tests = append(tests, apiRootTests...)
+	tests = append(tests, collectionTests...)
+	tests = append(tests, discoveryTests...)
+	tests = append(tests, objectTests...)
+	tests = append(tests, manifestTests...)
+	tests = append(tests, statusTests...)
+	tests = append(tests, userTests...)
+
+	for _, test := range tests {
+		run := test.run
+		t.Run(test.name, func(t *testing.T) {
+			ds := factory(t)
+			defer ds.Close()
+
+			seed(t, ds)
+			run(t, ds)
+		})
+	}
+}
+
+/* helpers */
+
+// seed creates the tester user, discovery, api root, collection and object
+func seed(t *testing.T, ds cabby.DataStore) {
+	ctx := context.Background()
+
+	if err := ds.UserService().CreateUser(ctx, tester.User, tester.UserPassword); err != nil {
+		t.Fatal("Can't create user: ", err)
+	}
+	if err := ds.DiscoveryService().CreateDiscovery(ctx, tester.Discovery); err != nil {
+		t.Fatal("Can't create discovery: ", err)
+	}
+	if err := ds.APIRootService().CreateAPIRoot(ctx, tester.APIRoot); err != nil {
+		t.Fatal("Can't create api root: ", err)
+	}
+	createCollection(t, ds, tester.Collection.ID)
+	createObject(t, ds, tester.Object)
+}
+
+// createCollection creates a collection in the tester api root the tester user can read and write
+func createCollection(t *testing.T, ds cabby.DataStore, id cabby.ID) cabby.Collection {
+	c := tester.Collection
+	c.ID = id
+	return createUserCollection(t, ds, c)
+}
+
+// createPolicyCollection creates a collection with a version policy the tester user can read and write
+func createPolicyCollection(t *testing.T, ds cabby.DataStore, policy string) cabby.Collection {
+	c := tester.Collection
+	c.ID = newID(t)
+	c.VersionPolicy = policy
+	return createUserCollection(t, ds, c)
+}
+
+func createUserCollection(t *testing.T, ds cabby.DataStore, c cabby.Collection) cabby.Collection {
+	if err := ds.CollectionService().CreateCollection(context.Background(), c); err != nil {
+		t.Fatal("Can't create collection: ", err)
+	}
+
+	ca := cabby.CollectionAccess{ID: c.ID, CanRead: true, CanWrite: true}
+	if err := ds.UserService().CreateUserCollection(context.Background(), tester.UserEmail, ca); err != nil {
+		t.Fatal("Can't create user collection: ", err)
+	}
+	return c
+}
+
+func createObject(t *testing.T, ds cabby.DataStore, o cabby.Object) {
+	if err := ds.ObjectService().CreateObject(context.Background(), o); err != nil {
+		t.Fatal("Can't create object: ", err)
+	}
+}
+
+func newID(t *testing.T) cabby.ID {
+	id, err := cabby.NewID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// allItems is a range that doesn't page
+func allItems() *cabby.Range {
+	return &cabby.Range{First: -1, Last: -1}
+}
+
+// sameJSON compares JSON documents semantically; data stores can reformat the objects they store
+func sameJSON(a, b []byte) bool {
+	var x, y interface{}
+
+	if err := json.Unmarshal(a, &x); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
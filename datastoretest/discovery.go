@@ -0,0 +1,90 @@
+package datastoretest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+var discoveryTests = []test{
+	{"CreateDiscoveryOnlyOne", testCreateDiscoveryOnlyOne},
+	{"DeleteDiscovery", testDeleteDiscovery},
+	{"Discovery", testDiscovery},
+	{"UpdateDiscovery", testUpdateDiscovery},
+}
+
+func testCreateDiscoveryOnlyOne(t *testing.T, ds cabby.DataStore) {
+	err := ds.DiscoveryService().CreateDiscovery(context.Background(), tester.Discovery)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// a data store with no discovery isn't an error
+func testDeleteDiscovery(t *testing.T, ds cabby.DataStore) {
+	s := ds.DiscoveryService()
+
+	if err := s.DeleteDiscovery(context.Background()); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	result, err := s.Discovery(context.Background())
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+	if result.Title != "" || len(result.APIRoots) > 0 {
+		t.Error("Got:", result, "Expected no discovery")
+	}
+}
+
+// the api roots of a discovery are the api roots in the data store
+func testDiscovery(t *testing.T, ds cabby.DataStore) {
+	result, err := ds.DiscoveryService().Discovery(context.Background())
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	compareDiscovery(t, result, tester.DiscoveryDataStore)
+	if result.UpdatedAt.IsZero() {
+		t.Error("Expected an update time")
+	}
+}
+
+func testUpdateDiscovery(t *testing.T, ds cabby.DataStore) {
+	s := ds.DiscoveryService()
+
+	expected := tester.DiscoveryDataStore
+	expected.Title = "an updated title"
+	expected.Description = "an updated description"
+	expected.Contact = "an updated contact"
+
+	if err := s.UpdateDiscovery(context.Background(), expected); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	result, _ := s.Discovery(context.Background())
+	compareDiscovery(t, result, expected)
+}
+
+func compareDiscovery(t *testing.T, result, expected cabby.Discovery) {
+	t.Helper()
+
+	if result.Title != expected.Title {
+		t.Error("Got:", result.Title, "Expected:", expected.Title)
+	}
+	if result.Description != expected.Description {
+		t.Error("Got:", result.Description, "Expected:", expected.Description)
+	}
+	if result.Contact != expected.Contact {
+		t.Error("Got:", result.Contact, "Expected:", expected.Contact)
+	}
+	if result.Default != expected.Default {
+		t.Error("Got:", result.Default, "Expected:", expected.Default)
+	}
+	if strings.Join(result.APIRoots, ",") != strings.Join(expected.APIRoots, ",") {
+		t.Error("Got:", result.APIRoots, "Expected:", expected.APIRoots)
+	}
+}
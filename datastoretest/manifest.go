@@ -0,0 +1,293 @@
+package datastoretest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+var manifestTests = []test{
+	{"IterateManifest", testIterateManifest},
+	{"IterateManifestDateAdded", testIterateManifestDateAdded},
+	{"Manifest", testManifest},
+	{"ManifestCursor", testManifestCursor},
+	{"ManifestDateAdded", testManifestDateAdded},
+	{"ManifestFilter", testManifestFilter},
+	{"ManifestRange", testManifestRange},
+	{"ManifestSort", testManifestSort},
+}
+
+func testIterateManifest(t *testing.T, ds cabby.DataStore) {
+	cr := cabby.Range{First: 0, Last: 0}
+
+	entries, err := ds.ManifestService().IterateManifest(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	defer entries.Close()
+
+	if !entries.Next() {
+		t.Fatal("Got:", entries.Err(), "Expected an entry")
+	}
+	compareManifestEntry(t, entries.Entry(), manifestEntry(t, ds))
+
+	if entries.Next() {
+		t.Error("Got:", entries.Entry(), "Expected no more entries")
+	}
+	if err := entries.Err(); err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+	if cr.Total != 1 {
+		t.Error("Got:", cr.Total, "Expected:", 1)
+	}
+	if entries.UpdatedAt().IsZero() {
+		t.Error("Expected an update time")
+	}
+}
+
+func testManifest(t *testing.T, ds cabby.DataStore) {
+	result, err := ds.ManifestService().Manifest(context.Background(), tester.CollectionID, allItems(), cabby.Filter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if len(result.Objects) != 1 {
+		t.Fatal("Got:", len(result.Objects), "Expected:", 1)
+	}
+
+	compareManifestEntry(t, result.Objects[0], manifestEntry(t, ds))
+}
+
+// the date added of the first and last entries in range are read with the entries
+func testIterateManifestDateAdded(t *testing.T, ds cabby.DataStore) {
+	for i := 0; i < 3; i++ {
+		time.Sleep(5 * time.Millisecond)
+		createObjectID(t, ds, newStixID(t, "malware"))
+	}
+
+	cr := cabby.Range{First: 1, Last: 2}
+	entries, err := ds.ManifestService().IterateManifest(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	defer entries.Close()
+
+	var added []time.Time
+	for entries.Next() {
+		ts, err := time.Parse(time.RFC3339Nano, entries.Entry().DateAdded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		added = append(added, ts)
+	}
+	if len(added) != 2 {
+		t.Fatal("Got:", len(added), "Expected:", 2)
+	}
+
+	expectedFirst, expectedLast := firstAndLast(added)
+	first, last := entries.DateAdded()
+
+	if !sameMillisecond(first, expectedFirst) {
+		t.Error("Got:", first, "Expected:", expectedFirst)
+	}
+	if !sameMillisecond(last, expectedLast) {
+		t.Error("Got:", last, "Expected:", expectedLast)
+	}
+}
+
+// each version's date added is when it was added to the collection, not when it was created
+func testManifestDateAdded(t *testing.T, ds cabby.DataStore) {
+	id := newStixID(t, "malware")
+	createObjectVersion(t, ds, id, "2018-01-01T00:00:00.000Z")
+	time.Sleep(5 * time.Millisecond)
+	createObjectVersion(t, ds, id, "2018-01-02T00:00:00.000Z")
+
+	objects, _ := ds.ObjectService().Object(context.Background(), tester.CollectionID, id, cabby.Filter{Versions: "all"})
+	added := map[string]time.Time{}
+	for _, o := range objects {
+		added[normalTime(o.Modified)] = o.DateAdded
+	}
+
+	result, _ := ds.ManifestService().Manifest(context.Background(), tester.CollectionID, allItems(), cabby.Filter{IDs: id})
+	if len(result.Objects) != 2 {
+		t.Fatal("Got:", len(result.Objects), "Expected:", 2)
+	}
+
+	for _, entry := range result.Objects {
+		expected := added[normalTime(entry.Versions[0])].Format(cabby.TimestampFormat)
+		if entry.DateAdded != expected {
+			t.Error("Got:", entry.DateAdded, "Expected:", expected, "Version:", entry.Versions[0])
+		}
+	}
+
+	if result.Objects[0].DateAdded == result.Objects[1].DateAdded {
+		t.Error("Got:", result.Objects[0].DateAdded, "Expected versions added at different times")
+	}
+}
+
+// a manifest has an entry for each version of an object that matches the filter
+func testManifestFilter(t *testing.T, ds cabby.DataStore) {
+	id := newStixID(t, "malware")
+	versions := []string{"2018-01-01T00:00:00.000Z", "2018-01-02T00:00:00.000Z"}
+
+	for _, v := range versions {
+		createObjectVersion(t, ds, id, v)
+	}
+
+	tests := []struct {
+		filter   cabby.Filter
+		expected int
+	}{
+		{cabby.Filter{}, 3},
+		{cabby.Filter{Types: "foo"}, 0},
+		{cabby.Filter{IDs: id}, 2},
+		{cabby.Filter{IDs: id, Versions: "last"}, 1},
+		{cabby.Filter{Versions: "first"}, 2},
+	}
+
+	for _, test := range tests {
+		result, err := ds.ManifestService().Manifest(context.Background(), tester.CollectionID, allItems(), test.filter)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Filter:", test.filter)
+		}
+
+		if len(result.Objects) != test.expected {
+			t.Error("Got:", len(result.Objects), "Expected:", test.expected, "Filter:", test.filter)
+		}
+	}
+}
+
+func testManifestRange(t *testing.T, ds cabby.DataStore) {
+	for i := 0; i < 10; i++ {
+		createObjectID(t, ds, newStixID(t, "malware"))
+	}
+
+	tests := []struct {
+		cabbyRange cabby.Range
+		entries    int
+	}{
+		{cabby.Range{First: -1, Last: -1}, 11},
+		{cabby.Range{First: 0, Last: 5}, 6},
+	}
+
+	for _, test := range tests {
+		result, err := ds.ManifestService().Manifest(context.Background(), tester.CollectionID, &test.cabbyRange, cabby.Filter{})
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+
+		if len(result.Objects) != test.entries {
+			t.Error("Got:", len(result.Objects), "Expected:", test.entries, "Range:", test.cabbyRange)
+		}
+		if test.cabbyRange.Total != 11 {
+			t.Error("Got:", test.cabbyRange.Total, "Expected:", 11, "Range:", test.cabbyRange)
+		}
+	}
+}
+
+// a manifest pages by cursor like objects do: each page starts after the last entry of the one before
+func testManifestCursor(t *testing.T, ds cabby.DataStore) {
+	c := createCollection(t, ds, newID(t))
+
+	var expected []string
+	for i := 0; i < 3; i++ {
+		id := newStixID(t, "malware")
+		createVersions(t, ds, c.ID, id, []string{"2018-01-01T00:00:00.000Z"})
+		expected = append(expected, id)
+	}
+
+	cr := cabby.Range{First: -1, Last: -1, Limit: 2, Cursored: true}
+	first, err := ds.ManifestService().Manifest(context.Background(), c.ID.String(), &cr, cabby.Filter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if len(first.Objects) != 2 || cr.Next.IsZero() || cr.Total != 3 {
+		t.Fatal("Got:", first.Objects, cr, "Expected 2 entries, a next cursor and a total of 3")
+	}
+
+	cr.After = cr.Next
+	second, err := ds.ManifestService().Manifest(context.Background(), c.ID.String(), &cr, cabby.Filter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if len(second.Objects) != 1 || !cr.Next.IsZero() || cr.Offset != 2 {
+		t.Fatal("Got:", second.Objects, cr, "Expected 1 entry, no next cursor and an offset of 2")
+	}
+
+	read := []string{first.Objects[0].ID, first.Objects[1].ID, second.Objects[0].ID}
+	if strings.Join(read, ",") != strings.Join(expected, ",") {
+		t.Error("Got:", read, "Expected:", expected)
+	}
+}
+
+// manifest entries are read in the order of a range's sort like objects are
+func testManifestSort(t *testing.T, ds cabby.DataStore) {
+	c, ids := createSortObjects(t, ds)
+
+	tests := []struct {
+		cabbyRange cabby.Range
+		expected   []string
+	}{
+		{cabby.Range{First: -1, Last: -1, Sort: cabby.Sort{Field: cabby.SortID, Descending: true}},
+			[]string{ids[2] + "@01", ids[1] + "@04", ids[1] + "@02", ids[0] + "@03"}},
+		{cabby.Range{First: 0, Last: 1, Sort: cabby.Sort{Field: cabby.SortModified}},
+			[]string{ids[2] + "@01", ids[1] + "@02"}},
+	}
+
+	for _, test := range tests {
+		result, err := ds.ManifestService().Manifest(context.Background(), c.ID.String(), &test.cabbyRange, cabby.Filter{})
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+
+		var read []string
+		for _, entry := range result.Objects {
+			if len(entry.Versions) != 1 {
+				t.Fatal("Got:", entry.Versions, "Expected one version")
+			}
+			read = append(read, entry.ID+"@"+entry.Versions[0][8:10])
+		}
+		if strings.Join(read, ",") != strings.Join(test.expected, ",") {
+			t.Error("Got:", read, "Expected:", test.expected, "Sort:", test.cabbyRange.Sort)
+		}
+	}
+}
+
+// manifestEntry is the entry expected for the tester object; its date added is set by the data store
+func manifestEntry(t *testing.T, ds cabby.DataStore) cabby.ManifestEntry {
+	t.Helper()
+
+	objects, err := ds.ObjectService().Object(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{})
+	if err != nil || len(objects) != 1 {
+		t.Fatal("Got:", objects, err, "Expected the tester object")
+	}
+
+	entry := tester.ManifestEntry
+	entry.DateAdded = objects[0].DateAdded.Format(cabby.TimestampFormat)
+	return entry
+}
+
+func compareManifestEntry(t *testing.T, result, expected cabby.ManifestEntry) {
+	t.Helper()
+
+	if result.ID != expected.ID {
+		t.Error("Got:", result.ID, "Expected:", expected.ID)
+	}
+	if !sameTime(result.DateAdded, expected.DateAdded) {
+		t.Error("Got:", result.DateAdded, "Expected:", expected.DateAdded)
+	}
+	if len(result.Versions) != len(expected.Versions) {
+		t.Error("Got:", result.Versions, "Expected:", expected.Versions)
+	}
+	for i := 0; i < len(result.Versions) && i < len(expected.Versions); i++ {
+		if !sameTime(result.Versions[i], expected.Versions[i]) {
+			t.Error("Got:", result.Versions[i], "Expected:", expected.Versions[i])
+		}
+	}
+	if strings.Join(result.MediaTypes, ",") != strings.Join(expected.MediaTypes, ",") {
+		t.Error("Got:", result.MediaTypes, "Expected:", expected.MediaTypes)
+	}
+}
@@ -0,0 +1,1365 @@
+package datastoretest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+	"github.com/pladdy/stones"
+)
+
+var objectTests = []test{
+	{"CompactObjectsRetainVersions", testCompactObjectsRetainVersions},
+	{"CompactObjectsRetainWindow", testCompactObjectsRetainWindow},
+	{"CreateBundle", testCreateBundle},
+	{"CreateBundleCanceled", testCreateBundleCanceled},
+	{"CreateBundleOtherCollection", testCreateBundleOtherCollection},
+	{"CreateBundleVersionIgnoreIdentical", testCreateBundleVersionIgnoreIdentical},
+	{"CreateBundleVersionOverwrite", testCreateBundleVersionOverwrite},
+	{"CreateBundleVersionReject", testCreateBundleVersionReject},
+	{"CreateBundleVersionsInBundle", testCreateBundleVersionsInBundle},
+	{"CreateObjectDuplicate", testCreateObjectDuplicate},
+	{"CreateObjectInvalidJSON", testCreateObjectInvalidJSON},
+	{"CreateObjectOtherCollection", testCreateObjectOtherCollection},
+	{"DeleteObject", testDeleteObject},
+	{"DeleteObjectOtherCollection", testDeleteObjectOtherCollection},
+	{"IterateObjects", testIterateObjects},
+	{"IterateObjectsDateAdded", testIterateObjectsDateAdded},
+	{"Object", testObject},
+	{"ObjectDateAdded", testObjectDateAdded},
+	{"ObjectVersions", testObjectVersions},
+	{"Objects", testObjects},
+	{"ObjectsExpiredAdded", testObjectsExpiredAdded},
+	{"ObjectsExpiredValidUntil", testObjectsExpiredValidUntil},
+	{"ObjectsAddedAfter", testObjectsAddedAfter},
+	{"ObjectsAddedAfterVersion", testObjectsAddedAfterVersion},
+	{"ObjectsCursor", testObjectsCursor},
+	{"ObjectsFilter", testObjectsFilter},
+	{"ObjectsFilterProperties", testObjectsFilterProperties},
+	{"ObjectsOtherCollection", testObjectsOtherCollection},
+	{"ObjectsSort", testObjectsSort},
+	{"ObjectsVersionFlags", testObjectsVersionFlags},
+	{"PurgeObjects", testPurgeObjects},
+	{"TombstonesAddedAfter", testTombstonesAddedAfter},
+	{"Versions", testVersions},
+	{"VersionsRange", testVersionsRange},
+}
+
+// the first version and the latest versions are kept; collections without a retention aren't compacted
+func testCompactObjectsRetainVersions(t *testing.T, ds cabby.DataStore) {
+	c := tester.Collection
+	c.ID, c.RetainVersions = newID(t), 2
+	createUserCollection(t, ds, c)
+
+	id := newStixID(t, "malware")
+	versions := []string{
+		"2018-01-01T00:00:00.000Z",
+		"2018-01-02T00:00:00.000Z",
+		"2018-01-03T00:00:00.000Z",
+		"2018-01-04T00:00:00.000Z",
+		"2018-01-05T00:00:00.000Z",
+	}
+	createVersions(t, ds, c.ID, id, versions)
+
+	compacted, err := ds.ObjectService().CompactObjects(context.Background())
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if compacted != 2 {
+		t.Error("Got:", compacted, "Expected:", 2)
+	}
+
+	expected := []string{versions[0], versions[3], versions[4]}
+	compareVersions(t, ds, c.ID.String(), id, expected)
+
+	all, _ := ds.ObjectService().Objects(context.Background(), c.ID.String(), allItems(), cabby.Filter{Versions: "all"})
+	if len(all) != len(expected) {
+		t.Error("Got:", len(all), "Expected:", len(expected))
+	}
+
+	first, _ := ds.ObjectService().Object(context.Background(), c.ID.String(), id, cabby.Filter{Versions: "first"})
+	if len(first) != 1 || normalTime(first[0].Modified) != normalTime(versions[0]) {
+		t.Error("Got:", first, "Expected:", versions[0])
+	}
+
+	seeded, _ := ds.ObjectService().Object(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{})
+	if len(seeded) != 1 {
+		t.Error("Got:", len(seeded), "Expected:", 1)
+	}
+}
+
+// versions modified within the window are kept, along with the first and latest versions
+func testCompactObjectsRetainWindow(t *testing.T, ds cabby.DataStore) {
+	c := tester.Collection
+	c.ID, c.RetainWindow = newID(t), 24*time.Hour
+	createUserCollection(t, ds, c)
+
+	recent := time.Now().UTC().Add(-time.Hour).Truncate(time.Millisecond).Format("2006-01-02T15:04:05.000Z")
+	id := newStixID(t, "malware")
+	versions := []string{"2018-01-01T00:00:00.000Z", "2018-01-02T00:00:00.000Z", "2018-01-03T00:00:00.000Z", recent}
+	createVersions(t, ds, c.ID, id, versions)
+
+	compacted, err := ds.ObjectService().CompactObjects(context.Background())
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if compacted != 2 {
+		t.Error("Got:", compacted, "Expected:", 2)
+	}
+
+	compareVersions(t, ds, c.ID.String(), id, []string{versions[0], recent})
+}
+
+// an invalid object in a bundle is a failure; the others are created
+func testCreateBundle(t *testing.T, ds cabby.DataStore) {
+	ss := ds.StatusService()
+
+	objects := [][]byte{
+		malware(newStixID(t, "malware"), "2016-04-06T20:07:09.000Z"),
+		malware(newStixID(t, "malware"), "2016-04-06T20:07:09.000Z"),
+		[]byte(`{"type": "malware", "created": "2016-04-06T20:07:09.000Z", "modified": "2016-04-06T20:07:09.000Z"}`),
+	}
+
+	st, _ := cabby.NewStatus(len(objects))
+	if err := ss.CreateStatus(context.Background(), st); err != nil {
+		t.Fatal(err)
+	}
+
+	ds.ObjectService().CreateBundle(context.Background(), toChannel(objects), tester.CollectionID, st, ss)
+
+	result, err := ss.Status(context.Background(), st.ID.String())
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	if result.Status != "complete" {
+		t.Error("Got:", result.Status, "Expected:", "complete")
+	}
+	if result.SuccessCount != 2 || result.FailureCount != 1 || result.PendingCount != 0 {
+		t.Error("Got:", result.SuccessCount, result.FailureCount, result.PendingCount, "Expected:", 2, 1, 0)
+	}
+
+	results, _ := ds.ObjectService().Objects(context.Background(), tester.CollectionID, allItems(), cabby.Filter{})
+	if len(results) != 3 {
+		t.Error("Got:", len(results), "Expected:", 3)
+	}
+}
+
+func testCreateBundleCanceled(t *testing.T, ds cabby.DataStore) {
+	ss := ds.StatusService()
+
+	objects := [][]byte{malware(newStixID(t, "malware"), "2016-04-06T20:07:09.000Z")}
+
+	st, _ := cabby.NewStatus(len(objects))
+	if err := ss.CreateStatus(context.Background(), st); err != nil {
+		t.Fatal(err)
+	}
+	if err := ss.CancelStatus(context.Background(), st.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	ds.ObjectService().CreateBundle(context.Background(), toChannel(objects), tester.CollectionID, st, ss)
+
+	result, _ := ss.Status(context.Background(), st.ID.String())
+	if result.Status != "canceled" {
+		t.Error("Got:", result.Status, "Expected:", "canceled")
+	}
+}
+
+// an object that's in one collection can be posted to another
+func testCreateBundleOtherCollection(t *testing.T, ds cabby.DataStore) {
+	ss := ds.StatusService()
+	other := createCollection(t, ds, newID(t))
+
+	objects := [][]byte{tester.Object.Object}
+
+	st, _ := cabby.NewStatus(len(objects))
+	if err := ss.CreateStatus(context.Background(), st); err != nil {
+		t.Fatal(err)
+	}
+
+	ds.ObjectService().CreateBundle(context.Background(), toChannel(objects), other.ID.String(), st, ss)
+
+	result, _ := ss.Status(context.Background(), st.ID.String())
+	if result.SuccessCount != 1 || result.FailureCount != 0 {
+		t.Error("Got:", result.SuccessCount, result.FailureCount, result.Failures, "Expected:", 1, 0)
+	}
+
+	results, _ := ds.ObjectService().Object(context.Background(), other.ID.String(), tester.ObjectID, cabby.Filter{})
+	if len(results) != 1 {
+		t.Error("Got:", len(results), "Expected:", 1)
+	}
+}
+
+// identical content is ignored whether it's posted as the same version or as a new one; a stored version can't be
+// changed
+func testCreateBundleVersionIgnoreIdentical(t *testing.T, ds cabby.DataStore) {
+	c := createPolicyCollection(t, ds, cabby.VersionPolicyIgnoreIdentical)
+	id, version := newStixID(t, "malware"), "2018-01-01T00:00:00.000Z"
+
+	o := tester.Object
+	o.ID, o.Modified, o.Object, o.CollectionID = stones.ID(id), version, malware(id, version), c.ID
+	createObject(t, ds, o)
+
+	changed := strings.Replace(string(malware(id, version)), "Poison Ivy", "Poison Oak", 1)
+	result := createBundle(t, ds, c.ID.String(), [][]byte{
+		[]byte(strings.Replace(string(malware(id, version)), ", ", ",\n  ", -1)),
+		malware(id, "2018-01-02T00:00:00.000Z"),
+		[]byte(changed),
+	})
+
+	if result.SuccessCount != 2 || result.FailureCount != 1 {
+		t.Error("Got:", result.SuccessCount, result.FailureCount, result.Failures, "Expected:", 2, 1)
+	}
+	if len(result.Successes) != 2 || result.Successes[0] != id {
+		t.Error("Got:", result.Successes, "Expected:", []string{id, id})
+	}
+	if len(result.Failures) != 1 || !strings.Contains(result.Failures[0].Message, "different content") {
+		t.Error("Got:", result.Failures, "Expected a failure for the changed version")
+	}
+
+	versions, _ := ds.ObjectService().Object(context.Background(), c.ID.String(), id, cabby.Filter{})
+	if len(versions) != 1 || !sameJSON(versions[0].Object, malware(id, version)) {
+		t.Error("Got:", versions, "Expected the version created first")
+	}
+}
+
+// a changed version replaces the stored one, without changing when it was added; older versions are added
+func testCreateBundleVersionOverwrite(t *testing.T, ds cabby.DataStore) {
+	c := createPolicyCollection(t, ds, cabby.VersionPolicyOverwrite)
+	id, version := newStixID(t, "malware"), "2018-01-02T00:00:00.000Z"
+
+	o := tester.Object
+	o.ID, o.Modified, o.Object, o.CollectionID = stones.ID(id), version, malware(id, version), c.ID
+	createObject(t, ds, o)
+
+	stored, _ := ds.ObjectService().Object(context.Background(), c.ID.String(), id, cabby.Filter{})
+	if len(stored) != 1 {
+		t.Fatal("Got:", len(stored), "Expected:", 1)
+	}
+
+	changed := []byte(strings.Replace(string(malware(id, version)), "Poison Ivy", "Poison Oak", 1))
+	result := createBundle(t, ds, c.ID.String(), [][]byte{changed, malware(id, "2018-01-01T00:00:00.000Z")})
+
+	if result.SuccessCount != 2 || result.FailureCount != 0 {
+		t.Error("Got:", result.SuccessCount, result.FailureCount, result.Failures, "Expected:", 2, 0)
+	}
+
+	versions, _ := ds.ObjectService().Object(
+		context.Background(), c.ID.String(), id, cabby.Filter{Versions: version})
+	if len(versions) != 1 {
+		t.Fatal("Got:", len(versions), "Expected:", 1)
+	}
+	if !sameJSON(versions[0].Object, changed) {
+		t.Error("Got:", string(versions[0].Object), "Expected:", string(changed))
+	}
+	if !sameMillisecond(versions[0].DateAdded, stored[0].DateAdded) {
+		t.Error("Got:", versions[0].DateAdded, "Expected:", stored[0].DateAdded)
+	}
+
+	all, _ := ds.ObjectService().Object(context.Background(), c.ID.String(), id, cabby.Filter{Versions: "all"})
+	if len(all) != 2 {
+		t.Error("Got:", len(all), "Expected:", 2)
+	}
+}
+
+// collections reject versions that exist or are older than the latest version by default
+func testCreateBundleVersionReject(t *testing.T, ds cabby.DataStore) {
+	result := createBundle(t, ds, tester.CollectionID, [][]byte{
+		tester.Object.Object,
+		malware(tester.ObjectID, "2016-01-01T00:00:00.000Z"),
+		malware(tester.ObjectID, "2018-01-01T00:00:00.000Z"),
+	})
+
+	if result.SuccessCount != 1 || result.FailureCount != 2 {
+		t.Error("Got:", result.SuccessCount, result.FailureCount, result.Failures, "Expected:", 1, 2)
+	}
+
+	expected := []string{"already exists", "older than the latest version"}
+	if len(result.Failures) != len(expected) {
+		t.Fatal("Got:", result.Failures, "Expected:", expected)
+	}
+	for i, message := range expected {
+		if result.Failures[i].ID != tester.ObjectID || !strings.Contains(result.Failures[i].Message, message) {
+			t.Error("Got:", result.Failures[i], "Expected:", tester.ObjectID, message)
+		}
+	}
+}
+
+// version policies apply to versions earlier in the same bundle: an older version after a newer one is rejected,
+// and an identical version after another is ignored
+func testCreateBundleVersionsInBundle(t *testing.T, ds cabby.DataStore) {
+	id := newStixID(t, "malware")
+	result := createBundle(t, ds, tester.CollectionID, [][]byte{
+		malware(id, "2016-04-07T20:07:09.000Z"),
+		malware(id, "2016-04-06T20:07:09.000Z"),
+	})
+
+	if result.SuccessCount != 1 || result.FailureCount != 1 {
+		t.Error("Got:", result.SuccessCount, result.FailureCount, result.Failures, "Expected:", 1, 1)
+	}
+	// the failure is the version that was rejected
+	if len(result.Failures) != 1 || result.Failures[0].ID != id || result.Failures[0].Version != "2016-04-06T20:07:09.000Z" {
+		t.Error("Got:", result.Failures, "Expected a failure for:", id, "2016-04-06T20:07:09.000Z")
+	}
+	compareVersions(t, ds, tester.CollectionID, id, []string{"2016-04-07T20:07:09.000Z"})
+
+	c := createPolicyCollection(t, ds, cabby.VersionPolicyIgnoreIdentical)
+	id = newStixID(t, "malware")
+	result = createBundle(t, ds, c.ID.String(), [][]byte{
+		malware(id, "2018-01-01T00:00:00.000Z"),
+		malware(id, "2018-01-01T00:00:00.000Z"),
+	})
+
+	if result.SuccessCount != 2 || result.FailureCount != 0 {
+		t.Error("Got:", result.SuccessCount, result.FailureCount, result.Failures, "Expected:", 2, 0)
+	}
+	compareVersions(t, ds, c.ID.String(), id, []string{"2018-01-01T00:00:00.000Z"})
+}
+
+// an object version is its id and modified time; a version can't be created twice in a collection
+func testCreateObjectDuplicate(t *testing.T, ds cabby.DataStore) {
+	err := ds.ObjectService().CreateObject(context.Background(), tester.Object)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func testCreateObjectInvalidJSON(t *testing.T, ds cabby.DataStore) {
+	o := tester.Object
+	o.Modified = "2018-01-01T00:00:00.000Z"
+	o.Object = []byte(`{"type": "malware"`)
+
+	err := ds.ObjectService().CreateObject(context.Background(), o)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+// the same version of an object can be in more than one collection; each collection has its own copy
+func testCreateObjectOtherCollection(t *testing.T, ds cabby.DataStore) {
+	other := createCollection(t, ds, newID(t))
+
+	o := tester.Object
+	o.CollectionID = other.ID
+	createObject(t, ds, o)
+
+	for _, collectionID := range []string{tester.CollectionID, other.ID.String()} {
+		results, err := ds.ObjectService().Object(context.Background(), collectionID, tester.ObjectID, cabby.Filter{})
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+
+		if len(results) != 1 {
+			t.Fatal("Got:", len(results), "Expected:", 1, "Collection:", collectionID)
+		}
+		if results[0].CollectionID.String() != collectionID {
+			t.Error("Got:", results[0].CollectionID, "Expected:", collectionID)
+		}
+		if normalTime(results[0].Modified) != normalTime(tester.Object.Modified) {
+			t.Error("Got:", results[0].Modified, "Expected:", tester.Object.Modified)
+		}
+
+		manifest, _ := ds.ManifestService().Manifest(context.Background(), collectionID, allItems(), cabby.Filter{})
+		if len(manifest.Objects) != 1 {
+			t.Error("Got:", len(manifest.Objects), "Expected:", 1, "Collection:", collectionID)
+		}
+	}
+
+	// a new version in one collection isn't in the other
+	createObjectVersion(t, ds, tester.ObjectID, "2018-01-01T00:00:00.000Z")
+
+	results, _ := ds.ObjectService().Object(context.Background(), other.ID.String(), tester.ObjectID, cabby.Filter{Versions: "all"})
+	if len(results) != 1 {
+		t.Error("Got:", len(results), "Expected:", 1)
+	}
+}
+
+// the date added of the first and last objects in range are read with the objects
+func testIterateObjectsDateAdded(t *testing.T, ds cabby.DataStore) {
+	for i := 0; i < 3; i++ {
+		time.Sleep(5 * time.Millisecond)
+		createObjectID(t, ds, newStixID(t, "malware"))
+	}
+
+	cr := cabby.Range{First: 1, Last: 2}
+	objects, err := ds.ObjectService().IterateObjects(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	defer objects.Close()
+
+	var added []time.Time
+	for objects.Next() {
+		added = append(added, objects.Object().DateAdded)
+	}
+	if len(added) != 2 {
+		t.Fatal("Got:", len(added), "Expected:", 2)
+	}
+
+	expectedFirst, expectedLast := firstAndLast(added)
+	first, last := objects.DateAdded()
+
+	if !sameMillisecond(first, expectedFirst) {
+		t.Error("Got:", first, "Expected:", expectedFirst)
+	}
+	if !sameMillisecond(last, expectedLast) {
+		t.Error("Got:", last, "Expected:", expectedLast)
+	}
+	if !last.After(first) {
+		t.Error("Got:", first, last, "Expected the last object added after the first")
+	}
+}
+
+// the versions in the filter are deleted and a tombstone is recorded for each
+func testDeleteObject(t *testing.T, ds cabby.DataStore) {
+	c := createCollection(t, ds, newID(t))
+	id := newStixID(t, "malware")
+	versions := []string{"2018-01-01T00:00:00.000Z", "2018-01-02T00:00:00.000Z", "2018-01-03T00:00:00.000Z"}
+	createVersions(t, ds, c.ID, id, versions)
+
+	deleted, err := ds.ObjectService().DeleteObject(context.Background(), c.ID.String(), id, cabby.Filter{Versions: "first"})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if deleted != 1 {
+		t.Error("Got:", deleted, "Expected:", 1)
+	}
+	compareVersions(t, ds, c.ID.String(), id, versions[1:])
+
+	deleted, _ = ds.ObjectService().DeleteObject(context.Background(), c.ID.String(), id, cabby.Filter{Versions: "all"})
+	if deleted != 2 {
+		t.Error("Got:", deleted, "Expected:", 2)
+	}
+	compareVersions(t, ds, c.ID.String(), id, []string{})
+
+	deleted, _ = ds.ObjectService().DeleteObject(context.Background(), c.ID.String(), id, cabby.Filter{Versions: "all"})
+	if deleted != 0 {
+		t.Error("Got:", deleted, "Expected:", 0)
+	}
+
+	tombstones, err := ds.ObjectService().Tombstones(context.Background(), c.ID.String(), cabby.Filter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if len(tombstones) != len(versions) {
+		t.Fatal("Got:", len(tombstones), "Expected:", len(versions))
+	}
+
+	deletedVersions := map[string]bool{}
+	for _, tombstone := range tombstones {
+		if tombstone.ID != id {
+			t.Error("Got:", tombstone.ID, "Expected:", id)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, tombstone.DateDeleted); err != nil {
+			t.Error("Got:", tombstone.DateDeleted, "Expected a timestamp")
+		}
+		deletedVersions[normalTime(tombstone.Version)] = true
+	}
+	for _, v := range versions {
+		if !deletedVersions[normalTime(v)] {
+			t.Error("Got:", deletedVersions, "Expected version:", v)
+		}
+	}
+}
+
+// deleting an object from a collection doesn't delete it from others
+func testDeleteObjectOtherCollection(t *testing.T, ds cabby.DataStore) {
+	other := createCollection(t, ds, newID(t))
+
+	o := tester.Object
+	o.CollectionID = other.ID
+	createObject(t, ds, o)
+
+	deleted, err := ds.ObjectService().DeleteObject(context.Background(), other.ID.String(), tester.ObjectID, cabby.Filter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if deleted != 1 {
+		t.Error("Got:", deleted, "Expected:", 1)
+	}
+
+	compareVersions(t, ds, other.ID.String(), tester.ObjectID, []string{})
+	compareVersions(t, ds, tester.CollectionID, tester.ObjectID, []string{tester.Object.Modified})
+
+	tombstones, _ := ds.ObjectService().Tombstones(context.Background(), tester.CollectionID, cabby.Filter{})
+	if len(tombstones) != 0 {
+		t.Error("Got:", len(tombstones), "Expected:", 0)
+	}
+}
+
+func testIterateObjects(t *testing.T, ds cabby.DataStore) {
+	for i := 0; i < 4; i++ {
+		createObjectID(t, ds, newStixID(t, "malware"))
+	}
+
+	cr := cabby.Range{First: 0, Last: 2}
+	objects, err := ds.ObjectService().IterateObjects(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	defer objects.Close()
+
+	count := 0
+	for objects.Next() {
+		if objects.Object().ID == "" {
+			t.Error("Got:", objects.Object(), "Expected an object")
+		}
+		count++
+	}
+
+	if err := objects.Err(); err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+	if count != 3 {
+		t.Error("Got:", count, "Expected:", 3)
+	}
+	if cr.Total != 5 {
+		t.Error("Got:", cr.Total, "Expected:", 5)
+	}
+	if objects.UpdatedAt().IsZero() {
+		t.Error("Expected an update time")
+	}
+}
+
+// adding a version of an object doesn't change when the other versions were added
+func testObjectDateAdded(t *testing.T, ds cabby.DataStore) {
+	before, _ := ds.ObjectService().Object(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{})
+	if len(before) != 1 || before[0].DateAdded.IsZero() {
+		t.Fatal("Got:", before, "Expected an object with a date added")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	createObjectVersion(t, ds, tester.ObjectID, "2018-01-01T00:00:00.000Z")
+
+	results, _ := ds.ObjectService().Object(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{Versions: "all"})
+	if len(results) != 2 {
+		t.Fatal("Got:", len(results), "Expected:", 2)
+	}
+
+	for _, o := range results {
+		if sameTime(o.Modified, tester.Object.Modified) {
+			if !sameMillisecond(o.DateAdded, before[0].DateAdded) {
+				t.Error("Got:", o.DateAdded, "Expected:", before[0].DateAdded)
+			}
+			continue
+		}
+
+		if !o.DateAdded.After(before[0].DateAdded) {
+			t.Error("Got:", o.DateAdded, "Expected after:", before[0].DateAdded)
+		}
+	}
+}
+
+func testObject(t *testing.T, ds cabby.DataStore) {
+	results, err := ds.ObjectService().Object(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if len(results) != 1 {
+		t.Fatal("Got:", len(results), "Expected:", 1)
+	}
+
+	result := results[0]
+	expected := tester.Object
+
+	if result.ID != expected.ID {
+		t.Error("Got:", result.ID, "Expected:", expected.ID)
+	}
+	if result.Type != expected.Type {
+		t.Error("Got:", result.Type, "Expected:", expected.Type)
+	}
+	if !sameTime(result.Created, expected.Created) {
+		t.Error("Got:", result.Created, "Expected:", expected.Created)
+	}
+	if !sameTime(result.Modified, expected.Modified) {
+		t.Error("Got:", result.Modified, "Expected:", expected.Modified)
+	}
+	if result.CollectionID.String() != expected.CollectionID.String() {
+		t.Error("Got:", result.CollectionID.String(), "Expected:", expected.CollectionID.String())
+	}
+	if !sameJSON(result.Object, expected.Object) {
+		t.Error("Got:", string(result.Object), "Expected:", string(expected.Object))
+	}
+	if result.UpdatedAt.IsZero() {
+		t.Error("Expected an update time")
+	}
+}
+
+func testObjectVersions(t *testing.T, ds cabby.DataStore) {
+	id := newStixID(t, "malware")
+	versions := []string{"2018-01-01T00:00:00.000Z", "2018-01-02T00:00:00.000Z", "2018-01-03T00:00:00.000Z"}
+
+	for _, v := range versions {
+		createObjectVersion(t, ds, id, v)
+	}
+
+	tests := []struct {
+		versions string
+		expected []string
+	}{
+		{"", versions},
+		{"all", versions},
+		{"first", versions[:1]},
+		{"last", versions[2:]},
+		{"first,last", []string{versions[0], versions[2]}},
+		{versions[1], versions[1:2]},
+		{"2017-01-01T00:00:00.000Z", []string{}},
+	}
+
+	for _, test := range tests {
+		results, err := ds.ObjectService().Object(context.Background(), tester.CollectionID, id, cabby.Filter{Versions: test.versions})
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Versions:", test.versions)
+		}
+
+		modified := map[string]bool{}
+		for _, o := range results {
+			modified[normalTime(o.Modified)] = true
+		}
+
+		if len(results) != len(test.expected) {
+			t.Error("Got:", len(results), "Expected:", len(test.expected), "Versions:", test.versions)
+			continue
+		}
+		for _, v := range test.expected {
+			if !modified[normalTime(v)] {
+				t.Error("Got:", results, "Expected version:", v, "Versions:", test.versions)
+			}
+		}
+	}
+
+	// versions are per object; the tester object is its own first and last version
+	results, _ := ds.ObjectService().Objects(context.Background(), tester.CollectionID, allItems(), cabby.Filter{Versions: "last"})
+	if len(results) != 2 {
+		t.Error("Got:", len(results), "Expected:", 2)
+	}
+}
+
+func testObjects(t *testing.T, ds cabby.DataStore) {
+	for i := 0; i < 10; i++ {
+		createObjectID(t, ds, newStixID(t, "malware"))
+	}
+
+	tests := []struct {
+		cabbyRange cabby.Range
+		objects    int
+	}{
+		{cabby.Range{First: -1, Last: -1}, 11},
+		{cabby.Range{First: 0, Last: 4}, 5},
+		{cabby.Range{First: 9, Last: 20}, 2},
+	}
+
+	for _, test := range tests {
+		results, err := ds.ObjectService().Objects(context.Background(), tester.CollectionID, &test.cabbyRange, cabby.Filter{})
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+
+		if len(results) != test.objects {
+			t.Error("Got:", len(results), "Expected:", test.objects, "Range:", test.cabbyRange)
+		}
+		if test.cabbyRange.Total != 11 {
+			t.Error("Got:", test.cabbyRange.Total, "Expected:", 11, "Range:", test.cabbyRange)
+		}
+	}
+}
+
+// objects are added after a time if they're created in the data store after it
+func testObjectsAddedAfter(t *testing.T, ds cabby.DataStore) {
+	time.Sleep(50 * time.Millisecond)
+	addedAfter := time.Now().UTC().Format(time.RFC3339Nano)
+	time.Sleep(50 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		createObjectID(t, ds, newStixID(t, "malware"))
+	}
+
+	tests := []struct {
+		addedAfter string
+		expected   int
+	}{
+		{"", 4},
+		{"2016-01-01T00:00:00.000Z", 4},
+		{addedAfter, 3},
+		{time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano), 0},
+	}
+
+	for _, test := range tests {
+		f := cabby.Filter{AddedAfter: test.addedAfter}
+		results, err := ds.ObjectService().Objects(context.Background(), tester.CollectionID, allItems(), f)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Added after:", test.addedAfter)
+		}
+
+		if len(results) != test.expected {
+			t.Error("Got:", len(results), "Expected:", test.expected, "Added after:", test.addedAfter)
+		}
+	}
+}
+
+// added after is compared to when each version was added, not when the object was first added
+func testObjectsAddedAfterVersion(t *testing.T, ds cabby.DataStore) {
+	time.Sleep(5 * time.Millisecond)
+	createObjectVersion(t, ds, tester.ObjectID, "2018-01-01T00:00:00.000Z")
+
+	objects, _ := ds.ObjectService().Object(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{Versions: "all"})
+	if len(objects) != 2 {
+		t.Fatal("Got:", len(objects), "Expected:", 2)
+	}
+
+	first := objects[0].DateAdded
+	if objects[1].DateAdded.Before(first) {
+		first = objects[1].DateAdded
+	}
+
+	f := cabby.Filter{AddedAfter: first.Format(time.RFC3339Nano), Versions: "all"}
+	results, _ := ds.ObjectService().Objects(context.Background(), tester.CollectionID, allItems(), f)
+	if len(results) != 1 || !sameTime(results[0].Modified, "2018-01-01T00:00:00.000Z") {
+		t.Error("Got:", results, "Expected the version added last")
+	}
+}
+
+func testObjectsFilter(t *testing.T, ds cabby.DataStore) {
+	ids := []string{}
+	for i := 0; i < 3; i++ {
+		id := newStixID(t, "indicator")
+		ids = append(ids, id)
+
+		o := tester.Object
+		o.ID = stones.ID(id)
+		o.Type = "indicator"
+		createObject(t, ds, o)
+	}
+
+	tests := []struct {
+		filter   cabby.Filter
+		expected int
+	}{
+		{cabby.Filter{}, 4},
+		{cabby.Filter{Types: "foo"}, 0},
+		{cabby.Filter{Types: "indicator"}, 3},
+		{cabby.Filter{Types: "indicator,malware"}, 4},
+		{cabby.Filter{IDs: ids[0]}, 1},
+		{cabby.Filter{IDs: strings.Join([]string{ids[1], tester.ObjectID}, ",")}, 2},
+		{cabby.Filter{IDs: ids[1], Types: "malware"}, 0},
+	}
+
+	for _, test := range tests {
+		results, err := ds.ObjectService().Objects(context.Background(), tester.CollectionID, allItems(), test.filter)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Filter:", test.filter)
+		}
+
+		if len(results) != test.expected {
+			t.Error("Got:", len(results), "Expected:", test.expected, "Filter:", test.filter)
+		}
+	}
+}
+
+// paging by cursor reads every object once in the order they were added, even when objects are added between pages;
+// they're added after the pages already read
+func testObjectsCursor(t *testing.T, ds cabby.DataStore) {
+	c := createCollection(t, ds, newID(t))
+
+	var expected []string
+	for i := 0; i < 5; i++ {
+		id := newStixID(t, "malware")
+		createVersions(t, ds, c.ID, id, []string{"2018-01-01T00:00:00.000Z"})
+		expected = append(expected, id)
+	}
+
+	var read []string
+	var offsets []int64
+	cr := cabby.Range{First: -1, Last: -1, Limit: 2, Cursored: true}
+
+	for page := 0; page < 10; page++ {
+		objects, err := ds.ObjectService().Objects(context.Background(), c.ID.String(), &cr, cabby.Filter{})
+		if err != nil {
+			t.Fatal("Got:", err, "Expected no error")
+		}
+
+		for _, o := range objects {
+			read = append(read, string(o.ID))
+		}
+		offsets = append(offsets, cr.Offset)
+
+		if page == 0 {
+			id := newStixID(t, "malware")
+			createVersions(t, ds, c.ID, id, []string{"2018-01-01T00:00:00.000Z"})
+			expected = append(expected, id)
+		}
+
+		if cr.Next.IsZero() {
+			break
+		}
+		cr.After = cr.Next
+	}
+
+	if strings.Join(read, ",") != strings.Join(expected, ",") {
+		t.Error("Got:", read, "Expected:", expected)
+	}
+	if len(offsets) != 3 || offsets[0] != 0 || offsets[1] != 2 || offsets[2] != 4 {
+		t.Error("Got:", offsets, "Expected:", []int64{0, 2, 4})
+	}
+	if cr.Total != 6 {
+		t.Error("Got:", cr.Total, "Expected:", 6)
+	}
+}
+
+// match filters on object properties apply to objects and manifests alike
+func testObjectsFilterProperties(t *testing.T, ds cabby.DataStore) {
+	c := createCollection(t, ds, newID(t))
+
+	objects := []struct {
+		objectType string
+		body       string
+	}{
+		{"indicator", `{"type": "indicator", "id": "%s", "modified": "%s", "labels": ["malicious-activity"],
+		  "confidence": 80, "created_by_ref": "identity--f431f809-377b-45e0-aa1c-6a4751cae5ff"}`},
+		{"indicator", `{"type": "indicator", "id": "%s", "modified": "%s", "spec_version": "2.1", "labels": ["benign"],
+		  "external_references": [{"source_name": "cve", "external_id": "CVE-2016-1234"}]}`},
+		{"relationship", `{"type": "relationship", "id": "%s", "modified": "%s", "spec_version": "2.1",
+		  "relationship_type": "uses", "source_ref": "indicator--8e2e2d2b-17d4-4cbf-938f-98ee46b3cd3f", "confidence": 5,
+		  "target_ref": "malware--31b940d4-6f7f-459a-80ea-9c1f17b5891b"}`},
+	}
+	for _, object := range objects {
+		o := tester.Object
+		o.ID = stones.ID(newStixID(t, object.objectType))
+		o.Type = object.objectType
+		o.CollectionID = c.ID
+		o.Object = []byte(fmt.Sprintf(object.body, o.ID, o.Modified))
+		createObject(t, ds, o)
+	}
+
+	tests := []struct {
+		properties map[string]string
+		expected   int
+	}{
+		{map[string]string{}, 3},
+		{map[string]string{cabby.MatchLabels: "malicious-activity"}, 1},
+		{map[string]string{cabby.MatchLabels: "benign,malicious-activity"}, 2},
+		{map[string]string{cabby.MatchConfidence: "80"}, 1},
+		{map[string]string{cabby.MatchConfidence: "5,80"}, 2},
+		{map[string]string{cabby.MatchCreatedByRef: "identity--f431f809-377b-45e0-aa1c-6a4751cae5ff"}, 1},
+		{map[string]string{cabby.MatchExternalID: "CVE-2016-1234"}, 1},
+		{map[string]string{cabby.MatchExternalID: "CVE-2016-0000"}, 0},
+		{map[string]string{cabby.MatchSpecVersion: "2.0"}, 1},
+		{map[string]string{cabby.MatchSpecVersion: "2.1"}, 2},
+		{map[string]string{cabby.MatchRelationshipType: "uses"}, 1},
+		{map[string]string{cabby.MatchSourceRef: "indicator--8e2e2d2b-17d4-4cbf-938f-98ee46b3cd3f"}, 1},
+		{map[string]string{cabby.MatchTargetRef: "malware--31b940d4-6f7f-459a-80ea-9c1f17b5891b"}, 1},
+		{map[string]string{cabby.MatchSpecVersion: "2.1", cabby.MatchLabels: "benign"}, 1},
+		{map[string]string{cabby.MatchSpecVersion: "2.1", cabby.MatchLabels: "malicious-activity"}, 0},
+	}
+
+	for _, test := range tests {
+		f := cabby.Filter{Properties: test.properties}
+
+		results, err := ds.ObjectService().Objects(context.Background(), c.ID.String(), allItems(), f)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Properties:", test.properties)
+		}
+		if len(results) != test.expected {
+			t.Error("Got:", len(results), "Expected:", test.expected, "Properties:", test.properties)
+		}
+
+		manifest, err := ds.ManifestService().Manifest(context.Background(), c.ID.String(), allItems(), f)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Properties:", test.properties)
+		}
+		if len(manifest.Objects) != test.expected {
+			t.Error("Got:", len(manifest.Objects), "Expected:", test.expected, "Properties:", test.properties)
+		}
+	}
+}
+
+// objects expire when their latest version was added more than the max age ago
+func testObjectsExpiredAdded(t *testing.T, ds cabby.DataStore) {
+	c := tester.Collection
+	c.ID, c.ExpireBy, c.MaxAge = newID(t), cabby.ExpireByAdded, time.Second
+	createUserCollection(t, ds, c)
+
+	expired := newStixID(t, "indicator")
+	createVersions(t, ds, c.ID, expired, []string{"2018-01-01T00:00:00.000Z"})
+
+	time.Sleep(1100 * time.Millisecond)
+
+	current := newStixID(t, "indicator")
+	createVersions(t, ds, c.ID, current, []string{"2018-01-01T00:00:00.000Z"})
+
+	results, err := ds.ObjectService().Objects(context.Background(), c.ID.String(), allItems(), cabby.Filter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if len(results) != 1 || string(results[0].ID) != current {
+		t.Error("Got:", results, "Expected:", current)
+	}
+}
+
+// objects expire when their latest version's valid_until has passed; an older version's valid_until doesn't matter
+// and objects without one don't expire
+func testObjectsExpiredValidUntil(t *testing.T, ds cabby.DataStore) {
+	c := createExpiringCollection(t, ds)
+	current, expired, revoked, undated := createExpiringObjects(t, ds, c.ID)
+
+	results, err := ds.ObjectService().Objects(
+		context.Background(), c.ID.String(), allItems(), cabby.Filter{Versions: "all"})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	ids := map[string]int{}
+	for _, o := range results {
+		ids[string(o.ID)]++
+	}
+	if len(results) != 3 || ids[current] != 2 || ids[undated] != 1 {
+		t.Error("Got:", ids, "Expected:", current, 2, undated, 1)
+	}
+
+	for _, id := range []string{expired, revoked} {
+		versions, _ := ds.ObjectService().Object(context.Background(), c.ID.String(), id, cabby.Filter{Versions: "all"})
+		if len(versions) != 0 {
+			t.Error("Got:", len(versions), "Expected:", 0, "ID:", id)
+		}
+	}
+
+	manifest, _ := ds.ManifestService().Manifest(
+		context.Background(), c.ID.String(), allItems(), cabby.Filter{Versions: "all"})
+	if len(manifest.Objects) != len(results) {
+		t.Error("Got:", len(manifest.Objects), "Expected:", len(results))
+	}
+}
+
+func testObjectsOtherCollection(t *testing.T, ds cabby.DataStore) {
+	other := createCollection(t, ds, newID(t))
+
+	o := tester.Object
+	o.ID = stones.ID(newStixID(t, "malware"))
+	o.CollectionID = other.ID
+	createObject(t, ds, o)
+
+	results, _ := ds.ObjectService().Objects(context.Background(), tester.CollectionID, allItems(), cabby.Filter{})
+	if len(results) != 1 || results[0].ID != tester.Object.ID {
+		t.Error("Got:", results, "Expected:", tester.ObjectID)
+	}
+
+	results, _ = ds.ObjectService().Object(context.Background(), tester.CollectionID, string(o.ID), cabby.Filter{})
+	if len(results) != 0 {
+		t.Error("Got:", len(results), "Expected:", 0)
+	}
+}
+
+// objects are read in the order of a range's sort; ties in its field are broken by the other fields
+func testObjectsSort(t *testing.T, ds cabby.DataStore) {
+	c, ids := createSortObjects(t, ds)
+
+	tests := []struct {
+		cabbyRange cabby.Range
+		expected   []string
+	}{
+		{cabby.Range{First: -1, Last: -1, Sort: cabby.Sort{Field: cabby.SortID}},
+			[]string{ids[0] + "@03", ids[1] + "@02", ids[1] + "@04", ids[2] + "@01"}},
+		{cabby.Range{First: -1, Last: -1, Sort: cabby.Sort{Field: cabby.SortID, Descending: true}},
+			[]string{ids[2] + "@01", ids[1] + "@04", ids[1] + "@02", ids[0] + "@03"}},
+		{cabby.Range{First: -1, Last: -1, Sort: cabby.Sort{Field: cabby.SortModified}},
+			[]string{ids[2] + "@01", ids[1] + "@02", ids[0] + "@03", ids[1] + "@04"}},
+		{cabby.Range{First: 1, Last: 2, Sort: cabby.Sort{Field: cabby.SortModified, Descending: true}},
+			[]string{ids[0] + "@03", ids[1] + "@02"}},
+	}
+
+	for _, test := range tests {
+		results, err := ds.ObjectService().Objects(context.Background(), c.ID.String(), &test.cabbyRange, cabby.Filter{})
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+
+		var read []string
+		for _, o := range results {
+			read = append(read, string(o.ID)+"@"+o.Modified[8:10])
+		}
+		if strings.Join(read, ",") != strings.Join(test.expected, ",") {
+			t.Error("Got:", read, "Expected:", test.expected, "Sort:", test.cabbyRange.Sort)
+		}
+	}
+
+	// the default order is the date added, then id, then modified
+	results, err := ds.ObjectService().Objects(context.Background(), c.ID.String(), &cabby.Range{First: -1, Last: -1}, cabby.Filter{})
+	if err != nil || len(results) != 4 {
+		t.Fatal("Got:", results, err, "Expected 4 objects")
+	}
+	for i := 1; i < len(results); i++ {
+		if (cabby.Sort{}).Less(objectSortKey(results[i]), objectSortKey(results[i-1])) {
+			t.Error("Got:", results[i-1], results[i], "Expected them in the default order")
+		}
+	}
+}
+
+// an object's first and last versions follow the versions in its collection as they're added and deleted, in any
+// order; versions of the object in other collections don't count
+func testObjectsVersionFlags(t *testing.T, ds cabby.DataStore) {
+	c := createPolicyCollection(t, ds, cabby.VersionPolicyOverwrite)
+	other := createCollection(t, ds, newID(t))
+	id := newStixID(t, "malware")
+
+	createVersions(t, ds, c.ID, id, []string{"2018-01-02T00:00:00.000Z", "2018-01-03T00:00:00.000Z"})
+	createVersions(t, ds, c.ID, id, []string{"2018-01-01T00:00:00.000Z"})
+	createVersions(t, ds, other.ID, id, []string{"2018-01-04T00:00:00.000Z"})
+
+	compareVersionFlags(t, ds, c.ID.String(), "2018-01-01T00:00:00.000Z", "2018-01-03T00:00:00.000Z")
+
+	if _, err := ds.ObjectService().DeleteObject(context.Background(), c.ID.String(), id, cabby.Filter{Versions: "last"}); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	compareVersionFlags(t, ds, c.ID.String(), "2018-01-01T00:00:00.000Z", "2018-01-02T00:00:00.000Z")
+
+	if _, err := ds.ObjectService().DeleteObject(context.Background(), c.ID.String(), id, cabby.Filter{Versions: "first"}); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	compareVersionFlags(t, ds, c.ID.String(), "2018-01-02T00:00:00.000Z", "2018-01-02T00:00:00.000Z")
+	compareVersionFlags(t, ds, other.ID.String(), "2018-01-04T00:00:00.000Z", "2018-01-04T00:00:00.000Z")
+}
+
+// every version of an expired object is purged; collections without an expiry aren't purged
+func testPurgeObjects(t *testing.T, ds cabby.DataStore) {
+	c := createExpiringCollection(t, ds)
+	current, _, _, undated := createExpiringObjects(t, ds, c.ID)
+
+	purged, err := ds.ObjectService().PurgeObjects(context.Background())
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if purged != 3 {
+		t.Error("Got:", purged, "Expected:", 3)
+	}
+
+	compareVersions(t, ds, c.ID.String(), current, []string{"2018-01-01T00:00:00.000Z", "2018-01-02T00:00:00.000Z"})
+	compareVersions(t, ds, c.ID.String(), undated, []string{"2018-01-01T00:00:00.000Z"})
+
+	seeded, _ := ds.ObjectService().Object(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{})
+	if len(seeded) != 1 {
+		t.Error("Got:", len(seeded), "Expected:", 1)
+	}
+
+	purged, _ = ds.ObjectService().PurgeObjects(context.Background())
+	if purged != 0 {
+		t.Error("Got:", purged, "Expected:", 0)
+	}
+}
+
+// like objects, tombstones are compared to added after at millisecond precision; one that can't be parsed matches nothing
+func testTombstonesAddedAfter(t *testing.T, ds cabby.DataStore) {
+	c := createCollection(t, ds, newID(t))
+	id := newStixID(t, "malware")
+	createVersions(t, ds, c.ID, id, []string{"2018-01-01T00:00:00.000Z"})
+
+	before := time.Now().UTC().Add(-time.Minute)
+	if _, err := ds.ObjectService().DeleteObject(context.Background(), c.ID.String(), id, cabby.Filter{}); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	after := time.Now().UTC().Add(time.Minute)
+
+	tests := []struct {
+		addedAfter string
+		expected   int
+	}{
+		{"", 1},
+		{before.Format(time.RFC3339Nano), 1},
+		{after.Format(time.RFC3339Nano), 0},
+		{"foo", 0},
+	}
+
+	for _, test := range tests {
+		results, err := ds.ObjectService().Tombstones(context.Background(), c.ID.String(), cabby.Filter{AddedAfter: test.addedAfter})
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Added after:", test.addedAfter)
+		}
+		if len(results) != test.expected {
+			t.Error("Got:", len(results), "Expected:", test.expected, "Added after:", test.addedAfter)
+		}
+	}
+}
+
+// versions are sorted as times, whatever precision they were written with
+func testVersions(t *testing.T, ds cabby.DataStore) {
+	c := createCollection(t, ds, newID(t))
+	id := newStixID(t, "malware")
+	versions := []string{"2018-01-01T00:00:00.000Z", "2018-01-01T12:00:00Z", "2018-01-02T00:00:00.000Z"}
+	createVersions(t, ds, c.ID, id, versions)
+
+	tests := []struct {
+		objectID   string
+		addedAfter string
+		expected   []string
+	}{
+		{id, "", versions},
+		{id, time.Now().UTC().Add(-time.Minute).Format(time.RFC3339Nano), versions},
+		{id, time.Now().UTC().Add(time.Minute).Format(time.RFC3339Nano), []string{}},
+		{newStixID(t, "malware"), "", []string{}},
+	}
+
+	for _, test := range tests {
+		cr := allItems()
+		results, err := ds.ObjectService().Versions(context.Background(), c.ID.String(), test.objectID, cr, cabby.Filter{AddedAfter: test.addedAfter})
+		if err != nil {
+			t.Fatal("Got:", err, "Expected no error")
+		}
+
+		if len(results) != len(test.expected) {
+			t.Error("Got:", results, "Expected:", test.expected)
+			continue
+		}
+		for i := range results {
+			if normalTime(results[i]) != normalTime(test.expected[i]) {
+				t.Error("Got:", results[i], "Expected:", test.expected[i])
+			}
+		}
+		if cr.Total != int64(len(test.expected)) {
+			t.Error("Got:", cr.Total, "Expected:", len(test.expected))
+		}
+	}
+}
+
+func testVersionsRange(t *testing.T, ds cabby.DataStore) {
+	c := createCollection(t, ds, newID(t))
+	id := newStixID(t, "malware")
+	versions := []string{"2018-01-01T00:00:00.000Z", "2018-01-02T00:00:00.000Z", "2018-01-03T00:00:00.000Z"}
+	createVersions(t, ds, c.ID, id, versions)
+
+	tests := []struct {
+		first    int64
+		last     int64
+		expected []string
+	}{
+		{0, 0, versions[:1]},
+		{1, 2, versions[1:]},
+		{2, 5, versions[2:]},
+	}
+
+	for _, test := range tests {
+		cr := cabby.Range{First: test.first, Last: test.last}
+		results, err := ds.ObjectService().Versions(context.Background(), c.ID.String(), id, &cr, cabby.Filter{})
+		if err != nil {
+			t.Fatal("Got:", err, "Expected no error")
+		}
+
+		if len(results) != len(test.expected) {
+			t.Error("Got:", results, "Expected:", test.expected)
+			continue
+		}
+		for i := range results {
+			if normalTime(results[i]) != normalTime(test.expected[i]) {
+				t.Error("Got:", results[i], "Expected:", test.expected[i])
+			}
+		}
+		if cr.Total != int64(len(versions)) {
+			t.Error("Got:", cr.Total, "Expected:", len(versions))
+		}
+	}
+}
+
+/* helpers */
+
+// compareVersions checks the versions of an object in a collection, in any order
+func compareVersions(t *testing.T, ds cabby.DataStore, collectionID, id string, expected []string) {
+	t.Helper()
+
+	results, err := ds.ObjectService().Object(context.Background(), collectionID, id, cabby.Filter{Versions: "all"})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	found := map[string]bool{}
+	for _, o := range results {
+		found[normalTime(o.Modified)] = true
+	}
+
+	if len(results) != len(expected) {
+		t.Error("Got:", len(results), "Expected:", len(expected))
+	}
+	for _, version := range expected {
+		if !found[normalTime(version)] {
+			t.Error("Got:", found, "Expected version:", version)
+		}
+	}
+}
+
+// compareVersionFlags checks the version of the only object in a collection that's read as its first and last version
+func compareVersionFlags(t *testing.T, ds cabby.DataStore, collectionID, first, last string) {
+	t.Helper()
+
+	for version, expected := range map[string]string{"first": first, "last": last} {
+		objects, err := ds.ObjectService().Objects(context.Background(), collectionID, &cabby.Range{}, cabby.Filter{Versions: version})
+		if err != nil || len(objects) != 1 {
+			t.Error("Got:", objects, err, "Expected one", version, "version")
+			continue
+		}
+		if !sameTime(objects[0].Modified, expected) {
+			t.Error("Got:", objects[0].Modified, "Expected:", expected, "Version:", version)
+		}
+	}
+}
+
+// createBundle posts objects to a collection and returns the status when it's done
+func createBundle(t *testing.T, ds cabby.DataStore, collectionID string, objects [][]byte) cabby.Status {
+	ss := ds.StatusService()
+
+	st, _ := cabby.NewStatus(len(objects))
+	if err := ss.CreateStatus(context.Background(), st); err != nil {
+		t.Fatal(err)
+	}
+
+	ds.ObjectService().CreateBundle(context.Background(), toChannel(objects), collectionID, st, ss)
+
+	result, err := ss.Status(context.Background(), st.ID.String())
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	return result
+}
+
+func createObjectID(t *testing.T, ds cabby.DataStore, id string) {
+	o := tester.Object
+	o.ID = stones.ID(id)
+	o.Object = malware(id, o.Modified)
+	createObject(t, ds, o)
+}
+
+func createObjectVersion(t *testing.T, ds cabby.DataStore, id, version string) {
+	o := tester.Object
+	o.ID = stones.ID(id)
+	o.Modified = version
+	o.Object = malware(id, version)
+	createObject(t, ds, o)
+}
+
+// firstAndLast returns the earliest and latest of the times
+func firstAndLast(times []time.Time) (first, last time.Time) {
+	for _, ts := range times {
+		if first.IsZero() || ts.Before(first) {
+			first = ts
+		}
+		if ts.After(last) {
+			last = ts
+		}
+	}
+	return
+}
+
+// createExpiringCollection creates a collection that expires objects by their valid_until
+func createExpiringCollection(t *testing.T, ds cabby.DataStore) cabby.Collection {
+	c := tester.Collection
+	c.ID, c.ExpireBy = newID(t), cabby.ExpireByValidUntil
+	return createUserCollection(t, ds, c)
+}
+
+// createExpiringObjects creates an object whose latest version is still valid, one that's expired, one whose latest
+// version is expired though an older version isn't, and one without a valid_until
+func createExpiringObjects(t *testing.T, ds cabby.DataStore, collectionID cabby.ID) (current, expired, revoked, undated string) {
+	past := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	future := time.Now().UTC().Add(time.Hour).Format(time.RFC3339)
+
+	current, expired, revoked = newStixID(t, "indicator"), newStixID(t, "indicator"), newStixID(t, "indicator")
+	undated = newStixID(t, "indicator")
+
+	objects := []struct {
+		id, modified, validUntil string
+	}{
+		{current, "2018-01-01T00:00:00.000Z", past},
+		{current, "2018-01-02T00:00:00.000Z", future},
+		{expired, "2018-01-01T00:00:00.000Z", past},
+		{revoked, "2018-01-01T00:00:00.000Z", future},
+		{revoked, "2018-01-02T00:00:00.000Z", past},
+		{undated, "2018-01-01T00:00:00.000Z", ""},
+	}
+
+	for _, object := range objects {
+		o := tester.Object
+		o.ID, o.Type, o.Modified, o.CollectionID = stones.ID(object.id), "indicator", object.modified, collectionID
+		o.Object = indicator(object.id, object.modified, object.validUntil)
+		createObject(t, ds, o)
+	}
+	return
+}
+
+// createVersions creates a version of a malware object in a collection for each modified time
+func createVersions(t *testing.T, ds cabby.DataStore, collectionID cabby.ID, id string, versions []string) {
+	for _, version := range versions {
+		o := tester.Object
+		o.ID, o.Modified, o.Object, o.CollectionID = stones.ID(id), version, malware(id, version), collectionID
+		createObject(t, ds, o)
+	}
+}
+
+// createSortObjects creates objects in a new collection whose ids, sorted, are modified in the reverse order; the
+// second id has a later version too
+func createSortObjects(t *testing.T, ds cabby.DataStore) (cabby.Collection, []string) {
+	c := createCollection(t, ds, newID(t))
+
+	ids := []string{newStixID(t, "malware"), newStixID(t, "malware"), newStixID(t, "malware")}
+	sort.Strings(ids)
+
+	createVersions(t, ds, c.ID, ids[1], []string{"2018-01-02T00:00:00.000Z"})
+	createVersions(t, ds, c.ID, ids[2], []string{"2018-01-01T00:00:00.000Z"})
+	createVersions(t, ds, c.ID, ids[0], []string{"2018-01-03T00:00:00.000Z"})
+	createVersions(t, ds, c.ID, ids[1], []string{"2018-01-04T00:00:00.000Z"})
+	return c, ids
+}
+
+func indicator(id, modified, validUntil string) []byte {
+	if validUntil == "" {
+		return []byte(fmt.Sprintf(`{"type": "indicator", "id": "%s", "modified": "%s"}`, id, modified))
+	}
+	return []byte(fmt.Sprintf(
+		`{"type": "indicator", "id": "%s", "modified": "%s", "valid_until": "%s"}`, id, modified, validUntil))
+}
+
+func malware(id, modified string) []byte {
+	return []byte(fmt.Sprintf(
+		`{"type": "malware", "id": "%s", "created": "2016-04-06T20:07:09.000Z", "modified": "%s", "name": "Poison Ivy"}`,
+		id, modified))
+}
+
+func newStixID(t *testing.T, objectType string) string {
+	id, err := stones.NewStixID(objectType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id.String()
+}
+
+func objectSortKey(o cabby.Object) cabby.SortKey {
+	return cabby.SortKey{DateAdded: o.DateAdded, ID: string(o.ID), Modified: o.Modified}
+}
+
+// normalTime formats a timestamp the same way however a data store wrote it
+func normalTime(s string) string {
+	ts, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return s
+	}
+	return ts.UTC().Format(time.RFC3339Nano)
+}
+
+func sameTime(a, b string) bool {
+	return normalTime(a) == normalTime(b)
+}
+
+// sameMillisecond compares times at the precision data stores keep
+func sameMillisecond(a, b time.Time) bool {
+	return a.Truncate(time.Millisecond).Equal(b.Truncate(time.Millisecond))
+}
+
+func toChannel(objects [][]byte) <-chan []byte {
+	c := make(chan []byte, len(objects))
+	for _, o := range objects {
+		c <- o
+	}
+	close(c)
+	return c
+}
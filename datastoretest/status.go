@@ -0,0 +1,274 @@
+package datastoretest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+var statusTests = []test{
+	{"CancelStatus", testCancelStatus},
+	{"PurgeStatuses", testPurgeStatuses},
+	{"Status", testStatus},
+	{"StatusMissing", testStatusMissing},
+	{"Statuses", testStatuses},
+	{"StatusesRange", testStatusesRange},
+	{"StatusesSameTime", testStatusesSameTime},
+	{"UpdateStatus", testUpdateStatus},
+}
+
+// a canceled status stays canceled; only pending statuses can be canceled
+func testCancelStatus(t *testing.T, ds cabby.DataStore) {
+	s := ds.StatusService()
+	st := createStatus(t, ds, tester.UserEmail, tester.CollectionID)
+
+	if err := s.CancelStatus(context.Background(), st.ID.String()); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	st.FailureCount = st.TotalCount
+	if err := s.UpdateStatus(context.Background(), st); err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	result, _ := s.Status(context.Background(), st.ID.String())
+	if result.Status != "canceled" {
+		t.Error("Got:", result.Status, "Expected:", "canceled")
+	}
+
+	if err := s.CancelStatus(context.Background(), st.ID.String()); err == nil {
+		t.Error("Expected an error canceling twice")
+	}
+	if err := s.CancelStatus(context.Background(), newID(t).String()); err == nil {
+		t.Error("Expected an error canceling a missing status")
+	}
+}
+
+// pending statuses aren't purged
+func testPurgeStatuses(t *testing.T, ds cabby.DataStore) {
+	s := ds.StatusService()
+
+	pending := createStatus(t, ds, tester.UserEmail, tester.CollectionID)
+	complete := createStatus(t, ds, tester.UserEmail, tester.CollectionID)
+	complete.SuccessCount = complete.TotalCount
+	if err := s.UpdateStatus(context.Background(), complete); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := s.PurgeStatuses(context.Background(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if purged != 1 {
+		t.Error("Got:", purged, "Expected:", 1)
+	}
+
+	result, _ := s.Status(context.Background(), complete.ID.String())
+	if !result.ID.IsEmpty() {
+		t.Error("Got:", result, "Expected no status")
+	}
+	result, _ = s.Status(context.Background(), pending.ID.String())
+	if result.ID.String() != pending.ID.String() {
+		t.Error("Got:", result, "Expected:", pending)
+	}
+}
+
+func testStatus(t *testing.T, ds cabby.DataStore) {
+	expected := createStatus(t, ds, tester.UserEmail, tester.CollectionID)
+
+	result, err := ds.StatusService().Status(context.Background(), expected.ID.String())
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	compareStatus(t, result, expected)
+	if result.CreatedAt == "" {
+		t.Error("Expected a created time")
+	}
+}
+
+func testStatusMissing(t *testing.T, ds cabby.DataStore) {
+	result, err := ds.StatusService().Status(context.Background(), newID(t).String())
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+	if !result.ID.IsEmpty() {
+		t.Error("Got:", result, "Expected no status")
+	}
+}
+
+// statuses are listed newest first
+func testStatuses(t *testing.T, ds cabby.DataStore) {
+	other := newID(t).String()
+
+	statuses := []struct {
+		user         string
+		collectionID string
+		complete     bool
+	}{
+		{tester.UserEmail, tester.CollectionID, false},
+		{tester.UserEmail, tester.CollectionID, true},
+		{tester.UserEmail, other, true},
+		{"other@cabby.com", other, true},
+	}
+
+	created := []cabby.Status{}
+	for _, st := range statuses {
+		s := createStatus(t, ds, st.user, st.collectionID)
+		if st.complete {
+			s.SuccessCount = s.TotalCount
+			if err := ds.StatusService().UpdateStatus(context.Background(), s); err != nil {
+				t.Fatal(err)
+			}
+		}
+		created = append(created, s)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	hourAgo := time.Now().Add(-time.Hour).Format(time.RFC3339Nano)
+	hourFromNow := time.Now().Add(time.Hour).Format(time.RFC3339Nano)
+
+	tests := []struct {
+		filter   cabby.StatusFilter
+		expected int
+	}{
+		{cabby.StatusFilter{}, 4},
+		{cabby.StatusFilter{User: tester.UserEmail}, 3},
+		{cabby.StatusFilter{CollectionID: other}, 2},
+		{cabby.StatusFilter{Status: "complete"}, 3},
+		{cabby.StatusFilter{User: tester.UserEmail, CollectionID: tester.CollectionID, Status: "pending"}, 1},
+		{cabby.StatusFilter{CreatedAfter: hourAgo}, 4},
+		{cabby.StatusFilter{CreatedAfter: hourFromNow}, 0},
+		{cabby.StatusFilter{CreatedBefore: hourAgo}, 0},
+		{cabby.StatusFilter{CreatedBefore: hourFromNow}, 4},
+	}
+
+	for _, test := range tests {
+		results, err := ds.StatusService().Statuses(context.Background(), allItems(), test.filter)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Filter:", test.filter)
+		}
+
+		if len(results) != test.expected {
+			t.Error("Got:", len(results), "Expected:", test.expected, "Filter:", test.filter)
+		}
+	}
+
+	results, _ := ds.StatusService().Statuses(context.Background(), allItems(), cabby.StatusFilter{})
+	for i, st := range results {
+		expected := created[len(created)-1-i]
+		if st.ID.String() != expected.ID.String() {
+			t.Error("Got:", st.ID.String(), "Expected:", expected.ID.String(), "Index:", i)
+		}
+	}
+}
+
+func testStatusesRange(t *testing.T, ds cabby.DataStore) {
+	for i := 0; i < 5; i++ {
+		createStatus(t, ds, tester.UserEmail, tester.CollectionID)
+	}
+
+	cr := cabby.Range{First: 0, Last: 1}
+	results, err := ds.StatusService().Statuses(context.Background(), &cr, cabby.StatusFilter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	if len(results) != 2 {
+		t.Error("Got:", len(results), "Expected:", 2)
+	}
+	if cr.Total != 5 {
+		t.Error("Got:", cr.Total, "Expected:", 5)
+	}
+}
+
+// statuses created in the same millisecond are listed newest first too, so pages of them don't overlap
+func testStatusesSameTime(t *testing.T, ds cabby.DataStore) {
+	created := []cabby.Status{}
+	for i := 0; i < 10; i++ {
+		created = append(created, createStatus(t, ds, tester.UserEmail, tester.CollectionID))
+	}
+
+	results, err := ds.StatusService().Statuses(context.Background(), allItems(), cabby.StatusFilter{})
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	if len(results) != len(created) {
+		t.Fatal("Got:", len(results), "Expected:", len(created))
+	}
+	for i, st := range results {
+		expected := created[len(created)-1-i]
+		if st.ID.String() != expected.ID.String() {
+			t.Error("Got:", st.ID.String(), "Expected:", expected.ID.String(), "Index:", i)
+		}
+	}
+}
+
+// a status is complete when nothing is pending
+func testUpdateStatus(t *testing.T, ds cabby.DataStore) {
+	s := ds.StatusService()
+	expected := createStatus(t, ds, tester.UserEmail, tester.CollectionID)
+
+	expected.FailureCount = 1
+	if err := s.UpdateStatus(context.Background(), expected); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	expected.PendingCount = 2
+	result, _ := s.Status(context.Background(), expected.ID.String())
+	compareStatus(t, result, expected)
+
+	expected.SuccessCount = 2
+	if err := s.UpdateStatus(context.Background(), expected); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	expected.PendingCount = 0
+	expected.Status = "complete"
+	result, _ = s.Status(context.Background(), expected.ID.String())
+	compareStatus(t, result, expected)
+}
+
+/* helpers */
+
+// createStatus creates a pending status for three objects
+func createStatus(t *testing.T, ds cabby.DataStore, user, collectionID string) cabby.Status {
+	st, err := cabby.NewStatus(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.User = user
+	st.CollectionID = collectionID
+
+	if err := ds.StatusService().CreateStatus(context.Background(), st); err != nil {
+		t.Fatal("Can't create status: ", err)
+	}
+	return st
+}
+
+func compareStatus(t *testing.T, result, expected cabby.Status) {
+	t.Helper()
+
+	if result.ID.String() != expected.ID.String() {
+		t.Error("Got:", result.ID.String(), "Expected:", expected.ID.String())
+	}
+	if result.Status != expected.Status {
+		t.Error("Got:", result.Status, "Expected:", expected.Status)
+	}
+	if result.TotalCount != expected.TotalCount {
+		t.Error("Got:", result.TotalCount, "Expected:", expected.TotalCount)
+	}
+	if result.SuccessCount != expected.SuccessCount {
+		t.Error("Got:", result.SuccessCount, "Expected:", expected.SuccessCount)
+	}
+	if result.FailureCount != expected.FailureCount {
+		t.Error("Got:", result.FailureCount, "Expected:", expected.FailureCount)
+	}
+	if result.PendingCount != expected.PendingCount {
+		t.Error("Got:", result.PendingCount, "Expected:", expected.PendingCount)
+	}
+}
@@ -0,0 +1,184 @@
+package datastoretest
+
+import (
+	"context"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+var userTests = []test{
+	{"CreateUserCollectionDuplicate", testCreateUserCollectionDuplicate},
+	{"CreateUserCollectionNoUser", testCreateUserCollectionNoUser},
+	{"CreateUserDuplicate", testCreateUserDuplicate},
+	{"CreateUserInvalid", testCreateUserInvalid},
+	{"DeleteUser", testDeleteUser},
+	{"DeleteUserCollection", testDeleteUserCollection},
+	{"UpdateUser", testUpdateUser},
+	{"UpdateUserCollection", testUpdateUserCollection},
+	{"User", testUser},
+	{"UserCollections", testUserCollections},
+}
+
+// creating a user collection that exists doesn't change it
+func testCreateUserCollectionDuplicate(t *testing.T, ds cabby.DataStore) {
+	s := ds.UserService()
+
+	ca := cabby.CollectionAccess{ID: tester.Collection.ID}
+	if err := s.CreateUserCollection(context.Background(), tester.UserEmail, ca); err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	result, _ := s.UserCollections(context.Background(), tester.UserEmail)
+	compareCollectionAccess(t, result, cabby.CollectionAccess{ID: tester.Collection.ID, CanRead: true, CanWrite: true})
+}
+
+func testCreateUserCollectionNoUser(t *testing.T, ds cabby.DataStore) {
+	ca := cabby.CollectionAccess{ID: tester.Collection.ID, CanRead: true}
+	err := ds.UserService().CreateUserCollection(context.Background(), "missing@cabby.com", ca)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func testCreateUserDuplicate(t *testing.T, ds cabby.DataStore) {
+	err := ds.UserService().CreateUser(context.Background(), tester.User, tester.UserPassword)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func testCreateUserInvalid(t *testing.T, ds cabby.DataStore) {
+	s := ds.UserService()
+
+	tests := []struct {
+		user     cabby.User
+		password string
+	}{
+		{cabby.User{}, tester.UserPassword},
+		{cabby.User{Email: "invalid"}, tester.UserPassword},
+		{cabby.User{Email: "other@cabby.com"}, ""},
+	}
+
+	for _, test := range tests {
+		if err := s.CreateUser(context.Background(), test.user, test.password); err == nil {
+			t.Error("Expected an error for:", test.user)
+		}
+	}
+}
+
+// deleting a user deletes its collection access
+func testDeleteUser(t *testing.T, ds cabby.DataStore) {
+	s := ds.UserService()
+
+	if err := s.DeleteUser(context.Background(), tester.UserEmail); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	result, _ := s.User(context.Background(), tester.UserEmail, tester.UserPassword)
+	if result.Email != "" {
+		t.Error("Got:", result, "Expected no user")
+	}
+
+	collections, _ := s.UserCollections(context.Background(), tester.UserEmail)
+	if len(collections.CollectionAccessList) != 0 {
+		t.Error("Got:", collections.CollectionAccessList, "Expected no collections")
+	}
+}
+
+func testDeleteUserCollection(t *testing.T, ds cabby.DataStore) {
+	s := ds.UserService()
+
+	if err := s.DeleteUserCollection(context.Background(), tester.UserEmail, tester.CollectionID); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	result, _ := s.UserCollections(context.Background(), tester.UserEmail)
+	if _, ok := result.CollectionAccessList[tester.Collection.ID]; ok {
+		t.Error("Got:", result.CollectionAccessList, "Expected no access to:", tester.CollectionID)
+	}
+}
+
+func testUpdateUser(t *testing.T, ds cabby.DataStore) {
+	s := ds.UserService()
+
+	u := tester.User
+	u.CanAdmin = false
+	if err := s.UpdateUser(context.Background(), u); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	result, _ := s.User(context.Background(), tester.UserEmail, tester.UserPassword)
+	if result.CanAdmin {
+		t.Error("Got:", result.CanAdmin, "Expected:", false)
+	}
+}
+
+// updating access to one collection leaves the user's other collections alone
+func testUpdateUserCollection(t *testing.T, ds cabby.DataStore) {
+	s := ds.UserService()
+
+	id := newID(t)
+	if err := s.CreateUserCollection(context.Background(), tester.UserEmail, cabby.CollectionAccess{ID: id}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := cabby.CollectionAccess{ID: id, CanRead: true}
+	if err := s.UpdateUserCollection(context.Background(), tester.UserEmail, expected); err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	result, _ := s.UserCollections(context.Background(), tester.UserEmail)
+	compareCollectionAccess(t, result, expected)
+	compareCollectionAccess(t, result, cabby.CollectionAccess{ID: tester.Collection.ID, CanRead: true, CanWrite: true})
+}
+
+// a user with the wrong password isn't returned
+func testUser(t *testing.T, ds cabby.DataStore) {
+	s := ds.UserService()
+
+	result, err := s.User(context.Background(), tester.UserEmail, tester.UserPassword)
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+	if result.Email != tester.UserEmail || result.CanAdmin != tester.User.CanAdmin {
+		t.Error("Got:", result, "Expected:", tester.User)
+	}
+
+	result, err = s.User(context.Background(), tester.UserEmail, "wrong-password")
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+	if result.Email != "" {
+		t.Error("Got:", result, "Expected no user")
+	}
+}
+
+func testUserCollections(t *testing.T, ds cabby.DataStore) {
+	result, err := ds.UserService().UserCollections(context.Background(), tester.UserEmail)
+	if err != nil {
+		t.Fatal("Got:", err, "Expected no error")
+	}
+
+	if result.Email != tester.UserEmail {
+		t.Error("Got:", result.Email, "Expected:", tester.UserEmail)
+	}
+	if len(result.CollectionAccessList) != 1 {
+		t.Error("Got:", result.CollectionAccessList, "Expected:", tester.UserCollectionList.CollectionAccessList)
+	}
+	compareCollectionAccess(t, result, tester.UserCollectionList.CollectionAccessList[tester.Collection.ID])
+}
+
+func compareCollectionAccess(t *testing.T, ucl cabby.UserCollectionList, expected cabby.CollectionAccess) {
+	t.Helper()
+
+	result, ok := ucl.CollectionAccessList[expected.ID]
+	if !ok {
+		t.Error("Got:", ucl.CollectionAccessList, "Expected access to:", expected.ID.String())
+		return
+	}
+	if result.CanRead != expected.CanRead || result.CanWrite != expected.CanWrite {
+		t.Error("Got:", result, "Expected:", expected)
+	}
+}
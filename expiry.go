@@ -0,0 +1,50 @@
+package cabby
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Expiries decide what an object's age is measured from; a collection without an expiry keeps objects until they're
+// deleted
+const (
+	ExpireByAdded      = "added"
+	ExpireByValidUntil = "valid_until"
+)
+
+// ValidExpiry returns whether a collection can use the expiry
+func ValidExpiry(expireBy string) bool {
+	switch expireBy {
+	case "", ExpireByAdded, ExpireByValidUntil:
+		return true
+	}
+	return false
+}
+
+// Expired returns whether an object has expired in the collection given its latest version.  Objects expire by the
+// date the latest version was added plus the max age, or by its valid_until plus the max age; an object without a
+// valid_until doesn't expire by it.
+func (c Collection) Expired(latest Object, now time.Time) bool {
+	switch c.ExpireBy {
+	case ExpireByAdded:
+		return c.MaxAge > 0 && latest.DateAdded.Add(c.MaxAge).Before(now)
+	case ExpireByValidUntil:
+		validUntil, ok := objectValidUntil(latest.Object)
+		return ok && validUntil.Add(c.MaxAge).Before(now)
+	}
+	return false
+}
+
+// objectValidUntil returns an object's valid_until and whether it has one that parses
+func objectValidUntil(object []byte) (time.Time, bool) {
+	var o struct {
+		ValidUntil string `json:"valid_until"`
+	}
+
+	if err := json.Unmarshal(object, &o); err != nil || o.ValidUntil == "" {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, o.ValidUntil)
+	return t, err == nil
+}
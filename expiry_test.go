@@ -0,0 +1,58 @@
+package cabby
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidExpiry(t *testing.T) {
+	tests := []struct {
+		expireBy string
+		expected bool
+	}{
+		{"", true},
+		{ExpireByAdded, true},
+		{ExpireByValidUntil, true},
+		{"modified", false},
+	}
+
+	for _, test := range tests {
+		result := ValidExpiry(test.expireBy)
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "Expire by:", test.expireBy)
+		}
+	}
+}
+
+func TestCollectionExpired(t *testing.T) {
+	now := time.Date(2018, 4, 6, 20, 3, 48, 0, time.UTC)
+
+	withValidUntil := func(validUntil string) Object {
+		return Object{
+			DateAdded: now.Add(-time.Hour),
+			Object:    []byte(`{"type": "indicator", "valid_until": "` + validUntil + `"}`)}
+	}
+	added := Object{DateAdded: now.Add(-48 * time.Hour), Object: []byte(`{"type": "indicator"}`)}
+
+	tests := []struct {
+		collection Collection
+		latest     Object
+		expected   bool
+	}{
+		{Collection{}, added, false},
+		{Collection{ExpireBy: ExpireByAdded, MaxAge: 24 * time.Hour}, added, true},
+		{Collection{ExpireBy: ExpireByAdded, MaxAge: 72 * time.Hour}, added, false},
+		{Collection{ExpireBy: ExpireByValidUntil}, withValidUntil("2018-04-06T19:03:48.000Z"), true},
+		{Collection{ExpireBy: ExpireByValidUntil}, withValidUntil("2018-04-06T21:03:48Z"), false},
+		{Collection{ExpireBy: ExpireByValidUntil, MaxAge: 2 * time.Hour}, withValidUntil("2018-04-06T19:03:48Z"), false},
+		{Collection{ExpireBy: ExpireByValidUntil}, withValidUntil("not a time"), false},
+		{Collection{ExpireBy: ExpireByValidUntil}, added, false},
+	}
+
+	for i, test := range tests {
+		result := test.collection.Expired(test.latest, now)
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "Test:", i)
+		}
+	}
+}
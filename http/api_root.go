@@ -29,10 +29,15 @@ func (h APIRootHandler) Get(w http.ResponseWriter, r *http.Request) {
 	if apiRoot.Title == "" {
 		resourceNotFound(w, fmt.Errorf("API Root not found"))
 	} else {
-		writeContent(w, cabby.TaxiiContentType, resourceToJSON(apiRoot))
+		writeContentIfModified(w, r, cabby.TaxiiContentType, resourceToJSON(apiRoot), apiRoot.UpdatedAt)
 	}
 }
 
+// Delete handles a delete request
+func (h APIRootHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
+}
+
 // Post handles post request
 func (h APIRootHandler) Post(w http.ResponseWriter, r *http.Request) {
 	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
@@ -89,6 +89,20 @@ func TestAPIRootHandlerGetNoAPIRoot(t *testing.T) {
 	}
 }
 
+func TestAPIRootHandleDelete(t *testing.T) {
+	as := mockAPIRootService()
+	as.APIRootFn = func(ctx context.Context, path string) (cabby.APIRoot, error) {
+		return cabby.APIRoot{Title: ""}, nil
+	}
+
+	h := APIRootHandler{APIRootService: &as}
+	status, _ := handlerTest(h.Delete, "DELETE", testAPIRootURL, nil)
+
+	if status != http.StatusMethodNotAllowed {
+		t.Error("Got:", status, "Expected:", http.StatusMethodNotAllowed)
+	}
+}
+
 func TestAPIRootHandlePost(t *testing.T) {
 	as := mockAPIRootService()
 	as.APIRootFn = func(ctx context.Context, path string) (cabby.APIRoot, error) {
@@ -0,0 +1,187 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pladdy/stones"
+)
+
+// envelopeKeys are the keys a bundle has besides its objects
+var envelopeKeys = []string{"id", "spec_version", "type"}
+
+// bundleReader decodes a bundle from a stream, one object at a time, so a large bundle is never held in memory.
+// The envelope (type, id, spec_version) is validated before any objects are read when it comes before them; keys
+// after the objects are validated once the objects have been read.
+type bundleReader struct {
+	decoder   *json.Decoder
+	envelope  map[string]json.RawMessage
+	first     json.RawMessage
+	sample    json.RawMessage
+	validated bool
+}
+
+func newBundleReader(r io.Reader) *bundleReader {
+	return &bundleReader{decoder: json.NewDecoder(r), envelope: map[string]json.RawMessage{}}
+}
+
+// readEnvelope reads up to the first object in the bundle and validates the bundle if its envelope has been read
+func (b *bundleReader) readEnvelope() error {
+	if err := b.expectDelim('{'); err != nil {
+		return err
+	}
+
+	for b.decoder.More() {
+		key, err := b.readKey()
+		if err != nil {
+			return err
+		}
+
+		if key == "objects" {
+			return b.readFirstObject()
+		}
+
+		var value json.RawMessage
+		if err := b.decoder.Decode(&value); err != nil {
+			return b.decodeError(err)
+		}
+		b.envelope[key] = value
+	}
+
+	return errors.New("Invalid bundle: no objects")
+}
+
+// next returns the next object in the bundle; io.EOF is returned once the bundle has been read and its envelope is
+// valid
+func (b *bundleReader) next() ([]byte, error) {
+	if b.first != nil {
+		object := b.first
+		b.first = nil
+		return object, nil
+	}
+
+	if b.decoder.More() {
+		var object json.RawMessage
+		if err := b.decoder.Decode(&object); err != nil {
+			return nil, b.decodeError(err)
+		}
+		return object, nil
+	}
+
+	if err := b.expectDelim(']'); err != nil {
+		return nil, err
+	}
+
+	// keys after the objects are part of the envelope too
+	for b.decoder.More() {
+		key, err := b.readKey()
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+		if err := b.decoder.Decode(&value); err != nil {
+			return nil, b.decodeError(err)
+		}
+		b.envelope[key] = value
+		b.validated = false
+	}
+
+	if err := b.expectDelim('}'); err != nil {
+		return nil, err
+	}
+
+	if !b.validated {
+		if err := b.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, io.EOF
+}
+
+func (b *bundleReader) readFirstObject() error {
+	if err := b.expectDelim('['); err != nil {
+		return err
+	}
+
+	if !b.decoder.More() {
+		return errors.New("Invalid bundle: no objects")
+	}
+
+	if err := b.decoder.Decode(&b.first); err != nil {
+		return b.decodeError(err)
+	}
+	b.sample = b.first
+
+	// an envelope that isn't all before the objects is validated once the rest of it is read
+	for _, key := range envelopeKeys {
+		if _, ok := b.envelope[key]; !ok {
+			return nil
+		}
+	}
+	return b.validate()
+}
+
+// validate validates the envelope with the first object; the rest of the objects are validated as they're written
+func (b *bundleReader) validate() error {
+	raw, err := json.Marshal(b.envelope)
+	if err != nil {
+		return err
+	}
+
+	var bundle stones.Bundle
+	if err := json.Unmarshal(raw, &bundle); err != nil {
+		return fmt.Errorf("Unable to convert json to bundle, error: %v", err)
+	}
+	bundle.Objects = []json.RawMessage{b.sample}
+
+	valid, errs := bundle.Validate()
+	if !valid {
+		errString := "Invalid bundle:"
+		for _, e := range errs {
+			errString = errString + fmt.Sprintf("\nValidation Error: %v", e)
+		}
+		return fmt.Errorf(errString)
+	}
+
+	b.validated = true
+	return nil
+}
+
+func (b *bundleReader) readKey() (string, error) {
+	t, err := b.decoder.Token()
+	if err != nil {
+		return "", b.decodeError(err)
+	}
+
+	key, ok := t.(string)
+	if !ok {
+		return "", fmt.Errorf("Unable to convert json to bundle, error: unexpected %v", t)
+	}
+	return key, nil
+}
+
+func (b *bundleReader) expectDelim(d json.Delim) error {
+	t, err := b.decoder.Token()
+	if err != nil {
+		return b.decodeError(err)
+	}
+
+	if t != d {
+		return fmt.Errorf("Unable to convert json to bundle, error: expected %v, got %v", d, t)
+	}
+	return nil
+}
+
+// errors from the underlying reader (like a body that's too large) are returned as is
+func (b *bundleReader) decodeError(err error) error {
+	if _, ok := err.(*json.SyntaxError); ok || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("Unable to convert json to bundle, error: %v", err)
+	}
+	if _, ok := err.(*json.UnmarshalTypeError); ok {
+		return fmt.Errorf("Unable to convert json to bundle, error: %v", err)
+	}
+	return err
+}
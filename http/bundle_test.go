@@ -0,0 +1,114 @@
+package http
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBundleReader(t *testing.T) {
+	bundle := `{"type": "bundle", "id": "bundle--5d0092c5-5f74-4287-9642-33f4c354e56d", "spec_version": "2.0",
+		"objects": [{"id": "indicator--1"}, {"id": "indicator--2"}], "foo": "bar"}`
+
+	br := newBundleReader(strings.NewReader(bundle))
+	if err := br.readEnvelope(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{`{"id": "indicator--1"}`, `{"id": "indicator--2"}`}
+
+	for _, e := range expected {
+		object, err := br.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(object) != e {
+			t.Error("Got:", string(object), "Expected:", e)
+		}
+	}
+
+	_, err := br.next()
+	if err != io.EOF {
+		t.Error("Got:", err, "Expected:", io.EOF)
+	}
+}
+
+func TestBundleReaderInvalidEnvelope(t *testing.T) {
+	tests := []struct {
+		bundle string
+	}{
+		{`{"foo": "bar"`},
+		{`{"foo": "bar"}`},
+		{`["foo"]`},
+		{`{"type": "bundle", "id": "bundle--5d0092c5-5f74-4287-9642-33f4c354e56d", "spec_version": "2.0", "objects": []}`},
+		{`{"type": "bundle", "id": "bundle--5d0092c5-5f74-4287-9642-33f4c354e56d", "spec_version": "2.0", "objects": {}}`},
+		{`{"type": "foo", "id": "bundle--5d0092c5-5f74-4287-9642-33f4c354e56d", "spec_version": "2.0", "objects": [{}]}`},
+	}
+
+	for _, test := range tests {
+		br := newBundleReader(strings.NewReader(test.bundle))
+		if err := br.readEnvelope(); err == nil {
+			t.Error("Expected error for bundle:", test.bundle)
+		}
+	}
+}
+
+func TestBundleReaderEnvelopeAfterObjects(t *testing.T) {
+	tests := []struct {
+		bundle string
+		valid  bool
+	}{
+		{`{"objects": [{"id": "indicator--1"}, {"id": "indicator--2"}], "type": "bundle",
+			"id": "bundle--5d0092c5-5f74-4287-9642-33f4c354e56d", "spec_version": "2.0"}`, true},
+		{`{"type": "bundle", "objects": [{"id": "indicator--1"}, {"id": "indicator--2"}],
+			"id": "bundle--5d0092c5-5f74-4287-9642-33f4c354e56d", "spec_version": "2.0"}`, true},
+		{`{"objects": [{"id": "indicator--1"}, {"id": "indicator--2"}], "type": "bundle",
+			"id": "bundle--5d0092c5-5f74-4287-9642-33f4c354e56d"}`, false},
+		{`{"objects": [{"id": "indicator--1"}, {"id": "indicator--2"}]}`, false},
+		// a key after the objects can invalidate an envelope that was valid before them
+		{`{"type": "bundle", "id": "bundle--5d0092c5-5f74-4287-9642-33f4c354e56d", "spec_version": "2.0",
+			"objects": [{"id": "indicator--1"}, {"id": "indicator--2"}], "type": "foo"}`, false},
+	}
+
+	for _, test := range tests {
+		br := newBundleReader(strings.NewReader(test.bundle))
+		if err := br.readEnvelope(); err != nil {
+			t.Fatal(err)
+		}
+
+		objects := 0
+		var err error
+		for {
+			if _, err = br.next(); err != nil {
+				break
+			}
+			objects++
+		}
+
+		if objects != 2 {
+			t.Error("Got:", objects, "Expected:", 2, "Bundle:", test.bundle)
+		}
+		if (err == io.EOF) != test.valid {
+			t.Error("Got:", err, "Expected valid:", test.valid, "Bundle:", test.bundle)
+		}
+	}
+}
+
+func TestBundleReaderInvalidObjects(t *testing.T) {
+	bundle := `{"type": "bundle", "id": "bundle--5d0092c5-5f74-4287-9642-33f4c354e56d", "spec_version": "2.0",
+		"objects": [{"id": "indicator--1"}, "foo": "bar"]}`
+
+	br := newBundleReader(strings.NewReader(bundle))
+	if err := br.readEnvelope(); err != nil {
+		t.Fatal(err)
+	}
+
+	var err error
+	for err == nil {
+		_, err = br.next()
+	}
+
+	if err == io.EOF {
+		t.Error("Expected a decode error, got:", err)
+	}
+}
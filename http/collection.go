@@ -28,7 +28,12 @@ func (h CollectionHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeContent(w, cabby.TaxiiContentType, resourceToJSON(collection))
+	writeContentIfModified(w, r, cabby.TaxiiContentType, resourceToJSON(collection), collection.UpdatedAt)
+}
+
+// Delete handles a delete request
+func (h CollectionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
 }
 
 // Post handles post request
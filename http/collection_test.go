@@ -88,6 +88,15 @@ func TestCollectionHandlerGetNoCollection(t *testing.T) {
 	}
 }
 
+func TestCollectionHandleDelete(t *testing.T) {
+	h := CollectionHandler{CollectionService: mockCollectionService()}
+	status, _ := handlerTest(h.Delete, "DELETE", testCollectionURL, nil)
+
+	if status != http.StatusMethodNotAllowed {
+		t.Error("Got:", status, "Expected:", http.StatusMethodNotAllowed)
+	}
+}
+
 func TestCollectionHandlePost(t *testing.T) {
 	h := CollectionHandler{CollectionService: mockCollectionService()}
 	status, _ := handlerTest(h.Post, "POST", testCollectionURL, nil)
@@ -11,6 +11,8 @@ import (
 // CollectionsHandler handles Collections requests
 type CollectionsHandler struct {
 	CollectionService cabby.CollectionService
+	DefaultPageSize   int64
+	MaxPageSize       int64
 }
 
 // Get handles a get request
@@ -28,6 +30,12 @@ func (h CollectionsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := takeSort(r, &cr, cabby.CollectionSorts); err != nil {
+		badRequest(w, err)
+		return
+	}
+	cr.Bound(h.DefaultPageSize, h.MaxPageSize)
+
 	collections, err := h.CollectionService.Collections(r.Context(), takeAPIRoot(r), &cr)
 	if err != nil {
 		internalServerError(w, err)
@@ -39,14 +47,24 @@ func (h CollectionsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	content := resourceToJSON(collections)
+	if notModified(w, r, newETag(content), collections.UpdatedAt) {
+		return
+	}
+
 	if cr.Valid() {
 		w.Header().Set("Content-Range", cr.String())
-		writePartialContent(w, cabby.TaxiiContentType, resourceToJSON(collections))
+		writePartialContent(w, cabby.TaxiiContentType, content)
 	} else {
-		writeContent(w, cabby.TaxiiContentType, resourceToJSON(collections))
+		writeContent(w, cabby.TaxiiContentType, content)
 	}
 }
 
+// Delete handles a delete request
+func (h CollectionsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
+}
+
 // Post handles post request
 func (h CollectionsHandler) Post(w http.ResponseWriter, r *http.Request) {
 	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
@@ -113,6 +113,36 @@ func TestCollectionsHandlerGetInvalidRange(t *testing.T) {
 	}
 }
 
+func TestCollectionsHandlerGetSort(t *testing.T) {
+	tests := []struct {
+		url            string
+		expectedSort   cabby.Sort
+		expectedStatus int
+	}{
+		{testCollectionsURL + "?sort=-title", cabby.Sort{Field: cabby.SortTitle, Descending: true}, http.StatusOK},
+		{testCollectionsURL + "?sort=modified", cabby.Sort{}, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		var sort cabby.Sort
+
+		cs := mockCollectionService()
+		cs.CollectionsFn = func(ctx context.Context, apiRootPath string, cr *cabby.Range) (cabby.Collections, error) {
+			sort = cr.Sort
+			return tester.Collections, nil
+		}
+		h := CollectionsHandler{CollectionService: cs}
+
+		status, _ := handlerTest(h.Get, "GET", test.url, nil)
+		if status != test.expectedStatus {
+			t.Error("Got:", status, "Expected:", test.expectedStatus, "URL:", test.url)
+		}
+		if sort != test.expectedSort {
+			t.Error("Got:", sort, "Expected:", test.expectedSort, "URL:", test.url)
+		}
+	}
+}
+
 func TestCollectionsHandlerGetFailures(t *testing.T) {
 	expected := cabby.Error{
 		Title: "Internal Server Error", Description: "Collection failure", HTTPStatus: http.StatusInternalServerError}
@@ -196,6 +226,15 @@ func TestCollectionsHandlerGetCollectionsNonExistant(t *testing.T) {
 	}
 }
 
+func TestCollectionsHandleDelete(t *testing.T) {
+	h := CollectionsHandler{CollectionService: mockCollectionService()}
+	status, _ := handlerTest(h.Delete, "DELETE", testCollectionsURL, nil)
+
+	if status != http.StatusMethodNotAllowed {
+		t.Error("Got:", status, "Expected:", http.StatusMethodNotAllowed)
+	}
+}
+
 func TestCollectionsHandlePost(t *testing.T) {
 	h := CollectionsHandler{CollectionService: mockCollectionService()}
 	status, _ := handlerTest(h.Post, "POST", testCollectionsURL, nil)
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	deflateEncoding  = "deflate"
+	gzipEncoding     = "gzip"
+	identityEncoding = "identity"
+)
+
+// compressWriter compresses a response with the encoding negotiated with the client
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	compressor  io.WriteCloser
+	wroteHeader bool
+}
+
+func (c *compressWriter) WriteHeader(status int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+
+	// a compressed representation is a different representation, so it gets its own entity tag
+	if etag := c.Header().Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		c.Header().Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+c.encoding+`"`)
+	}
+
+	// responses without a body can't be compressed
+	if status != http.StatusNoContent && status != http.StatusNotModified {
+		c.Header().Set("Content-Encoding", c.encoding)
+		c.Header().Del("Content-Length")
+		c.compressor = newCompressor(c.ResponseWriter, c.encoding)
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *compressWriter) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+
+	if c.compressor == nil {
+		return c.ResponseWriter.Write(b)
+	}
+	return c.compressor.Write(b)
+}
+
+// Close flushes anything left in the compressor to the response
+func (c *compressWriter) Close() error {
+	if c.compressor == nil {
+		return nil
+	}
+	return c.compressor.Close()
+}
+
+func newCompressor(w io.Writer, encoding string) io.WriteCloser {
+	// in HTTP 'deflate' is the zlib format
+	if encoding == deflateEncoding {
+		return zlib.NewWriter(w)
+	}
+	return gzip.NewWriter(w)
+}
+
+func contentEncoding(r *http.Request) string {
+	return strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+}
+
+// decompressedBody returns a reader of the request body decoded with the request's content encoding
+func decompressedBody(r *http.Request) (io.ReadCloser, error) {
+	encoding := contentEncoding(r)
+
+	switch encoding {
+	case "", identityEncoding:
+		return r.Body, nil
+	case gzipEncoding:
+		return gzip.NewReader(r.Body)
+	case deflateEncoding:
+		return zlib.NewReader(r.Body)
+	}
+	return nil, fmt.Errorf("Invalid 'Content-Encoding' Header: %v", encoding)
+}
+
+func verifySupportedEncoding(w http.ResponseWriter, r *http.Request) bool {
+	switch contentEncoding(r) {
+	case "", identityEncoding, gzipEncoding, deflateEncoding:
+		return true
+	}
+
+	unsupportedMediaType(w, fmt.Errorf("Invalid 'Content-Encoding' Header: %v", contentEncoding(r)))
+	return false
+}
+
+// negotiateEncoding returns the supported encoding the client prefers from an 'Accept-Encoding' header; an empty
+// string means the response shouldn't be compressed
+func negotiateEncoding(acceptEncoding string) string {
+	encoding, bestQuality := "", 0.0
+
+	// gzip is listed first so it wins a tie
+	for _, supported := range []string{gzipEncoding, deflateEncoding} {
+		quality := encodingQuality(acceptEncoding, supported)
+		if quality > bestQuality {
+			encoding, bestQuality = supported, quality
+		}
+	}
+	return encoding
+}
+
+// encodingQuality returns the 'q' value of an encoding in an 'Accept-Encoding' header; a wildcard applies to
+// encodings that aren't listed
+func encodingQuality(acceptEncoding, encoding string) float64 {
+	quality, wildcard := -1.0, 0.0
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		tokens := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(tokens[0]))
+
+		q := 1.0
+		for _, param := range tokens[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		switch name {
+		case encoding:
+			quality = q
+		case "*":
+			wildcard = q
+		}
+	}
+
+	if quality < 0 {
+		return wildcard
+	}
+	return quality
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"br", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"gzip;q=0", ""},
+		{"*", "gzip"},
+		{"*;q=0.5, deflate;q=0.8", "deflate"},
+		{"*, gzip;q=0", "deflate"},
+		{"GZIP", "gzip"},
+	}
+
+	for _, test := range tests {
+		result := negotiateEncoding(test.acceptEncoding)
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "Accept-Encoding:", test.acceptEncoding)
+		}
+	}
+}
+
+func TestDecompressedBody(t *testing.T) {
+	content := []byte(`{"foo": "bar"}`)
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write(content)
+	gw.Close()
+
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	zw.Write(content)
+	zw.Close()
+
+	tests := []struct {
+		encoding    string
+		body        []byte
+		expectError bool
+	}{
+		{"", content, false},
+		{"identity", content, false},
+		{"gzip", gzipped.Bytes(), false},
+		{"deflate", deflated.Bytes(), false},
+		{"gzip", content, true},
+		{"br", content, true},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", testObjectsURL, bytes.NewBuffer(test.body))
+		req.Header.Set("Content-Encoding", test.encoding)
+
+		body, err := decompressedBody(req)
+		if test.expectError {
+			if err == nil {
+				t.Error("Expected error for encoding:", test.encoding)
+			}
+			continue
+		}
+
+		result, err := ioutil.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(result) != string(content) {
+			t.Error("Got:", string(result), "Expected:", string(content))
+		}
+	}
+}
+
+func TestCompressWriterNoBody(t *testing.T) {
+	res := httptest.NewRecorder()
+
+	cw := &compressWriter{ResponseWriter: res, encoding: gzipEncoding}
+	cw.WriteHeader(http.StatusNotModified)
+	cw.Close()
+
+	if res.Header().Get("Content-Encoding") != "" {
+		t.Error("Got:", res.Header().Get("Content-Encoding"), "Expected no encoding")
+	}
+	if res.Body.Len() != 0 {
+		t.Error("Got:", res.Body.Len(), "Expected:", 0)
+	}
+}
+
+func TestCompressWriterETag(t *testing.T) {
+	tests := []struct {
+		etag     string
+		expected string
+	}{
+		{`"foo"`, `"foo-gzip"`},
+		{`W/"foo"`, `W/"foo"`},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		if test.etag != "" {
+			res.Header().Set("ETag", test.etag)
+		}
+
+		cw := &compressWriter{ResponseWriter: res, encoding: gzipEncoding}
+		cw.WriteHeader(http.StatusOK)
+		cw.Close()
+
+		if res.Header().Get("ETag") != test.expected {
+			t.Error("Got:", res.Header().Get("ETag"), "Expected:", test.expected)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// newETag returns a strong entity tag for the parts that identify a representation
+func newETag(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		io.WriteString(h, p)
+		h.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// streamETag returns an entity tag for a streamed resource; it can't be hashed before it's written so it's tagged
+// by what was requested and when what was read was last updated
+func streamETag(r *http.Request, cr cabby.Range, updatedAt time.Time) string {
+	return newETag(
+		r.URL.RequestURI(),
+		r.Header.Get("Range"),
+		updatedAt.UTC().Format(time.RFC3339Nano),
+		strconv.FormatInt(cr.Total, 10))
+}
+
+// notModified sets the validators of a representation and writes a 304 when the client already has it
+func notModified(w http.ResponseWriter, r *http.Request, etag string, updatedAt time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !updatedAt.IsZero() {
+		w.Header().Set("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+	}
+
+	if !requestNotModified(r, etag, updatedAt) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+func requestNotModified(r *http.Request, etag string, updatedAt time.Time) bool {
+	// If-None-Match takes precedence over If-Modified-Since
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, etag)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || updatedAt.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	// Last-Modified is only precise to the second
+	return !updatedAt.Truncate(time.Second).After(since)
+}
+
+// etagMatches compares tags the way If-None-Match does, ignoring weakness and the encoding of compressed responses
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || opaqueTag(candidate) == opaqueTag(etag) {
+			return true
+		}
+	}
+	return false
+}
+
+func opaqueTag(etag string) string {
+	etag = strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+
+	for _, encoding := range []string{gzipEncoding, deflateEncoding} {
+		etag = strings.TrimSuffix(etag, "-"+encoding)
+	}
+	return etag
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestNewETag(t *testing.T) {
+	if newETag("foo") != newETag("foo") {
+		t.Error("Expected the same parts to have the same tag")
+	}
+	if newETag("foo", "bar") == newETag("foobar") {
+		t.Error("Expected parts to be delimited")
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header   string
+		etag     string
+		expected bool
+	}{
+		{`"foo"`, `"foo"`, true},
+		{`"bar"`, `"foo"`, false},
+		{`"bar", "foo"`, `"foo"`, true},
+		{`W/"foo"`, `"foo"`, true},
+		{`"foo-gzip"`, `"foo"`, true},
+		{`"foo-deflate"`, `"foo"`, true},
+		{`*`, `"foo"`, true},
+	}
+
+	for _, test := range tests {
+		result := etagMatches(test.header, test.etag)
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "Header:", test.header)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := newETag("foo")
+	updatedAt := time.Date(2018, 10, 1, 12, 0, 0, 500000000, time.UTC)
+
+	tests := []struct {
+		ifNoneMatch     string
+		ifModifiedSince string
+		updatedAt       time.Time
+		expected        bool
+	}{
+		{"", "", updatedAt, false},
+		{etag, "", updatedAt, true},
+		{newETag("bar"), "", updatedAt, false},
+		// If-None-Match takes precedence
+		{newETag("bar"), updatedAt.Format(http.TimeFormat), updatedAt, false},
+		{"", updatedAt.Format(http.TimeFormat), updatedAt, true},
+		{"", updatedAt.Add(time.Hour).Format(http.TimeFormat), updatedAt, true},
+		{"", updatedAt.Add(-time.Hour).Format(http.TimeFormat), updatedAt, false},
+		{"", "invalid", updatedAt, false},
+		{"", updatedAt.Format(http.TimeFormat), time.Time{}, false},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", testDiscoveryURL, nil)
+		req.Header.Set("If-None-Match", test.ifNoneMatch)
+		req.Header.Set("If-Modified-Since", test.ifModifiedSince)
+
+		res := httptest.NewRecorder()
+		result := notModified(res, req, etag, test.updatedAt)
+
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "Test:", test)
+		}
+		if test.expected && res.Code != http.StatusNotModified {
+			t.Error("Got:", res.Code, "Expected:", http.StatusNotModified)
+		}
+		if res.Header().Get("ETag") != etag {
+			t.Error("Got:", res.Header().Get("ETag"), "Expected:", etag)
+		}
+		if !test.updatedAt.IsZero() && res.Header().Get("Last-Modified") != test.updatedAt.Format(http.TimeFormat) {
+			t.Error("Got:", res.Header().Get("Last-Modified"), "Expected:", test.updatedAt.Format(http.TimeFormat))
+		}
+	}
+}
+
+func TestConditionalGets(t *testing.T) {
+	updatedAt := time.Now().UTC()
+
+	ds := mockDiscoveryService()
+	discoveryFn := ds.DiscoveryFn
+	ds.DiscoveryFn = func(ctx context.Context) (cabby.Discovery, error) {
+		d, err := discoveryFn(ctx)
+		// the handler rewrites api roots in place
+		d.APIRoots = append([]string{}, d.APIRoots...)
+		d.UpdatedAt = updatedAt
+		return d, err
+	}
+
+	osv := mockObjectService()
+	osv.IterateObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+		return &tester.ObjectIterator{Objects: tester.Objects, Updated: updatedAt}, nil
+	}
+
+	ms := mockManifestService()
+	ms.IterateManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+		return &tester.ManifestIterator{Entries: tester.Manifest.Objects, Updated: updatedAt}, nil
+	}
+
+	tests := []struct {
+		handler http.HandlerFunc
+		url     string
+	}{
+		{DiscoveryHandler{DiscoveryService: ds}.Get, testDiscoveryURL},
+		{APIRootHandler{APIRootService: mockAPIRootService()}.Get, testAPIRootURL},
+		{CollectionsHandler{CollectionService: mockCollectionService()}.Get, testCollectionsURL},
+		{CollectionHandler{CollectionService: mockCollectionService()}.Get, testCollectionURL},
+		{ManifestHandler{ManifestService: ms}.Get, testManifestURL},
+		{ObjectsHandler{ObjectService: osv}.getObjects, testObjectsURL},
+		{ObjectsHandler{ObjectService: osv}.getObject, testObjectURL},
+	}
+
+	newUserRequest := func(url string) *http.Request {
+		req := newRequest("GET", url, nil)
+		return req.WithContext(cabby.WithUser(req.Context(), tester.User))
+	}
+
+	for _, test := range tests {
+		req := newUserRequest(test.url)
+		res := httptest.NewRecorder()
+		test.handler(res, req)
+
+		if res.Code != http.StatusOK {
+			t.Fatal("Got:", res.Code, "Expected:", http.StatusOK, "URL:", test.url)
+		}
+
+		etag := res.Header().Get("ETag")
+		if etag == "" {
+			t.Error("Expected an ETag for URL:", test.url)
+		}
+
+		// the same request gets the same representation
+		again := httptest.NewRecorder()
+		test.handler(again, newUserRequest(test.url))
+		if again.Body.String() != res.Body.String() || again.Header().Get("ETag") != etag {
+			t.Error("Expected the same representation for URL:", test.url)
+		}
+
+		req = newUserRequest(test.url)
+		req.Header.Set("If-None-Match", etag)
+		res = httptest.NewRecorder()
+		test.handler(res, req)
+
+		if res.Code != http.StatusNotModified {
+			t.Error("Got:", res.Code, "Expected:", http.StatusNotModified, "URL:", test.url)
+		}
+		if res.Body.Len() != 0 {
+			t.Error("Got:", res.Body.String(), "Expected no body")
+		}
+	}
+
+	// only the handlers with an update time from the mocks have a Last-Modified
+	req := newRequest("GET", testDiscoveryURL, nil)
+	req.Header.Set("If-Modified-Since", updatedAt.Add(time.Second).Format(http.TimeFormat))
+	res := httptest.NewRecorder()
+	DiscoveryHandler{DiscoveryService: ds}.Get(res, req)
+
+	if res.Code != http.StatusNotModified {
+		t.Error("Got:", res.Code, "Expected:", http.StatusNotModified)
+	}
+}
@@ -35,10 +35,15 @@ func (h DiscoveryHandler) Get(w http.ResponseWriter, r *http.Request) {
 	if discovery.Title == "" {
 		resourceNotFound(w, errors.New("Discovery not defined"))
 	} else {
-		writeContent(w, cabby.TaxiiContentType, resourceToJSON(discovery))
+		writeContentIfModified(w, r, cabby.TaxiiContentType, resourceToJSON(discovery), discovery.UpdatedAt)
 	}
 }
 
+// Delete handles a delete request
+func (h DiscoveryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
+}
+
 // Post handles post request
 func (h DiscoveryHandler) Post(w http.ResponseWriter, r *http.Request) {
 	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
@@ -88,6 +88,20 @@ func TestDiscoveryHandlerGetNoDiscovery(t *testing.T) {
 	}
 }
 
+func TestDiscoveryHandleDelete(t *testing.T) {
+	ds := mockDiscoveryService()
+	ds.DiscoveryFn = func(ctx context.Context) (cabby.Discovery, error) {
+		return cabby.Discovery{Title: ""}, nil
+	}
+
+	h := DiscoveryHandler{DiscoveryService: &ds, Port: tester.Port}
+	status, _ := handlerTest(h.Delete, "DELETE", testDiscoveryURL, nil)
+
+	if status != http.StatusMethodNotAllowed {
+		t.Error("Got:", status, "Expected:", http.StatusMethodNotAllowed)
+	}
+}
+
 func TestDiscoveryHandlePost(t *testing.T) {
 	ds := mockDiscoveryService()
 	ds.DiscoveryFn = func(ctx context.Context) (cabby.Discovery, error) {
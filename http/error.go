@@ -52,6 +52,11 @@ func requestTooLarge(w http.ResponseWriter, rc, mc int64) {
 	errorStatus(w, "Request Too large", err, http.StatusRequestEntityTooLarge)
 }
 
+func requestBodyTooLarge(w http.ResponseWriter, mc int64) {
+	err := fmt.Errorf("request body can't be bigger than %v", mc)
+	errorStatus(w, "Request Too large", err, http.StatusRequestEntityTooLarge)
+}
+
 func rangeNotSatisfiable(w http.ResponseWriter, err error) {
 	errorStatus(w, "Requested Range Not Satisfiable", err, http.StatusRequestedRangeNotSatisfiable)
 }
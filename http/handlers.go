@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -17,6 +18,7 @@ func handleUndefinedRoute(w http.ResponseWriter, r *http.Request) {
 
 // RequestHandler interface for handling requests
 type RequestHandler interface {
+	Delete(w http.ResponseWriter, r *http.Request)
 	Get(w http.ResponseWriter, r *http.Request)
 	Post(w http.ResponseWriter, r *http.Request)
 }
@@ -27,6 +29,8 @@ func RouteRequest(h RequestHandler) http.HandlerFunc {
 		defer recoverFromPanic(w)
 
 		switch r.Method {
+		case http.MethodDelete:
+			h.Delete(w, r)
 		case http.MethodGet:
 			h.Get(w, r)
 		case http.MethodPost:
@@ -87,6 +91,58 @@ func withBasicAuth(h http.Handler, us cabby.UserService) http.Handler {
 	})
 }
 
+func withCompression(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.Close()
+
+		h.ServeHTTP(cw, r)
+	})
+}
+
+// withRequestTimeout cancels the request's context if the handler hasn't started its response within the timeout;
+// once the response starts the timeout is stopped so a streamed body isn't cut off.  A timeout of zero leaves the
+// request without one
+func withRequestTimeout(h http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		timer := time.AfterFunc(timeout, cancel)
+		defer timer.Stop()
+
+		h.ServeHTTP(&timeoutWriter{ResponseWriter: w, timer: timer}, r.WithContext(ctx))
+	})
+}
+
+// timeoutWriter stops a request's timeout when the response is started
+type timeoutWriter struct {
+	http.ResponseWriter
+	timer *time.Timer
+}
+
+func (t *timeoutWriter) WriteHeader(status int) {
+	t.timer.Stop()
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *timeoutWriter) Write(b []byte) (int, error) {
+	t.timer.Stop()
+	return t.ResponseWriter.Write(b)
+}
+
 func withRequestLogging(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		milliSecondOfNanoSeconds := int64(1000000)
@@ -2,6 +2,8 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"encoding/json"
 	"errors"
@@ -11,7 +13,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	cabby "github.com/pladdy/cabby2"
 	"github.com/pladdy/cabby2/tester"
@@ -44,6 +48,9 @@ func TestHandleUndefinedRequest(t *testing.T) {
 type mockRequestHandler struct {
 }
 
+func (m mockRequestHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, r.Method)
+}
 func (m mockRequestHandler) Get(w http.ResponseWriter, r *http.Request) {
 	io.WriteString(w, r.Method)
 }
@@ -60,6 +67,7 @@ func TestRequestHandlerRouteRequest(t *testing.T) {
 		status int
 	}{
 		{"CUSTOM", testDiscoveryURL, http.StatusMethodNotAllowed},
+		{"DELETE", testDiscoveryURL, http.StatusOK},
 		{"GET", testDiscoveryURL, http.StatusOK},
 		{"POST", testDiscoveryURL, http.StatusOK},
 	}
@@ -172,6 +180,102 @@ func TestWithBasicAuth(t *testing.T) {
 	}
 }
 
+func TestWithCompression(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+	}
+
+	for _, test := range tests {
+		decoratedHandler := withCompression(testHandlerFunc(t.Name()))
+
+		req := httptest.NewRequest("GET", testDiscoveryURL, nil)
+		req.Header.Set("Accept-Encoding", test.acceptEncoding)
+
+		res := httptest.NewRecorder()
+		decoratedHandler.ServeHTTP(res, req)
+
+		if res.Header().Get("Content-Encoding") != test.expected {
+			t.Error("Got:", res.Header().Get("Content-Encoding"), "Expected:", test.expected)
+		}
+		if res.Header().Get("Vary") != "Accept-Encoding" {
+			t.Error("Got:", res.Header().Get("Vary"), "Expected:", "Accept-Encoding")
+		}
+
+		var body io.Reader = res.Body
+		switch test.expected {
+		case "gzip":
+			body, _ = gzip.NewReader(res.Body)
+		case "deflate":
+			body, _ = zlib.NewReader(res.Body)
+		}
+
+		result, err := ioutil.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(result) != t.Name() {
+			t.Error("Got:", string(result), "Expected:", t.Name())
+		}
+	}
+}
+
+func TestWithRequestTimeout(t *testing.T) {
+	tests := []struct {
+		timeout  time.Duration
+		canceled bool
+	}{
+		{0, false},
+		{10 * time.Millisecond, true},
+	}
+
+	for _, test := range tests {
+		var canceled bool
+		handler := withRequestTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+				canceled = true
+			case <-time.After(100 * time.Millisecond):
+			}
+		}), test.timeout)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", testDiscoveryURL, nil))
+
+		if canceled != test.canceled {
+			t.Error("Got:", canceled, "Expected:", test.canceled, "Timeout:", test.timeout)
+		}
+	}
+}
+
+func TestWithRequestTimeoutStreamedResponse(t *testing.T) {
+	timeout := 10 * time.Millisecond
+	chunks := 10
+
+	// the body takes several times the timeout to write, but it's started before the timeout
+	handler := withRequestTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < chunks; i++ {
+			time.Sleep(timeout / 2)
+			if r.Context().Err() != nil {
+				return
+			}
+			w.Write([]byte("chunk\n"))
+		}
+	}), timeout)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest("GET", testDiscoveryURL, nil))
+
+	expected := strings.Repeat("chunk\n", chunks)
+	if res.Body.String() != expected {
+		t.Error("Got:", res.Body.String(), "Expected:", expected)
+	}
+}
+
 func TestWithRequestLogging(t *testing.T) {
 	// redirect log output for test
 	var buf bytes.Buffer
@@ -16,7 +16,6 @@ import (
 
 	cabby "github.com/pladdy/cabby2"
 	"github.com/pladdy/cabby2/tester"
-	"github.com/pladdy/stones"
 )
 
 var (
@@ -26,7 +25,10 @@ var (
 	testManifestURL    = testCollectionURL + "manifest/"
 	testObjectsURL     = testCollectionURL + "objects/"
 	testObjectURL      = testObjectsURL + tester.ObjectID + "/"
-	testStatusURL      = testAPIRootURL + "status/" + tester.StatusID + "/"
+	testVersionsURL    = testObjectURL + "versions/"
+	testStatusesURL    = testAPIRootURL + "status/"
+	testTombstonesURL  = testCollectionURL + "tombstones/"
+	testStatusURL      = testStatusesURL + tester.StatusID + "/"
 	testDiscoveryURL   = tester.BaseURL + "/taxii/"
 )
 
@@ -208,6 +210,9 @@ func mockDiscoveryService() tester.DiscoveryService {
 
 func mockManifestService() tester.ManifestService {
 	ms := tester.ManifestService{}
+	ms.IterateManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+		return &tester.ManifestIterator{Entries: tester.Manifest.Objects}, nil
+	}
 	ms.ManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error) {
 		return tester.Manifest, nil
 	}
@@ -216,15 +221,30 @@ func mockManifestService() tester.ManifestService {
 
 func mockObjectService() tester.ObjectService {
 	osv := tester.ObjectService{}
-	osv.CreateBundleFn = func(ctx context.Context, b stones.Bundle, collectionID string, s cabby.Status, ss cabby.StatusService) {
+	osv.CreateBundleFn = func(ctx context.Context, objects <-chan []byte, collectionID string, s cabby.Status, ss cabby.StatusService) {
+		for range objects {
+		}
 		tester.Info.Println("mock Creating Bundle")
 	}
+	osv.DeleteObjectFn = func(ctx context.Context, collectionID, objectID string, f cabby.Filter) (int64, error) {
+		return 1, nil
+	}
+	osv.IterateObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+		return &tester.ObjectIterator{Objects: tester.Objects}, nil
+	}
 	osv.ObjectFn = func(ctx context.Context, collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
 		return tester.Objects, nil
 	}
 	osv.ObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
 		return tester.Objects, nil
 	}
+	osv.TombstonesFn = func(ctx context.Context, collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+		return []cabby.Tombstone{tester.Tombstone}, nil
+	}
+	osv.VersionsFn = func(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+		cr.Total = 1
+		return []string{tester.Object.Modified}, nil
+	}
 	return osv
 }
 
@@ -232,6 +252,9 @@ func mockStatusService() tester.StatusService {
 	ss := tester.StatusService{}
 	ss.CreateStatusFn = func(ctx context.Context, status cabby.Status) error { return nil }
 	ss.StatusFn = func(ctx context.Context, statusID string) (cabby.Status, error) { return tester.Status, nil }
+	ss.StatusesFn = func(ctx context.Context, cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+		return []cabby.Status{tester.Status}, nil
+	}
 	ss.UpdateStatusFn = func(ctx context.Context, status cabby.Status) error { return nil }
 	return ss
 }
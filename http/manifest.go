@@ -11,6 +11,8 @@ import (
 // ManifestHandler holds a cabby ManifestService
 type ManifestHandler struct {
 	ManifestService cabby.ManifestService
+	DefaultPageSize int64
+	MaxPageSize     int64
 }
 
 // Get serves a manifest resource
@@ -23,23 +25,56 @@ func (h ManifestHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	manifest, err := h.ManifestService.Manifest(r.Context(), takeCollectionID(r), &cr, newFilter(r))
+	if err := takeCursor(r, &cr); err != nil {
+		badRequest(w, err)
+		return
+	}
+
+	if err := takeSort(r, &cr, cabby.ObjectSorts); err != nil {
+		badRequest(w, err)
+		return
+	}
+	cr.Bound(h.DefaultPageSize, h.MaxPageSize)
+
+	entries, err := h.ManifestService.IterateManifest(r.Context(), takeCollectionID(r), &cr, newFilter(r))
 	if err != nil {
 		internalServerError(w, err)
 		return
 	}
+	defer entries.Close()
 
-	if len(manifest.Objects) <= 0 {
+	// the first entry is read before the response is started so errors can still be returned
+	if !entries.Next() {
+		if err := entries.Err(); err != nil {
+			internalServerError(w, err)
+			return
+		}
 		resourceNotFound(w, errors.New("No manifest available for this collection"))
 		return
 	}
 
-	if cr.Valid() {
-		w.Header().Set("Content-Range", cr.String())
-		writePartialContent(w, cabby.TaxiiContentType, resourceToJSON(manifest))
-	} else {
-		writeContent(w, cabby.TaxiiContentType, resourceToJSON(manifest))
+	if notModified(w, r, streamETag(r, cr, entries.UpdatedAt()), entries.UpdatedAt()) {
+		return
+	}
+
+	first, last := entries.DateAdded()
+	setDateAddedHeaders(w, first, last)
+	setNextLink(w, r, cr)
+	writeStreamHeader(w, cabby.TaxiiContentType, contentRange(r, cr))
+
+	sw := newJSONStreamWriter(w)
+	sw.begin(pageFields(r, cr), "objects")
+	for more := true; more; more = entries.Next() {
+		sw.writeValue(entries.Entry())
 	}
+	sw.end()
+
+	logStreamError(entries.Err(), sw.err)
+}
+
+// Delete handles a delete request
+func (h ManifestHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
 }
 
 // Post handles post request
@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	cabby "github.com/pladdy/cabby2"
 	"github.com/pladdy/cabby2/tester"
@@ -35,6 +36,90 @@ func TestManifestHandlerGet(t *testing.T) {
 	}
 }
 
+func TestManifestHandlerGetDateAdded(t *testing.T) {
+	first := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ms := mockManifestService()
+	ms.IterateManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+		return &tester.ManifestIterator{Entries: tester.Manifest.Objects, AddedFirst: first, AddedLast: first}, nil
+	}
+	h := ManifestHandler{ManifestService: ms}
+
+	req := newRequest("GET", testManifestURL, nil)
+	_, _, headers := callHandler(h.Get, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+	for _, header := range []string{"X-TAXII-Date-Added-First", "X-TAXII-Date-Added-Last"} {
+		if headers.Get(header) != "2018-01-01T00:00:00.000Z" {
+			t.Error("Got:", headers.Get(header), "Expected:", "2018-01-01T00:00:00.000Z", "Header:", header)
+		}
+	}
+}
+
+// a date added that isn't known isn't a header
+func TestManifestHandlerGetNoDateAdded(t *testing.T) {
+	h := ManifestHandler{ManifestService: mockManifestService()}
+
+	req := newRequest("GET", testManifestURL, nil)
+	_, _, headers := callHandler(h.Get, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+	if _, ok := headers["X-Taxii-Date-Added-First"]; ok {
+		t.Error("Got:", headers.Get("X-TAXII-Date-Added-First"), "Expected no header")
+	}
+}
+
+func TestManifestHandlerGetCursor(t *testing.T) {
+	next := cabby.Cursor{DateAdded: time.Date(2018, 10, 30, 12, 3, 48, 123000000, time.UTC), Row: 1}
+
+	ms := mockManifestService()
+	ms.IterateManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+		cr.SetPage(2, 2, next)
+		return &tester.ManifestIterator{Entries: tester.Manifest.Objects}, nil
+	}
+	h := ManifestHandler{ManifestService: ms}
+
+	status, body := handlerTest(h.Get, "GET", testManifestURL+"?limit=1", nil)
+
+	if status != http.StatusOK {
+		t.Error("Got:", status, "Expected:", http.StatusOK)
+	}
+
+	var result struct {
+		More    bool
+		Next    string
+		Objects []cabby.ManifestEntry
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.More || result.Next != next.String() {
+		t.Error("Got:", result.More, result.Next, "Expected:", true, next.String())
+	}
+	if len(result.Objects) != len(tester.Manifest.Objects) {
+		t.Error("Got:", len(result.Objects), "Expected:", len(tester.Manifest.Objects))
+	}
+}
+
+func TestManifestHandlerGetMaxPageSize(t *testing.T) {
+	var limit int64
+
+	ms := mockManifestService()
+	ms.IterateManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+		limit = cr.Limit
+		return &tester.ManifestIterator{Entries: tester.Manifest.Objects}, nil
+	}
+	h := ManifestHandler{ManifestService: ms, MaxPageSize: 3}
+
+	status, _ := handlerTest(h.Get, "GET", testManifestURL+"?limit=10", nil)
+
+	if status != http.StatusOK {
+		t.Error("Got:", status, "Expected:", http.StatusOK)
+	}
+	if limit != 3 {
+		t.Error("Got:", limit, "Expected:", 3)
+	}
+}
+
 func TestManifestHandlerGetRange(t *testing.T) {
 	tests := []struct {
 		first    int
@@ -48,14 +133,14 @@ func TestManifestHandlerGetRange(t *testing.T) {
 	for _, test := range tests {
 		// set up mock service
 		ms := mockManifestService()
-		ms.ManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error) {
+		ms.IterateManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
 			manifest := cabby.Manifest{}
 			for i := 0; i < test.expected; i++ {
 				manifest.Objects = append(manifest.Objects, cabby.ManifestEntry{})
 			}
 
 			cr.Total = int64(test.expected)
-			return manifest, nil
+			return &tester.ManifestIterator{Entries: manifest.Objects}, nil
 		}
 		h := ManifestHandler{ManifestService: ms}
 
@@ -119,8 +204,8 @@ func TestManifestHandlerGetFailures(t *testing.T) {
 		Title: "Internal Server Error", Description: "Manifest failure", HTTPStatus: http.StatusInternalServerError}
 
 	ms := mockManifestService()
-	ms.ManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error) {
-		return cabby.Manifest{}, errors.New(expected.Description)
+	ms.IterateManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+		return nil, errors.New(expected.Description)
 	}
 
 	h := ManifestHandler{ManifestService: &ms}
@@ -142,10 +227,27 @@ func TestManifestHandlerGetFailures(t *testing.T) {
 	}
 }
 
+func TestManifestHandlerGetIteratorFailure(t *testing.T) {
+	expected := cabby.Error{
+		Title: "Internal Server Error", Description: "Manifest failure", HTTPStatus: http.StatusInternalServerError}
+
+	ms := mockManifestService()
+	ms.IterateManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+		return &tester.ManifestIterator{Error: errors.New(expected.Description)}, nil
+	}
+
+	h := ManifestHandler{ManifestService: &ms}
+	status, _ := handlerTest(h.Get, "GET", testManifestURL, nil)
+
+	if status != expected.HTTPStatus {
+		t.Error("Got:", status, "Expected:", expected.HTTPStatus)
+	}
+}
+
 func TestManifestHandlerGetNoManifest(t *testing.T) {
 	ms := mockManifestService()
-	ms.ManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error) {
-		return cabby.Manifest{}, nil
+	ms.IterateManifestFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+		return &tester.ManifestIterator{}, nil
 	}
 
 	h := ManifestHandler{ManifestService: &ms}
@@ -170,6 +272,15 @@ func TestManifestHandlerGetNoManifest(t *testing.T) {
 	}
 }
 
+func TestManifestHandleDelete(t *testing.T) {
+	h := ManifestHandler{ManifestService: mockManifestService()}
+	status, _ := handlerTest(h.Delete, "DELETE", testManifestURL, nil)
+
+	if status != http.StatusMethodNotAllowed {
+		t.Error("Got:", status, "Expected:", http.StatusMethodNotAllowed)
+	}
+}
+
 func TestManifestHandlePost(t *testing.T) {
 	h := ManifestHandler{ManifestService: mockManifestService()}
 	status, _ := handlerTest(h.Post, "POST", testManifestURL, nil)
@@ -0,0 +1,26 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	cabby "github.com/pladdy/cabby2"
+	log "github.com/sirupsen/logrus"
+)
+
+// handleMetrics writes the counts of what background jobs have done, like objects purged, for admins
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	log.WithFields(log.Fields{"handler": "handleMetrics"}).Debug("Handler called")
+
+	if !cabby.TakeUser(r.Context()).CanAdmin {
+		forbidden(w, errors.New("Unauthorized to read metrics"))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
+		return
+	}
+
+	writeContent(w, "application/json", cabby.JobMetrics.String())
+}
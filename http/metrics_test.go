@@ -0,0 +1,49 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+const testMetricsURL = "https://localhost/admin/metrics/"
+
+func TestHandleMetrics(t *testing.T) {
+	cabby.JobMetrics.Add("objects_purged", 2)
+
+	status, body := handlerTest(handleMetrics, "GET", testMetricsURL, nil)
+	if status != http.StatusOK {
+		t.Error("Got:", status, "Expected:", http.StatusOK)
+	}
+
+	var result map[string]int64
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result["objects_purged"] < 2 {
+		t.Error("Got:", result["objects_purged"], "Expected at least:", 2)
+	}
+}
+
+func TestHandleMetricsFailures(t *testing.T) {
+	tests := []struct {
+		method   string
+		user     cabby.User
+		expected int
+	}{
+		{"GET", cabby.User{Email: tester.UserEmail}, http.StatusForbidden},
+		{"POST", tester.User, http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+		req := newRequest(test.method, testMetricsURL, nil)
+		status, _, _ := callHandler(handleMetrics, req.WithContext(cabby.WithUser(req.Context(), test.user)))
+
+		if status != test.expected {
+			t.Error("Got:", status, "Expected:", test.expected, "Method:", test.method)
+		}
+	}
+}
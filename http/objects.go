@@ -1,22 +1,65 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	cabby "github.com/pladdy/cabby2"
 	"github.com/pladdy/stones"
 	log "github.com/sirupsen/logrus"
 )
 
+// objects are buffered between the request body and the data store
+const maxStreamedObjects = 50
+
 // ObjectsHandler handles Objects requests
 type ObjectsHandler struct {
 	ObjectService    cabby.ObjectService
 	StatusService    cabby.StatusService
 	MaxContentLength int64
+	DefaultPageSize  int64
+	MaxPageSize      int64
+}
+
+/* Delete */
+
+// Delete handles a delete request; like TAXII 2.1 every version of the object is deleted unless match[version] says
+// which to delete.  Deleted versions are recorded as tombstones
+func (h ObjectsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	log.WithFields(log.Fields{"handler": "ObjectsHandler"}).Debug("Handler called")
+
+	if takeObjectID(r) == "" || takeObjectResource(r) != "" {
+		methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" requires an object id"))
+		return
+	}
+
+	if !takeCollectionAccess(r).CanWrite {
+		forbidden(w, fmt.Errorf("Unauthorized to delete from collection"))
+		return
+	}
+
+	f := cabby.Filter{Versions: takeMatchVersions(r)}
+	if f.Versions == "" {
+		f.Versions = "all"
+	}
+
+	deleted, err := h.ObjectService.DeleteObject(r.Context(), takeCollectionID(r), takeObjectID(r), f)
+	if err != nil {
+		internalServerError(w, err)
+		return
+	}
+
+	if deleted <= 0 {
+		resourceNotFound(w, errors.New("No objects defined in this collection"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
 }
 
 /* Get */
@@ -25,6 +68,11 @@ type ObjectsHandler struct {
 func (h ObjectsHandler) Get(w http.ResponseWriter, r *http.Request) {
 	log.WithFields(log.Fields{"handler": "ObjectsHandler"}).Debug("Handler called")
 
+	if takeObjectID(r) != "" && takeObjectResource(r) == "versions" {
+		h.getVersions(w, r)
+		return
+	}
+
 	if !verifySupportedMimeType(w, r, "Accept", cabby.StixContentType) {
 		return
 	}
@@ -43,29 +91,62 @@ func (h ObjectsHandler) getObjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	objects, err := h.ObjectService.Objects(r.Context(), takeCollectionID(r), &cr, newFilter(r))
+	if err := takeCursor(r, &cr); err != nil {
+		badRequest(w, err)
+		return
+	}
+
+	if err := takeSort(r, &cr, cabby.ObjectSorts); err != nil {
+		badRequest(w, err)
+		return
+	}
+	cr.Bound(h.DefaultPageSize, h.MaxPageSize)
+
+	objects, err := h.ObjectService.IterateObjects(r.Context(), takeCollectionID(r), &cr, newFilter(r))
 	if err != nil {
 		internalServerError(w, err)
 		return
 	}
+	defer objects.Close()
 
-	if len(objects) <= 0 {
+	// the first object is read before the response is started so errors can still be returned
+	if !objects.Next() {
+		if err := objects.Err(); err != nil {
+			internalServerError(w, err)
+			return
+		}
 		resourceNotFound(w, errors.New("No objects defined in this collection"))
 		return
 	}
 
-	bundle, err := objectsToBundle(objects)
+	etag := streamETag(r, cr, objects.UpdatedAt())
+	if notModified(w, r, etag, objects.UpdatedAt()) {
+		return
+	}
+
+	bundle, err := bundleHead(etag)
 	if err != nil {
 		internalServerError(w, errors.New("Unable to create bundle"))
 		return
 	}
 
-	if cr.Valid() {
-		w.Header().Set("Content-Range", cr.String())
-		writePartialContent(w, cabby.StixContentType, resourceToJSON(bundle))
-	} else {
-		writeContent(w, cabby.StixContentType, resourceToJSON(bundle))
+	for field, value := range pageFields(r, cr) {
+		bundle[field] = value
 	}
+
+	first, last := objects.DateAdded()
+	setDateAddedHeaders(w, first, last)
+	setNextLink(w, r, cr)
+	writeStreamHeader(w, cabby.StixContentType, contentRange(r, cr))
+
+	sw := newJSONStreamWriter(w)
+	sw.begin(bundle, "objects")
+	for more := true; more; more = objects.Next() {
+		sw.write(objects.Object().Object)
+	}
+	sw.end()
+
+	logStreamError(objects.Err(), sw.err)
 }
 
 func (h ObjectsHandler) getObject(w http.ResponseWriter, r *http.Request) {
@@ -80,13 +161,76 @@ func (h ObjectsHandler) getObject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bundle, err := objectsToBundle(objects)
+	var updatedAt, firstAdded, lastAdded time.Time
+	for _, o := range objects {
+		if o.UpdatedAt.After(updatedAt) {
+			updatedAt = o.UpdatedAt
+		}
+		if firstAdded.IsZero() || o.DateAdded.Before(firstAdded) {
+			firstAdded = o.DateAdded
+		}
+		if o.DateAdded.After(lastAdded) {
+			lastAdded = o.DateAdded
+		}
+	}
+
+	etag := streamETag(r, cabby.Range{Total: int64(len(objects))}, updatedAt)
+	if notModified(w, r, etag, updatedAt) {
+		return
+	}
+
+	bundle, err := bundleHead(etag)
 	if err != nil {
 		internalServerError(w, errors.New("Unable to create bundle"))
 		return
 	}
 
-	writeContent(w, cabby.StixContentType, resourceToJSON(bundle))
+	setDateAddedHeaders(w, firstAdded, lastAdded)
+	w.Header().Set("Content-Type", cabby.StixContentType)
+
+	sw := newJSONStreamWriter(w)
+	sw.begin(bundle, "objects")
+	for _, o := range objects {
+		sw.write(o.Object)
+	}
+	sw.end()
+
+	logStreamError(sw.err)
+}
+
+// getVersions serves the versions of an object; like the manifest they're a taxii resource
+func (h ObjectsHandler) getVersions(w http.ResponseWriter, r *http.Request) {
+	if !verifySupportedMimeType(w, r, "Accept", cabby.TaxiiContentType) {
+		return
+	}
+
+	cr, err := cabby.NewRange(r.Header.Get("Range"))
+	if err != nil {
+		rangeNotSatisfiable(w, err)
+		return
+	}
+	cr.Bound(h.DefaultPageSize, h.MaxPageSize)
+
+	f := cabby.Filter{AddedAfter: takeAddedAfter(r)}
+	versions, err := h.ObjectService.Versions(r.Context(), takeCollectionID(r), takeObjectID(r), &cr, f)
+	if err != nil {
+		internalServerError(w, err)
+		return
+	}
+
+	if len(versions) <= 0 {
+		resourceNotFound(w, errors.New("No versions defined for this object"))
+		return
+	}
+
+	v := cabby.Versions{Versions: versions}
+	if cr.Valid() {
+		v.More = cr.Last+1 < cr.Total
+		w.Header().Set("Content-Range", cr.String())
+		writePartialContent(w, cabby.TaxiiContentType, resourceToJSON(v))
+		return
+	}
+	writeContent(w, cabby.TaxiiContentType, resourceToJSON(v))
 }
 
 /* Post */
@@ -99,36 +243,80 @@ func (h ObjectsHandler) Post(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body, err := ioutil.ReadAll(r.Body)
+	decompressed, err := decompressedBody(r)
 	if err != nil {
 		badRequest(w, err)
 		return
 	}
-	defer r.Body.Close()
 
-	bundle, err := bundleFromBytes(body)
-	if err != nil {
-		badRequest(w, err)
+	// chunked requests have no content length, the reader enforces the limit as the body is read; the limit applies
+	// to the decompressed body so a small compressed body can't expand without bound
+	body := http.MaxBytesReader(w, decompressed, h.MaxContentLength)
+	defer body.Close()
+
+	br := newBundleReader(body)
+	if err := br.readEnvelope(); err != nil {
+		h.postError(w, err)
 		return
 	}
 
-	status, err := cabby.NewStatus(len(bundle.Objects))
+	id, err := cabby.NewID()
 	if err != nil {
 		internalServerError(w, errors.New("Unable to initialize status resource"))
 		return
 	}
 
+	status := cabby.Status{ID: id, Status: "pending"}
+	status.User = cabby.TakeUser(r.Context()).Email
+	status.CollectionID = takeCollectionID(r)
+
 	err = h.StatusService.CreateStatus(r.Context(), status)
 	if err != nil {
 		internalServerError(w, errors.New("Unable to store status resource"))
 		return
 	}
 
+	// the bundle is written after the response is sent, so it isn't canceled with the request
+	objects := make(chan []byte, maxStreamedObjects)
+	ss := &streamStatusService{StatusService: h.StatusService}
+	go h.ObjectService.CreateBundle(cabby.JobContext(r.Context()), objects, takeCollectionID(r), status, ss)
+
+	// objects read before an error in the rest of the bundle are still written, so the post is accepted and the error
+	// is a failure in its status
+	count, err := streamObjects(br, objects)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "status_id": status.ID}).Warn("Failed to read the rest of the bundle")
+		ss.err = h.streamError(err)
+	}
+	close(objects)
+
+	status.TotalCount = count
+	status.PendingCount = count
+	if ss.err != nil {
+		status.TotalCount++
+		status.FailureCount++
+		status.Failures = append(status.Failures, ss.failure())
+	}
+
 	w.Header().Set("Content-Type", cabby.TaxiiContentType)
 	w.WriteHeader(http.StatusAccepted)
 	writeContent(w, cabby.TaxiiContentType, resourceToJSON(status))
+}
 
-	go h.ObjectService.CreateBundle(r.Context(), bundle, takeCollectionID(r), status, h.StatusService)
+func (h ObjectsHandler) postError(w http.ResponseWriter, err error) {
+	if bodyTooLarge(err) {
+		requestBodyTooLarge(w, h.MaxContentLength)
+		return
+	}
+	badRequest(w, err)
+}
+
+// streamError describes an error reading a bundle for its status
+func (h ObjectsHandler) streamError(err error) error {
+	if bodyTooLarge(err) {
+		return fmt.Errorf("request body can't be bigger than %v", h.MaxContentLength)
+	}
+	return err
 }
 
 func (h ObjectsHandler) validPost(w http.ResponseWriter, r *http.Request) (isValid bool) {
@@ -140,6 +328,10 @@ func (h ObjectsHandler) validPost(w http.ResponseWriter, r *http.Request) (isVal
 		return
 	}
 
+	if !verifySupportedEncoding(w, r) {
+		return
+	}
+
 	if greaterThan(r.ContentLength, h.MaxContentLength) {
 		requestTooLarge(w, r.ContentLength, h.MaxContentLength)
 		return
@@ -155,24 +347,18 @@ func (h ObjectsHandler) validPost(w http.ResponseWriter, r *http.Request) (isVal
 
 /* helpers */
 
-func bundleFromBytes(b []byte) (stones.Bundle, error) {
-	var bundle stones.Bundle
-
-	err := json.Unmarshal(b, &bundle)
-	if err != nil {
-		return bundle, fmt.Errorf("Unable to convert json to bundle, error: %v", err)
-	}
+// the error http.MaxBytesReader returns isn't exported, match on its message
+func bodyTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "request body too large")
+}
 
-	valid, errs := bundle.Validate()
-	if !valid {
-		errString := "Invalid bundle:"
-		for _, e := range errs {
-			errString = errString + fmt.Sprintf("\nValidation Error: %v", e)
+// errors after a stream is started can only be logged, the client gets a truncated resource
+func logStreamError(errs ...error) {
+	for _, err := range errs {
+		if err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("Failed to stream resource")
 		}
-		err = fmt.Errorf(errString)
 	}
-
-	return bundle, err
 }
 
 func greaterThan(r, m int64) bool {
@@ -182,19 +368,65 @@ func greaterThan(r, m int64) bool {
 	return false
 }
 
-func objectsToBundle(objects []cabby.Object) (stones.Bundle, error) {
+// streamObjects sends the objects in a bundle to a channel; the channel is left open so an error can be recorded before
+// the bundle's job sees the end of it
+func streamObjects(br *bundleReader, objects chan []byte) (int64, error) {
+	count := int64(0)
+	for {
+		object, err := br.next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		objects <- object
+		count++
+	}
+}
+
+// streamStatusService records an error reading a bundle in the bundle's status.  The error is set before the
+// bundle's objects channel is closed and the status is updated after it's drained, so the update sees it.  The rest
+// of the bundle counts as one failure
+type streamStatusService struct {
+	cabby.StatusService
+	err error
+}
+
+func (s *streamStatusService) UpdateStatus(ctx context.Context, st cabby.Status) error {
+	if s.err != nil {
+		st.TotalCount++
+		st.FailureCount++
+		st.Failures = append(st.Failures, s.failure())
+	}
+	return s.StatusService.UpdateStatus(ctx, st)
+}
+
+func (s *streamStatusService) failure() cabby.StatusFailure {
+	return cabby.StatusFailure{Message: "Unable to read the rest of the bundle: " + s.err.Error()}
+}
+
+// bundleHead returns a bundle without objects; its id is derived from the entity tag of the objects that go in it so
+// the same objects are always sent in the same bundle
+func bundleHead(etag string) (map[string]json.RawMessage, error) {
+	head := map[string]json.RawMessage{}
+
 	bundle, err := stones.NewBundle()
 	if err != nil {
-		return bundle, err
+		return head, err
 	}
 
-	for _, o := range objects {
-		bundle.Objects = append(bundle.Objects, o.Object)
+	id, err := cabby.IDUsingString(etag)
+	if err != nil {
+		return head, err
 	}
 
-	if len(bundle.Objects) == 0 {
-		log.Warn("Can't return an empty bundle, returning error to caller")
-		return bundle, errors.New("No data returned: empty bundle")
+	b, err := json.Marshal(bundle)
+	if err == nil {
+		err = json.Unmarshal(b, &head)
 	}
-	return bundle, err
+
+	head["id"] = json.RawMessage(resourceToJSON("bundle--" + id.String()))
+	return head, err
 }
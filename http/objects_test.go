@@ -2,32 +2,41 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	cabby "github.com/pladdy/cabby2"
 	"github.com/pladdy/cabby2/tester"
 	"github.com/pladdy/stones"
 )
 
-func TestBundleFromBytesUnmarshalFail(t *testing.T) {
-	b, err := bundleFromBytes([]byte(`{"foo": "bar"`))
-	if err == nil {
-		t.Error("Expected error for bundle:", b)
+func TestBodyTooLarge(t *testing.T) {
+	tests := []struct {
+		err    error
+		result bool
+	}{
+		{nil, false},
+		{errors.New("foo"), false},
+		{errors.New("http: request body too large"), true},
 	}
-}
 
-func TestBundleFromBytesInvalidBundle(t *testing.T) {
-	b, err := bundleFromBytes([]byte(`{"foo": "bar"}`))
-	if err == nil {
-		t.Error("Expected error for bundle:", b)
+	for _, test := range tests {
+		result := bodyTooLarge(test.err)
+		if result != test.result {
+			t.Error("Got:", result, "Expected:", test.result)
+		}
 	}
 }
 
@@ -49,6 +58,73 @@ func TestGreaterThan(t *testing.T) {
 	}
 }
 
+func TestObjectsHandlerDelete(t *testing.T) {
+	tests := []struct {
+		url      string
+		versions string
+	}{
+		{testObjectURL, "all"},
+		{testObjectURL + "?match[version]=first", "first"},
+		{testObjectURL + "?match[version]=2016-04-06T20:07:09.000Z", "2016-04-06T20:07:09.000Z"},
+	}
+
+	for _, test := range tests {
+		var result cabby.Filter
+
+		s := mockObjectService()
+		s.DeleteObjectFn = func(ctx context.Context, collectionID, objectID string, f cabby.Filter) (int64, error) {
+			result = f
+			return 1, nil
+		}
+
+		h := ObjectsHandler{ObjectService: &s}
+		status, _ := handlerTest(h.Delete, "DELETE", test.url, nil)
+
+		if status != http.StatusOK {
+			t.Error("Got:", status, "Expected:", http.StatusOK)
+		}
+		if result.Versions != test.versions {
+			t.Error("Got:", result.Versions, "Expected:", test.versions)
+		}
+	}
+}
+
+func TestObjectsHandlerDeleteFailures(t *testing.T) {
+	s := mockObjectService()
+	s.DeleteObjectFn = func(ctx context.Context, collectionID, objectID string, f cabby.Filter) (int64, error) {
+		return 0, errors.New("Delete failure")
+	}
+
+	noObject := mockObjectService()
+	noObject.DeleteObjectFn = func(ctx context.Context, collectionID, objectID string, f cabby.Filter) (int64, error) {
+		return 0, nil
+	}
+
+	tests := []struct {
+		service tester.ObjectService
+		url     string
+		user    cabby.User
+		status  int
+	}{
+		{mockObjectService(), testObjectsURL, tester.User, http.StatusMethodNotAllowed},
+		{mockObjectService(), testVersionsURL, tester.User, http.StatusMethodNotAllowed},
+		{mockObjectService(), testObjectURL, cabby.User{Email: tester.UserEmail}, http.StatusForbidden},
+		{s, testObjectURL, tester.User, http.StatusInternalServerError},
+		{noObject, testObjectURL, tester.User, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		h := ObjectsHandler{ObjectService: test.service}
+
+		req := newRequest("DELETE", test.url, nil)
+		status, _, _ := callHandler(h.Delete, req.WithContext(cabby.WithUser(req.Context(), test.user)))
+
+		if status != test.status {
+			t.Error("Got:", status, "Expected:", test.status, "URL:", test.url)
+		}
+	}
+}
+
 func TestObjectsHandlerGet(t *testing.T) {
 	h := ObjectsHandler{ObjectService: mockObjectService()}
 
@@ -201,6 +277,90 @@ func TestObjectsHandlerGetObjects(t *testing.T) {
 	}
 }
 
+func TestObjectsHandlerGetObjectsDateAdded(t *testing.T) {
+	first := time.Date(2018, 1, 1, 0, 0, 0, 123000000, time.UTC)
+	last := first.Add(time.Hour)
+
+	osv := mockObjectService()
+	osv.IterateObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+		return &tester.ObjectIterator{Objects: tester.Objects, AddedFirst: first, AddedLast: last}, nil
+	}
+	h := ObjectsHandler{ObjectService: osv}
+
+	req := newRequest("GET", testObjectsURL, nil)
+	status, _, headers := callHandler(h.getObjects, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+	if status != http.StatusOK {
+		t.Error("Got:", status, "Expected:", http.StatusOK)
+	}
+	if headers.Get("X-TAXII-Date-Added-First") != "2018-01-01T00:00:00.123Z" {
+		t.Error("Got:", headers.Get("X-TAXII-Date-Added-First"), "Expected:", "2018-01-01T00:00:00.123Z")
+	}
+	if headers.Get("X-TAXII-Date-Added-Last") != "2018-01-01T01:00:00.123Z" {
+		t.Error("Got:", headers.Get("X-TAXII-Date-Added-Last"), "Expected:", "2018-01-01T01:00:00.123Z")
+	}
+}
+
+// the first and last versions of an object are the ones added first and last, whatever order they're read in
+func TestObjectsHandlerGetObjectDateAdded(t *testing.T) {
+	first := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	osv := mockObjectService()
+	osv.ObjectFn = func(ctx context.Context, collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
+		later, earlier := tester.Object, tester.Object
+		later.DateAdded = first.Add(time.Minute)
+		earlier.DateAdded = first
+		return []cabby.Object{later, earlier}, nil
+	}
+	h := ObjectsHandler{ObjectService: osv}
+
+	req := newRequest("GET", testObjectURL, nil)
+	_, _, headers := callHandler(h.getObject, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+	if headers.Get("X-TAXII-Date-Added-First") != "2018-01-01T00:00:00.000Z" {
+		t.Error("Got:", headers.Get("X-TAXII-Date-Added-First"), "Expected:", "2018-01-01T00:00:00.000Z")
+	}
+	if headers.Get("X-TAXII-Date-Added-Last") != "2018-01-01T00:01:00.000Z" {
+		t.Error("Got:", headers.Get("X-TAXII-Date-Added-Last"), "Expected:", "2018-01-01T00:01:00.000Z")
+	}
+}
+
+func TestObjectsHandlerGetObjectsPageSize(t *testing.T) {
+	tests := []struct {
+		rangeHeader   string
+		expectedRange string
+	}{
+		{"", "items 0-1/5"},
+		{"items 0-4", "items 0-2/5"},
+		{"items 0-0", "items 0-0/5"},
+	}
+
+	for _, test := range tests {
+		obs := mockObjectService()
+		obs.IterateObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+			cr.Total = 5
+			return &tester.ObjectIterator{Objects: make([]cabby.Object, cr.Last-cr.First+1)}, nil
+		}
+		h := ObjectsHandler{ObjectService: obs, DefaultPageSize: 2, MaxPageSize: 3}
+
+		req := newRequest("GET", testObjectsURL, nil)
+		req.Header.Set("Accept", cabby.StixContentType)
+		if test.rangeHeader != "" {
+			req.Header.Set("Range", test.rangeHeader)
+		}
+
+		res := httptest.NewRecorder()
+		h.Get(res, req)
+
+		if res.Code != http.StatusPartialContent {
+			t.Error("Got:", res.Code, "Expected:", http.StatusPartialContent, "Range:", test.rangeHeader)
+		}
+		if res.Header().Get("Content-Range") != test.expectedRange {
+			t.Error("Got:", res.Header().Get("Content-Range"), "Expected:", test.expectedRange)
+		}
+	}
+}
+
 func TestObjectsHandlerGetObjectsRange(t *testing.T) {
 	tests := []struct {
 		first    int
@@ -214,14 +374,14 @@ func TestObjectsHandlerGetObjectsRange(t *testing.T) {
 	for _, test := range tests {
 		// set up mock service
 		obs := mockObjectService()
-		obs.ObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
+		obs.IterateObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
 			objects := []cabby.Object{}
 			for i := 0; i < test.expected; i++ {
 				objects = append(objects, cabby.Object{})
 			}
 
 			cr.Total = int64(test.expected)
-			return objects, nil
+			return &tester.ObjectIterator{Objects: objects}, nil
 		}
 		h := ObjectsHandler{ObjectService: obs}
 
@@ -256,6 +416,89 @@ func TestObjectsHandlerGetObjectsRange(t *testing.T) {
 	}
 }
 
+func TestObjectsHandlerGetObjectsCursor(t *testing.T) {
+	after := cabby.Cursor{DateAdded: time.Date(2018, 10, 30, 12, 3, 48, 123000000, time.UTC), Row: 2}
+	next := cabby.Cursor{DateAdded: after.DateAdded, Row: 4}
+
+	var requested cabby.Range
+	obs := mockObjectService()
+	obs.IterateObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+		requested = *cr
+		cr.SetPage(5, 3, next)
+		return &tester.ObjectIterator{Objects: []cabby.Object{tester.Object, tester.Object}}, nil
+	}
+	h := ObjectsHandler{ObjectService: obs}
+
+	tests := []struct {
+		rangeHeader  string
+		status       int
+		contentRange string
+		more         bool
+	}{
+		{"", http.StatusOK, "", true},
+		{"items 0-1", http.StatusPartialContent, "items 2-3/5", false},
+	}
+
+	for _, test := range tests {
+		req := newRequest("GET", testObjectsURL+"?limit=2&next="+after.String(), nil)
+		req.Header.Set("Accept", cabby.StixContentType)
+		if test.rangeHeader != "" {
+			req.Header.Set("Range", test.rangeHeader)
+		}
+
+		res := httptest.NewRecorder()
+		h.Get(res, req)
+
+		if res.Code != test.status {
+			t.Error("Got:", res.Code, "Expected:", test.status)
+		}
+		if requested.After != after || requested.Limit != 2 || !requested.Cursored {
+			t.Error("Got:", requested, "Expected a range of 2 after:", after)
+		}
+		if res.Header().Get("Content-Range") != test.contentRange {
+			t.Error("Got:", res.Header().Get("Content-Range"), "Expected:", test.contentRange)
+		}
+		if !strings.Contains(res.Header().Get("Link"), "next="+next.String()) {
+			t.Error("Got:", res.Header().Get("Link"), "Expected a link to:", next.String())
+		}
+
+		var result struct {
+			More    bool
+			Next    string
+			Objects []json.RawMessage
+		}
+		if err := json.Unmarshal(res.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Objects) != 2 {
+			t.Error("Got:", len(result.Objects), "Expected:", 2)
+		}
+		if result.More != test.more {
+			t.Error("Got:", result.More, "Expected:", test.more)
+		}
+		if test.more && result.Next != next.String() {
+			t.Error("Got:", result.Next, "Expected:", next.String())
+		}
+	}
+}
+
+func TestObjectsHandlerGetObjectsInvalidCursor(t *testing.T) {
+	h := ObjectsHandler{ObjectService: mockObjectService()}
+
+	for _, query := range []string{"?limit=0", "?next=not-a-cursor"} {
+		req := newRequest("GET", testObjectsURL+query, nil)
+		req.Header.Set("Accept", cabby.StixContentType)
+
+		res := httptest.NewRecorder()
+		h.Get(res, req)
+
+		if res.Code != http.StatusBadRequest {
+			t.Error("Got:", res.Code, "Expected:", http.StatusBadRequest, "Query:", query)
+		}
+	}
+}
+
 func TestObjectsHandlerGetInvalidRange(t *testing.T) {
 	tests := []struct {
 		rangeString    string
@@ -287,8 +530,8 @@ func TestObjectsGetObjectsFailure(t *testing.T) {
 		Title: "Internal Server Error", Description: "Collection failure", HTTPStatus: http.StatusInternalServerError}
 
 	s := mockObjectService()
-	s.ObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
-		return []cabby.Object{}, errors.New(expected.Description)
+	s.IterateObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+		return nil, errors.New(expected.Description)
 	}
 
 	h := ObjectsHandler{ObjectService: &s}
@@ -310,10 +553,24 @@ func TestObjectsGetObjectsFailure(t *testing.T) {
 	}
 }
 
+func TestObjectsGetObjectsIteratorFailure(t *testing.T) {
+	s := mockObjectService()
+	s.IterateObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+		return &tester.ObjectIterator{Error: errors.New("Collection failure")}, nil
+	}
+
+	h := ObjectsHandler{ObjectService: &s}
+	status, _ := handlerTest(h.getObjects, "GET", testObjectsURL, nil)
+
+	if status != http.StatusInternalServerError {
+		t.Error("Got:", status, "Expected:", http.StatusInternalServerError)
+	}
+}
+
 func TestObjectsHandlerGetObjectsNoObjects(t *testing.T) {
 	s := mockObjectService()
-	s.ObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
-		return []cabby.Object{}, nil
+	s.IterateObjectsFn = func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+		return &tester.ObjectIterator{}, nil
 	}
 
 	h := ObjectsHandler{ObjectService: &s}
@@ -340,9 +597,173 @@ func TestObjectsHandlerGetObjectsNoObjects(t *testing.T) {
 
 /* Post */
 
+func TestObjectsHandlerGetVersions(t *testing.T) {
+	h := ObjectsHandler{ObjectService: mockObjectService()}
+
+	req := newRequest("GET", testVersionsURL, nil)
+	req.Header.Set("Accept", cabby.TaxiiContentType)
+	status, body, headers := callHandler(h.Get, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+	if status != http.StatusOK {
+		t.Error("Got:", status, "Expected:", http.StatusOK)
+	}
+	if headers.Get("Content-Type") != cabby.TaxiiContentType {
+		t.Error("Got:", headers.Get("Content-Type"), "Expected:", cabby.TaxiiContentType)
+	}
+
+	var result cabby.Versions
+	err := json.Unmarshal([]byte(body), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Versions) != 1 || result.Versions[0] != tester.Object.Modified {
+		t.Error("Got:", result.Versions, "Expected:", tester.Object.Modified)
+	}
+	if result.More {
+		t.Error("Got:", result.More, "Expected:", false)
+	}
+}
+
+func TestObjectsHandlerGetVersionsRange(t *testing.T) {
+	versions := []string{"2018-01-01T00:00:00.000Z", "2018-01-02T00:00:00.000Z", "2018-01-03T00:00:00.000Z"}
+
+	tests := []struct {
+		first    int64
+		last     int64
+		expected []string
+		more     bool
+	}{
+		{0, 0, versions[:1], true},
+		{0, 1, versions[:2], true},
+		{0, 2, versions, false},
+	}
+
+	for _, test := range tests {
+		s := mockObjectService()
+		s.VersionsFn = func(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+			cr.Total = int64(len(versions))
+			return versions[cr.First : cr.Last+1], nil
+		}
+		h := ObjectsHandler{ObjectService: s}
+
+		req := newRequest("GET", testVersionsURL, nil)
+		req.Header.Set("Accept", cabby.TaxiiContentType)
+		req.Header.Set("Range", "items "+strconv.FormatInt(test.first, 10)+"-"+strconv.FormatInt(test.last, 10))
+		status, body, headers := callHandler(h.Get, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+		if status != http.StatusPartialContent {
+			t.Error("Got:", status, "Expected:", http.StatusPartialContent)
+		}
+
+		expectedRange := cabby.Range{First: test.first, Last: test.last, Total: int64(len(versions))}
+		if headers.Get("Content-Range") != expectedRange.String() {
+			t.Error("Got:", headers.Get("Content-Range"), "Expected:", expectedRange.String())
+		}
+
+		var result cabby.Versions
+		err := json.Unmarshal([]byte(body), &result)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Join(result.Versions, ",") != strings.Join(test.expected, ",") {
+			t.Error("Got:", result.Versions, "Expected:", test.expected)
+		}
+		if result.More != test.more {
+			t.Error("Got:", result.More, "Expected:", test.more)
+		}
+	}
+}
+
+func TestObjectsHandlerGetVersionsPageSize(t *testing.T) {
+	tests := []struct {
+		rangeHeader   string
+		expectedRange string
+		expected      int
+	}{
+		{"", "items 0-1/5", 2},
+		{"items 0-4", "items 0-2/5", 3},
+		{"items 0-0", "items 0-0/5", 1},
+	}
+
+	for _, test := range tests {
+		s := mockObjectService()
+		s.VersionsFn = func(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+			cr.Total = 5
+			return make([]string, cr.Last-cr.First+1), nil
+		}
+		h := ObjectsHandler{ObjectService: s, DefaultPageSize: 2, MaxPageSize: 3}
+
+		req := newRequest("GET", testVersionsURL, nil)
+		req.Header.Set("Accept", cabby.TaxiiContentType)
+		if test.rangeHeader != "" {
+			req.Header.Set("Range", test.rangeHeader)
+		}
+		status, body, headers := callHandler(h.Get, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+		if status != http.StatusPartialContent {
+			t.Error("Got:", status, "Expected:", http.StatusPartialContent, "Range:", test.rangeHeader)
+		}
+		if headers.Get("Content-Range") != test.expectedRange {
+			t.Error("Got:", headers.Get("Content-Range"), "Expected:", test.expectedRange)
+		}
+
+		var result cabby.Versions
+		if err := json.Unmarshal([]byte(body), &result); err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Versions) != test.expected {
+			t.Error("Got:", len(result.Versions), "Expected:", test.expected, "Range:", test.rangeHeader)
+		}
+		if !result.More {
+			t.Error("Got:", result.More, "Expected:", true)
+		}
+	}
+}
+
+func TestObjectsHandlerGetVersionsFailures(t *testing.T) {
+	s := mockObjectService()
+	s.VersionsFn = func(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+		return []string{}, errors.New("Versions failure")
+	}
+
+	none := mockObjectService()
+	none.VersionsFn = func(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+		return []string{}, nil
+	}
+
+	tests := []struct {
+		service tester.ObjectService
+		accept  string
+		rangeH  string
+		status  int
+	}{
+		{mockObjectService(), cabby.StixContentType, "", http.StatusUnsupportedMediaType},
+		{mockObjectService(), cabby.TaxiiContentType, "items invalid", http.StatusRequestedRangeNotSatisfiable},
+		{s, cabby.TaxiiContentType, "", http.StatusInternalServerError},
+		{none, cabby.TaxiiContentType, "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		h := ObjectsHandler{ObjectService: test.service}
+
+		req := newRequest("GET", testVersionsURL, nil)
+		req.Header.Set("Accept", test.accept)
+		req.Header.Set("Range", test.rangeH)
+		status, _, _ := callHandler(h.Get, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+		if status != test.status {
+			t.Error("Got:", status, "Expected:", test.status)
+		}
+	}
+}
+
 func TestObjectsHandlerPost(t *testing.T) {
 	osv := mockObjectService()
-	osv.CreateBundleFn = func(ctx context.Context, b stones.Bundle, collectionID string, s cabby.Status, ss cabby.StatusService) {
+	osv.CreateBundleFn = func(ctx context.Context, objects <-chan []byte, collectionID string, s cabby.Status, ss cabby.StatusService) {
+		for range objects {
+		}
 		tester.Info.Println("mock call of CreateBundle")
 	}
 
@@ -404,6 +825,157 @@ func TestObjectsHandlerPostContentTooLarge(t *testing.T) {
 	}
 }
 
+func TestObjectsHandlerPostChunkedTooLarge(t *testing.T) {
+	osv := mockObjectService()
+	ssv := mockStatusService()
+	h := ObjectsHandler{MaxContentLength: int64(256), ObjectService: osv, StatusService: ssv}
+
+	bundleFile, _ := os.Open("testdata/malware_bundle.json")
+	bundle, _ := ioutil.ReadAll(bundleFile)
+	b := bytes.NewBuffer(bundle)
+
+	// a chunked request doesn't have a content length; this one is too large before its first object is read
+	req := newPostRequest(testObjectsURL, b)
+	req.ContentLength = -1
+	status, _, _ := callHandler(h.Post, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+	if status != http.StatusRequestEntityTooLarge {
+		t.Error("Got:", status, "Expected:", http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestObjectsHandlerPostStreamed(t *testing.T) {
+	received := make(chan int, 1)
+
+	osv := mockObjectService()
+	osv.CreateBundleFn = func(ctx context.Context, objects <-chan []byte, collectionID string, s cabby.Status, ss cabby.StatusService) {
+		count := 0
+		for range objects {
+			count++
+		}
+		received <- count
+	}
+
+	ssv := mockStatusService()
+	ssv.CreateStatusFn = func(ctx context.Context, s cabby.Status) error {
+		if s.Status != "pending" || s.TotalCount != 0 {
+			t.Error("Got:", s, "Expected: a pending status with no objects counted")
+		}
+		return nil
+	}
+	h := ObjectsHandler{MaxContentLength: int64(2048), ObjectService: osv, StatusService: ssv}
+
+	bundleFile, _ := os.Open("testdata/malware_bundle.json")
+	bundle, _ := ioutil.ReadAll(bundleFile)
+
+	req := newPostRequest(testObjectsURL, bytes.NewBuffer(bundle))
+	status, _, _ := callHandler(h.Post, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+	if status != http.StatusAccepted {
+		t.Error("Got:", status, "Expected:", http.StatusAccepted)
+	}
+
+	count := <-received
+	if count != 3 {
+		t.Error("Got:", count, "Expected:", 3)
+	}
+}
+
+func TestObjectsHandlerPostTruncatedBundle(t *testing.T) {
+	updated := make(chan cabby.Status, 1)
+
+	// the mock writes every object it gets
+	osv := mockObjectService()
+	osv.CreateBundleFn = func(ctx context.Context, objects <-chan []byte, collectionID string, s cabby.Status, ss cabby.StatusService) {
+		for range objects {
+			s.TotalCount++
+			s.SuccessCount++
+		}
+		ss.UpdateStatus(ctx, s)
+	}
+
+	ssv := mockStatusService()
+	ssv.UpdateStatusFn = func(ctx context.Context, s cabby.Status) error {
+		updated <- s
+		return nil
+	}
+	h := ObjectsHandler{MaxContentLength: int64(2048), ObjectService: osv, StatusService: ssv}
+
+	bundle := []byte(`{"type": "bundle", "id": "bundle--5d0092c5-5f74-4287-9642-33f4c354e56d", "spec_version": "2.0",
+		"objects": [{"id": "indicator--1"}, {"id": "indicator--2"`)
+	b := bytes.NewBuffer(bundle)
+
+	req := newPostRequest(testObjectsURL, b)
+	status, body, _ := callHandler(h.Post, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+	// the object read before the error is written, so the post is accepted and the error is in its status
+	if status != http.StatusAccepted {
+		t.Error("Got:", status, "Expected:", http.StatusAccepted)
+	}
+
+	var result cabby.Status
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalCount != 2 || result.PendingCount != 1 || result.FailureCount != 1 {
+		t.Error("Got:", result, "Expected: 2 objects, 1 pending and 1 failure")
+	}
+
+	final := <-updated
+	if final.TotalCount != 2 || final.SuccessCount != 1 || final.FailureCount != 1 || len(final.Failures) != 1 {
+		t.Error("Got:", final, "Expected: 2 objects, 1 success and 1 failure")
+	}
+}
+
+func TestObjectsHandlerPostCompressed(t *testing.T) {
+	bundleFile, _ := os.Open("testdata/malware_bundle.json")
+	bundle, _ := ioutil.ReadAll(bundleFile)
+
+	// a bomb is a bundle padded with whitespace before its objects; compressed it's under the max content length, so
+	// only the limit on the decompressed body stops it
+	padded := append(append([]byte("{"), bytes.Repeat([]byte(" "), 64*1024)...), bundle[1:]...)
+
+	compress := func(w io.WriteCloser, body []byte) {
+		w.Write(body)
+		w.Close()
+	}
+
+	var gzipped, gzipBomb, deflateBomb bytes.Buffer
+	compress(gzip.NewWriter(&gzipped), bundle)
+	compress(gzip.NewWriter(&gzipBomb), padded)
+	compress(zlib.NewWriter(&deflateBomb), padded)
+
+	for _, bomb := range [][]byte{gzipBomb.Bytes(), deflateBomb.Bytes()} {
+		if len(bomb) >= 2048 {
+			t.Fatal("Got:", len(bomb), "Expected a bomb under the max content length")
+		}
+	}
+
+	tests := []struct {
+		encoding       string
+		body           []byte
+		expectedStatus int
+	}{
+		{"gzip", gzipped.Bytes(), http.StatusAccepted},
+		{"gzip", gzipBomb.Bytes(), http.StatusRequestEntityTooLarge},
+		{"deflate", deflateBomb.Bytes(), http.StatusRequestEntityTooLarge},
+		{"gzip", bundle, http.StatusBadRequest},
+		{"br", bundle, http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		h := ObjectsHandler{MaxContentLength: int64(2048), ObjectService: mockObjectService(), StatusService: mockStatusService()}
+
+		req := newPostRequest(testObjectsURL, bytes.NewBuffer(test.body))
+		req.Header.Set("Content-Encoding", test.encoding)
+		status, body, _ := callHandler(h.Post, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+		if status != test.expectedStatus {
+			t.Error("Got:", status, "Expected:", test.expectedStatus, "Body:", body)
+		}
+	}
+}
+
 func TestObjectsHandlerPostInvalidBundle(t *testing.T) {
 	h := ObjectsHandler{MaxContentLength: int64(2048), ObjectService: mockObjectService()}
 
@@ -498,9 +1070,27 @@ func TestObjectsPostValidPost(t *testing.T) {
 	}
 }
 
-func TestObjectsToBundleError(t *testing.T) {
-	_, err := objectsToBundle([]cabby.Object{})
-	if err == nil {
-		t.Error("Expected error")
+func TestBundleHead(t *testing.T) {
+	head, err := bundleHead(`"foo"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(head["type"]) != `"bundle"` {
+		t.Error("Got:", string(head["type"]), "Expected:", `"bundle"`)
+	}
+	if _, ok := head["objects"]; ok && string(head["objects"]) != "null" {
+		t.Error("Got:", string(head["objects"]), "Expected no objects")
+	}
+
+	// the same tag is the same bundle
+	again, _ := bundleHead(`"foo"`)
+	if string(head["id"]) != string(again["id"]) {
+		t.Error("Got:", string(again["id"]), "Expected:", string(head["id"]))
+	}
+
+	other, _ := bundleHead(`"bar"`)
+	if string(head["id"]) == string(other["id"]) {
+		t.Error("Got:", string(other["id"]), "Expected a different id")
 	}
 }
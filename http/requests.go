@@ -1,8 +1,10 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -36,6 +38,7 @@ func newFilter(r *http.Request) (f cabby.Filter) {
 	f.AddedAfter = takeAddedAfter(r)
 	f.IDs = takeMatchIDs(r)
 	f.Types = takeMatchTypes(r)
+	f.Properties = takeMatchProperties(r)
 
 	f.Versions = takeMatchVersions(r)
 	if f.Versions == "" {
@@ -44,6 +47,33 @@ func newFilter(r *http.Request) (f cabby.Filter) {
 	return
 }
 
+func newStatusFilter(r *http.Request) (f cabby.StatusFilter, err error) {
+	q := r.URL.Query()
+
+	f.User = q.Get("user")
+	f.CollectionID = q.Get("collection_id")
+	f.Status = q.Get("status")
+
+	f.CreatedAfter, err = takeStatusTimestamp(q.Get("created_after"), "created_after")
+	if err != nil {
+		return
+	}
+	f.CreatedBefore, err = takeStatusTimestamp(q.Get("created_before"), "created_before")
+	return
+}
+
+func takeStatusTimestamp(raw, param string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return "", errors.New("Invalid " + param + ", it has to be an RFC 3339 timestamp")
+	}
+	return t.Format(time.RFC3339Nano), nil
+}
+
 func takeAddedAfter(r *http.Request) string {
 	af := r.URL.Query()["added_after"]
 
@@ -88,6 +118,63 @@ func takeObjectID(r *http.Request) string {
 	return getToken(r.URL.Path, objectIDIndex)
 }
 
+// takeObjectResource returns the resource under an object, like versions
+func takeObjectResource(r *http.Request) string {
+	var objectResourceIndex = 6
+	return getToken(r.URL.Path, objectResourceIndex)
+}
+
+// takeCursor makes a range cursored when the request has a next or limit parameter: next is the cursor of the last
+// page read, empty to start at the beginning, and limit is the most items to return.  With a Range header and no
+// limit, the page is as long as the header's range.
+func takeCursor(r *http.Request, cr *cabby.Range) error {
+	q := r.URL.Query()
+	_, hasNext := q["next"]
+	_, hasLimit := q["limit"]
+	if !hasNext && !hasLimit {
+		return nil
+	}
+
+	after, err := cabby.NewCursor(q.Get("next"))
+	if err != nil {
+		return err
+	}
+
+	limit := int64(0)
+	if cr.Valid() {
+		limit = cr.Last - cr.First + 1
+	}
+	if hasLimit {
+		limit, err = strconv.ParseInt(q.Get("limit"), 10, 64)
+		if err != nil || limit < 1 {
+			return errors.New("Invalid limit, it has to be a positive integer")
+		}
+	}
+
+	*cr = cabby.Range{First: -1, Last: -1, After: after, Limit: limit, Cursored: true}
+	return nil
+}
+
+// takeSort sets the sort of a range from the sort parameter, one of the fields given; a cursored range is always in
+// the order its items were added, so it can't be sorted
+func takeSort(r *http.Request, cr *cabby.Range, fields []string) error {
+	raw := r.URL.Query().Get("sort")
+	if raw == "" {
+		return nil
+	}
+
+	if cr.Cursored {
+		return errors.New("Invalid sort, a page by cursor is always in the order items were added")
+	}
+
+	sort, err := cabby.NewSort(raw, fields)
+	if err != nil {
+		return err
+	}
+	cr.Sort = sort
+	return nil
+}
+
 func takeMatchFilters(r *http.Request, filter string) string {
 	filters := r.URL.Query()[filter]
 
@@ -101,6 +188,18 @@ func takeMatchIDs(r *http.Request) string {
 	return takeMatchFilters(r, "match[id]")
 }
 
+// takeMatchProperties returns the match filters on properties other than id, type and version
+func takeMatchProperties(r *http.Request) map[string]string {
+	properties := map[string]string{}
+
+	for _, p := range cabby.MatchProperties {
+		if values := takeMatchFilters(r, "match["+p+"]"); values != "" {
+			properties[p] = values
+		}
+	}
+	return properties
+}
+
 func takeMatchTypes(r *http.Request) string {
 	return takeMatchFilters(r, "match[type]")
 }
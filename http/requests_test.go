@@ -3,7 +3,9 @@ package http
 import (
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	cabby "github.com/pladdy/cabby2"
 )
@@ -46,6 +48,43 @@ func TestLastURLPathToken(t *testing.T) {
 	}
 }
 
+func TestNewStatusFilter(t *testing.T) {
+	req := httptest.NewRequest(
+		"GET",
+		"/foo/status/?user=foo@bar.com&collection_id=some-id&status=pending&created_after=2016-02-21T05:01:01.000Z&created_before=2016-02-22T05:01:01.123Z",
+		nil)
+
+	expected := cabby.StatusFilter{
+		User:          "foo@bar.com",
+		CollectionID:  "some-id",
+		Status:        "pending",
+		CreatedAfter:  "2016-02-21T05:01:01Z",
+		CreatedBefore: "2016-02-22T05:01:01.123Z"}
+
+	result, err := newStatusFilter(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != expected {
+		t.Error("Got:", result, "Expected:", expected)
+	}
+}
+
+func TestNewStatusFilterInvalidTimestamps(t *testing.T) {
+	tests := []string{
+		"/foo/status/?created_after=after",
+		"/foo/status/?created_before=before",
+		"/foo/status/?created_after=2016-02-21T05:01:01.000Z&created_before=2016-02-22",
+	}
+
+	for _, test := range tests {
+		_, err := newStatusFilter(httptest.NewRequest("GET", test, nil))
+		if err == nil {
+			t.Error("Expected an error for:", test)
+		}
+	}
+}
+
 func TestTakeAddedAfter(t *testing.T) {
 	tests := []struct {
 		request    *http.Request
@@ -102,6 +141,105 @@ func TestTakeMatchIDs(t *testing.T) {
 	}
 }
 
+func TestTakeCursor(t *testing.T) {
+	after := cabby.Cursor{DateAdded: time.Date(2018, 10, 30, 12, 3, 48, 123000000, time.UTC), Row: 7}
+
+	tests := []struct {
+		url         string
+		rangeHeader string
+		expected    cabby.Range
+	}{
+		{"/foo", "", cabby.Range{First: -1, Last: -1}},
+		{"/foo", "items 0-9", cabby.Range{First: 0, Last: 9}},
+		{"/foo?limit=2", "", cabby.Range{First: -1, Last: -1, Limit: 2, Cursored: true}},
+		{"/foo?next=", "", cabby.Range{First: -1, Last: -1, Cursored: true}},
+		{"/foo?next=" + after.String() + "&limit=5", "", cabby.Range{First: -1, Last: -1, After: after, Limit: 5, Cursored: true}},
+		{"/foo?next=" + after.String(), "items 0-9", cabby.Range{First: -1, Last: -1, After: after, Limit: 10, Cursored: true}},
+		{"/foo?next=&limit=3", "items 0-9", cabby.Range{First: -1, Last: -1, Limit: 3, Cursored: true}},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", test.url, nil)
+		cr, _ := cabby.NewRange(test.rangeHeader)
+
+		if err := takeCursor(req, &cr); err != nil {
+			t.Error("Got:", err, "Expected no error", "URL:", test.url)
+		}
+		if cr != test.expected {
+			t.Error("Got:", cr, "Expected:", test.expected, "URL:", test.url)
+		}
+	}
+}
+
+func TestTakeCursorInvalid(t *testing.T) {
+	for _, url := range []string{"/foo?limit=0", "/foo?limit=-1", "/foo?limit=many", "/foo?next=not-a-cursor"} {
+		cr := cabby.Range{First: -1, Last: -1}
+		if err := takeCursor(httptest.NewRequest("GET", url, nil), &cr); err == nil {
+			t.Error("Got no error", "Expected an error", "URL:", url)
+		}
+	}
+}
+
+func TestTakeSort(t *testing.T) {
+	tests := []struct {
+		url      string
+		fields   []string
+		expected cabby.Sort
+	}{
+		{"/foo", cabby.ObjectSorts, cabby.Sort{}},
+		{"/foo?sort=", cabby.ObjectSorts, cabby.Sort{}},
+		{"/foo?sort=-modified", cabby.ObjectSorts, cabby.Sort{Field: cabby.SortModified, Descending: true}},
+		{"/foo?sort=title", cabby.CollectionSorts, cabby.Sort{Field: cabby.SortTitle}},
+	}
+
+	for _, test := range tests {
+		cr := cabby.Range{First: -1, Last: -1}
+		if err := takeSort(httptest.NewRequest("GET", test.url, nil), &cr, test.fields); err != nil {
+			t.Error("Got:", err, "Expected no error", "URL:", test.url)
+		}
+		if cr.Sort != test.expected {
+			t.Error("Got:", cr.Sort, "Expected:", test.expected, "URL:", test.url)
+		}
+	}
+}
+
+func TestTakeSortInvalid(t *testing.T) {
+	tests := []struct {
+		url        string
+		cabbyRange cabby.Range
+	}{
+		{"/foo?sort=title", cabby.Range{First: -1, Last: -1}},
+		{"/foo?sort=id", cabby.Range{First: -1, Last: -1, Cursored: true}},
+	}
+
+	for _, test := range tests {
+		if err := takeSort(httptest.NewRequest("GET", test.url, nil), &test.cabbyRange, cabby.ObjectSorts); err == nil {
+			t.Error("Got no error", "Expected an error", "URL:", test.url)
+		}
+	}
+}
+
+func TestTakeMatchProperties(t *testing.T) {
+	tests := []struct {
+		request  *http.Request
+		expected map[string]string
+	}{
+		{httptest.NewRequest("GET", "/foo/bar/baz", nil), map[string]string{}},
+		{httptest.NewRequest("GET", "/foo/bar/baz?match[labels]=apt&match[labels]=botnet", nil),
+			map[string]string{"labels": "apt,botnet"}},
+		{httptest.NewRequest("GET", "/foo/bar/baz?match[spec_version]=2.1&match[confidence]=85", nil),
+			map[string]string{"spec_version": "2.1", "confidence": "85"}},
+		{httptest.NewRequest("GET", "/foo/bar/baz?match[name]=ivy", nil), map[string]string{}},
+	}
+
+	for _, test := range tests {
+		result := takeMatchProperties(test.request)
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
+
 func TestTakeMatchTypes(t *testing.T) {
 	tests := []struct {
 		request   *http.Request
@@ -1,10 +1,13 @@
 package http
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"net/http"
+	"time"
 
+	cabby "github.com/pladdy/cabby2"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -26,8 +29,156 @@ func writeContent(w http.ResponseWriter, contentType, content string) {
 	io.WriteString(w, content)
 }
 
+// writeContentIfModified writes content unless the client already has it; the content is hashed for its entity tag
+func writeContentIfModified(w http.ResponseWriter, r *http.Request, contentType, content string, updatedAt time.Time) {
+	if notModified(w, r, newETag(content), updatedAt) {
+		return
+	}
+	writeContent(w, contentType, content)
+}
+
 func writePartialContent(w http.ResponseWriter, contentType, content string) {
 	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(http.StatusPartialContent)
 	io.WriteString(w, content)
 }
+
+// setDateAddedHeaders sets the headers for when the first and last objects in a response were added; times that
+// aren't known are left out
+func setDateAddedHeaders(w http.ResponseWriter, first, last time.Time) {
+	if !first.IsZero() {
+		w.Header().Set("X-TAXII-Date-Added-First", first.UTC().Format(cabby.TimestampFormat))
+	}
+	if !last.IsZero() {
+		w.Header().Set("X-TAXII-Date-Added-Last", last.UTC().Format(cabby.TimestampFormat))
+	}
+}
+
+// contentRange returns the range of items a response has.  A cursored page requested with a Range header has the items
+// from its offset, so clients paging with Range headers get the Content-Range they expect.
+func contentRange(r *http.Request, cr cabby.Range) cabby.Range {
+	if !cr.Cursored || r.Header.Get("Range") == "" {
+		return cr
+	}
+
+	last := cr.Total
+	if cr.Limit > 0 && cr.Offset+cr.Limit < last {
+		last = cr.Offset + cr.Limit
+	}
+	return cabby.Range{First: cr.Offset, Last: last - 1, Total: cr.Total}
+}
+
+// pageFields returns the TAXII 2.1 fields of a cursored page requested without a Range header: whether more items
+// follow it and the cursor to read them from
+func pageFields(r *http.Request, cr cabby.Range) map[string]json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	if !cr.Cursored || r.Header.Get("Range") != "" {
+		return fields
+	}
+
+	fields["more"] = json.RawMessage(resourceToJSON(!cr.Next.IsZero()))
+	if !cr.Next.IsZero() {
+		fields["next"] = json.RawMessage(resourceToJSON(cr.Next.String()))
+	}
+	return fields
+}
+
+// setNextLink links to the next page of a cursored range when there is one
+func setNextLink(w http.ResponseWriter, r *http.Request, cr cabby.Range) {
+	if cr.Next.IsZero() {
+		return
+	}
+
+	u := *r.URL
+	q := u.Query()
+	q.Set("next", cr.Next.String())
+	u.RawQuery = q.Encode()
+	w.Header().Set("Link", "<"+u.RequestURI()+`>; rel="next"`)
+}
+
+// writeStreamHeader writes the response header for a resource that's streamed; a valid range is partial content
+func writeStreamHeader(w http.ResponseWriter, contentType string, cr cabby.Range) {
+	w.Header().Set("Content-Type", contentType)
+
+	if cr.Valid() {
+		w.Header().Set("Content-Range", cr.String())
+		w.WriteHeader(http.StatusPartialContent)
+	}
+}
+
+// jsonStreamWriter writes a resource with one array field an element at a time, so the resource is never held in
+// memory.  Once the header is written errors can't be returned to the client; they're kept so they can be logged.
+type jsonStreamWriter struct {
+	w       io.Writer
+	written int
+	err     error
+}
+
+func newJSONStreamWriter(w io.Writer) *jsonStreamWriter {
+	return &jsonStreamWriter{w: w}
+}
+
+// begin writes the fields of the head resource and opens the array field
+func (s *jsonStreamWriter) begin(head interface{}, field string) {
+	fields := map[string]json.RawMessage{}
+
+	b, err := json.Marshal(head)
+	if err == nil {
+		err = json.Unmarshal(b, &fields)
+	}
+	if err != nil {
+		s.err = err
+		return
+	}
+	delete(fields, field)
+
+	b, err = json.Marshal(fields)
+	if err != nil {
+		s.err = err
+		return
+	}
+
+	b = bytes.TrimSuffix(b, []byte("}"))
+	if len(fields) > 0 {
+		b = append(b, ',')
+	}
+
+	s.writeRaw(b)
+	s.writeRaw([]byte(resourceToJSON(field) + ":["))
+}
+
+// write an element of the array that's already JSON
+func (s *jsonStreamWriter) write(b []byte) {
+	if s.written > 0 {
+		s.writeRaw([]byte(","))
+	}
+
+	if len(b) == 0 {
+		b = []byte("null")
+	}
+
+	s.writeRaw(b)
+	s.written++
+}
+
+// writeValue converts a value to JSON and writes it as an element of the array
+func (s *jsonStreamWriter) writeValue(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		s.err = err
+		return
+	}
+	s.write(b)
+}
+
+// end closes the array and the resource
+func (s *jsonStreamWriter) end() {
+	s.writeRaw([]byte("]}"))
+}
+
+func (s *jsonStreamWriter) writeRaw(b []byte) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = s.w.Write(b)
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"bytes"
 	"testing"
 
 	cabby "github.com/pladdy/cabby2"
@@ -41,3 +42,52 @@ func TestResourceToJSONFail(t *testing.T) {
 		t.Error("Got:", result, "Expected: 'recovered' to be true")
 	}
 }
+
+func TestJSONStreamWriter(t *testing.T) {
+	tests := []struct {
+		head     interface{}
+		elements [][]byte
+		expected string
+	}{
+		{cabby.Manifest{}, [][]byte{}, `{"objects":[]}`},
+		{cabby.Manifest{}, [][]byte{[]byte(`{"id":"foo"}`), nil}, `{"objects":[{"id":"foo"},null]}`},
+		{cabby.Manifest{Objects: []cabby.ManifestEntry{{ID: "bar"}}}, [][]byte{[]byte(`{"id":"foo"}`)},
+			`{"objects":[{"id":"foo"}]}`},
+		{map[string]string{"type": "bundle", "id": "bundle--1"}, [][]byte{[]byte(`{}`), []byte(`{}`)},
+			`{"id":"bundle--1","type":"bundle","objects":[{},{}]}`},
+	}
+
+	for _, test := range tests {
+		var b bytes.Buffer
+
+		sw := newJSONStreamWriter(&b)
+		sw.begin(test.head, "objects")
+		for _, e := range test.elements {
+			sw.write(e)
+		}
+		sw.end()
+
+		if sw.err != nil {
+			t.Error("Got:", sw.err, "Expected: no error")
+		}
+		if b.String() != test.expected {
+			t.Error("Got:", b.String(), "Expected:", test.expected)
+		}
+	}
+}
+
+func TestJSONStreamWriterFail(t *testing.T) {
+	var b bytes.Buffer
+
+	sw := newJSONStreamWriter(&b)
+	sw.begin(make(chan int), "objects")
+	sw.writeValue(cabby.ManifestEntry{})
+	sw.end()
+
+	if sw.err == nil {
+		t.Error("Expected an error")
+	}
+	if b.Len() > 0 {
+		t.Error("Got:", b.String(), "Expected nothing written after an error")
+	}
+}
@@ -29,17 +29,26 @@ func registerAPIRoot(ah APIRootHandler, path string, sm *http.ServeMux) {
 }
 
 func registerCollectionRoutes(ds cabby.DataStore, apiRoot cabby.APIRoot, sm *http.ServeMux) {
-	csh := CollectionsHandler{CollectionService: ds.CollectionService()}
+	csh := CollectionsHandler{
+		CollectionService: ds.CollectionService(),
+		DefaultPageSize:   apiRoot.DefaultPageSize,
+		MaxPageSize:       apiRoot.MaxPageSize}
 	registerRoute(sm, apiRoot.Path+"/collections", WithMimeType(RouteRequest(csh), "Accept", cabby.TaxiiContentType))
 
 	ss := ds.StatusService()
 	oh := ObjectsHandler{
 		MaxContentLength: apiRoot.MaxContentLength,
+		DefaultPageSize:  apiRoot.DefaultPageSize,
+		MaxPageSize:      apiRoot.MaxPageSize,
 		ObjectService:    ds.ObjectService(),
 		StatusService:    ss}
 
-	mh := ManifestHandler{ManifestService: ds.ManifestService()}
+	mh := ManifestHandler{
+		ManifestService: ds.ManifestService(),
+		DefaultPageSize: apiRoot.DefaultPageSize,
+		MaxPageSize:     apiRoot.MaxPageSize}
 	ch := CollectionHandler{CollectionService: ds.CollectionService()}
+	th := TombstonesHandler{ObjectService: ds.ObjectService()}
 
 	acs, err := csh.CollectionService.CollectionsInAPIRoot(context.Background(), apiRoot.Path)
 	if err != nil {
@@ -60,6 +69,10 @@ func registerCollectionRoutes(ds cabby.DataStore, apiRoot cabby.APIRoot, sm *htt
 			sm,
 			apiRoot.Path+"/collections/"+collectionID.String()+"/manifest",
 			WithMimeType(RouteRequest(mh), "Accept", cabby.TaxiiContentType))
+		registerRoute(
+			sm,
+			apiRoot.Path+"/collections/"+collectionID.String()+"/tombstones",
+			WithMimeType(RouteRequest(th), "Accept", cabby.TaxiiContentType))
 	}
 
 	sh := StatusHandler{StatusService: ss}
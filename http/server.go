@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"net/http"
 	"strconv"
+	"time"
 
 	cabby "github.com/pladdy/cabby2"
 	log "github.com/sirupsen/logrus"
@@ -18,6 +19,7 @@ func NewCabby(ds cabby.DataStore, c cabby.Config) *http.Server {
 	dh := DiscoveryHandler{DiscoveryService: ds.DiscoveryService(), Port: c.Port}
 	registerRoute(handler, "taxii", WithMimeType(RouteRequest(dh), "Accept", cabby.TaxiiContentType))
 
+	registerRoute(handler, "admin/metrics", handleMetrics)
 	registerRoute(handler, "/", handleUndefinedRoute)
 
 	return setupServer(ds, handler, c)
@@ -29,12 +31,25 @@ func setupServer(ds cabby.DataStore, h http.Handler, c cabby.Config) *http.Serve
 
 	return &http.Server{
 		Addr:         ":" + p,
-		Handler:      withBasicAuth(withRequestLogging(h), ds.UserService()),
+		Handler:      withCompression(withRequestTimeout(withBasicAuth(withRequestLogging(h), ds.UserService()), requestTimeout(c))),
 		TLSConfig:    setupTLS(),
 		TLSNextProto: make(map[string]func(*http.Server, *tls.Conn, http.Handler)),
 	}
 }
 
+// requestTimeout returns how long a request has to start its response; without one it has as long as the client waits
+func requestTimeout(c cabby.Config) time.Duration {
+	if c.RequestTimeout == "" {
+		return 0
+	}
+
+	timeout, err := time.ParseDuration(c.RequestTimeout)
+	if err != nil || timeout < 0 {
+		log.WithFields(log.Fields{"error": err, "request_timeout": c.RequestTimeout}).Panic("Invalid request timeout")
+	}
+	return timeout
+}
+
 func setupTLS() *tls.Config {
 	return &tls.Config{
 		MinVersion:               tls.VersionTLS12,
@@ -9,6 +9,7 @@ import (
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	cabby "github.com/pladdy/cabby2"
 	"github.com/pladdy/cabby2/tester"
@@ -163,6 +164,33 @@ func TestSetupServerSettings(t *testing.T) {
 	}
 }
 
+func TestRequestTimeout(t *testing.T) {
+	tests := []struct {
+		timeout  string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"30s", 30 * time.Second},
+	}
+
+	for _, test := range tests {
+		result := requestTimeout(cabby.Config{RequestTimeout: test.timeout})
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
+
+func TestRequestTimeoutInvalid(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+
+	requestTimeout(cabby.Config{RequestTimeout: "forever"})
+}
+
 func TestSetupTLS(t *testing.T) {
 	tlsSetup := setupTLS()
 
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	cabby "github.com/pladdy/cabby2"
+	log "github.com/sirupsen/logrus"
 )
 
 // StatusHandler holds a cabby StatusService
@@ -14,13 +15,19 @@ type StatusHandler struct {
 
 // Get serves a status resource
 func (h StatusHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if takeStatusID(r) == "" {
+		h.getStatuses(w, r)
+		return
+	}
+
 	status, err := h.StatusService.Status(r.Context(), takeStatusID(r))
 	if err != nil {
 		internalServerError(w, err)
 		return
 	}
 
-	if status.TotalCount <= 0 {
+	// a status is created before any objects are counted
+	if status.ID.IsEmpty() {
 		resourceNotFound(w, errors.New("No status available for this id"))
 		return
 	}
@@ -28,6 +35,67 @@ func (h StatusHandler) Get(w http.ResponseWriter, r *http.Request) {
 	writeContent(w, cabby.TaxiiContentType, resourceToJSON(status))
 }
 
+// statusEntry includes the internal status fields for admins
+type statusEntry struct {
+	cabby.Status
+	User         string `json:"user,omitempty"`
+	CollectionID string `json:"collection_id,omitempty"`
+	CreatedAt    string `json:"created_at,omitempty"`
+}
+
+type statusList struct {
+	Statuses []statusEntry `json:"statuses"`
+}
+
+func (h StatusHandler) getStatuses(w http.ResponseWriter, r *http.Request) {
+	log.WithFields(log.Fields{"handler": "StatusHandler"}).Debug("Handler called")
+
+	if !cabby.TakeUser(r.Context()).CanAdmin {
+		forbidden(w, errors.New("Unauthorized to list statuses"))
+		return
+	}
+
+	cr, err := cabby.NewRange(r.Header.Get("Range"))
+	if err != nil {
+		rangeNotSatisfiable(w, err)
+		return
+	}
+
+	f, err := newStatusFilter(r)
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+
+	statuses, err := h.StatusService.Statuses(r.Context(), &cr, f)
+	if err != nil {
+		internalServerError(w, err)
+		return
+	}
+
+	if len(statuses) <= 0 {
+		resourceNotFound(w, errors.New("No statuses available"))
+		return
+	}
+
+	sl := statusList{}
+	for _, s := range statuses {
+		sl.Statuses = append(sl.Statuses, statusEntry{Status: s, User: s.User, CollectionID: s.CollectionID, CreatedAt: s.CreatedAt})
+	}
+
+	if cr.Valid() {
+		w.Header().Set("Content-Range", cr.String())
+		writePartialContent(w, cabby.TaxiiContentType, resourceToJSON(sl))
+	} else {
+		writeContent(w, cabby.TaxiiContentType, resourceToJSON(sl))
+	}
+}
+
+// Delete handles a delete request
+func (h StatusHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
+}
+
 // Post handles post request
 func (h StatusHandler) Post(w http.ResponseWriter, r *http.Request) {
 	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
@@ -88,6 +88,99 @@ func TestStatusHandlerGetNoStatus(t *testing.T) {
 	}
 }
 
+func TestStatusHandlerGetStatuses(t *testing.T) {
+	h := StatusHandler{StatusService: mockStatusService()}
+	status, body := handlerTest(h.Get, "GET", testStatusesURL, nil)
+
+	if status != http.StatusOK {
+		t.Error("Got:", status, "Expected:", http.StatusOK)
+	}
+
+	var result statusList
+	err := json.Unmarshal([]byte(body), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Statuses) != 1 {
+		t.Fatal("Got:", len(result.Statuses), "Expected:", 1)
+	}
+
+	passed := tester.CompareStatus(result.Statuses[0].Status, tester.Status)
+	if !passed {
+		t.Error("Comparison failed")
+	}
+}
+
+func TestStatusHandlerGetStatusesRange(t *testing.T) {
+	ms := mockStatusService()
+	ms.StatusesFn = func(ctx context.Context, cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+		cr.Total = 3
+		return []cabby.Status{tester.Status}, nil
+	}
+
+	h := StatusHandler{StatusService: ms}
+	req := newRequest("GET", testStatusesURL, nil)
+	req.Header.Set("Range", "items 0-0")
+	status, _, headers := callHandler(h.Get, req.WithContext(cabby.WithUser(req.Context(), tester.User)))
+
+	if status != http.StatusPartialContent {
+		t.Error("Got:", status, "Expected:", http.StatusPartialContent)
+	}
+
+	expected := "items 0-0/3"
+	if headers.Get("Content-Range") != expected {
+		t.Error("Got:", headers.Get("Content-Range"), "Expected:", expected)
+	}
+}
+
+func TestStatusHandlerGetStatusesFailures(t *testing.T) {
+	noStatuses := mockStatusService()
+	noStatuses.StatusesFn = func(ctx context.Context, cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+		return []cabby.Status{}, nil
+	}
+
+	statusesErr := mockStatusService()
+	statusesErr.StatusesFn = func(ctx context.Context, cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+		return []cabby.Status{}, errors.New("Statuses failure")
+	}
+
+	tests := []struct {
+		service  tester.StatusService
+		user     cabby.User
+		url      string
+		rangeStr string
+		expected int
+	}{
+		{mockStatusService(), cabby.User{Email: tester.UserEmail}, testStatusesURL, "", http.StatusForbidden},
+		{mockStatusService(), tester.User, testStatusesURL, "invalid", http.StatusRequestedRangeNotSatisfiable},
+		{mockStatusService(), tester.User, testStatusesURL + "?created_after=invalid", "", http.StatusBadRequest},
+		{mockStatusService(), tester.User, testStatusesURL + "?created_before=invalid", "", http.StatusBadRequest},
+		{noStatuses, tester.User, testStatusesURL, "", http.StatusNotFound},
+		{statusesErr, tester.User, testStatusesURL, "", http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		h := StatusHandler{StatusService: test.service}
+		req := newRequest("GET", test.url, nil)
+		req.Header.Set("Range", test.rangeStr)
+		status, _, _ := callHandler(h.Get, req.WithContext(cabby.WithUser(req.Context(), test.user)))
+
+		if status != test.expected {
+			t.Error("Got:", status, "Expected:", test.expected)
+		}
+	}
+}
+
+func TestStatusHandleDelete(t *testing.T) {
+	h := StatusHandler{StatusService: mockStatusService()}
+	status, _ := handlerTest(h.Delete, "DELETE", testStatusURL, nil)
+
+	if status != http.StatusMethodNotAllowed {
+		t.Error("Got:", status, "Expected:", http.StatusMethodNotAllowed)
+	}
+}
+
 func TestStatusHandlePost(t *testing.T) {
 	h := StatusHandler{StatusService: mockStatusService()}
 	status, _ := handlerTest(h.Post, "POST", testStatusURL, nil)
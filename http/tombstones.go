@@ -0,0 +1,42 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	cabby "github.com/pladdy/cabby2"
+	log "github.com/sirupsen/logrus"
+)
+
+// TombstonesHandler holds a cabby ObjectService
+type TombstonesHandler struct {
+	ObjectService cabby.ObjectService
+}
+
+// Delete handles a delete request
+func (h TombstonesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
+}
+
+// Get serves the object versions deleted from a collection; added_after limits them to versions deleted after it
+func (h TombstonesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	log.WithFields(log.Fields{"handler": "TombstonesHandler"}).Debug("Handler called")
+
+	tombstones, err := h.ObjectService.Tombstones(r.Context(), takeCollectionID(r), cabby.Filter{AddedAfter: takeAddedAfter(r)})
+	if err != nil {
+		internalServerError(w, err)
+		return
+	}
+
+	if len(tombstones) <= 0 {
+		resourceNotFound(w, errors.New("No objects deleted from this collection"))
+		return
+	}
+
+	writeContent(w, cabby.TaxiiContentType, resourceToJSON(cabby.Tombstones{Objects: tombstones}))
+}
+
+// Post handles post request
+func (h TombstonesHandler) Post(w http.ResponseWriter, r *http.Request) {
+	methodNotAllowed(w, errors.New("HTTP Method "+r.Method+" unrecognized"))
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestTombstonesHandlerGet(t *testing.T) {
+	var result cabby.Filter
+
+	s := mockObjectService()
+	s.TombstonesFn = func(ctx context.Context, collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+		result = f
+		return []cabby.Tombstone{tester.Tombstone}, nil
+	}
+
+	h := TombstonesHandler{ObjectService: s}
+	status, body := handlerTest(h.Get, "GET", testTombstonesURL+"?added_after=2016-04-06T20:07:09.000Z", nil)
+
+	if status != http.StatusOK {
+		t.Error("Got:", status, "Expected:", http.StatusOK)
+	}
+	if result.AddedAfter != "2016-04-06T20:07:09Z" {
+		t.Error("Got:", result.AddedAfter, "Expected:", "2016-04-06T20:07:09Z")
+	}
+
+	var tombstones cabby.Tombstones
+	err := json.Unmarshal([]byte(body), &tombstones)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tombstones.Objects) != 1 || tombstones.Objects[0] != tester.Tombstone {
+		t.Error("Got:", tombstones.Objects, "Expected:", tester.Tombstone)
+	}
+}
+
+func TestTombstonesHandlerGetFailures(t *testing.T) {
+	s := mockObjectService()
+	s.TombstonesFn = func(ctx context.Context, collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+		return []cabby.Tombstone{}, errors.New("Tombstones failure")
+	}
+
+	none := mockObjectService()
+	none.TombstonesFn = func(ctx context.Context, collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+		return []cabby.Tombstone{}, nil
+	}
+
+	tests := []struct {
+		service tester.ObjectService
+		status  int
+	}{
+		{s, http.StatusInternalServerError},
+		{none, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		h := TombstonesHandler{ObjectService: test.service}
+		status, _ := handlerTest(h.Get, "GET", testTombstonesURL, nil)
+
+		if status != test.status {
+			t.Error("Got:", status, "Expected:", test.status)
+		}
+	}
+}
+
+func TestTombstonesHandleDelete(t *testing.T) {
+	h := TombstonesHandler{ObjectService: mockObjectService()}
+	status, _ := handlerTest(h.Delete, "DELETE", testTombstonesURL, nil)
+
+	if status != http.StatusMethodNotAllowed {
+		t.Error("Got:", status, "Expected:", http.StatusMethodNotAllowed)
+	}
+}
+
+func TestTombstonesHandlePost(t *testing.T) {
+	h := TombstonesHandler{ObjectService: mockObjectService()}
+	status, _ := handlerTest(h.Post, "POST", testTombstonesURL, nil)
+
+	if status != http.StatusMethodNotAllowed {
+		t.Error("Got:", status, "Expected:", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,82 @@
+package cabby
+
+import (
+	"context"
+	"expvar"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// JobMetrics has the counts of what background jobs have done since the server started
+var JobMetrics = expvar.NewMap("cabby_jobs")
+
+// JobFunc is work done by a background job
+type JobFunc func(ctx context.Context) error
+
+// RunJob calls a JobFunc every interval until the context is done
+func RunJob(ctx context.Context, name string, interval time.Duration, fn JobFunc) {
+	log.WithFields(log.Fields{"job": name, "interval": interval.String()}).Info("Starting background job")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.WithFields(log.Fields{"job": name}).Info("Stopping background job")
+			return
+		case <-ticker.C:
+			start := time.Now().In(time.UTC)
+
+			err := fn(ctx)
+			if err != nil {
+				log.WithFields(log.Fields{"job": name, "error": err}).Error("Background job failed")
+				continue
+			}
+
+			log.WithFields(log.Fields{
+				"elapsed_ms": float64(time.Since(start).Nanoseconds()) / float64(milliSecondOfNanoSeconds),
+				"job":        name,
+			}).Info("Background job finished")
+		}
+	}
+}
+
+// PurgeStatusesJob returns a JobFunc that deletes statuses older than maxAge
+func PurgeStatusesJob(ss StatusService, maxAge time.Duration) JobFunc {
+	return func(ctx context.Context) error {
+		before := time.Now().In(time.UTC).Add(-maxAge)
+
+		purged, err := ss.PurgeStatuses(ctx, before)
+		if err == nil {
+			JobMetrics.Add("statuses_purged", purged)
+			log.WithFields(log.Fields{"before": before.Format(time.RFC3339Nano), "purged": purged}).Info("Purged statuses")
+		}
+		return err
+	}
+}
+
+// CompactObjectsJob returns a JobFunc that deletes the object versions collections don't retain
+func CompactObjectsJob(os ObjectService) JobFunc {
+	return func(ctx context.Context) error {
+		compacted, err := os.CompactObjects(ctx)
+		if err == nil {
+			JobMetrics.Add("object_versions_compacted", compacted)
+			log.WithFields(log.Fields{"compacted": compacted}).Info("Compacted object versions")
+		}
+		return err
+	}
+}
+
+// PurgeObjectsJob returns a JobFunc that deletes the objects that have expired in their collections
+func PurgeObjectsJob(os ObjectService) JobFunc {
+	return func(ctx context.Context) error {
+		purged, err := os.PurgeObjects(ctx)
+		if err == nil {
+			JobMetrics.Add("objects_purged", purged)
+			log.WithFields(log.Fields{"purged": purged}).Info("Purged expired objects")
+		}
+		return err
+	}
+}
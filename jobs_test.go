@@ -0,0 +1,174 @@
+package cabby
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+)
+
+// only PurgeStatuses is implemented; calling anything else will panic
+type purgeStatusService struct {
+	StatusService
+	before time.Time
+	err    error
+}
+
+func (s *purgeStatusService) PurgeStatuses(ctx context.Context, before time.Time) (int64, error) {
+	s.before = before
+	return 1, s.err
+}
+
+// only CompactObjects is implemented; calling anything else will panic
+type compactObjectService struct {
+	ObjectService
+	calls int
+	err   error
+}
+
+func (s *compactObjectService) CompactObjects(ctx context.Context) (int64, error) {
+	s.calls++
+	return 1, s.err
+}
+
+// only PurgeObjects is implemented; calling anything else will panic
+type purgeObjectService struct {
+	ObjectService
+	purged int64
+	err    error
+}
+
+func (s *purgeObjectService) PurgeObjects(ctx context.Context) (int64, error) {
+	return s.purged, s.err
+}
+
+// jobMetric returns a count in the job metrics
+func jobMetric(name string) int64 {
+	if v, ok := JobMetrics.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestRunJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan bool, 10)
+
+	done := make(chan bool)
+	go func() {
+		RunJob(ctx, t.Name(), time.Millisecond, func(ctx context.Context) error {
+			calls <- true
+			return nil
+		})
+		done <- true
+	}()
+
+	<-calls
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Expected job to stop when context is canceled")
+	}
+}
+
+func TestRunJobError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan bool, 10)
+
+	go RunJob(ctx, t.Name(), time.Millisecond, func(ctx context.Context) error {
+		calls <- true
+		return errors.New("job failed")
+	})
+
+	// a failed run shouldn't stop the job
+	<-calls
+	<-calls
+	cancel()
+}
+
+func TestPurgeStatusesJob(t *testing.T) {
+	tests := []struct {
+		err         error
+		expectError bool
+	}{
+		{nil, false},
+		{errors.New("purge failed"), true},
+	}
+
+	for _, test := range tests {
+		ss := purgeStatusService{err: test.err}
+		maxAge := time.Hour
+
+		err := PurgeStatusesJob(&ss, maxAge)(context.Background())
+		if test.expectError && err == nil {
+			t.Error("Expected an error")
+		}
+		if !test.expectError && err != nil {
+			t.Error("Got:", err, "Expected: no error")
+		}
+
+		expected := time.Now().In(time.UTC).Add(-maxAge)
+		if ss.before.After(expected) || expected.Sub(ss.before) > time.Minute {
+			t.Error("Got:", ss.before, "Expected about:", expected)
+		}
+	}
+}
+
+func TestCompactObjectsJob(t *testing.T) {
+	tests := []struct {
+		err         error
+		expectError bool
+	}{
+		{nil, false},
+		{errors.New("compaction failed"), true},
+	}
+
+	for _, test := range tests {
+		os := compactObjectService{err: test.err}
+
+		err := CompactObjectsJob(&os)(context.Background())
+		if test.expectError && err == nil {
+			t.Error("Expected an error")
+		}
+		if !test.expectError && err != nil {
+			t.Error("Got:", err, "Expected: no error")
+		}
+
+		if os.calls != 1 {
+			t.Error("Got:", os.calls, "Expected:", 1)
+		}
+	}
+}
+
+func TestPurgeObjectsJob(t *testing.T) {
+	tests := []struct {
+		purged      int64
+		err         error
+		expectError bool
+		expected    int64
+	}{
+		{3, nil, false, 3},
+		{3, errors.New("purge failed"), true, 0},
+	}
+
+	for _, test := range tests {
+		os := purgeObjectService{purged: test.purged, err: test.err}
+		before := jobMetric("objects_purged")
+
+		err := PurgeObjectsJob(&os)(context.Background())
+		if test.expectError && err == nil {
+			t.Error("Expected an error")
+		}
+		if !test.expectError && err != nil {
+			t.Error("Got:", err, "Expected: no error")
+		}
+
+		result := jobMetric("objects_purged") - before
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
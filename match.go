@@ -0,0 +1,182 @@
+package cabby
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Match properties are the object properties, besides id, type and version, that objects can be filtered on with a
+// match[<property>] parameter; they're the common properties of the TAXII 2.1 interoperability filtering extension
+const (
+	MatchConfidence       = "confidence"
+	MatchCreatedByRef     = "created_by_ref"
+	MatchExternalID       = "external_id"
+	MatchLabels           = "labels"
+	MatchRelationshipType = "relationship_type"
+	MatchSourceRef        = "source_ref"
+	MatchSpecVersion      = "spec_version"
+	MatchTargetRef        = "target_ref"
+)
+
+// DefaultSpecVersion is the spec version matched for objects without one; STIX 2.0 objects don't have the property
+const DefaultSpecVersion = "2.0"
+
+// MatchProperties lists the properties objects can be filtered on
+var MatchProperties = []string{
+	MatchConfidence,
+	MatchCreatedByRef,
+	MatchExternalID,
+	MatchLabels,
+	MatchRelationshipType,
+	MatchSourceRef,
+	MatchSpecVersion,
+	MatchTargetRef,
+}
+
+// Version filter keywords; a version filter is a list of them and modified timestamps, and any other keyword is the
+// latest version
+const (
+	VersionAll   = "all"
+	VersionFirst = "first"
+	VersionLast  = "last"
+)
+
+// VersionPlaces returns the places among an object's versions, 'first', 'last' or 'only', that a version filter
+// keyword matches; it's nil for 'all', which matches every version
+func VersionPlaces(keyword string) []string {
+	switch keyword {
+	case VersionAll:
+		return nil
+	case VersionFirst:
+		return []string{"first", "only"}
+	}
+	return []string{"last", "only"}
+}
+
+// Match returns whether an object is in the filter for data stores that filter objects themselves; the place is the
+// object's place among the versions in its collection: 'first', 'last' or 'only'
+func (f Filter) Match(o Object, place string, createdAt time.Time) bool {
+	if !f.MatchesAddedAfter(createdAt) || !f.MatchesVersion(o.Modified, place) {
+		return false
+	}
+
+	if len(f.IDs) > 0 && !anyIn([]string{string(o.ID)}, strings.Split(f.IDs, ",")) {
+		return false
+	}
+
+	if len(f.Types) > 0 && !anyIn([]string{o.Type}, strings.Split(f.Types, ",")) {
+		return false
+	}
+
+	return f.MatchesProperties(o.Object)
+}
+
+// MatchesAddedAfter returns whether a time is after the filter's added after, at millisecond precision; like sqlite,
+// an added after that can't be parsed matches nothing
+func (f Filter) MatchesAddedAfter(t time.Time) bool {
+	if f.AddedAfter == "" {
+		return true
+	}
+
+	addedAfter, err := time.Parse(time.RFC3339Nano, f.AddedAfter)
+	if err != nil {
+		return false
+	}
+	return t.Truncate(time.Millisecond).After(addedAfter.Truncate(time.Millisecond))
+}
+
+// MatchesVersion returns whether an object version is in the filter's versions given its modified timestamp and its
+// place among the versions in its collection
+func (f Filter) MatchesVersion(modified, place string) bool {
+	if f.Versions == "" {
+		return true
+	}
+
+	for _, v := range strings.Split(f.Versions, ",") {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err == nil {
+			if sameMillisecond(modified, t) {
+				return true
+			}
+			continue
+		}
+
+		places := VersionPlaces(v)
+		if places == nil || anyIn([]string{place}, places) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesProperties returns whether an object has one of the values of each property in the filter.  Labels match
+// any of an object's labels and external ids match the id of any of its external references.
+func (f Filter) MatchesProperties(object []byte) bool {
+	if len(f.Properties) == 0 {
+		return true
+	}
+
+	var o map[string]interface{}
+	if err := json.Unmarshal(object, &o); err != nil {
+		return false
+	}
+
+	for property, raw := range f.Properties {
+		if raw == "" {
+			continue
+		}
+		if !anyIn(propertyValues(o, property), strings.Split(raw, ",")) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyIn(values, in []string) bool {
+	for _, v := range values {
+		for _, i := range in {
+			if v == i {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// propertyValues returns the values of a match property in an object as strings
+func propertyValues(o map[string]interface{}, property string) []string {
+	switch property {
+	case MatchExternalID:
+		var values []string
+		references, _ := o["external_references"].([]interface{})
+		for _, r := range references {
+			reference, _ := r.(map[string]interface{})
+			values = append(values, propertyString(reference["external_id"])...)
+		}
+		return values
+	case MatchLabels:
+		var values []string
+		labels, _ := o["labels"].([]interface{})
+		for _, l := range labels {
+			values = append(values, propertyString(l)...)
+		}
+		return values
+	case MatchSpecVersion:
+		if _, ok := o[property]; !ok {
+			return []string{DefaultSpecVersion}
+		}
+	}
+	return propertyString(o[property])
+}
+
+func propertyString(v interface{}) []string {
+	switch value := v.(type) {
+	case string:
+		return []string{value}
+	case float64:
+		return []string{strconv.FormatFloat(value, 'f', -1, 64)}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package cabby
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterMatchesProperties(t *testing.T) {
+	object := []byte(`{
+		"type": "indicator",
+		"id": "indicator--1",
+		"spec_version": "2.1",
+		"created_by_ref": "identity--1",
+		"confidence": 85,
+		"labels": ["malicious-activity", "apt"],
+		"external_references": [{"source_name": "capec", "external_id": "CAPEC-163"}, {"source_name": "url"}]
+	}`)
+	noSpecVersion := []byte(`{"type": "indicator", "id": "indicator--2"}`)
+
+	tests := []struct {
+		object     []byte
+		properties map[string]string
+		expected   bool
+	}{
+		{object, nil, true},
+		{object, map[string]string{MatchSpecVersion: "2.1"}, true},
+		{object, map[string]string{MatchSpecVersion: "2.0"}, false},
+		{noSpecVersion, map[string]string{MatchSpecVersion: "2.0"}, true},
+		{object, map[string]string{MatchCreatedByRef: "identity--2,identity--1"}, true},
+		{object, map[string]string{MatchConfidence: "85"}, true},
+		{object, map[string]string{MatchConfidence: "50"}, false},
+		{object, map[string]string{MatchLabels: "apt"}, true},
+		{object, map[string]string{MatchLabels: "benign"}, false},
+		{object, map[string]string{MatchExternalID: "CAPEC-163"}, true},
+		{object, map[string]string{MatchExternalID: "CAPEC-1"}, false},
+		{object, map[string]string{MatchLabels: "apt", MatchConfidence: "50"}, false},
+		{noSpecVersion, map[string]string{MatchLabels: "apt"}, false},
+		{[]byte(`not json`), map[string]string{MatchLabels: "apt"}, false},
+	}
+
+	for _, test := range tests {
+		result := Filter{Properties: test.properties}.MatchesProperties(test.object)
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "Properties:", test.properties)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	created := time.Date(2018, 10, 30, 12, 3, 48, 123000000, time.UTC)
+
+	o := Object{
+		ID:       "indicator--8e2e2d2b-17d4-4cbf-938f-98ee46b3cd3f",
+		Type:     "indicator",
+		Modified: "2018-10-30T12:03:48.123Z",
+		Object:   []byte(`{"created_by_ref": "identity--1"}`)}
+
+	tests := []struct {
+		filter   Filter
+		place    string
+		expected bool
+	}{
+		{Filter{}, "only", true},
+		{Filter{AddedAfter: "2018-10-30T12:03:48.122Z"}, "only", true},
+		{Filter{AddedAfter: "2018-10-30T12:03:48.123Z"}, "only", false},
+		{Filter{AddedAfter: "not a time"}, "only", false},
+		{Filter{IDs: "indicator--8e2e2d2b-17d4-4cbf-938f-98ee46b3cd3f"}, "only", true},
+		{Filter{IDs: "indicator--1,indicator--8e2e2d2b-17d4-4cbf-938f-98ee46b3cd3f"}, "only", true},
+		{Filter{Types: "malware"}, "only", false},
+		{Filter{Types: "malware,indicator"}, "only", true},
+		{Filter{Versions: "2018-10-30T12:03:48.123Z"}, "first", true},
+		{Filter{Versions: "2018-10-30T12:03:48.124Z"}, "first", false},
+		{Filter{Versions: "first"}, "last", false},
+		{Filter{Versions: "first"}, "only", true},
+		{Filter{Versions: "last"}, "last", true},
+		{Filter{Versions: "first,last"}, "last", true},
+		{Filter{Versions: "all"}, "", true},
+		{Filter{Properties: map[string]string{MatchCreatedByRef: "identity--1"}}, "only", true},
+		{Filter{Properties: map[string]string{MatchCreatedByRef: "identity--2"}}, "only", false},
+	}
+
+	for _, test := range tests {
+		result := test.filter.Match(o, test.place, created)
+
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "Filter:", test.filter, "Place:", test.place)
+		}
+	}
+}
+
+func TestVersionPlaces(t *testing.T) {
+	tests := []struct {
+		keyword  string
+		expected []string
+	}{
+		{VersionAll, nil},
+		{VersionFirst, []string{"first", "only"}},
+		{VersionLast, []string{"last", "only"}},
+		{"latest", []string{"last", "only"}},
+	}
+
+	for _, test := range tests {
+		result := VersionPlaces(test.keyword)
+
+		if strings.Join(result, ",") != strings.Join(test.expected, ",") || (result == nil) != (test.expected == nil) {
+			t.Error("Got:", result, "Expected:", test.expected, "Keyword:", test.keyword)
+		}
+	}
+}
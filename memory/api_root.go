@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// APIRootService implements an in-memory version of the APIRootService interface
+type APIRootService struct {
+	DataStore *DataStore
+}
+
+type apiRootRecord struct {
+	path             string
+	title            string
+	description      string
+	versions         string
+	maxContentLength int64
+	defaultPageSize  int64
+	maxPageSize      int64
+	updatedAt        time.Time
+}
+
+func (r *apiRootRecord) apiRoot() cabby.APIRoot {
+	return cabby.APIRoot{
+		Path:             r.path,
+		Title:            r.title,
+		Description:      r.description,
+		Versions:         strings.Split(r.versions, ","),
+		MaxContentLength: r.maxContentLength,
+		DefaultPageSize:  r.defaultPageSize,
+		MaxPageSize:      r.maxPageSize,
+		UpdatedAt:        r.updatedAt}
+}
+
+// APIRoot will read from the data store and return the resource
+func (s APIRootService) APIRoot(ctx context.Context, path string) (cabby.APIRoot, error) {
+	resource, action := "APIRoot", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.apiRoot(path)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s APIRootService) apiRoot(path string) (cabby.APIRoot, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	if r := s.DataStore.findAPIRoot(path); r != nil {
+		return r.apiRoot(), nil
+	}
+	return cabby.APIRoot{}, nil
+}
+
+// APIRoots will read from the data store and return the resource
+func (s APIRootService) APIRoots(ctx context.Context) ([]cabby.APIRoot, error) {
+	resource, action := "APIRoots", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.apiRoots()
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s APIRootService) apiRoots() ([]cabby.APIRoot, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	as := []cabby.APIRoot{}
+	for _, r := range s.DataStore.apiRoots {
+		as = append(as, r.apiRoot())
+	}
+	return as, nil
+}
+
+// CreateAPIRoot creates a user in the data store
+func (s APIRootService) CreateAPIRoot(ctx context.Context, a cabby.APIRoot) error {
+	resource, action := "APIRoot", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := a.Validate()
+	if err == nil {
+		err = s.createAPIRoot(a)
+	} else {
+		log.WithFields(log.Fields{"api_root": a, "error": err}).Error("Invalid API Root")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s APIRootService) createAPIRoot(a cabby.APIRoot) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	if s.DataStore.findAPIRoot(a.Path) != nil {
+		return fmt.Errorf("API root %s already exists", a.Path)
+	}
+
+	s.DataStore.apiRoots = append(s.DataStore.apiRoots, &apiRootRecord{
+		path:             a.Path,
+		title:            a.Title,
+		description:      a.Description,
+		versions:         strings.Join(a.Versions, ","),
+		maxContentLength: a.MaxContentLength,
+		defaultPageSize:  a.DefaultPageSize,
+		maxPageSize:      a.MaxPageSize,
+		updatedAt:        now()})
+	return nil
+}
+
+// DeleteAPIRoot creates a user in the data store
+func (s APIRootService) DeleteAPIRoot(ctx context.Context, id string) error {
+	resource, action := "APIRoot", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.deleteAPIRoot(id)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s APIRootService) deleteAPIRoot(path string) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	kept := s.DataStore.apiRoots[:0]
+	for _, r := range s.DataStore.apiRoots {
+		if r.path != path {
+			kept = append(kept, r)
+		}
+	}
+	s.DataStore.apiRoots = kept
+	return nil
+}
+
+// UpdateAPIRoot creates a user in the data store
+func (s APIRootService) UpdateAPIRoot(ctx context.Context, a cabby.APIRoot) error {
+	resource, action := "APIRoot", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := a.Validate()
+	if err == nil {
+		err = s.updateAPIRoot(a)
+	} else {
+		log.WithFields(log.Fields{"api_root": a, "error": err}).Error("Invalid API Root")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s APIRootService) updateAPIRoot(a cabby.APIRoot) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	if r := s.DataStore.findAPIRoot(a.Path); r != nil {
+		r.title = a.Title
+		r.description = a.Description
+		r.versions = strings.Join(a.Versions, ",")
+		r.maxContentLength = a.MaxContentLength
+		r.defaultPageSize = a.DefaultPageSize
+		r.maxPageSize = a.MaxPageSize
+		r.updatedAt = now()
+	}
+	return nil
+}
+
+// findAPIRoot expects the lock to be held
+func (s *DataStore) findAPIRoot(path string) *apiRootRecord {
+	for _, r := range s.apiRoots {
+		if r.path == path {
+			return r
+		}
+	}
+	return nil
+}
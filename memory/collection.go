@@ -0,0 +1,256 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// CollectionService implements an in-memory version of the CollectionService interface
+type CollectionService struct {
+	DataStore *DataStore
+}
+
+type collectionRecord struct {
+	id             cabby.ID
+	apiRootPath    string
+	title          string
+	description    string
+	mediaTypes     string
+	versionPolicy  string
+	retainVersions int
+	retainWindow   time.Duration
+	expireBy       string
+	maxAge         time.Duration
+	updatedAt      time.Time
+}
+
+// collection returns the collection with a user's access to it
+func (r *collectionRecord) collection(uc *userCollectionRecord) cabby.Collection {
+	return cabby.Collection{
+		ID:             r.id,
+		Title:          r.title,
+		Description:    r.description,
+		CanRead:        uc.canRead,
+		CanWrite:       uc.canWrite,
+		MediaTypes:     strings.Split(r.mediaTypes, ","),
+		VersionPolicy:  r.versionPolicy,
+		RetainVersions: r.retainVersions,
+		RetainWindow:   r.retainWindow,
+		ExpireBy:       r.expireBy,
+		MaxAge:         r.maxAge,
+		UpdatedAt:      cabby.LaterTime(r.updatedAt, uc.updatedAt)}
+}
+
+// Collection will read from the data store and return the resource
+func (s CollectionService) Collection(ctx context.Context, apiRootPath, collectionID string) (cabby.Collection, error) {
+	resource, action := "Collection", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.collection(cabby.TakeUser(ctx).Email, apiRootPath, collectionID)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s CollectionService) collection(user, apiRootPath, collectionID string) (cabby.Collection, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	c := cabby.Collection{}
+
+	r := s.DataStore.findCollection(collectionID)
+	if r == nil || r.apiRootPath != apiRootPath {
+		return c, nil
+	}
+
+	uc := s.DataStore.findUserCollection(user, collectionID)
+	if uc == nil || !uc.canRead {
+		return c, nil
+	}
+	return r.collection(uc), nil
+}
+
+// Collections will read from the data store and return the resource
+func (s CollectionService) Collections(ctx context.Context, apiRootPath string, cr *cabby.Range) (cabby.Collections, error) {
+	resource, action := "Collections", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.collections(cabby.TakeUser(ctx).Email, apiRootPath, cr)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// like the sql data stores, the range total and latest update are only set if the range has collections
+func (s CollectionService) collections(user, apiRootPath string, cr *cabby.Range) (cabby.Collections, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	var data []cabby.Collection
+	var lastUpdatedAt time.Time
+
+	for _, r := range s.DataStore.collections {
+		if r.apiRootPath != apiRootPath {
+			continue
+		}
+
+		uc := s.DataStore.findUserCollection(user, r.id.String())
+		if uc == nil || !(uc.canRead || uc.canWrite) {
+			continue
+		}
+
+		c := r.collection(uc)
+		lastUpdatedAt = cabby.LaterTime(lastUpdatedAt, c.UpdatedAt)
+		data = append(data, c)
+	}
+
+	sort.SliceStable(data, func(i, j int) bool { return cr.Sort.Less(collectionSortKey(data[i]), collectionSortKey(data[j])) })
+
+	cs := cabby.Collections{}
+	r := Range{cr}
+	first, last := r.Bounds(len(data))
+
+	if first < last {
+		cr.Total = int64(len(data))
+		cs.UpdatedAt = lastUpdatedAt
+		cs.Collections = append(cs.Collections, data[first:last]...)
+	}
+	return cs, nil
+}
+
+func collectionSortKey(c cabby.Collection) cabby.SortKey {
+	return cabby.SortKey{ID: c.ID.String(), Title: c.Title}
+}
+
+// CollectionsInAPIRoot return collections in a given api root
+func (s CollectionService) CollectionsInAPIRoot(ctx context.Context, apiRootPath string) (cabby.CollectionsInAPIRoot, error) {
+	resource, action := "CollectionsInAPIRoot", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.collectionsInAPIRoot(apiRootPath)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s CollectionService) collectionsInAPIRoot(apiRootPath string) (cabby.CollectionsInAPIRoot, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	ac := cabby.CollectionsInAPIRoot{}
+
+	for _, r := range s.DataStore.collections {
+		if r.apiRootPath == apiRootPath {
+			ac.Path = r.apiRootPath
+			ac.CollectionIDs = append(ac.CollectionIDs, r.id)
+		}
+	}
+	return ac, nil
+}
+
+// CreateCollection creates a user in the data store
+func (s CollectionService) CreateCollection(ctx context.Context, c cabby.Collection) error {
+	resource, action := "Collection", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := c.Validate()
+	if err == nil {
+		err = s.createCollection(c)
+	} else {
+		log.WithFields(log.Fields{"collection": c, "error": err}).Error("Invalid Collection")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s CollectionService) createCollection(c cabby.Collection) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	if s.DataStore.findCollection(c.ID.String()) != nil {
+		return fmt.Errorf("Collection %s already exists", c.ID.String())
+	}
+
+	s.DataStore.collections = append(s.DataStore.collections, &collectionRecord{
+		id:             c.ID,
+		apiRootPath:    c.APIRootPath,
+		title:          c.Title,
+		description:    c.Description,
+		mediaTypes:     strings.Join(c.MediaTypes, ","),
+		versionPolicy:  c.VersionPolicy,
+		retainVersions: c.RetainVersions,
+		retainWindow:   c.RetainWindow,
+		expireBy:       c.ExpireBy,
+		maxAge:         c.MaxAge,
+		updatedAt:      now()})
+	return nil
+}
+
+// DeleteCollection creates a user in the data store
+func (s CollectionService) DeleteCollection(ctx context.Context, id string) error {
+	resource, action := "Collection", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.deleteCollection(id)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s CollectionService) deleteCollection(id string) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	kept := s.DataStore.collections[:0]
+	for _, r := range s.DataStore.collections {
+		if r.id.String() != id {
+			kept = append(kept, r)
+		}
+	}
+	s.DataStore.collections = kept
+	return nil
+}
+
+// UpdateCollection creates a user in the data store
+func (s CollectionService) UpdateCollection(ctx context.Context, c cabby.Collection) error {
+	resource, action := "Collection", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := c.Validate()
+	if err == nil {
+		err = s.updateCollection(c)
+	} else {
+		log.WithFields(log.Fields{"collection": c, "error": err}).Error("Invalid Collection")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s CollectionService) updateCollection(c cabby.Collection) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	if r := s.DataStore.findCollection(c.ID.String()); r != nil {
+		r.apiRootPath = c.APIRootPath
+		r.title = c.Title
+		r.description = c.Description
+		r.versionPolicy = c.VersionPolicy
+		r.retainVersions = c.RetainVersions
+		r.retainWindow = c.RetainWindow
+		r.expireBy = c.ExpireBy
+		r.maxAge = c.MaxAge
+		r.updatedAt = now()
+	}
+	return nil
+}
+
+// findCollection expects the lock to be held
+func (s *DataStore) findCollection(id string) *collectionRecord {
+	for _, r := range s.collections {
+		if r.id.String() == id {
+			return r
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// DiscoveryService implements an in-memory version of the DiscoveryService interface
+type DiscoveryService struct {
+	DataStore *DataStore
+}
+
+type discoveryRecord struct {
+	discovery cabby.Discovery
+	updatedAt time.Time
+}
+
+// CreateDiscovery creates a user in the data store
+func (s DiscoveryService) CreateDiscovery(ctx context.Context, d cabby.Discovery) error {
+	resource, action := "Discovery", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := d.Validate()
+	if err == nil {
+		err = s.createDiscovery(d)
+	} else {
+		log.WithFields(log.Fields{"discovery": d, "error": err}).Error("Invalid Discovery")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s DiscoveryService) createDiscovery(d cabby.Discovery) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	if s.DataStore.discovery != nil {
+		return errors.New("Only one discovery can be defined")
+	}
+
+	s.DataStore.discovery = &discoveryRecord{discovery: discoveryFields(d), updatedAt: now()}
+	return nil
+}
+
+// DeleteDiscovery creates a user in the data store
+func (s DiscoveryService) DeleteDiscovery(ctx context.Context) error {
+	resource, action := "Discovery", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.deleteDiscovery()
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s DiscoveryService) deleteDiscovery() error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	s.DataStore.discovery = nil
+	return nil
+}
+
+// Discovery will read from the data store and return the resource
+func (s DiscoveryService) Discovery(ctx context.Context) (cabby.Discovery, error) {
+	resource, action := "Discovery", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.discovery()
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// every api root belongs to the discovery
+func (s DiscoveryService) discovery() (cabby.Discovery, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	d := cabby.Discovery{}
+	if s.DataStore.discovery == nil {
+		return d, nil
+	}
+
+	d = s.DataStore.discovery.discovery
+	d.UpdatedAt = s.DataStore.discovery.updatedAt
+
+	for _, r := range s.DataStore.apiRoots {
+		d.UpdatedAt = cabby.LaterTime(d.UpdatedAt, r.updatedAt)
+		d.APIRoots = append(d.APIRoots, r.path)
+	}
+	return d, nil
+}
+
+// UpdateDiscovery creates a user in the data store
+func (s DiscoveryService) UpdateDiscovery(ctx context.Context, d cabby.Discovery) error {
+	resource, action := "Discovery", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := d.Validate()
+	if err == nil {
+		err = s.updateDiscovery(d)
+	} else {
+		log.WithFields(log.Fields{"discovery": d, "error": err}).Error("Invalid Discovery")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s DiscoveryService) updateDiscovery(d cabby.Discovery) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	if s.DataStore.discovery != nil {
+		s.DataStore.discovery.discovery = discoveryFields(d)
+		s.DataStore.discovery.updatedAt = now()
+	}
+	return nil
+}
+
+// discoveryFields are the fields a discovery keeps; its api roots come from the api roots in the data store
+func discoveryFields(d cabby.Discovery) cabby.Discovery {
+	return cabby.Discovery{Title: d.Title, Description: d.Description, Contact: d.Contact, Default: d.Default}
+}
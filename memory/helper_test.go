@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+	"github.com/pladdy/stones"
+)
+
+/* helpers */
+
+func createAPIRoot(ds *DataStore) {
+	err := ds.APIRootService().CreateAPIRoot(context.Background(), tester.APIRoot)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+}
+
+func createCollection(ds *DataStore, id string) {
+	cid, _ := cabby.IDFromString(id)
+	c := tester.Collection
+	c.ID = cid
+
+	err := ds.CollectionService().CreateCollection(context.Background(), c)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+
+	ca := cabby.CollectionAccess{ID: c.ID, CanRead: true, CanWrite: true}
+	err = ds.UserService().CreateUserCollection(context.Background(), tester.UserEmail, ca)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+}
+
+func createDiscovery(ds *DataStore) {
+	err := ds.DiscoveryService().CreateDiscovery(context.Background(), tester.Discovery)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+}
+
+func createObject(ds *DataStore, id string) {
+	o := tester.Object
+	o.ID = stones.ID(id)
+
+	err := ds.ObjectService().CreateObject(context.Background(), o)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+}
+
+func createUser(ds *DataStore) {
+	err := ds.UserService().CreateUser(context.Background(), tester.User, tester.UserPassword)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+}
+
+// setupMemory returns a new data store with the tester resources in it
+func setupMemory() *DataStore {
+	ds, err := NewDataStore("")
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+
+	createUser(ds)
+	createDiscovery(ds)
+	createAPIRoot(ds)
+	createCollection(ds, tester.Collection.ID.String())
+	createObject(ds, string(tester.Object.ID))
+	return ds
+}
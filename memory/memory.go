@@ -0,0 +1,247 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/stones"
+	log "github.com/sirupsen/logrus"
+)
+
+// timeFormat matches the format the sqlite data store has for status created_at times
+const timeFormat = "2006-01-02 15:04:05.000"
+
+// DataStore keeps resources in memory; it's safe for concurrent use and everything in it is lost when it's closed
+type DataStore struct {
+	SeedPath string
+
+	mu              sync.RWMutex
+	apiRoots        []*apiRootRecord
+	collections     []*collectionRecord
+	discovery       *discoveryRecord
+	objects         []*objectRecord
+	rows            int64
+	statuses        []*statusRecord
+	tombstones      []*tombstoneRecord
+	users           map[string]*userRecord
+	userCollections map[string][]*userCollectionRecord
+}
+
+// NewDataStore returns an in-memory data store; if a seed path is given the bundles in it are loaded.  Bundles are
+// read from JSON files in subdirectories named for the collection they belong to, like <seed path>/<collection id>/
+func NewDataStore(seedPath string) (*DataStore, error) {
+	s := DataStore{SeedPath: seedPath}
+	err := s.Open()
+	return &s, err
+}
+
+// APIRootService returns a service for api root resources
+func (s *DataStore) APIRootService() cabby.APIRootService {
+	return APIRootService{DataStore: s}
+}
+
+// Close the datastore; its resources are dropped
+func (s *DataStore) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset()
+}
+
+// CollectionService returns a service for collection resources
+func (s *DataStore) CollectionService() cabby.CollectionService {
+	return CollectionService{DataStore: s}
+}
+
+// DiscoveryService returns a service for discovery resources
+func (s *DataStore) DiscoveryService() cabby.DiscoveryService {
+	return DiscoveryService{DataStore: s}
+}
+
+// ManifestService returns a service for object resources
+func (s *DataStore) ManifestService() cabby.ManifestService {
+	return ManifestService{DataStore: s}
+}
+
+// ObjectService returns a service for object resources
+func (s *DataStore) ObjectService() cabby.ObjectService {
+	return ObjectService{DataStore: s}
+}
+
+// Open the datastore; it starts empty unless there's a seed path to load
+func (s *DataStore) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset()
+	if s.SeedPath == "" {
+		return nil
+	}
+
+	err := s.seed(s.SeedPath)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "seed_path": s.SeedPath}).Error("Failed to seed data store")
+	}
+	return err
+}
+
+// StatusService returns service for status resources
+func (s *DataStore) StatusService() cabby.StatusService {
+	return StatusService{DataStore: s}
+}
+
+// UserService returns a service for user resources
+func (s *DataStore) UserService() cabby.UserService {
+	return UserService{DataStore: s}
+}
+
+/* helpers */
+
+// now is truncated to milliseconds, the precision the sql data stores keep
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func (s *DataStore) reset() {
+	s.apiRoots = nil
+	s.collections = nil
+	s.discovery = nil
+	s.objects = nil
+	s.rows = 0
+	s.statuses = nil
+	s.tombstones = nil
+	s.users = map[string]*userRecord{}
+	s.userCollections = map[string][]*userCollectionRecord{}
+}
+
+// seed loads bundles from each collection directory in the path; it expects the lock to be held
+func (s *DataStore) seed(path string) error {
+	dirs, err := ioutil.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+
+		collectionID, err := cabby.IDFromString(dir.Name())
+		if err != nil {
+			return fmt.Errorf("Invalid collection directory %s: %v", dir.Name(), err)
+		}
+
+		files, err := filepath.Glob(filepath.Join(path, dir.Name(), "*.json"))
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if err := s.seedBundle(file, collectionID.String()); err != nil {
+				return fmt.Errorf("Can't seed %s: %v", file, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *DataStore) seedBundle(file, collectionID string) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var bundle stones.Bundle
+	if err := json.Unmarshal(b, &bundle); err != nil {
+		return err
+	}
+
+	for _, object := range bundle.Objects {
+		o, err := cabby.NewObject(object)
+		if err != nil {
+			return err
+		}
+
+		if err := s.createObject(o, collectionID); err != nil {
+			return err
+		}
+	}
+
+	log.WithFields(log.Fields{"file": file, "objects": len(bundle.Objects)}).Info("Seeded bundle")
+	return nil
+}
+
+/* pagination helpers */
+
+// Range implementation for the memory data store
+type Range struct {
+	*cabby.Range
+}
+
+// position is where a page of records is in the records read
+type position struct {
+	total, remaining int64
+	last             cabby.Cursor
+}
+
+// set sets the total of a range, and where a cursored range is in the records read
+func (p position) set(cr *cabby.Range) {
+	if !cr.Cursored {
+		cr.Total = p.total
+		return
+	}
+	cr.SetPage(p.total, p.remaining, p.last)
+}
+
+// page returns the records in a range and where they are in the records; a cursored page is in the order the records
+// were added and other pages are in the order of the range's sort
+func page(data []*objectRecord, cr *cabby.Range) ([]*objectRecord, position) {
+	p := position{total: int64(len(data))}
+	sorted := append([]*objectRecord(nil), data...)
+
+	if !cr.Cursored {
+		sort.SliceStable(sorted, func(i, j int) bool { return cr.Sort.Less(sorted[i].sortKey(), sorted[j].sortKey()) })
+		r := Range{cr}
+		first, last := r.Bounds(len(sorted))
+		return sorted[first:last], p
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].cursor().Before(sorted[j].cursor()) })
+
+	var after []*objectRecord
+	for _, r := range sorted {
+		if cr.After.Before(r.cursor()) {
+			after = append(after, r)
+		}
+	}
+	p.remaining = int64(len(after))
+
+	if cr.Limit > 0 && int64(len(after)) > cr.Limit {
+		after = after[:cr.Limit]
+	}
+	if len(after) > 0 {
+		p.last = after[len(after)-1].cursor()
+	}
+	return after, p
+}
+
+// Bounds returns the start and end indexes of the range in a list of the given length
+func (r *Range) Bounds(length int) (start, end int) {
+	if !r.Valid() {
+		return 0, length
+	}
+
+	start, end = int(r.First), int(r.Last)+1
+	if start > length {
+		start = length
+	}
+	if end > length {
+		end = length
+	}
+	return
+}
@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/datastoretest"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestNewDataStore(t *testing.T) {
+	_, err := NewDataStore("")
+
+	if err != nil {
+		t.Error("Got:", err, "Expected: nil")
+	}
+}
+
+func TestNewDataStoreSeed(t *testing.T) {
+	ds, err := NewDataStore("testdata/seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cr := cabby.Range{First: -1, Last: -1}
+	objects, err := ds.ObjectService().Objects(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+	if err != nil {
+		t.Error("Got:", err)
+	}
+
+	if len(objects) != 3 {
+		t.Error("Got:", len(objects), "Expected:", 3)
+	}
+	for _, o := range objects {
+		if o.CollectionID.String() != tester.CollectionID {
+			t.Error("Got:", o.CollectionID.String(), "Expected:", tester.CollectionID)
+		}
+	}
+}
+
+func TestNewDataStoreSeedInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cabby-seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	collectionDir := filepath.Join(dir, tester.CollectionID)
+	if err := os.Mkdir(collectionDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		file   string
+		bundle string
+	}{
+		{filepath.Join(dir, "missing"), "", ""},
+		{dir, filepath.Join(collectionDir, "bundle.json"), `{"type": "bundle", "objects": [`},
+		{dir, filepath.Join(collectionDir, "bundle.json"), `{"type": "bundle", "objects": [{"type": "malware"}]}`},
+		{dir, filepath.Join(dir, "not-a-collection", "bundle.json"), `{"type": "bundle", "objects": []}`},
+	}
+
+	for _, test := range tests {
+		if test.file != "" {
+			os.MkdirAll(filepath.Dir(test.file), 0700)
+			if err := ioutil.WriteFile(test.file, []byte(test.bundle), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, err := NewDataStore(test.path)
+		if err == nil {
+			t.Error("Expected an error for:", test.bundle)
+		}
+
+		if test.file != "" {
+			os.Remove(test.file)
+		}
+	}
+}
+
+func TestDataStoreClose(t *testing.T) {
+	ds := setupMemory()
+	ds.Close()
+
+	result, _ := ds.DiscoveryService().Discovery(context.Background())
+	if result.Title != "" {
+		t.Error("Got:", result, "Expected no discovery")
+	}
+
+	// it can be opened again
+	err := ds.Open()
+	if err != nil {
+		t.Error("Got:", err)
+	}
+	createDiscovery(ds)
+}
+
+func TestDataStoreConformance(t *testing.T) {
+	datastoretest.Run(t, func(t *testing.T) cabby.DataStore {
+		ds, err := NewDataStore("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ds
+	})
+}
+
+func TestDataStoreConcurrentUse(t *testing.T) {
+	ds := setupMemory()
+	s := ds.ObjectService()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			id, _ := cabby.NewID()
+			createObject(ds, id.String())
+		}()
+
+		go func() {
+			defer wg.Done()
+			_, err := s.Objects(context.Background(), tester.CollectionID, &cabby.Range{First: -1, Last: -1}, cabby.Filter{})
+			if err != nil {
+				t.Error("Got:", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	results, _ := s.Objects(context.Background(), tester.CollectionID, &cabby.Range{First: -1, Last: -1}, cabby.Filter{})
+	if len(results) != 11 {
+		t.Error("Got:", len(results), "Expected:", 11)
+	}
+}
+
+func TestRangeBounds(t *testing.T) {
+	tests := []struct {
+		cabbyRange cabby.Range
+		length     int
+		start      int
+		end        int
+	}{
+		{cabby.Range{First: -1, Last: -1}, 10, 0, 10},
+		{cabby.Range{First: 0, Last: 5}, 10, 0, 6},
+		{cabby.Range{First: 8, Last: 12}, 10, 8, 10},
+		{cabby.Range{First: 12, Last: 15}, 10, 10, 10},
+	}
+
+	for _, test := range tests {
+		r := Range{&test.cabbyRange}
+		start, end := r.Bounds(test.length)
+
+		if start != test.start || end != test.end {
+			t.Error("Got:", start, end, "Expected:", test.start, test.end)
+		}
+	}
+}
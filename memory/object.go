@@ -0,0 +1,605 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	log "github.com/sirupsen/logrus"
+)
+
+// the status of a bundle is updated, and checked for a cancel, every progressInterval objects
+const progressInterval = 50
+
+// ObjectService implements an in-memory version of the ObjectService interface
+type ObjectService struct {
+	DataStore *DataStore
+}
+
+type objectRecord struct {
+	object       cabby.Object
+	collectionID string
+	createdAt    time.Time
+	updatedAt    time.Time
+	// row orders records added at the same time
+	row int64
+}
+
+func (r *objectRecord) cursor() cabby.Cursor {
+	return cabby.Cursor{DateAdded: r.createdAt, Row: r.row}
+}
+
+func (r *objectRecord) sortKey() cabby.SortKey {
+	return cabby.SortKey{DateAdded: r.createdAt, ID: string(r.object.ID), Modified: r.object.Modified, Row: r.row}
+}
+
+type tombstoneRecord struct {
+	collectionID string
+	id           string
+	modified     string
+	deletedAt    time.Time
+}
+
+// CompactObjects will delete the object versions collections don't retain
+func (s ObjectService) CompactObjects(ctx context.Context) (int64, error) {
+	resource, action := "Objects", "compact"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result := s.DataStore.compactObjects(now())
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, nil
+}
+
+func (s *DataStore) compactObjects(t time.Time) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type version struct{ collectionID, id, modified string }
+	expired := map[version]bool{}
+
+	for _, c := range s.collections {
+		if c.retainVersions <= 0 && c.retainWindow <= 0 {
+			continue
+		}
+		collection := cabby.Collection{RetainVersions: c.retainVersions, RetainWindow: c.retainWindow}
+
+		versions := map[string][]string{}
+		for _, r := range s.objects {
+			if r.collectionID == c.id.String() {
+				versions[string(r.object.ID)] = append(versions[string(r.object.ID)], r.object.Modified)
+			}
+		}
+
+		for id, modified := range versions {
+			for _, m := range collection.ExpiredVersions(modified, t) {
+				expired[version{c.id.String(), id, m}] = true
+			}
+		}
+	}
+
+	kept := s.objects[:0]
+	for _, r := range s.objects {
+		if !expired[version{r.collectionID, string(r.object.ID), r.object.Modified}] {
+			kept = append(kept, r)
+		}
+	}
+
+	compacted := int64(len(s.objects) - len(kept))
+	s.objects = kept
+	return compacted
+}
+
+// CreateBundle will write objects to the data store as they're received and update the status when done
+func (s ObjectService) CreateBundle(ctx context.Context, objects <-chan []byte, collectionID string, st cabby.Status, ss cabby.StatusService) {
+	resource, action := "Bundle", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	s.createBundle(ctx, objects, collectionID, st, ss)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+}
+
+func (s ObjectService) createBundle(ctx context.Context, objects <-chan []byte, collectionID string, st cabby.Status, ss cabby.StatusService) {
+	var failures []cabby.StatusFailure
+	var successes []string
+	total, canceled := int64(0), int64(0)
+
+	// the channel is always drained so the sender doesn't block, even after a cancel
+	for object := range objects {
+		total++
+
+		if canceled > 0 {
+			canceled++
+			continue
+		}
+
+		if total > 1 && (total-1)%progressInterval == 0 {
+			s.DataStore.updateStatusProgress(st, total-1)
+
+			if cabby.StatusCanceled(ctx, st, ss) {
+				canceled++
+				log.WithFields(log.Fields{"status_id": st.ID}).Warn("Status canceled, stopping bundle")
+				continue
+			}
+		}
+
+		var action cabby.VersionAction
+
+		o, err := cabby.NewObject(object)
+		if err == nil {
+			action, err = s.DataStore.writeObject(o, collectionID)
+		}
+		if err != nil {
+			failures = append(failures, cabby.StatusFailure{ID: string(o.ID), Version: o.Modified, Message: err.Error()})
+			continue
+		}
+
+		if action == cabby.IgnoreVersion {
+			successes = append(successes, string(o.ID))
+		}
+	}
+
+	st.TotalCount = total
+	st.Failures = failures
+	st.Successes = successes
+	cabby.UpdateStatusCounts(ctx, st, canceled, ss)
+}
+
+// CreateObject will read from the data store and return the resource
+func (s ObjectService) CreateObject(ctx context.Context, object cabby.Object) error {
+	resource, action := "Object", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	_, err := s.DataStore.writeObject(object, object.CollectionID.String())
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+// DeleteObject will delete the versions of an object in the filter and record a tombstone for each
+func (s ObjectService) DeleteObject(ctx context.Context, collectionID, objectID string, f cabby.Filter) (int64, error) {
+	resource, action := "Object", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result := s.DataStore.deleteObject(collectionID, objectID, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, nil
+}
+
+func (s *DataStore) deleteObject(collectionID, objectID string, f cabby.Filter) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := map[*objectRecord]bool{}
+	for _, r := range s.filterObjects(collectionID, f) {
+		if string(r.object.ID) == objectID {
+			deleted[r] = true
+		}
+	}
+
+	t := now()
+	kept := s.objects[:0]
+	for _, r := range s.objects {
+		if !deleted[r] {
+			kept = append(kept, r)
+			continue
+		}
+		s.tombstones = append(s.tombstones,
+			&tombstoneRecord{collectionID: collectionID, id: objectID, modified: r.object.Modified, deletedAt: t})
+	}
+
+	s.objects = kept
+	return int64(len(deleted))
+}
+
+// Object will read from the data store and return the resource
+func (s ObjectService) Object(ctx context.Context, collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
+	resource, action := "Object", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.object(collectionID, objectID, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ObjectService) object(collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	objects := []cabby.Object{}
+
+	for _, r := range s.DataStore.filterObjects(collectionID, f) {
+		if string(r.object.ID) != objectID {
+			continue
+		}
+
+		o, err := r.read()
+		if err != nil {
+			return objects, err
+		}
+		objects = append(objects, o)
+	}
+	return objects, nil
+}
+
+// PurgeObjects will delete every version of the objects that have expired in their collections
+func (s ObjectService) PurgeObjects(ctx context.Context) (int64, error) {
+	resource, action := "Objects", "purge"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result := s.DataStore.purgeObjects(now())
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, nil
+}
+
+func (s *DataStore) purgeObjects(t time.Time) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := map[string]map[string]bool{}
+	for _, c := range s.collections {
+		expired[c.id.String()] = s.expiredObjects(c.id.String(), t)
+	}
+
+	kept := s.objects[:0]
+	for _, r := range s.objects {
+		if !expired[r.collectionID][string(r.object.ID)] {
+			kept = append(kept, r)
+		}
+	}
+
+	purged := int64(len(s.objects) - len(kept))
+	s.objects = kept
+	return purged
+}
+
+// IterateObjects will read from the data store and return an iterator over the resource; the range total is
+// set once Next is called
+func (s ObjectService) IterateObjects(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+	resource, action := "Objects", "iterate"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.iterateObjects(collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// the objects in range are copied when the iterator is created so it doesn't hold a lock while it's read
+func (s ObjectService) iterateObjects(collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	data := s.DataStore.filterObjects(collectionID, f)
+	records, p := page(data, cr)
+	it := objectIterator{cr: cr, position: p}
+
+	for _, r := range data {
+		it.lastUpdatedAt = cabby.LaterTime(it.lastUpdatedAt, r.updatedAt)
+	}
+
+	it.firstAdded, it.lastAdded = dateAdded(records)
+
+	for _, r := range records {
+		o, err := r.read()
+		if err != nil {
+			it.err = err
+			break
+		}
+		it.objects = append(it.objects, o)
+	}
+	return &it, nil
+}
+
+// Objects will read from the data store and return the resource
+func (s ObjectService) Objects(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
+	resource, action := "Objects", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.objects(collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ObjectService) objects(collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
+	objects := []cabby.Object{}
+
+	or, err := s.iterateObjects(collectionID, cr, f)
+	if err != nil {
+		return objects, err
+	}
+	defer or.Close()
+
+	for or.Next() {
+		objects = append(objects, or.Object())
+	}
+
+	err = or.Err()
+	return objects, err
+}
+
+// Tombstones will read the object versions deleted from a collection; the filter's added after is compared to when
+// they were deleted
+func (s ObjectService) Tombstones(ctx context.Context, collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+	resource, action := "Tombstones", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result := s.DataStore.readTombstones(collectionID, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, nil
+}
+
+func (s *DataStore) readTombstones(collectionID string, f cabby.Filter) []cabby.Tombstone {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tombstones := []cabby.Tombstone{}
+
+	for _, r := range s.tombstones {
+		if r.collectionID != collectionID {
+			continue
+		}
+		if !f.MatchesAddedAfter(r.deletedAt) {
+			continue
+		}
+
+		tombstones = append(tombstones,
+			cabby.Tombstone{ID: r.id, Version: r.modified, DateDeleted: r.deletedAt.Format(cabby.TimestampFormat)})
+	}
+	return tombstones
+}
+
+// Versions will read the modified times of an object's versions in a collection, earliest first; the range total is
+// set
+func (s ObjectService) Versions(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+	resource, action := "Versions", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result := s.versions(collectionID, objectID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, nil
+}
+
+func (s ObjectService) versions(collectionID, objectID string, cr *cabby.Range, f cabby.Filter) []string {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	versions := []string{}
+	for _, r := range s.DataStore.filterObjects(collectionID, f) {
+		if string(r.object.ID) == objectID {
+			versions = append(versions, r.object.Modified)
+		}
+	}
+	cabby.SortVersions(versions)
+
+	cr.Total = int64(len(versions))
+	r := Range{cr}
+	first, last := r.Bounds(len(versions))
+	return versions[first:last]
+}
+
+// objectIterator implements a cabby.ObjectIterator over objects read from the data store
+type objectIterator struct {
+	objects       []cabby.Object
+	cr            *cabby.Range
+	position      position
+	object        cabby.Object
+	lastUpdatedAt time.Time
+	updatedAt     time.Time
+	firstAdded    time.Time
+	lastAdded     time.Time
+	err           error
+}
+
+func (o *objectIterator) Next() bool {
+	if len(o.objects) == 0 {
+		return false
+	}
+
+	o.object, o.objects = o.objects[0], o.objects[1:]
+	o.position.set(o.cr)
+	o.updatedAt = o.lastUpdatedAt
+	return true
+}
+
+func (o *objectIterator) UpdatedAt() time.Time {
+	return o.updatedAt
+}
+
+func (o *objectIterator) DateAdded() (time.Time, time.Time) {
+	return o.firstAdded, o.lastAdded
+}
+
+func (o *objectIterator) Object() cabby.Object {
+	return o.object
+}
+
+func (o *objectIterator) Err() error {
+	return o.err
+}
+
+func (o *objectIterator) Close() error {
+	o.objects = nil
+	return nil
+}
+
+/* helpers */
+
+// read returns the object as it's stored in a collection
+func (r *objectRecord) read() (cabby.Object, error) {
+	o := r.object
+	o.Object = append([]byte(nil), r.object.Object...)
+	o.DateAdded = r.createdAt
+	o.UpdatedAt = r.updatedAt
+
+	var err error
+	o.CollectionID, err = cabby.IDFromString(r.collectionID)
+	return o, err
+}
+
+// createObject expects the lock to be held; like the sql data stores an object's id and version are unique in a
+// collection, the same version can be in other collections
+func (s *DataStore) createObject(o cabby.Object, collectionID string) error {
+	if !json.Valid(o.Object) {
+		return fmt.Errorf("Object %s isn't valid JSON", o.ID)
+	}
+
+	for _, r := range s.objects {
+		if r.collectionID == collectionID && r.object.ID == o.ID && r.object.Modified == o.Modified {
+			return fmt.Errorf("Object %s with version %s already exists in collection %s", o.ID, o.Modified, collectionID)
+		}
+	}
+
+	t := now()
+	o.Object = append([]byte(nil), o.Object...)
+	o.CollectionID = cabby.ID{}
+	o.UpdatedAt = time.Time{}
+
+	s.rows++
+	s.objects = append(s.objects,
+		&objectRecord{object: o, collectionID: collectionID, createdAt: t, updatedAt: t, row: s.rows})
+	return nil
+}
+
+// filterObjects expects the lock to be held; it returns the objects in a collection that are in the filter
+func (s *DataStore) filterObjects(collectionID string, f cabby.Filter) []*objectRecord {
+	type versions struct{ first, last string }
+	ids := map[string]versions{}
+
+	for _, r := range s.objects {
+		if r.collectionID != collectionID {
+			continue
+		}
+
+		id := string(r.object.ID)
+		v, ok := ids[id]
+		if !ok || r.object.Modified < v.first {
+			v.first = r.object.Modified
+		}
+		if !ok || r.object.Modified > v.last {
+			v.last = r.object.Modified
+		}
+		ids[id] = v
+	}
+
+	expired := s.expiredObjects(collectionID, now())
+	var matched []*objectRecord
+
+	for _, r := range s.objects {
+		if r.collectionID != collectionID || expired[string(r.object.ID)] {
+			continue
+		}
+
+		v := ids[string(r.object.ID)]
+		if f.Match(r.object, objectVersion(r.object.Modified, v.first, v.last), r.createdAt) {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+// expiredObjects expects the lock to be held; it returns the ids of the objects in a collection whose latest version
+// has expired
+func (s *DataStore) expiredObjects(collectionID string, t time.Time) map[string]bool {
+	expired := map[string]bool{}
+
+	c := s.findCollection(collectionID)
+	if c == nil || c.expireBy == "" {
+		return expired
+	}
+	collection := cabby.Collection{ExpireBy: c.expireBy, MaxAge: c.maxAge}
+
+	latest := map[string]*objectRecord{}
+	for _, r := range s.objects {
+		if r.collectionID != collectionID {
+			continue
+		}
+
+		id := string(r.object.ID)
+		if l, ok := latest[id]; !ok || r.object.Modified > l.object.Modified {
+			latest[id] = r
+		}
+	}
+
+	for id, r := range latest {
+		o := r.object
+		o.DateAdded = r.createdAt
+		if collection.Expired(o, t) {
+			expired[id] = true
+		}
+	}
+	return expired
+}
+
+// dateAdded returns when the first and last of the objects were added
+func dateAdded(data []*objectRecord) (first, last time.Time) {
+	for _, r := range data {
+		if first.IsZero() || r.createdAt.Before(first) {
+			first = r.createdAt
+		}
+		if r.createdAt.After(last) {
+			last = r.createdAt
+		}
+	}
+	return
+}
+
+// objectVersion places a version among the first and last versions of an object
+func objectVersion(modified, first, last string) string {
+	switch {
+	case modified == first && modified == last:
+		return "only"
+	case modified == last:
+		return "last"
+	case modified == first:
+		return "first"
+	}
+	return ""
+}
+
+// writeObject applies the collection's version policy to an object; it returns what was done with the object
+func (s *DataStore) writeObject(o cabby.Object, collectionID string) (cabby.VersionAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	action, err := cabby.ResolveVersion(s.versionPolicy(collectionID), o, s.objectVersions(o, collectionID))
+	if err == nil {
+		switch action {
+		case cabby.WriteVersion:
+			err = s.createObject(o, collectionID)
+		case cabby.OverwriteVersion:
+			err = s.overwriteObject(o, collectionID)
+		}
+	}
+
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "id": o.ID}).Error("Failed to write object")
+	}
+	return action, err
+}
+
+// overwriteObject replaces a stored version of an object; it expects the lock to be held
+func (s *DataStore) overwriteObject(o cabby.Object, collectionID string) error {
+	if !json.Valid(o.Object) {
+		return fmt.Errorf("Object %s isn't valid JSON", o.ID)
+	}
+
+	for _, r := range s.objects {
+		if r.collectionID == collectionID && r.object.ID == o.ID && r.object.Modified == o.Modified {
+			r.object.Type = o.Type
+			r.object.Created = o.Created
+			r.object.Object = append([]byte(nil), o.Object...)
+			r.updatedAt = now()
+		}
+	}
+	return nil
+}
+
+// objectVersions returns the versions of an object in a collection; it expects the lock to be held
+func (s *DataStore) objectVersions(o cabby.Object, collectionID string) []cabby.Object {
+	var versions []cabby.Object
+
+	for _, r := range s.objects {
+		if r.collectionID == collectionID && r.object.ID == o.ID {
+			versions = append(versions, r.object)
+		}
+	}
+	return versions
+}
+
+// versionPolicy returns a collection's version policy; it expects the lock to be held
+func (s *DataStore) versionPolicy(collectionID string) string {
+	if r := s.findCollection(collectionID); r != nil {
+		return r.versionPolicy
+	}
+	return ""
+}
@@ -0,0 +1,267 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// StatusService implements an in-memory version of the StatusService interface
+type StatusService struct {
+	DataStore *DataStore
+}
+
+type statusRecord struct {
+	status    cabby.Status
+	createdAt time.Time
+	updatedAt time.Time
+	// row orders statuses created at the same time
+	row int64
+}
+
+// read returns the fields a status read has; like the sql data stores, pendings aren't kept
+func (r *statusRecord) read() cabby.Status {
+	st := r.status
+	st.Pendings = nil
+	st.Failures = append([]cabby.StatusFailure(nil), r.status.Failures...)
+	st.Successes = append([]string(nil), r.status.Successes...)
+	st.CreatedAt = r.createdAt.Format(timeFormat)
+	return st
+}
+
+// CancelStatus will mark a pending status as canceled
+func (s StatusService) CancelStatus(ctx context.Context, statusID string) error {
+	resource, action := "Status", "cancel"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.cancelStatus(statusID)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s StatusService) cancelStatus(statusID string) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	canceled := 0
+	for _, r := range s.DataStore.statuses {
+		if r.status.ID.String() == statusID && r.status.Status == "pending" {
+			r.status.Status = "canceled"
+			r.updatedAt = now()
+			canceled++
+		}
+	}
+
+	if canceled == 0 {
+		return errors.New("No pending status found for this id")
+	}
+	return nil
+}
+
+// CreateStatus will read from the data store and return the resource
+func (s StatusService) CreateStatus(ctx context.Context, status cabby.Status) error {
+	resource, action := "Status", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.createStatus(status)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s StatusService) createStatus(st cabby.Status) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	t := now()
+	st.Failures = nil
+	st.Successes = nil
+	s.DataStore.rows++
+	s.DataStore.statuses = append(s.DataStore.statuses, &statusRecord{status: st, createdAt: t, updatedAt: t, row: s.DataStore.rows})
+	return nil
+}
+
+// PurgeStatuses will delete statuses created before a given time that are no longer pending
+func (s StatusService) PurgeStatuses(ctx context.Context, before time.Time) (int64, error) {
+	resource, action := "Statuses", "purge"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.purgeStatuses(before)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s StatusService) purgeStatuses(before time.Time) (int64, error) {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	before = before.Truncate(time.Millisecond)
+	var purged int64
+
+	kept := s.DataStore.statuses[:0]
+	for _, r := range s.DataStore.statuses {
+		if r.createdAt.Before(before) && r.status.Status != "pending" {
+			purged++
+			continue
+		}
+		kept = append(kept, r)
+	}
+	s.DataStore.statuses = kept
+	return purged, nil
+}
+
+// Status will read from the data store and return the resource
+func (s StatusService) Status(ctx context.Context, statusID string) (cabby.Status, error) {
+	resource, action := "Status", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.status(statusID)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s StatusService) status(statusID string) (cabby.Status, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	st := cabby.Status{}
+	for _, r := range s.DataStore.statuses {
+		if r.status.ID.String() == statusID {
+			st = r.read()
+		}
+	}
+	return st, nil
+}
+
+// Statuses will read from the data store and return the resource
+func (s StatusService) Statuses(ctx context.Context, cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+	resource, action := "Statuses", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.statuses(cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// statuses are listed newest first; like the sql data stores, statuses in a list leave out their failures and successes
+func (s StatusService) statuses(cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	filter := StatusFilter{f}
+	var data []*statusRecord
+
+	for _, r := range s.DataStore.statuses {
+		if filter.Match(r.status, r.createdAt) {
+			data = append(data, r)
+		}
+	}
+
+	sort.SliceStable(data, func(i, j int) bool {
+		if data[i].createdAt.Equal(data[j].createdAt) {
+			return data[i].row > data[j].row
+		}
+		return data[i].createdAt.After(data[j].createdAt)
+	})
+
+	statuses := []cabby.Status{}
+	r := Range{cr}
+	first, last := r.Bounds(len(data))
+
+	for _, d := range data[first:last] {
+		st := d.read()
+		st.Failures = nil
+		st.Successes = nil
+		statuses = append(statuses, st)
+		cr.Total = int64(len(data))
+	}
+	return statuses, nil
+}
+
+// UpdateStatus will read from the data store and return the resource
+func (s StatusService) UpdateStatus(ctx context.Context, status cabby.Status) error {
+	resource, action := "Status", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.updateStatus(status)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s StatusService) updateStatus(st cabby.Status) error {
+	st.PendingCount = st.TotalCount - st.SuccessCount - st.FailureCount
+
+	if st.PendingCount == 0 {
+		st.SuccessCount = st.TotalCount - st.FailureCount
+		st.Status = "complete"
+	}
+
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	for _, r := range s.DataStore.statuses {
+		if r.status.ID != st.ID {
+			continue
+		}
+
+		// a canceled status stays canceled
+		if r.status.Status != "canceled" {
+			r.status.Status = st.Status
+		}
+		r.status.TotalCount = st.TotalCount
+		r.status.SuccessCount = st.SuccessCount
+		r.status.FailureCount = st.FailureCount
+		r.status.Failures = append([]cabby.StatusFailure(nil), st.Failures...)
+		r.status.Successes = append([]string(nil), st.Successes...)
+		r.status.PendingCount = st.PendingCount
+		r.updatedAt = now()
+	}
+	return nil
+}
+
+// updateStatusProgress sets the counts of a pending status to what's been received
+func (s *DataStore) updateStatusProgress(st cabby.Status, received int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.statuses {
+		if r.status.ID == st.ID && r.status.Status == "pending" {
+			r.status.TotalCount = received
+			r.status.PendingCount = received
+			r.updatedAt = now()
+		}
+	}
+}
+
+// StatusFilter implementation for the memory data store
+type StatusFilter struct {
+	cabby.StatusFilter
+}
+
+// Match returns whether a status created at the given time is in the filter
+func (f *StatusFilter) Match(st cabby.Status, createdAt time.Time) bool {
+	fields := []struct {
+		field string
+		value string
+	}{
+		{st.User, f.User},
+		{st.CollectionID, f.CollectionID},
+		{st.Status, f.Status},
+	}
+
+	for _, field := range fields {
+		if len(field.value) > 0 && field.field != field.value {
+			return false
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, f.CreatedAfter); err == nil {
+		if !createdAt.After(t.Truncate(time.Millisecond)) {
+			return false
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, f.CreatedBefore); err == nil {
+		if !createdAt.Before(t.Truncate(time.Millisecond)) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,243 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// UserService implements an in-memory version of the servce
+type UserService struct {
+	DataStore *DataStore
+}
+
+type userRecord struct {
+	email     string
+	canAdmin  bool
+	pass      string
+	updatedAt time.Time
+}
+
+type userCollectionRecord struct {
+	collectionID cabby.ID
+	canRead      bool
+	canWrite     bool
+	updatedAt    time.Time
+}
+
+// CreateUser creates a user in the data store
+func (s UserService) CreateUser(ctx context.Context, user cabby.User, password string) error {
+	resource, action := "User", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := cabby.ValidateUserPassword(user, password)
+	if err == nil {
+		err = s.createUser(user, password)
+	} else {
+		log.WithFields(log.Fields{"error": err, "password": password, "user": user}).Error("Invalid user and/or password")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s UserService) createUser(u cabby.User, password string) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	if _, ok := s.DataStore.users[u.Email]; ok {
+		return fmt.Errorf("User %s already exists", u.Email)
+	}
+
+	s.DataStore.users[u.Email] = &userRecord{email: u.Email, canAdmin: u.CanAdmin, pass: cabby.HashPassword(password), updatedAt: now()}
+	return nil
+}
+
+// CreateUserCollection creates an association of a user to a collection
+func (s UserService) CreateUserCollection(ctx context.Context, user string, ca cabby.CollectionAccess) error {
+	resource, action := "UserCollection", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := cabby.ValidateUserCollection(user, ca)
+	if err == nil {
+		err = s.createUserCollection(user, ca)
+	} else {
+		log.WithFields(log.Fields{"error": err, "collection_access": ca, "user": user}).Error("Invalid user and/or collection")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+// like the sql data stores, an association that already exists is left as is
+func (s UserService) createUserCollection(user string, ca cabby.CollectionAccess) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	if _, ok := s.DataStore.users[user]; !ok {
+		return fmt.Errorf("User %s doesn't exist", user)
+	}
+
+	if s.DataStore.findUserCollection(user, ca.ID.String()) != nil {
+		return nil
+	}
+
+	s.DataStore.userCollections[user] = append(s.DataStore.userCollections[user], &userCollectionRecord{
+		collectionID: ca.ID, canRead: ca.CanRead, canWrite: ca.CanWrite, updatedAt: now()})
+	return nil
+}
+
+// DeleteUserCollection deletes a collection from a user
+func (s UserService) DeleteUserCollection(ctx context.Context, user, id string) error {
+	resource, action := "UserCollection", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.deleteUserCollection(user, id)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s UserService) deleteUserCollection(user, id string) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	kept := s.DataStore.userCollections[user][:0]
+	for _, uc := range s.DataStore.userCollections[user] {
+		if uc.collectionID.String() != id {
+			kept = append(kept, uc)
+		}
+	}
+	s.DataStore.userCollections[user] = kept
+	return nil
+}
+
+// DeleteUser creates a user in the data store
+func (s UserService) DeleteUser(ctx context.Context, user string) error {
+	resource, action := "User", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.deleteUser(user)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+// a user's collection associations are deleted with it
+func (s UserService) deleteUser(user string) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	delete(s.DataStore.users, user)
+	delete(s.DataStore.userCollections, user)
+	return nil
+}
+
+// UpdateUser creates a user in the data store
+func (s UserService) UpdateUser(ctx context.Context, user cabby.User) error {
+	resource, action := "User", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := user.Validate()
+	if err == nil {
+		err = s.updateUser(user)
+	} else {
+		log.WithFields(log.Fields{"error": err, "user": user}).Error("Invalid user")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s UserService) updateUser(u cabby.User) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	if r, ok := s.DataStore.users[u.Email]; ok {
+		r.canAdmin = u.CanAdmin
+		r.updatedAt = now()
+	}
+	return nil
+}
+
+// UpdateUserCollection update a users access to a specfific collection
+func (s UserService) UpdateUserCollection(ctx context.Context, user string, ca cabby.CollectionAccess) error {
+	resource, action := "UserCollection", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := cabby.ValidateUserCollection(user, ca)
+	if err == nil {
+		err = s.updateUserCollection(user, ca)
+	} else {
+		log.WithFields(log.Fields{"error": err, "collection_access": ca, "user": user}).Error("Invalid user and/or collection")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s UserService) updateUserCollection(user string, ca cabby.CollectionAccess) error {
+	s.DataStore.mu.Lock()
+	defer s.DataStore.mu.Unlock()
+
+	if uc := s.DataStore.findUserCollection(user, ca.ID.String()); uc != nil {
+		uc.canRead = ca.CanRead
+		uc.canWrite = ca.CanWrite
+		uc.updatedAt = now()
+	}
+	return nil
+}
+
+// User will read from the data store and populate the result with a resource
+func (s UserService) User(ctx context.Context, user, password string) (cabby.User, error) {
+	resource, action := "User", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.user(user, password)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s UserService) user(user, password string) (cabby.User, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	u := cabby.User{}
+
+	if r, ok := s.DataStore.users[user]; ok && r.pass == cabby.HashPassword(password) {
+		u.Email = r.email
+		u.CanAdmin = r.canAdmin
+	}
+	return u, nil
+}
+
+// UserCollections will read from the data store and populate the result with a resource
+func (s UserService) UserCollections(ctx context.Context, user string) (cabby.UserCollectionList, error) {
+	resource, action := "UserCollectionList", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.userCollections(user)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s UserService) userCollections(user string) (cabby.UserCollectionList, error) {
+	s.DataStore.mu.RLock()
+	defer s.DataStore.mu.RUnlock()
+
+	ucl := cabby.UserCollectionList{Email: user, CollectionAccessList: map[cabby.ID]cabby.CollectionAccess{}}
+
+	for _, uc := range s.DataStore.userCollections[user] {
+		ca := cabby.CollectionAccess{ID: uc.collectionID, CanRead: uc.canRead, CanWrite: uc.canWrite}
+		ucl.CollectionAccessList[ca.ID] = ca
+	}
+	return ucl, nil
+}
+
+// findUserCollection expects the lock to be held
+func (s *DataStore) findUserCollection(user, collectionID string) *userCollectionRecord {
+	for _, uc := range s.userCollections[user] {
+		if uc.collectionID.String() == collectionID {
+			return uc
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestAPIRootServiceAPIRoot(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.APIRootService()
+
+	expected := tester.APIRoot
+
+	result, err := s.APIRoot(context.Background(), expected.Path)
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	passed := tester.CompareAPIRoot(result, expected)
+	if !passed {
+		t.Error("Comparison failed")
+	}
+
+	if result.UpdatedAt.IsZero() {
+		t.Error("Got:", result.UpdatedAt, "Expected an updated time")
+	}
+}
+
+func TestAPIRootServiceAPIRoots(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.APIRootService()
+
+	result, err := s.APIRoots(context.Background())
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	if len(result) != 1 {
+		t.Fatal("Got:", len(result), "Expected:", 1)
+	}
+
+	passed := tester.CompareAPIRoot(result[0], tester.APIRoot)
+	if !passed {
+		t.Error("Comparison failed")
+	}
+}
+
+func TestAPIRootServiceCreateAPIRootDuplicate(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.APIRootService()
+
+	err := s.CreateAPIRoot(context.Background(), tester.APIRoot)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestAPIRootServiceUpdateAPIRoot(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.APIRootService()
+
+	expected := tester.APIRoot
+	expected.Title = "an updated title"
+
+	err := s.UpdateAPIRoot(context.Background(), expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, _ := s.APIRoot(context.Background(), expected.Path)
+	if result.Title != expected.Title {
+		t.Error("Got:", result.Title, "Expected:", expected.Title)
+	}
+}
+
+func TestAPIRootServiceDeleteAPIRoot(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.APIRootService()
+
+	err := s.DeleteAPIRoot(context.Background(), tester.APIRootPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, _ := s.APIRoot(context.Background(), tester.APIRootPath)
+	if result.Path != "" {
+		t.Error("Got:", result.Path, "Expected no api root")
+	}
+}
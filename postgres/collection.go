@@ -0,0 +1,258 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// CollectionService implements a Postgres version of the CollectionService interface
+type CollectionService struct {
+	DB        *sql.DB
+	DataStore *DataStore
+}
+
+// Collection will read from the data store and return the resource
+func (s CollectionService) Collection(ctx context.Context, apiRootPath, collectionID string) (cabby.Collection, error) {
+	resource, action := "Collection", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.collection(cabby.TakeUser(ctx).Email, apiRootPath, collectionID)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s CollectionService) collection(user, apiRootPath, collectionID string) (cabby.Collection, error) {
+	sql := `select c.id, c.title, c.description, uc.can_read, uc.can_write, c.media_types,
+					  coalesce(c.version_policy, ''), coalesce(c.retain_versions, 0),
+						  coalesce(c.retain_window, 0), coalesce(c.expire_by, ''), coalesce(c.max_age, 0), greatest(c.updated_at, uc.updated_at) as updated_at
+					from
+						taxii_collection c
+						inner join taxii_user_collection uc
+							on c.id = uc.collection_id
+					where uc.email = ? and c.api_root_path = ? and c.id = ? and uc.can_read`
+	args := []interface{}{user, apiRootPath, collectionID}
+
+	c := cabby.Collection{}
+	var err error
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return c, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mediaTypes string
+		var updatedAt pq.NullTime
+
+		var retainWindow, maxAge int64
+
+		if err := rows.Scan(&c.ID, &c.Title, &c.Description, &c.CanRead, &c.CanWrite, &mediaTypes, &c.VersionPolicy,
+			&c.RetainVersions, &retainWindow, &c.ExpireBy, &maxAge, &updatedAt); err != nil {
+			return c, err
+		}
+		c.RetainWindow = time.Duration(retainWindow) * time.Second
+		c.MaxAge = time.Duration(maxAge) * time.Second
+		c.UpdatedAt = laterUpdate(c.UpdatedAt, updatedAt)
+		c.MediaTypes = strings.Split(mediaTypes, ",")
+	}
+
+	err = rows.Err()
+	return c, err
+}
+
+// Collections will read from the data store and return the resource
+func (s CollectionService) Collections(ctx context.Context, apiRootPath string, cr *cabby.Range) (cabby.Collections, error) {
+	resource, action := "Collections", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.collections(cabby.TakeUser(ctx).Email, apiRootPath, cr)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// collectionSortColumns are the columns collections are sorted on
+var collectionSortColumns = map[string]string{cabby.SortID: "id", cabby.SortTitle: "title"}
+
+func (s CollectionService) collections(user, apiRootPath string, cr *cabby.Range) (cabby.Collections, error) {
+	sql := `with data as (
+					  select id, title, description, can_read, can_write, media_types, updated_at, 1 as count
+					  from (
+						  select c.id, c.title, c.description, uc.can_read, uc.can_write, c.media_types,
+							  greatest(c.updated_at, uc.updated_at) as updated_at
+						  from
+							  taxii_collection c
+							  inner join taxii_user_collection uc
+								  on c.id = uc.collection_id
+						  where
+						 	 uc.email = ?
+							 and c.api_root_path = ?
+					 		 and (uc.can_read or uc.can_write)
+					  ) as user_collections
+				  )
+				  select
+					  id, title, description, can_read, can_write, media_types, updated_at,
+					  (select sum(count) from data) as total, (select max(updated_at) from data) as last_updated_at
+				  from data
+				  order by $order
+					$paginate`
+
+	args := []interface{}{user, apiRootPath}
+	sql = strings.Replace(sql, "$order", ordering(cr.Sort, collectionSortColumns, "id"), -1)
+	sql, args = applyPaging(sql, cr, args)
+
+	cs := cabby.Collections{}
+	var err error
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return cs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c cabby.Collection
+		var mediaTypes string
+		var updatedAt, lastUpdatedAt pq.NullTime
+
+		if err := rows.Scan(
+			&c.ID, &c.Title, &c.Description, &c.CanRead, &c.CanWrite, &mediaTypes, &updatedAt, &cr.Total, &lastUpdatedAt); err != nil {
+			return cs, err
+		}
+		c.UpdatedAt = laterUpdate(c.UpdatedAt, updatedAt)
+		cs.UpdatedAt = laterUpdate(cs.UpdatedAt, lastUpdatedAt)
+		c.MediaTypes = strings.Split(mediaTypes, ",")
+		cs.Collections = append(cs.Collections, c)
+	}
+
+	err = rows.Err()
+	return cs, err
+}
+
+// CollectionsInAPIRoot return collections in a given api root
+func (s CollectionService) CollectionsInAPIRoot(ctx context.Context, apiRootPath string) (cabby.CollectionsInAPIRoot, error) {
+	resource, action := "CollectionsInAPIRoot", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.collectionsInAPIRoot(apiRootPath)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s CollectionService) collectionsInAPIRoot(apiRootPath string) (cabby.CollectionsInAPIRoot, error) {
+	sql := `select c.api_root_path, c.id from taxii_collection c where c.api_root_path = ? order by c.id`
+	args := []interface{}{apiRootPath}
+
+	ac := cabby.CollectionsInAPIRoot{}
+	var err error
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return ac, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id cabby.ID
+
+		if err := rows.Scan(&ac.Path, &id); err != nil {
+			return ac, err
+		}
+		ac.CollectionIDs = append(ac.CollectionIDs, id)
+	}
+
+	err = rows.Err()
+	return ac, err
+}
+
+// CreateCollection creates a user in the data store
+func (s CollectionService) CreateCollection(ctx context.Context, c cabby.Collection) error {
+	resource, action := "Collection", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := c.Validate()
+	if err == nil {
+		err = s.createCollection(c)
+	} else {
+		log.WithFields(log.Fields{"collection": c, "error": err}).Error("Invalid Collection")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s CollectionService) createCollection(c cabby.Collection) error {
+	sql := `insert into taxii_collection (
+						id, api_root_path, title, description, media_types, version_policy, retain_versions, retain_window,
+						expire_by, max_age
+					)
+					values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{
+		c.ID.String(), c.APIRootPath, c.Title, c.Description, strings.Join(c.MediaTypes, ","), c.VersionPolicy,
+		c.RetainVersions, int64(c.RetainWindow / time.Second), c.ExpireBy, int64(c.MaxAge / time.Second)}
+
+	err := s.DataStore.write(sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+	}
+	return err
+}
+
+// DeleteCollection creates a user in the data store
+func (s CollectionService) DeleteCollection(ctx context.Context, id string) error {
+	resource, action := "Collection", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.deleteCollection(id)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s CollectionService) deleteCollection(id string) error {
+	sql := `delete from taxii_collection where id = ?`
+	args := []interface{}{id}
+
+	_, err := s.DB.Exec(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+	}
+	return err
+}
+
+// UpdateCollection creates a user in the data store
+func (s CollectionService) UpdateCollection(ctx context.Context, c cabby.Collection) error {
+	resource, action := "Collection", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+
+	err := c.Validate()
+	if err == nil {
+		err = s.updateCollection(c)
+	} else {
+		log.WithFields(log.Fields{"collection": c, "error": err}).Error("Invalid Collection")
+	}
+
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s CollectionService) updateCollection(c cabby.Collection) error {
+	sql := `update taxii_collection
+					set api_root_path = ?, title = ?, description = ?, version_policy = ?, retain_versions = ?, retain_window = ?,
+					  expire_by = ?, max_age = ?
+					where id = ?`
+	args := []interface{}{
+		c.APIRootPath, c.Title, c.Description, c.VersionPolicy, c.RetainVersions, int64(c.RetainWindow / time.Second),
+		c.ExpireBy, int64(c.MaxAge / time.Second), c.ID.String()}
+
+	err := s.DataStore.write(sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestCollectionServiceCollection(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.CollectionService()
+
+	expected := tester.Collection
+
+	result, err := s.Collection(tester.Context, tester.APIRootPath, expected.ID.String())
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	passed := tester.CompareCollection(result, expected)
+	if !passed {
+		t.Error("Comparison failed")
+	}
+}
+
+func TestCollectionServiceCollectionNoAccess(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.CollectionService()
+
+	ca := cabby.CollectionAccess{ID: tester.Collection.ID, CanRead: false, CanWrite: true}
+	err := ds.UserService().UpdateUserCollection(context.Background(), tester.UserEmail, ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.Collection(tester.Context, tester.APIRootPath, tester.Collection.ID.String())
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	if !result.ID.IsEmpty() {
+		t.Error("Got:", result.ID, "Expected no collection")
+	}
+}
+
+func TestCollectionServiceCollections(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.CollectionService()
+
+	for i := 0; i < 10; i++ {
+		id, _ := cabby.NewID()
+		createCollection(ds, id.String())
+	}
+
+	tests := []struct {
+		cabbyRange          cabby.Range
+		expectedCollections int
+	}{
+		{cabby.Range{First: -1, Last: -1}, 11},
+		{cabby.Range{First: 0, Last: 4}, 5},
+	}
+
+	for _, test := range tests {
+		results, err := s.Collections(tester.Context, tester.APIRootPath, &test.cabbyRange)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+
+		if len(results.Collections) != test.expectedCollections {
+			t.Error("Got:", len(results.Collections), "Expected:", test.expectedCollections)
+		}
+
+		if test.cabbyRange.Total != 11 {
+			t.Error("Got:", test.cabbyRange.Total, "Expected:", 11)
+		}
+
+		if results.UpdatedAt.IsZero() {
+			t.Error("Got:", results.UpdatedAt, "Expected an updated time")
+		}
+	}
+}
+
+func TestCollectionServiceCollectionsInAPIRoot(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.CollectionService()
+
+	result, err := s.CollectionsInAPIRoot(context.Background(), tester.APIRootPath)
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	if len(result.CollectionIDs) != 1 || result.CollectionIDs[0].String() != tester.CollectionID {
+		t.Error("Got:", result.CollectionIDs, "Expected:", tester.CollectionID)
+	}
+}
+
+func TestCollectionServiceUpdateCollection(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.CollectionService()
+
+	expected := tester.Collection
+	expected.Title = "an updated title"
+
+	err := s.UpdateCollection(context.Background(), expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, _ := s.Collection(tester.Context, tester.APIRootPath, expected.ID.String())
+	if result.Title != expected.Title {
+		t.Error("Got:", result.Title, "Expected:", expected.Title)
+	}
+}
+
+func TestCollectionServiceDeleteCollection(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.CollectionService()
+
+	err := s.DeleteCollection(context.Background(), tester.CollectionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, _ := s.Collection(tester.Context, tester.APIRootPath, tester.CollectionID)
+	if !result.ID.IsEmpty() {
+		t.Error("Got:", result.ID, "Expected no collection")
+	}
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestDiscoveryServiceDiscovery(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.DiscoveryService()
+
+	result, err := s.Discovery(context.Background())
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	passed := tester.CompareDiscovery(result, tester.DiscoveryDataStore)
+	if !passed {
+		t.Error("Comparison failed")
+	}
+}
+
+func TestDiscoveryServiceCreateDiscoveryOnlyOne(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.DiscoveryService()
+
+	err := s.CreateDiscovery(context.Background(), tester.Discovery)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestDiscoveryServiceUpdateDiscovery(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.DiscoveryService()
+
+	expected := tester.DiscoveryDataStore
+	expected.Title = "an updated title"
+
+	err := s.UpdateDiscovery(context.Background(), expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, _ := s.Discovery(context.Background())
+	if result.Title != expected.Title {
+		t.Error("Got:", result.Title, "Expected:", expected.Title)
+	}
+}
+
+func TestDiscoveryServiceDeleteDiscovery(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.DiscoveryService()
+
+	err := s.DeleteDiscovery(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, _ := s.Discovery(context.Background())
+	if result.Title != "" {
+		t.Error("Got:", result.Title, "Expected no discovery")
+	}
+}
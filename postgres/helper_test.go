@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+	"github.com/pladdy/stones"
+)
+
+// testURLEnv can point the tests at an existing server; its public schema is dropped by every test
+const testURLEnv = "CABBY_TEST_POSTGRES_URL"
+
+var testURL string
+
+func TestMain(m *testing.M) {
+	stop := startPostgres()
+	code := m.Run()
+	stop()
+	os.Exit(code)
+}
+
+/* helpers */
+
+func createAPIRoot(ds *DataStore) {
+	err := ds.APIRootService().CreateAPIRoot(context.Background(), tester.APIRoot)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+}
+
+func createCollection(ds *DataStore, id string) {
+	cid, _ := cabby.IDFromString(id)
+	c := tester.Collection
+	c.ID = cid
+
+	err := ds.CollectionService().CreateCollection(context.Background(), c)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+
+	ca := cabby.CollectionAccess{ID: c.ID, CanRead: true, CanWrite: true}
+	err = ds.UserService().CreateUserCollection(context.Background(), tester.UserEmail, ca)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+}
+
+func createDiscovery(ds *DataStore) {
+	err := ds.DiscoveryService().CreateDiscovery(context.Background(), tester.Discovery)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+}
+
+func createObject(ds *DataStore, id string) {
+	o := tester.Object
+	o.ID = stones.ID(id)
+
+	err := ds.ObjectService().CreateObject(context.Background(), o)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+}
+
+func createObjectVersion(ds *DataStore, id, version string) {
+	o := tester.Object
+	o.ID = stones.ID(id)
+	o.Modified = version
+	o.Created = time.Now().UTC().Format(time.RFC3339Nano)
+
+	err := ds.ObjectService().CreateObject(context.Background(), o)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+}
+
+func createUser(ds *DataStore) {
+	err := ds.UserService().CreateUser(context.Background(), tester.User, tester.UserPassword)
+	if err != nil {
+		tester.Error.Fatal(err)
+	}
+}
+
+// setupPostgres recreates the schema and the tester resources; without a server the test is skipped
+func setupPostgres(t *testing.T) *DataStore {
+	if testURL == "" {
+		t.Skip("No postgres server, set " + testURLEnv + " or put initdb and pg_ctl on the path")
+	}
+
+	ds, err := NewDataStore(testURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ds.DB.Exec(`drop schema public cascade; create schema public`)
+	if err != nil {
+		t.Fatal("Couldn't reset schema: ", err)
+	}
+
+	_, err = ds.Migrate(context.Background())
+	if err != nil {
+		t.Fatal("Couldn't migrate schema: ", err)
+	}
+
+	createUser(ds)
+	createDiscovery(ds)
+	createAPIRoot(ds)
+	createCollection(ds, tester.Collection.ID.String())
+	createObject(ds, string(tester.Object.ID))
+	return ds
+}
+
+// startPostgres starts a throwaway server if the postgres binaries are on the path and returns a function to stop
+// it; a url in the environment is used instead if one is set
+func startPostgres() (stop func()) {
+	stop = func() {}
+
+	if testURL = os.Getenv(testURLEnv); testURL != "" {
+		return
+	}
+
+	initdb, initdbErr := exec.LookPath("initdb")
+	pgCtl, pgCtlErr := exec.LookPath("pg_ctl")
+	if initdbErr != nil || pgCtlErr != nil {
+		tester.Info.Println("No postgres binaries found, data store tests will be skipped")
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "cabby-postgres")
+	if err != nil {
+		tester.Error.Println("Couldn't create a data directory:", err)
+		return
+	}
+
+	port, err := freePort()
+	if err != nil {
+		tester.Error.Println("Couldn't find a port:", err)
+		os.RemoveAll(dir)
+		return
+	}
+
+	err = exec.Command(initdb, "-D", dir, "-U", "cabby", "--auth=trust").Run()
+	if err == nil {
+		options := fmt.Sprintf("-p %d -k %s -c listen_addresses=''", port, dir)
+		err = exec.Command(pgCtl, "-D", dir, "-o", options, "-l", filepath.Join(dir, "postgres.log"), "-w", "start").Run()
+	}
+	if err != nil {
+		tester.Error.Println("Couldn't start postgres, data store tests will be skipped:", err)
+		os.RemoveAll(dir)
+		return
+	}
+
+	testURL = fmt.Sprintf("postgres://cabby@/postgres?host=%s&port=%d&sslmode=disable", dir, port)
+	tester.Info.Println("Started test postgres in", dir)
+
+	return func() {
+		exec.Command(pgCtl, "-D", dir, "-m", "fast", "-w", "stop").Run()
+		os.RemoveAll(dir)
+	}
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// manifestEntry returns the manifest entry for the tester object; its date added is set by the data store
+func manifestEntry(t *testing.T, ds *DataStore) cabby.ManifestEntry {
+	objects, err := ds.ObjectService().Object(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{})
+	if err != nil || len(objects) != 1 {
+		t.Fatal("Can't read tester object: ", err)
+	}
+
+	entry := tester.ManifestEntry
+	entry.DateAdded = objects[0].DateAdded.Format(cabby.TimestampFormat)
+	return entry
+}
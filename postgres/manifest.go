@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	cabby "github.com/pladdy/cabby2"
+)
+
+// ManifestService implements a Postgres version of the ManifestService interface
+type ManifestService struct {
+	DB *sql.DB
+}
+
+// IterateManifest will read from the data store and return an iterator over the resource; the range total is
+// set once Next is called
+func (s ManifestService) IterateManifest(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+	resource, action := "Manifest", "iterate"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.iterateManifest(collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// manifestSortColumns are the columns manifest entries are sorted on; an entry's versions is the modified time of
+// its one version
+var manifestSortColumns = map[string]string{
+	cabby.SortDateAdded: "created_at",
+	cabby.SortID:        "id",
+	cabby.SortModified:  `case when versions ~ '^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(\.\d+){0,1}Z$' then versions::timestamptz end`,
+}
+
+func (s ManifestService) iterateManifest(collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+	sql := `with data as (
+						select row_id, id, max(created_at) as created_at, string_agg(modified, ',') as versions,
+						  max(updated_at) as updated_at, 1 as count
+						-- media_types omitted...should that be in this table?
+						from stix_objects_data
+						where
+							collection_id = ?
+							and id not in (select id from stix_objects_expired where collection_id = ?)
+							and $filter
+						group by row_id, id
+					),
+					after as (
+						select * from data
+						$after
+					),
+					page as (
+						select * from after
+						order by $order
+						$paginate
+					)
+					select id, created_at, versions, (select max(updated_at) from data) as last_updated_at,
+					  (select min(created_at) from page) as first_added, (select max(created_at) from page) as last_added,
+					  ` + pageColumns + `
+					from page
+					order by $order`
+
+	args := []interface{}{collectionID, collectionID}
+
+	sql, args = applyFiltering(sql, f, args)
+	sql, args = applyCursor(sql, cr, manifestSortColumns, args)
+	sql, args = applyPaging(sql, cr, args)
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return nil, err
+	}
+	return &manifestRows{rows: rows, cr: cr}, nil
+}
+
+// Manifest will read from the data store and return the resource
+func (s ManifestService) Manifest(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error) {
+	resource, action := "Manifest", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.manifest(collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ManifestService) manifest(collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error) {
+	m := cabby.Manifest{}
+
+	mr, err := s.iterateManifest(collectionID, cr, f)
+	if err != nil {
+		return m, err
+	}
+	defer mr.Close()
+
+	for mr.Next() {
+		m.Objects = append(m.Objects, mr.Entry())
+	}
+
+	err = mr.Err()
+	return m, err
+}
+
+// manifestRows implements a cabby.ManifestIterator over sql rows
+type manifestRows struct {
+	rows       *sql.Rows
+	cr         *cabby.Range
+	entry      cabby.ManifestEntry
+	updatedAt  time.Time
+	firstAdded time.Time
+	lastAdded  time.Time
+	err        error
+}
+
+func (m *manifestRows) Next() bool {
+	if m.err != nil || !m.rows.Next() {
+		return false
+	}
+
+	m.entry = cabby.ManifestEntry{}
+	var versions string
+	var createdAt, lastUpdatedAt, firstAdded, lastAdded, nextAdded pq.NullTime
+	var total, remaining, nextRow int64
+
+	m.err = m.rows.Scan(&m.entry.ID, &createdAt, &versions, &lastUpdatedAt, &firstAdded, &lastAdded,
+		&total, &remaining, &nextAdded, &nextRow)
+	if m.err != nil {
+		return false
+	}
+	setPage(m.cr, total, remaining, nextAdded, nextRow)
+	m.updatedAt = laterUpdate(m.updatedAt, lastUpdatedAt)
+	m.firstAdded, m.lastAdded = utcTime(firstAdded), utcTime(lastAdded)
+
+	m.entry.DateAdded = utcTime(createdAt).Format(cabby.TimestampFormat)
+
+	m.entry.MediaTypes = []string{cabby.StixContentType}
+	m.entry.Versions = strings.Split(string(versions), ",")
+	return true
+}
+
+func (m *manifestRows) Entry() cabby.ManifestEntry {
+	return m.entry
+}
+
+func (m *manifestRows) UpdatedAt() time.Time {
+	return m.updatedAt
+}
+
+func (m *manifestRows) DateAdded() (time.Time, time.Time) {
+	return m.firstAdded, m.lastAdded
+}
+
+func (m *manifestRows) Err() error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.rows.Err()
+}
+
+func (m *manifestRows) Close() error {
+	return m.rows.Close()
+}
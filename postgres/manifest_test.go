@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestManifestServiceManifest(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.ManifestService()
+
+	result, err := s.Manifest(context.Background(), tester.CollectionID, &cabby.Range{First: -1, Last: -1}, cabby.Filter{})
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	if len(result.Objects) != 1 {
+		t.Fatal("Got:", len(result.Objects), "Expected:", 1)
+	}
+
+	passed := tester.CompareManifestEntry(result.Objects[0], manifestEntry(t, ds))
+	if !passed {
+		t.Error("Comparison failed")
+	}
+}
+
+func TestManifestServiceManifestFilterAndRange(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.ManifestService()
+
+	ids := []cabby.ID{}
+	for i := 0; i < 10; i++ {
+		id, _ := cabby.NewID()
+		ids = append(ids, id)
+		createObject(ds, id.String())
+	}
+
+	tests := []struct {
+		filter          cabby.Filter
+		cabbyRange      cabby.Range
+		expectedEntries int
+		expectedTotal   int64
+	}{
+		{cabby.Filter{}, cabby.Range{First: -1, Last: -1}, 11, 11},
+		{cabby.Filter{}, cabby.Range{First: 0, Last: 5}, 6, 11},
+		{cabby.Filter{Types: "foo"}, cabby.Range{First: -1, Last: -1}, 0, 0},
+		{cabby.Filter{IDs: ids[0].String()}, cabby.Range{First: -1, Last: -1}, 1, 1},
+	}
+
+	for _, test := range tests {
+		results, err := s.Manifest(context.Background(), tester.CollectionID, &test.cabbyRange, test.filter)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+
+		if len(results.Objects) != test.expectedEntries {
+			t.Error("Got:", len(results.Objects), "Expected:", test.expectedEntries, "Filter:", test.filter)
+		}
+
+		if test.cabbyRange.Total != test.expectedTotal {
+			t.Error("Got:", test.cabbyRange.Total, "Expected:", test.expectedTotal, "Filter:", test.filter)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const schemaVersionTable = `create table if not exists schema_version (
+                              version     integer not null primary key,
+                              description text,
+                              applied_at  timestamptz not null default now()
+                            )`
+
+// migration is a versioned change to the schema
+type migration struct {
+	version     int
+	description string
+	sql         string
+}
+
+// LatestSchemaVersion returns the version of the schema this build expects
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// Migrate applies migrations newer than the data store's schema version, each in its own transaction, and returns
+// how many were applied.  Migrations only go forward; a schema newer than this build knows about is an error.
+func (s *DataStore) Migrate(ctx context.Context) (applied int, err error) {
+	if _, err = s.DB.ExecContext(ctx, schemaVersionTable); err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("Failed to create schema version table")
+		return
+	}
+
+	current, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return
+	}
+
+	if current > LatestSchemaVersion() {
+		return applied, newerSchemaError(current)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		if err = s.applyMigration(ctx, m); err != nil {
+			return
+		}
+		applied++
+	}
+	return
+}
+
+// SchemaVersion returns the version of the schema in the data store; a data store without migrations is version 0
+func (s *DataStore) SchemaVersion(ctx context.Context) (version int, err error) {
+	var tables int
+	sql := `select count(*) from information_schema.tables
+	        where table_schema = current_schema() and table_name = 'schema_version'`
+
+	if err = s.DB.QueryRowContext(ctx, sql).Scan(&tables); err != nil || tables == 0 {
+		return
+	}
+
+	sql = `select coalesce(max(version), 0) from schema_version`
+	err = s.DB.QueryRowContext(ctx, sql).Scan(&version)
+	if err != nil {
+		logSQLError(sql, nil, err)
+	}
+	return
+}
+
+// VerifySchema returns an error unless the data store's schema is the version this build expects
+func (s *DataStore) VerifySchema(ctx context.Context) error {
+	current, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if current > LatestSchemaVersion() {
+		return newerSchemaError(current)
+	}
+	if current < LatestSchemaVersion() {
+		return fmt.Errorf("Schema version %d is behind version %d, run 'cabby-cli migrate up'", current, LatestSchemaVersion())
+	}
+	return nil
+}
+
+func (s *DataStore) applyMigration(ctx context.Context, m migration) error {
+	log.WithFields(log.Fields{"version": m.version, "description": m.description}).Info("Applying migration")
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Failed to begin transaction")
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, m.sql); err != nil {
+		tx.Rollback()
+		log.WithFields(log.Fields{"error": err, "version": m.version}).Error("Failed to apply migration")
+		return err
+	}
+
+	sql := `insert into schema_version (version, description) values (?, ?)`
+	args := []interface{}{m.version, m.description}
+
+	if _, err = tx.ExecContext(ctx, rebind(sql), args...); err != nil {
+		tx.Rollback()
+		logSQLError(sql, args, err)
+		return err
+	}
+	return tx.Commit()
+}
+
+func newerSchemaError(version int) error {
+	return fmt.Errorf("Schema version %d is newer than version %d this build supports, upgrade cabby", version, LatestSchemaVersion())
+}
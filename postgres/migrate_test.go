@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Error("Got:", m.version, "Expected:", i+1, "Description:", m.description)
+		}
+		if strings.Contains(strings.ToLower(m.sql), "drop table") {
+			t.Error("Got: drop table", "Expected: forward only migrations", "Version:", m.version)
+		}
+	}
+}
+
+func TestDataStoreMigrate(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+
+	err := ds.VerifySchema(context.Background())
+	if err != nil {
+		t.Error("Got:", err, "Expected: nil")
+	}
+
+	// migrating again is a no-op
+	applied, err := ds.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 0 {
+		t.Error("Got:", applied, "Expected:", 0)
+	}
+
+	_, err = ds.DB.Exec(`insert into schema_version (version, description) values ($1, 'from the future')`,
+		LatestSchemaVersion()+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ds.Migrate(context.Background())
+	if err == nil {
+		t.Error("Expected an error migrating a newer schema")
+	}
+
+	err = ds.VerifySchema(context.Background())
+	if err == nil {
+		t.Error("Expected an error verifying a newer schema")
+	}
+}
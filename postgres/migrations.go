@@ -0,0 +1,384 @@
+package postgres
+
+// migrations are applied in order and never edited once released; a schema change is a new migration at the end
+// of the list.  Versions match the sqlite migrations so both data stores describe the same schema at a given version.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "initial schema",
+		sql: `
+create or replace function set_updated_at() returns trigger as $$
+  begin
+    new.updated_at = now();
+    return new;
+  end;
+$$ language plpgsql;
+
+/* stix */
+
+create table stix_objects (
+  row_id        bigserial   not null unique,
+  id            text        not null,
+  type          text        not null,
+  created       text        not null,
+  modified      text        not null,
+  object        jsonb       not null,
+  collection_id text        not null,
+  created_at    timestamptz not null default now(),
+  updated_at    timestamptz not null default now(),
+
+  primary key (id, modified)
+);
+
+  create trigger stix_objects_bu_updated_at before update on stix_objects
+    for each row execute procedure set_updated_at();
+
+  create index stix_objects_id on stix_objects (id);
+  create index stix_objects_type on stix_objects (type);
+  create index stix_objects_version on stix_objects (id, type, modified);
+  create index stix_objects_collection_id on stix_objects (collection_id, row_id);
+
+  create view stix_objects_id_aggregate as
+    select id,
+           type,
+           collection_id,
+           min(modified) as first,
+           max(modified) as last
+    from stix_objects
+    group by id,
+             type,
+             collection_id;
+
+  create view stix_objects_data as
+    select
+      so.row_id,
+      so.id,
+      so.type,
+      so.created,
+      so.modified,
+      so.object,
+      so.collection_id,
+      case when so.modified = sa.first and so.modified = sa.last then 'only'
+           when so.modified = sa.last then 'last'
+           when so.modified = sa.first then 'first'
+      end as version,
+      so.created_at,
+      so.updated_at
+    from
+      stix_objects so
+      left join stix_objects_id_aggregate sa
+        on so.id = sa.id
+        and so.collection_id = sa.collection_id;
+
+/* taxii */
+
+create table taxii_api_root (
+  id                 serial      not null primary key,
+  discovery_id       integer     not null default 1 check (discovery_id = 1),
+  api_root_path      text        not null unique check (api_root_path <> ''),
+  title              text        not null,
+  description        text,
+  versions           text,
+  max_content_length bigint      not null,
+  created_at         timestamptz not null default now(),
+  updated_at         timestamptz not null default now()
+);
+
+  create trigger taxii_api_root_bu_updated_at before update on taxii_api_root
+    for each row execute procedure set_updated_at();
+
+create table taxii_collection (
+  id            text        not null primary key,
+  api_root_path text        not null,
+  title         text,
+  description   text,
+  media_types   text        default '',
+  created_at    timestamptz not null default now(),
+  updated_at    timestamptz not null default now()
+);
+
+  create trigger taxii_collection_bu_updated_at before update on taxii_collection
+    for each row execute procedure set_updated_at();
+
+/* there can only be one discovery */
+create table taxii_discovery (
+  id          integer     not null default 1 primary key check (id = 1),
+  title       text        not null,
+  description text,
+  contact     text,
+  default_url text,
+  created_at  timestamptz not null default now(),
+  updated_at  timestamptz not null default now()
+);
+
+  create trigger taxii_discovery_bu_updated_at before update on taxii_discovery
+    for each row execute procedure set_updated_at();
+
+create table taxii_status (
+  id                text        not null,
+  status            text        not null,
+  request_timestamp text,
+  total_count       bigint      not null,
+  success_count     bigint      not null,
+  successes         text,
+  failure_count     bigint      not null,
+  failures          text,
+  pending_count     bigint      not null,
+  pendings          text,
+  /* internal */
+  created_at        timestamptz not null default now(),
+  updated_at        timestamptz not null default now()
+);
+
+  create trigger taxii_status_bu_updated_at before update on taxii_status
+    for each row execute procedure set_updated_at();
+
+  create index taxii_status_taxii_id on taxii_status (id);
+
+create table taxii_user (
+  email      text        not null primary key check (email like '%_@__%.__%'),
+  can_admin  boolean     not null default false,
+  created_at timestamptz not null default now(),
+  updated_at timestamptz not null default now()
+);
+
+  create trigger taxii_user_bu_updated_at before update on taxii_user
+    for each row execute procedure set_updated_at();
+
+create table taxii_user_collection (
+  id            serial      not null primary key,
+  email         text        not null references taxii_user (email) on delete cascade,
+  collection_id text        not null,
+  can_read      boolean     not null,
+  can_write     boolean     not null,
+  created_at    timestamptz not null default now(),
+  updated_at    timestamptz not null default now(),
+
+  unique (email, collection_id)
+);
+
+  create trigger taxii_user_collection_bu_updated_at before update on taxii_user_collection
+    for each row execute procedure set_updated_at();
+
+create table taxii_user_pass (
+  id         serial      not null primary key,
+  email      text        not null unique references taxii_user (email) on delete cascade,
+  -- check password is not empty string or sha256 of empty string
+  pass       text        not null check (
+               pass not in ('', 'e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855')
+               and length(pass) = 64
+             ),
+  created_at timestamptz not null default now(),
+  updated_at timestamptz not null default now()
+);
+
+  create trigger taxii_user_pass_bu_updated_at before update on taxii_user_pass
+    for each row execute procedure set_updated_at();
+`,
+	},
+	{
+		version:     2,
+		description: "track status owner and collection",
+		sql: `
+alter table taxii_status add column email text;
+alter table taxii_status add column collection_id text;
+
+  create index taxii_status_created_at on taxii_status (created_at);
+  create index taxii_status_email on taxii_status (email);
+  create index taxii_status_collection_id on taxii_status (collection_id);
+`,
+	},
+	{
+		version:     3,
+		description: "key objects by collection",
+		sql: `
+alter table stix_objects drop constraint stix_objects_pkey;
+alter table stix_objects add primary key (collection_id, id, modified);
+`,
+	},
+	{
+		version:     4,
+		description: "set an object version's created_at once",
+		sql: `
+/* created_at already defaults to the time a version is written and isn't updated; this version matches the sqlite
+   migration that fixed its triggers */
+
+comment on column stix_objects.created_at is 'when the version was added to the collection; it does not change';
+`,
+	},
+	{
+		version:     5,
+		description: "version policy per collection",
+		sql: `
+/* what's done with posted versions that are already in a collection or older than its latest version; null is the
+   default policy, reject */
+
+alter table taxii_collection add column version_policy text;
+`,
+	},
+	{
+		version:     6,
+		description: "version retention per collection",
+		sql: `
+/* how many of an object's latest versions are kept, or for how many seconds after they're modified; null keeps every
+   version */
+
+alter table taxii_collection add column retain_versions integer;
+alter table taxii_collection add column retain_window integer;
+`,
+	},
+	{
+		version:     7,
+		description: "object expiry per collection",
+		sql: `
+/* objects expire by the date their latest version was added, or by its valid_until, plus max_age seconds; null never
+   expires them */
+
+alter table taxii_collection add column expire_by text;
+alter table taxii_collection add column max_age integer;
+
+  /* the objects whose latest version has expired; every version of them is hidden from reads until it's purged.  a
+     valid_until that isn't a timestamp doesn't expire an object */
+  create view stix_objects_expired as
+    select so.collection_id, so.id
+    from
+      stix_objects_data so
+      inner join taxii_collection c
+        on so.collection_id = c.id
+    where
+      so.version in ('last', 'only')
+      and case
+        when c.expire_by = 'added'
+          then so.created_at < now() - make_interval(secs => c.max_age)
+        when c.expire_by = 'valid_until'
+             and so.object->>'valid_until' ~ '^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(\.\d+)?Z$'
+          then (so.object->>'valid_until')::timestamptz < now() - make_interval(secs => coalesce(c.max_age, 0))
+        else false
+      end;
+`,
+	},
+	{
+		version:     8,
+		description: "object tombstones",
+		sql: `
+/* object versions deleted from a collection; clients replicating a collection read them to delete their copies */
+
+create table stix_objects_tombstone (
+  collection_id text        not null,
+  id            text        not null,
+  modified      text        not null,
+  deleted_at    timestamptz not null default now()
+);
+
+  create index stix_objects_tombstone_deleted_at on stix_objects_tombstone (collection_id, deleted_at);
+`,
+	},
+	{
+		version:     9,
+		description: "object property indexes",
+		sql: `
+/* match filters query into the stored objects; the scalar properties most filtered on are indexed, matching the
+   expressions the filters use */
+
+  create index stix_objects_created_by_ref on stix_objects (collection_id, (object->>'created_by_ref'));
+  create index stix_objects_relationship_type on stix_objects (collection_id, (object->>'relationship_type'));
+  create index stix_objects_source_ref on stix_objects (collection_id, (object->>'source_ref'));
+  create index stix_objects_target_ref on stix_objects (collection_id, (object->>'target_ref'));
+  create index stix_objects_spec_version on stix_objects (collection_id, (coalesce(object->>'spec_version', '2.0')));
+`,
+	},
+	{
+		version:     10,
+		description: "page sizes per api root",
+		sql: `
+/* how many items a page of a listing has when a request doesn't ask for a range, and the most it can ask for; null
+   doesn't limit pages */
+
+alter table taxii_api_root add column default_page_size bigint;
+alter table taxii_api_root add column max_page_size bigint;
+`,
+	},
+	{
+		version:     11,
+		description: "object version flags kept at write time",
+		sql: `
+/* whether a version is an object's first, last or only version was worked out on every read by grouping every object
+   in every collection; the first and last versions of each object are kept in a table a trigger maintains, and the
+   data view looks them up by key */
+
+create table stix_objects_versions (
+  collection_id  text not null,
+  id             text not null,
+  first_modified text not null,
+  last_modified  text not null,
+
+  primary key (collection_id, id)
+);
+
+insert into stix_objects_versions (collection_id, id, first_modified, last_modified)
+  select collection_id, id, min(modified), max(modified)
+  from stix_objects
+  group by collection_id, id;
+
+create or replace function set_object_versions() returns trigger as $$
+  begin
+    if tg_op = 'INSERT' then
+      insert into stix_objects_versions (collection_id, id, first_modified, last_modified)
+        values (new.collection_id, new.id, new.modified, new.modified)
+        on conflict (collection_id, id) do update
+        set first_modified = least(stix_objects_versions.first_modified, excluded.first_modified),
+            last_modified = greatest(stix_objects_versions.last_modified, excluded.last_modified);
+      return new;
+    end if;
+
+    delete from stix_objects_versions where collection_id = old.collection_id and id = old.id;
+    insert into stix_objects_versions (collection_id, id, first_modified, last_modified)
+      select collection_id, id, min(modified), max(modified)
+      from stix_objects
+      where collection_id = old.collection_id and id = old.id
+      group by collection_id, id;
+    return old;
+  end;
+$$ language plpgsql;
+
+  create trigger stix_objects_aid_versions after insert or delete on stix_objects
+    for each row execute procedure set_object_versions();
+
+  create or replace view stix_objects_data as
+    select
+      so.row_id,
+      so.id,
+      so.type,
+      so.created,
+      so.modified,
+      so.object,
+      so.collection_id,
+      case when so.modified = sv.first_modified and so.modified = sv.last_modified then 'only'
+           when so.modified = sv.last_modified then 'last'
+           when so.modified = sv.first_modified then 'first'
+      end as version,
+      so.created_at,
+      so.updated_at
+    from
+      stix_objects so
+      left join stix_objects_versions sv
+        on so.collection_id = sv.collection_id
+        and so.id = sv.id;
+
+drop view stix_objects_id_aggregate;
+
+  /* reads are of a collection, by the date objects were added or by type */
+  create index stix_objects_collection_created_at on stix_objects (collection_id, created_at, row_id);
+  create index stix_objects_collection_type on stix_objects (collection_id, type);
+`,
+	},
+	{
+		version:     12,
+		description: "status row ids",
+		sql: `
+/* statuses created at the same time are listed newest first by the order they were inserted; statuses that were
+   already there are numbered in no particular order */
+alter table taxii_status add column row_id bigserial not null;
+`,
+	},
+}
@@ -0,0 +1,730 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	cabby "github.com/pladdy/cabby2"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	createObjectSQL = `insert into stix_objects (id, type, created, modified, object, collection_id)
+				             values (?, ?, ?, ?, ?::jsonb, ?)`
+	// collections that overwrite versions replace the stored version; the date it was added doesn't change
+	overwriteObjectSQL = createObjectSQL + `
+	                       on conflict (collection_id, id, modified) do update
+	                       set type = excluded.type, created = excluded.created, object = excluded.object`
+	batchBufferSize = 50
+)
+
+// ObjectService implements a Postgres version of the ObjectService interface
+type ObjectService struct {
+	DB        *sql.DB
+	DataStore *DataStore
+}
+
+// CompactObjects will delete the object versions collections don't retain
+func (s ObjectService) CompactObjects(ctx context.Context) (int64, error) {
+	resource, action := "Objects", "compact"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.compactObjects(time.Now().In(time.UTC))
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ObjectService) compactObjects(now time.Time) (int64, error) {
+	collections, err := s.retainingCollections()
+	if err != nil {
+		return 0, err
+	}
+
+	var compacted int64
+	for _, c := range collections {
+		versions, err := s.objectVersions(c.ID.String())
+		if err != nil {
+			return compacted, err
+		}
+
+		for id, modified := range versions {
+			for _, expired := range c.ExpiredVersions(modified, now) {
+				deleted, err := s.deleteObjectVersion(c.ID.String(), id, expired)
+				if err != nil {
+					return compacted, err
+				}
+				compacted += deleted
+			}
+		}
+	}
+	return compacted, nil
+}
+
+func (s ObjectService) deleteObjectVersion(collectionID, objectID, modified string) (int64, error) {
+	sql := `delete from stix_objects where collection_id = ? and id = ? and modified = ?`
+	args := []interface{}{collectionID, objectID, modified}
+
+	result, err := s.DB.Exec(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// objectVersions returns the modified times of every object version in a collection by object id
+func (s ObjectService) objectVersions(collectionID string) (map[string][]string, error) {
+	sql := `select id, modified from stix_objects where collection_id = ?`
+	args := []interface{}{collectionID}
+
+	versions := map[string][]string{}
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return versions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, modified string
+		if err := rows.Scan(&id, &modified); err != nil {
+			return versions, err
+		}
+		versions[id] = append(versions[id], modified)
+	}
+
+	err = rows.Err()
+	return versions, err
+}
+
+// retainingCollections returns the collections that don't keep every object version
+func (s ObjectService) retainingCollections() ([]cabby.Collection, error) {
+	sql := `select id, coalesce(retain_versions, 0), coalesce(retain_window, 0) from taxii_collection
+					where coalesce(retain_versions, 0) > 0 or coalesce(retain_window, 0) > 0`
+
+	var collections []cabby.Collection
+
+	rows, err := s.DB.Query(rebind(sql))
+	if err != nil {
+		logSQLError(sql, nil, err)
+		return collections, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c cabby.Collection
+		var retainWindow int64
+
+		if err := rows.Scan(&c.ID, &c.RetainVersions, &retainWindow); err != nil {
+			return collections, err
+		}
+		c.RetainWindow = time.Duration(retainWindow) * time.Second
+		collections = append(collections, c)
+	}
+
+	err = rows.Err()
+	return collections, err
+}
+
+// CreateBundle will write objects to the data store as they're received and update the status when done
+func (s ObjectService) CreateBundle(ctx context.Context, objects <-chan []byte, collectionID string, st cabby.Status, ss cabby.StatusService) {
+	resource, action := "Bundle", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	s.createBundle(ctx, objects, collectionID, st, ss)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+}
+
+func (s ObjectService) createBundle(ctx context.Context, objects <-chan []byte, collectionID string, st cabby.Status, ss cabby.StatusService) {
+	errs := make(chan error, batchBufferSize)
+	toWrite := make(chan interface{}, batchBufferSize)
+
+	policy := s.versionPolicy(collectionID)
+	go s.DataStore.batchWrite(writeObjectSQL(policy), toWrite, errs)
+
+	writeFailures := make(chan []cabby.StatusFailure)
+	go collectFailures(errs, writeFailures)
+
+	// versions are resolved in the batch's transaction so they see versions written earlier in the bundle; ignored
+	// versions are only read once the batch is done
+	var failures []cabby.StatusFailure
+	var ignored []string
+	total, canceled := int64(0), int64(0)
+
+	// the channel is always drained so the sender doesn't block, even after a cancel
+	for object := range objects {
+		total++
+
+		if canceled > 0 {
+			canceled++
+			continue
+		}
+
+		if total > 1 && (total-1)%batchBufferSize == 0 {
+			s.updateStatusProgress(st, total-1)
+
+			if cabby.StatusCanceled(ctx, st, ss) {
+				canceled++
+				log.WithFields(log.Fields{"status_id": st.ID}).Warn("Status canceled, stopping bundle")
+				continue
+			}
+		}
+
+		o, err := cabby.NewObject(object)
+		if err != nil {
+			failures = append(failures, cabby.StatusFailure{ID: string(o.ID), Version: o.Modified, Message: err.Error()})
+			continue
+		}
+
+		log.WithFields(log.Fields{"id": o.ID}).Info("Sending to data store")
+		toWrite <- objectVersion(policy, o, collectionID, &ignored)
+	}
+	close(toWrite)
+
+	st.TotalCount = total
+	st.Failures = append(failures, <-writeFailures...)
+	st.Successes = ignored
+	cabby.UpdateStatusCounts(ctx, st, canceled, ss)
+}
+
+// objectVersion is a batch write of an object version; the version policy is applied in the batch's transaction and
+// the ids of ignored versions are added to ignored
+func objectVersion(policy string, o cabby.Object, collectionID string, ignored *[]string) batchOperation {
+	args := []interface{}{o.ID, o.Type, o.Created, o.Modified, string(o.Object), collectionID}
+
+	return batchOperation{args: args, fn: func(tx *sql.Tx, stmt *sql.Stmt) error {
+		action, err := resolveVersion(tx, policy, o, collectionID)
+		if err != nil {
+			return err
+		}
+		if action == cabby.IgnoreVersion {
+			*ignored = append(*ignored, string(o.ID))
+			return nil
+		}
+
+		_, err = stmt.Exec(args...)
+		if err != nil {
+			log.WithFields(log.Fields{"err": err, "args": args}).Error("Failed to execute")
+		}
+		return err
+	}}
+}
+
+// CreateObject will read from the data store and return the resource
+func (s ObjectService) CreateObject(ctx context.Context, object cabby.Object) error {
+	resource, action := "Object", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.createObject(object)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+// the version is resolved and written in one transaction so a version written between the two isn't missed
+func (s ObjectService) createObject(o cabby.Object) error {
+	policy := s.versionPolicy(o.CollectionID.String())
+
+	return s.DataStore.transaction(func(tx *sql.Tx) error {
+		action, err := resolveVersion(tx, policy, o, o.CollectionID.String())
+		if err != nil || action == cabby.IgnoreVersion {
+			return err
+		}
+
+		sql := writeObjectSQL(policy)
+		args := []interface{}{o.ID, o.Type, o.Created, o.Modified, string(o.Object), o.CollectionID}
+
+		_, err = tx.Exec(rebind(sql), args...)
+		if err != nil {
+			logSQLError(sql, args, err)
+		}
+		return err
+	})
+}
+
+// DeleteObject will delete the versions of an object in the filter and record a tombstone for each
+func (s ObjectService) DeleteObject(ctx context.Context, collectionID, objectID string, f cabby.Filter) (int64, error) {
+	resource, action := "Object", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.deleteObjectVersions(collectionID, objectID, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// the versions are read before any are deleted; deleting a version changes which versions are first and last
+func (s ObjectService) deleteObjectVersions(collectionID, objectID string, f cabby.Filter) (int64, error) {
+	versions, err := s.object(collectionID, objectID, f)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, v := range versions {
+		count, err := s.deleteObjectVersion(collectionID, objectID, v.Modified)
+		if err != nil {
+			return deleted, err
+		}
+		if count <= 0 {
+			continue
+		}
+
+		if err := s.createTombstone(collectionID, objectID, v.Modified); err != nil {
+			return deleted, err
+		}
+		deleted += count
+	}
+	return deleted, nil
+}
+
+func (s ObjectService) createTombstone(collectionID, objectID, modified string) error {
+	sql := `insert into stix_objects_tombstone (collection_id, id, modified) values (?, ?, ?)`
+	args := []interface{}{collectionID, objectID, modified}
+
+	err := s.DataStore.write(sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+	}
+	return err
+}
+
+// Object will read from the data store and return the resource
+func (s ObjectService) Object(ctx context.Context, collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
+	resource, action := "Object", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.object(collectionID, objectID, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ObjectService) object(collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
+	sql := `select id, type, created, modified, object, collection_id, created_at, updated_at
+	        from stix_objects_data
+					where
+					  collection_id = ?
+						and id = ?
+						and id not in (select id from stix_objects_expired where collection_id = ?)
+						and $filter
+					order by row_id`
+
+	args := []interface{}{collectionID, objectID, collectionID}
+	sql, args = applyFiltering(sql, f, args)
+
+	objects := []cabby.Object{}
+	var err error
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return objects, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var o cabby.Object
+		var createdAt, updatedAt pq.NullTime
+
+		if err := rows.Scan(&o.ID, &o.Type, &o.Created, &o.Modified, &o.Object, &o.CollectionID, &createdAt, &updatedAt); err != nil {
+			return objects, err
+		}
+		o.DateAdded = utcTime(createdAt)
+		o.UpdatedAt = laterUpdate(o.UpdatedAt, updatedAt)
+		objects = append(objects, o)
+	}
+
+	err = rows.Err()
+	return objects, err
+}
+
+// IterateObjects will read from the data store and return an iterator over the resource; the range total is
+// set once Next is called
+func (s ObjectService) IterateObjects(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+	resource, action := "Objects", "iterate"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.iterateObjects(collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// objectSortColumns are the columns objects are sorted on; like versions, modified times are sorted as times when
+// they're timestamps
+var objectSortColumns = map[string]string{
+	cabby.SortDateAdded: "created_at",
+	cabby.SortID:        "id",
+	cabby.SortModified:  `case when modified ~ '^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(\.\d+){0,1}Z$' then modified::timestamptz end`,
+}
+
+func (s ObjectService) iterateObjects(collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+	sql := `with data as (
+						select row_id, id, type, created, modified, object, collection_id, created_at, updated_at, 1 as count
+						from stix_objects_data
+						where
+							collection_id = ?
+							and id not in (select id from stix_objects_expired where collection_id = ?)
+							and $filter
+					),
+					after as (
+						select * from data
+						$after
+					),
+					page as (
+						select * from after
+						order by $order
+						$paginate
+					)
+					select id, type, created, modified, object, collection_id, created_at, updated_at,
+					  (select max(updated_at) from data) as last_updated_at,
+					  (select min(created_at) from page) as first_added, (select max(created_at) from page) as last_added,
+					  ` + pageColumns + `
+					from page
+					order by $order`
+
+	args := []interface{}{collectionID, collectionID}
+
+	sql, args = applyFiltering(sql, f, args)
+	sql, args = applyCursor(sql, cr, objectSortColumns, args)
+	sql, args = applyPaging(sql, cr, args)
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return nil, err
+	}
+	return &objectRows{rows: rows, cr: cr}, nil
+}
+
+// Objects will read from the data store and return the resource
+func (s ObjectService) Objects(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
+	resource, action := "Objects", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.objects(collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ObjectService) objects(collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
+	objects := []cabby.Object{}
+
+	or, err := s.iterateObjects(collectionID, cr, f)
+	if err != nil {
+		return objects, err
+	}
+	defer or.Close()
+
+	for or.Next() {
+		objects = append(objects, or.Object())
+	}
+
+	err = or.Err()
+	return objects, err
+}
+
+// PurgeObjects will delete every version of the objects that have expired in their collections
+func (s ObjectService) PurgeObjects(ctx context.Context) (int64, error) {
+	resource, action := "Objects", "purge"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.purgeObjects()
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// expired objects are read before any are deleted; deleting an object's latest version would change which version
+// the view sees as latest
+func (s ObjectService) purgeObjects() (int64, error) {
+	sql := `select collection_id, id from stix_objects_expired`
+
+	type expiredObject struct{ collectionID, id string }
+	var expired []expiredObject
+
+	rows, err := s.DB.Query(rebind(sql))
+	if err != nil {
+		logSQLError(sql, nil, err)
+		return 0, err
+	}
+
+	for rows.Next() {
+		var e expiredObject
+		if err := rows.Scan(&e.collectionID, &e.id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		expired = append(expired, e)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, e := range expired {
+		deleted, err := s.deleteObject(e.collectionID, e.id)
+		if err != nil {
+			return purged, err
+		}
+		purged += deleted
+	}
+	return purged, nil
+}
+
+func (s ObjectService) deleteObject(collectionID, objectID string) (int64, error) {
+	sql := `delete from stix_objects where collection_id = ? and id = ?`
+	args := []interface{}{collectionID, objectID}
+
+	result, err := s.DB.Exec(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Tombstones will read the object versions deleted from a collection; the filter's added after is compared to when
+// they were deleted
+func (s ObjectService) Tombstones(ctx context.Context, collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+	resource, action := "Tombstones", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.tombstones(collectionID, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// like the other data stores, an added after that can't be parsed matches nothing
+func (s ObjectService) tombstones(collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+	sql := `select id, modified, deleted_at from stix_objects_tombstone where collection_id = ?`
+	args := []interface{}{collectionID}
+
+	tombstones := []cabby.Tombstone{}
+
+	if len(f.AddedAfter) > 0 {
+		t, err := time.Parse(time.RFC3339Nano, f.AddedAfter)
+		if err != nil {
+			return tombstones, nil
+		}
+		sql += ` and date_trunc('milliseconds', deleted_at) > date_trunc('milliseconds', ?::timestamptz)`
+		args = append(args, t)
+	}
+	sql += ` order by deleted_at`
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return tombstones, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t cabby.Tombstone
+		var deletedAt pq.NullTime
+
+		if err := rows.Scan(&t.ID, &t.Version, &deletedAt); err != nil {
+			return tombstones, err
+		}
+		t.DateDeleted = utcTime(deletedAt).Format(cabby.TimestampFormat)
+		tombstones = append(tombstones, t)
+	}
+
+	err = rows.Err()
+	return tombstones, err
+}
+
+// Versions will read the modified times of an object's versions in a collection, earliest first; the range total is
+// set
+func (s ObjectService) Versions(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+	resource, action := "Versions", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.versions(collectionID, objectID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// modified times are sorted as times when they're timestamps, so versions written with a different precision are
+// still in order; the pattern can't have a '?', it would be rebound as a placeholder
+func (s ObjectService) versions(collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+	sql := `with data as (
+						select modified, 1 count
+						from stix_objects_data
+						where
+							collection_id = ?
+							and id = ?
+							and id not in (select id from stix_objects_expired where collection_id = ?)
+							and $filter
+					)
+					select modified, (select sum(count) from data) total
+					from data
+					order by
+					  case when modified ~ '^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(\.\d+){0,1}Z$' then modified::timestamptz end,
+					  modified
+					$paginate`
+
+	args := []interface{}{collectionID, objectID, collectionID}
+
+	sql, args = applyFiltering(sql, f, args)
+	sql, args = applyPaging(sql, cr, args)
+
+	versions := []string{}
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return versions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var modified string
+		if err := rows.Scan(&modified, &cr.Total); err != nil {
+			return versions, err
+		}
+		versions = append(versions, modified)
+	}
+
+	err = rows.Err()
+	return versions, err
+}
+
+// objectRows implements a cabby.ObjectIterator over sql rows
+type objectRows struct {
+	rows       *sql.Rows
+	cr         *cabby.Range
+	object     cabby.Object
+	updatedAt  time.Time
+	firstAdded time.Time
+	lastAdded  time.Time
+	err        error
+}
+
+func (o *objectRows) Next() bool {
+	if o.err != nil || !o.rows.Next() {
+		return false
+	}
+
+	o.object = cabby.Object{}
+	var createdAt, updatedAt, lastUpdatedAt, firstAdded, lastAdded, nextAdded pq.NullTime
+	var total, remaining, nextRow int64
+
+	o.err = o.rows.Scan(
+		&o.object.ID, &o.object.Type, &o.object.Created, &o.object.Modified, &o.object.Object, &o.object.CollectionID,
+		&createdAt, &updatedAt, &lastUpdatedAt, &firstAdded, &lastAdded, &total, &remaining, &nextAdded, &nextRow)
+
+	setPage(o.cr, total, remaining, nextAdded, nextRow)
+
+	o.object.DateAdded = utcTime(createdAt)
+	o.object.UpdatedAt = laterUpdate(o.object.UpdatedAt, updatedAt)
+	o.updatedAt = laterUpdate(o.updatedAt, lastUpdatedAt)
+	o.firstAdded, o.lastAdded = utcTime(firstAdded), utcTime(lastAdded)
+	return o.err == nil
+}
+
+func (o *objectRows) UpdatedAt() time.Time {
+	return o.updatedAt
+}
+
+func (o *objectRows) DateAdded() (time.Time, time.Time) {
+	return o.firstAdded, o.lastAdded
+}
+
+func (o *objectRows) Object() cabby.Object {
+	return o.object
+}
+
+func (o *objectRows) Err() error {
+	if o.err != nil {
+		return o.err
+	}
+	return o.rows.Err()
+}
+
+func (o *objectRows) Close() error {
+	return o.rows.Close()
+}
+
+/* helpers */
+
+func collectFailures(errs chan error, failures chan []cabby.StatusFailure) {
+	var collected []cabby.StatusFailure
+
+	for err := range errs {
+		failure := cabby.StatusFailure{Message: err.Error()}
+
+		// an object write's args start with the object's id, type, created and modified
+		if we, ok := err.(writeError); ok {
+			if len(we.args) > 0 {
+				failure.ID = fmt.Sprintf("%v", we.args[0])
+			}
+			if len(we.args) > 3 {
+				failure.Version = fmt.Sprintf("%v", we.args[3])
+			}
+		}
+		collected = append(collected, failure)
+	}
+
+	failures <- collected
+}
+
+func (s ObjectService) updateStatusProgress(st cabby.Status, received int64) {
+	sql := `update taxii_status set total_count = ?, pending_count = ? where id = ? and status = 'pending'`
+	args := []interface{}{received, received, st.ID}
+
+	err := s.DataStore.write(sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+	}
+}
+
+// resolveVersion applies a collection's version policy to an object using the versions already in the collection;
+// it reads in the writer's transaction so versions written earlier in it are seen
+func resolveVersion(tx *sql.Tx, policy string, o cabby.Object, collectionID string) (cabby.VersionAction, error) {
+	sql := `select modified, object::text from stix_objects where collection_id = ? and id = ?`
+	args := []interface{}{collectionID, o.ID}
+
+	rows, err := tx.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return cabby.WriteVersion, err
+	}
+	defer rows.Close()
+
+	var stored []cabby.Object
+	for rows.Next() {
+		v := cabby.Object{ID: o.ID}
+		if err := rows.Scan(&v.Modified, &v.Object); err != nil {
+			return cabby.WriteVersion, err
+		}
+		stored = append(stored, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return cabby.WriteVersion, err
+	}
+	return cabby.ResolveVersion(policy, o, stored)
+}
+
+// versionPolicy returns a collection's version policy; if it can't be read the default policy is used
+func (s ObjectService) versionPolicy(collectionID string) string {
+	sql := `select coalesce(version_policy, '') from taxii_collection where id = ?`
+	args := []interface{}{collectionID}
+
+	var policy string
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return policy
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := rows.Scan(&policy); err != nil {
+			logSQLError(sql, args, err)
+		}
+	}
+	return policy
+}
+
+// writeObjectSQL returns the statement that writes objects under a version policy
+func writeObjectSQL(policy string) string {
+	if policy == cabby.VersionPolicyOverwrite {
+		return overwriteObjectSQL
+	}
+	return createObjectSQL
+}
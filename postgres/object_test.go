@@ -0,0 +1,183 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+	"github.com/pladdy/stones"
+)
+
+func TestObjectServiceObject(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.ObjectService()
+
+	expected := tester.Object
+
+	results, err := s.Object(context.Background(), tester.CollectionID, string(expected.ID), cabby.Filter{})
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	if len(results) != 1 {
+		t.Fatal("Got:", len(results), "Expected:", 1)
+	}
+
+	if !sameObject(t, results[0], expected) {
+		t.Error("Comparison failed")
+	}
+}
+
+func TestObjectServiceObjectsFilter(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.ObjectService()
+
+	ids := []cabby.ID{}
+	for i := 0; i < 10; i++ {
+		id, _ := cabby.NewID()
+		ids = append(ids, id)
+		createObject(ds, id.String())
+	}
+
+	// create an object with multiple versions
+	id, _ := cabby.NewID()
+	objectID := "malware--" + id.String()
+	versions := []string{}
+
+	for i := 0; i < 5; i++ {
+		t := time.Now().UTC()
+		createObjectVersion(ds, objectID, t.Format(time.RFC3339Nano))
+		versions = append(versions, t.Format(time.RFC3339Nano))
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	tests := []struct {
+		filter          cabby.Filter
+		expectedObjects int
+	}{
+		{cabby.Filter{}, 16},
+		{cabby.Filter{Types: "foo"}, 0},
+		{cabby.Filter{Types: "foo,malware"}, 16},
+		{cabby.Filter{IDs: ids[0].String()}, 1},
+		{cabby.Filter{IDs: strings.Join([]string{ids[0].String(), ids[4].String(), ids[8].String()}, ",")}, 3},
+		{cabby.Filter{Versions: versions[2]}, 1},
+		{cabby.Filter{IDs: objectID, Versions: "first"}, 1},
+		{cabby.Filter{IDs: objectID, Versions: "last"}, 1},
+		{cabby.Filter{IDs: objectID, Versions: "all"}, 5},
+		{cabby.Filter{AddedAfter: versions[0]}, 5},
+	}
+
+	for _, test := range tests {
+		results, err := s.Objects(context.Background(), tester.CollectionID, &cabby.Range{First: -1, Last: -1}, test.filter)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+
+		if len(results) != test.expectedObjects {
+			t.Error("Got:", len(results), "Expected:", test.expectedObjects, "Filter:", test.filter)
+		}
+	}
+}
+
+func TestObjectServiceObjectsRange(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.ObjectService()
+
+	for i := 0; i < 10; i++ {
+		id, _ := cabby.NewID()
+		createObject(ds, id.String())
+	}
+
+	cr := cabby.Range{First: 0, Last: 5}
+
+	results, err := s.Objects(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	if len(results) != 6 {
+		t.Error("Got:", len(results), "Expected:", 6)
+	}
+
+	if cr.Total != 11 {
+		t.Error("Got:", cr.Total, "Expected:", 11)
+	}
+}
+
+func TestObjectServiceCreateBundle(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	osv := ds.ObjectService()
+	ssv := ds.StatusService()
+
+	content, err := ioutil.ReadFile("testdata/malware_bundle.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bundle stones.Bundle
+	err = json.Unmarshal(content, &bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// one object is sent twice; the duplicate fails without failing the rest
+	objects := append(bundle.Objects, bundle.Objects[0])
+
+	st := tester.Status
+	err = ssv.CreateStatus(context.Background(), st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := make(chan []byte, len(objects))
+	for _, o := range objects {
+		c <- o
+	}
+	close(c)
+
+	osv.CreateBundle(context.Background(), c, tester.CollectionID, st, ssv)
+
+	for _, object := range bundle.Objects {
+		expected, _ := cabby.NewObject(object)
+		expected.CollectionID = tester.Collection.ID
+
+		results, _ := osv.Object(context.Background(), tester.CollectionID, string(expected.ID), cabby.Filter{})
+		if len(results) != 1 {
+			t.Fatal("Got:", len(results), "Expected:", 1)
+		}
+
+		if !sameObject(t, results[0], expected) {
+			t.Error("Comparison failed")
+		}
+	}
+
+	result, _ := ssv.Status(context.Background(), st.ID.String())
+	if result.FailureCount != 1 || result.SuccessCount != int64(len(bundle.Objects)) {
+		t.Error("Got:", result.FailureCount, result.SuccessCount, "Expected:", 1, len(bundle.Objects))
+	}
+}
+
+// jsonb doesn't keep the formatting of an object, only its content
+func sameObject(t *testing.T, result, expected cabby.Object) bool {
+	var r, e interface{}
+
+	if err := json.Unmarshal(result.Object, &r); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(expected.Object, &e); err != nil {
+		t.Fatal(err)
+	}
+
+	result.Object, expected.Object = nil, nil
+	return tester.CompareObject(result, expected) && reflect.DeepEqual(r, e)
+}
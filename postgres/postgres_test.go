@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/datastoretest"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestLaterUpdate(t *testing.T) {
+	earlier := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := time.Date(2018, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		current   time.Time
+		updatedAt pq.NullTime
+		expected  time.Time
+	}{
+		{time.Time{}, pq.NullTime{Time: later, Valid: true}, later},
+		{earlier, pq.NullTime{Time: later, Valid: true}, later},
+		{later, pq.NullTime{Time: earlier, Valid: true}, later},
+		{earlier, pq.NullTime{}, earlier},
+	}
+
+	for _, test := range tests {
+		result := laterUpdate(test.current, test.updatedAt)
+		if !result.Equal(test.expected) {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected string
+	}{
+		{"select 1", "select 1"},
+		{"select * from t where a = ?", "select * from t where a = $1"},
+		{"insert into t (a, b, c) values (?, ?, ?::jsonb)", "insert into t (a, b, c) values ($1, $2, $3::jsonb)"},
+	}
+
+	for _, test := range tests {
+		result := rebind(test.sql)
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
+
+func TestNewDataStoreNoURL(t *testing.T) {
+	_, err := NewDataStore("")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestDataStoreBatchWrite(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+
+	sql := `insert into taxii_user (email, can_admin) values (?, ?)`
+	toWrite := make(chan interface{}, 10)
+	errs := make(chan error, 10)
+
+	go ds.batchWrite(sql, toWrite, errs)
+
+	toWrite <- []interface{}{"batch1@cabby.com", false}
+	toWrite <- []interface{}{tester.UserEmail, false} // already exists
+	toWrite <- []interface{}{"batch2@cabby.com", false}
+	close(toWrite)
+
+	failures := 0
+	for range errs {
+		failures++
+	}
+
+	if failures != 1 {
+		t.Error("Got:", failures, "Expected:", 1)
+	}
+
+	// a failed write doesn't abort the writes after it
+	var count int
+	err := ds.DB.QueryRow(`select count(*) from taxii_user where email like 'batch%'`).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Error("Got:", count, "Expected:", 2)
+	}
+}
+
+func TestDataStoreConformance(t *testing.T) {
+	if testURL == "" {
+		t.Skip("No postgres server, set " + testURLEnv + " or put initdb and pg_ctl on the path")
+	}
+
+	datastoretest.Run(t, func(t *testing.T) cabby.DataStore {
+		ds, err := NewDataStore(testURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ds.DB.Exec(`drop schema public cascade; create schema public`)
+		if err != nil {
+			t.Fatal("Couldn't reset schema: ", err)
+		}
+		if _, err := ds.Migrate(context.Background()); err != nil {
+			t.Fatal("Couldn't migrate schema: ", err)
+		}
+		return ds
+	})
+}
+
+func TestFilterQueryString(t *testing.T) {
+	version, _ := time.Parse(time.RFC3339Nano, "2018-10-30T12:03:48.123Z")
+
+	tests := []struct {
+		filter        cabby.Filter
+		expectedQuery string
+		expectedArgs  []interface{}
+	}{
+		{cabby.Filter{}, ``, []interface{}{}},
+		{cabby.Filter{AddedAfter: "2018-10-30T12:03:48.123Z"},
+			`date_trunc('milliseconds', created_at) > date_trunc('milliseconds', ?::timestamptz)`,
+			[]interface{}{version}},
+		{cabby.Filter{AddedAfter: "not a time"}, ``, []interface{}{}},
+		{cabby.Filter{IDs: "indicator--8e2e2d2b-17d4-4cbf-938f-98ee46b3cd3f"},
+			"(id = ?)",
+			[]interface{}{"indicator--8e2e2d2b-17d4-4cbf-938f-98ee46b3cd3f"}},
+		{cabby.Filter{Types: "indicator,malware"},
+			"(type = ? or type = ?)",
+			[]interface{}{"indicator", "malware"}},
+		{cabby.Filter{Versions: "2018-10-30T12:03:48.123Z"},
+			`(date_trunc('milliseconds', modified::timestamptz) = date_trunc('milliseconds', ?::timestamptz))`,
+			[]interface{}{version}},
+		{cabby.Filter{Versions: "first"},
+			"(version in ('first', 'only'))",
+			[]interface{}{}},
+		{cabby.Filter{Versions: "last"},
+			"(version in ('last', 'only'))",
+			[]interface{}{}},
+		{cabby.Filter{Versions: "all"},
+			"(1 = 1)",
+			[]interface{}{}},
+		{cabby.Filter{Properties: map[string]string{cabby.MatchConfidence: "80,90"}},
+			"(object->>'confidence' in (?, ?))",
+			[]interface{}{"80", "90"}},
+		{cabby.Filter{Properties: map[string]string{cabby.MatchLabels: "malicious-activity"}},
+			"(exists (select 1 from jsonb_array_elements_text(case when jsonb_typeof(object->'labels') = 'array' " +
+				"then object->'labels' else '[]'::jsonb end) l where l in (?)))",
+			[]interface{}{"malicious-activity"}},
+		{cabby.Filter{Properties: map[string]string{cabby.MatchExternalID: "CVE-2016-1234"}},
+			"(exists (select 1 from jsonb_array_elements(case when jsonb_typeof(object->'external_references') = 'array' " +
+				"then object->'external_references' else '[]'::jsonb end) e where e->>'external_id' in (?)))",
+			[]interface{}{"CVE-2016-1234"}},
+		{cabby.Filter{Properties: map[string]string{cabby.MatchSpecVersion: "2.1", cabby.MatchCreatedByRef: "identity--1"}},
+			"(object->>'created_by_ref' in (?)) and (coalesce(object->>'spec_version', '2.0') in (?))",
+			[]interface{}{"identity--1", "2.1"}},
+	}
+
+	for _, test := range tests {
+		filter := Filter{test.filter}
+		resultQuery, resultArgs := filter.QueryString()
+
+		re := regexp.MustCompile(`\s+`)
+		resultQuery = re.ReplaceAllString(resultQuery, " ")
+
+		if resultQuery != test.expectedQuery {
+			t.Error("Got:", resultQuery, "Expected:", test.expectedQuery)
+		}
+
+		if len(resultArgs) != len(test.expectedArgs) {
+			t.Error("Got:", len(resultArgs), "Expected:", len(test.expectedArgs))
+			continue
+		}
+
+		for i := 0; i < len(resultArgs); i++ {
+			if resultArgs[i] != test.expectedArgs[i] {
+				t.Error("Got:", resultArgs[i], "Expected:", test.expectedArgs[i])
+			}
+		}
+	}
+}
+
+func TestRangeQueryString(t *testing.T) {
+	tests := []struct {
+		cabbyRange    cabby.Range
+		expectedQuery string
+		expectedArgs  []interface{}
+	}{
+		{cabby.Range{First: -1, Last: -1}, "", nil},
+		{cabby.Range{First: 0, Last: 5}, "limit ? offset ?", []interface{}{int64(6), int64(0)}},
+	}
+
+	for _, test := range tests {
+		r := Range{&test.cabbyRange}
+		resultQuery, resultArgs := r.QueryString()
+
+		if resultQuery != test.expectedQuery {
+			t.Error("Got:", resultQuery, "Expected:", test.expectedQuery)
+		}
+
+		if len(resultArgs) != len(test.expectedArgs) {
+			t.Error("Got:", len(resultArgs), "Expected:", len(test.expectedArgs))
+			continue
+		}
+
+		for i := 0; i < len(resultArgs); i++ {
+			if resultArgs[i] != test.expectedArgs[i] {
+				t.Error("Got:", resultArgs[i], "Expected:", test.expectedArgs[i])
+			}
+		}
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	columns := map[string]string{cabby.SortDateAdded: "created_at", cabby.SortID: "id", cabby.SortTitle: "title"}
+
+	tests := []struct {
+		sort     cabby.Sort
+		row      string
+		expected string
+	}{
+		{cabby.Sort{}, "row_id", "created_at, id, title, row_id"},
+		{cabby.Sort{Field: cabby.SortID, Descending: true}, "row_id", "id desc, created_at desc, title desc, row_id desc"},
+		{cabby.Sort{Field: cabby.SortModified}, "row_id", "created_at, id, title, row_id"},
+		{cabby.Sort{Field: cabby.SortTitle}, "id", "title, created_at, id"},
+	}
+
+	for _, test := range tests {
+		if result := ordering(test.sort, columns, test.row); result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// StatusService implements a Postgres version of the StatusService interface
+type StatusService struct {
+	DB        *sql.DB
+	DataStore *DataStore
+}
+
+// CancelStatus will mark a pending status as canceled
+func (s StatusService) CancelStatus(ctx context.Context, statusID string) error {
+	resource, action := "Status", "cancel"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.cancelStatus(statusID)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s StatusService) cancelStatus(statusID string) error {
+	sql := `update taxii_status set status = 'canceled' where id = ? and status = 'pending'`
+	args := []interface{}{statusID}
+
+	result, err := s.DB.Exec(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return err
+	}
+
+	canceled, err := result.RowsAffected()
+	if err == nil && canceled == 0 {
+		err = errors.New("No pending status found for this id")
+	}
+	return err
+}
+
+// CreateStatus will read from the data store and return the resource
+func (s StatusService) CreateStatus(ctx context.Context, status cabby.Status) error {
+	resource, action := "Status", "create"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.createStatus(status)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s StatusService) createStatus(st cabby.Status) error {
+	sql := `insert into taxii_status (
+						id, status, total_count, success_count, failure_count, pending_count, email, collection_id
+					)
+					values (?, ?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{
+		st.ID, st.Status, st.TotalCount, st.SuccessCount, st.FailureCount, st.PendingCount, st.User, st.CollectionID}
+
+	err := s.DataStore.write(sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+	}
+	return err
+}
+
+// PurgeStatuses will delete statuses created before a given time that are no longer pending
+func (s StatusService) PurgeStatuses(ctx context.Context, before time.Time) (int64, error) {
+	resource, action := "Statuses", "purge"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.purgeStatuses(before)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s StatusService) purgeStatuses(before time.Time) (int64, error) {
+	sql := `delete from taxii_status where created_at < ? and status != 'pending'`
+	args := []interface{}{before}
+
+	result, err := s.DB.Exec(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Status will read from the data store and return the resource
+func (s StatusService) Status(ctx context.Context, statusID string) (cabby.Status, error) {
+	resource, action := "Status", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.status(statusID)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s StatusService) status(statusID string) (cabby.Status, error) {
+	sql := `select id, status, total_count, success_count, pending_count, failure_count, coalesce(failures, ''),
+					  coalesce(successes, ''), coalesce(email, ''), coalesce(collection_id, ''),
+					  to_char(created_at at time zone 'UTC', ?)
+					from taxii_status where id = ?`
+	args := []interface{}{statusTimeFormat, statusID}
+
+	st := cabby.Status{}
+	var err error
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return st, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var failures, successes string
+
+		if err := rows.Scan(
+			&st.ID, &st.Status, &st.TotalCount, &st.SuccessCount, &st.PendingCount, &st.FailureCount, &failures,
+			&successes, &st.User, &st.CollectionID, &st.CreatedAt); err != nil {
+			return st, err
+		}
+
+		if len(failures) > 0 {
+			if err := json.Unmarshal([]byte(failures), &st.Failures); err != nil {
+				return st, err
+			}
+		}
+
+		if len(successes) > 0 {
+			if err := json.Unmarshal([]byte(successes), &st.Successes); err != nil {
+				return st, err
+			}
+		}
+	}
+
+	err = rows.Err()
+	return st, err
+}
+
+// Statuses will read from the data store and return the resource
+func (s StatusService) Statuses(ctx context.Context, cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+	resource, action := "Statuses", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.statuses(cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s StatusService) statuses(cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+	sql := `with data as (
+						select id, status, total_count, success_count, pending_count, failure_count,
+						  coalesce(email, '') as email, coalesce(collection_id, '') as collection_id, created_at, row_id, 1 as count
+						from taxii_status
+						where
+							1 = 1
+							and $filter
+					)
+					select id, status, total_count, success_count, pending_count, failure_count,
+					  email, collection_id, to_char(created_at at time zone 'UTC', ?), (select sum(count) from data) as total
+					from data
+					order by created_at desc, row_id desc
+					$paginate`
+
+	filter := StatusFilter{f}
+	qs, filterArgs := filter.QueryString()
+	sql = filterRemoveTrailingAnd(strings.Replace(sql, "$filter", qs, -1))
+
+	// the filter is in the common table expression, before the created_at format in the select
+	args := append(filterArgs, statusTimeFormat)
+
+	sql, args = applyPaging(sql, cr, args)
+
+	statuses := []cabby.Status{}
+	var err error
+
+	rows, err := s.DB.Query(rebind(sql), args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return statuses, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st cabby.Status
+		if err := rows.Scan(
+			&st.ID, &st.Status, &st.TotalCount, &st.SuccessCount, &st.PendingCount, &st.FailureCount,
+			&st.User, &st.CollectionID, &st.CreatedAt, &cr.Total); err != nil {
+			return statuses, err
+		}
+		statuses = append(statuses, st)
+	}
+
+	err = rows.Err()
+	return statuses, err
+}
+
+// UpdateStatus will read from the data store and return the resource
+func (s StatusService) UpdateStatus(ctx context.Context, status cabby.Status) error {
+	resource, action := "Status", "update"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.updateStatus(status)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s StatusService) updateStatus(st cabby.Status) error {
+	// a canceled status stays canceled
+	sql := `update taxii_status
+          set status = case when status = 'canceled' then status else ? end,
+              total_count = ?, success_count = ?, failure_count = ?, failures = ?, successes = ?,
+              pending_count = ?
+          where id = ?`
+
+	st.PendingCount = st.TotalCount - st.SuccessCount - st.FailureCount
+
+	if st.PendingCount == 0 {
+		st.SuccessCount = st.TotalCount - st.FailureCount
+		st.Status = "complete"
+	}
+
+	failures, err := json.Marshal(st.Failures)
+	if err != nil {
+		return err
+	}
+
+	successes, err := json.Marshal(st.Successes)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{
+		st.Status, st.TotalCount, st.SuccessCount, st.FailureCount, string(failures), string(successes), st.PendingCount,
+		st.ID}
+
+	err = s.DataStore.write(sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+	}
+	return err
+}
+
+// StatusFilter implementation for Postgres
+type StatusFilter struct {
+	cabby.StatusFilter
+}
+
+// QueryString will convert a status filter into a query string for a status query
+func (f *StatusFilter) QueryString() (q string, args []interface{}) {
+	var filters []string
+
+	fields := []struct {
+		column string
+		value  string
+	}{
+		{"email", f.User},
+		{"collection_id", f.CollectionID},
+		{"status", f.Status},
+	}
+
+	for _, field := range fields {
+		if len(field.value) > 0 {
+			filters = append(filters, field.column+" = ?")
+			args = append(args, field.value)
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, f.CreatedAfter); err == nil {
+		filters = append(filters, "created_at > ?")
+		args = append(args, t)
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, f.CreatedBefore); err == nil {
+		filters = append(filters, "created_at < ?")
+		args = append(args, t)
+	}
+
+	return strings.Join(filters, " and "), args
+}
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestStatusServiceStatus(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.StatusService()
+
+	expected := tester.Status
+	err := s.CreateStatus(context.Background(), expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.Status(context.Background(), expected.ID.String())
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	passed := tester.CompareStatus(result, expected)
+	if !passed {
+		t.Error("Comparison failed")
+	}
+
+	if _, err := time.Parse("2006-01-02 15:04:05.000", result.CreatedAt); err != nil {
+		t.Error("Got:", result.CreatedAt, "Expected a created time")
+	}
+}
+
+func TestStatusServiceCancelStatus(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.StatusService()
+
+	test := tester.Status
+	test.Status = "pending"
+
+	err := s.CreateStatus(context.Background(), test)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.CancelStatus(context.Background(), test.ID.String())
+	if err != nil {
+		t.Error("Got:", err)
+	}
+
+	// updates don't undo a cancel
+	test.FailureCount = test.TotalCount
+	err = s.UpdateStatus(context.Background(), test)
+	if err != nil {
+		t.Error("Got:", err)
+	}
+
+	result, _ := s.Status(context.Background(), test.ID.String())
+	if result.Status != "canceled" {
+		t.Error("Got:", result.Status, "Expected:", "canceled")
+	}
+
+	err = s.CancelStatus(context.Background(), test.ID.String())
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestStatusServicePurgeStatuses(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.StatusService()
+
+	statuses := []struct {
+		status    string
+		createdAt time.Time
+	}{
+		{"complete", time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"canceled", time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"pending", time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"complete", time.Now()},
+	}
+
+	for _, st := range statuses {
+		test := tester.Status
+		test.ID, _ = cabby.NewID()
+		test.Status = st.status
+
+		err := s.CreateStatus(context.Background(), test)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ds.DB.Exec("update taxii_status set created_at = $1 where id = $2", st.createdAt, test.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	purged, err := s.PurgeStatuses(context.Background(), time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Error("Got:", err)
+	}
+
+	// pending statuses aren't purged
+	if purged != 2 {
+		t.Error("Got:", purged, "Expected:", 2)
+	}
+}
+
+func TestStatusServiceStatuses(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.StatusService()
+
+	otherCollection, _ := cabby.NewID()
+
+	statuses := []struct {
+		user         string
+		collectionID string
+		status       string
+	}{
+		{tester.UserEmail, tester.CollectionID, "pending"},
+		{tester.UserEmail, tester.CollectionID, "complete"},
+		{tester.UserEmail, otherCollection.String(), "complete"},
+		{"other@cabby.com", otherCollection.String(), "complete"},
+	}
+
+	for _, st := range statuses {
+		test := tester.Status
+		test.ID, _ = cabby.NewID()
+		test.User = st.user
+		test.CollectionID = st.collectionID
+		test.Status = st.status
+
+		err := s.CreateStatus(context.Background(), test)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hourAgo := time.Now().Add(-time.Hour).Format(time.RFC3339Nano)
+	hourFromNow := time.Now().Add(time.Hour).Format(time.RFC3339Nano)
+
+	tests := []struct {
+		filter     cabby.StatusFilter
+		cabbyRange cabby.Range
+		expected   int
+	}{
+		{cabby.StatusFilter{}, cabby.Range{First: -1, Last: -1}, 4},
+		{cabby.StatusFilter{}, cabby.Range{First: 0, Last: 1}, 2},
+		{cabby.StatusFilter{User: tester.UserEmail}, cabby.Range{First: -1, Last: -1}, 3},
+		{cabby.StatusFilter{CollectionID: otherCollection.String()}, cabby.Range{First: -1, Last: -1}, 2},
+		{cabby.StatusFilter{Status: "complete"}, cabby.Range{First: -1, Last: -1}, 3},
+		{cabby.StatusFilter{CreatedAfter: hourAgo}, cabby.Range{First: -1, Last: -1}, 4},
+		{cabby.StatusFilter{CreatedAfter: hourFromNow}, cabby.Range{First: -1, Last: -1}, 0},
+		{cabby.StatusFilter{CreatedBefore: hourFromNow, Status: "pending"}, cabby.Range{First: -1, Last: -1}, 1},
+	}
+
+	for _, test := range tests {
+		results, err := s.Statuses(context.Background(), &test.cabbyRange, test.filter)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error")
+		}
+
+		if len(results) != test.expected {
+			t.Error("Got:", len(results), "Expected:", test.expected, "Filter:", test.filter)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestUserServiceUser(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.UserService()
+
+	result, err := s.User(context.Background(), tester.UserEmail, tester.UserPassword)
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	if result.Email != tester.UserEmail || !result.CanAdmin {
+		t.Error("Got:", result, "Expected:", tester.User)
+	}
+
+	result, _ = s.User(context.Background(), tester.UserEmail, "wrong password")
+	if result.Email != "" {
+		t.Error("Got:", result.Email, "Expected no user")
+	}
+}
+
+func TestUserServiceCreateUserInvalidEmail(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.UserService()
+
+	_, err := ds.DB.Exec(`insert into taxii_user (email) values ('not an email')`)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+
+	err = s.CreateUser(context.Background(), cabby.User{Email: "new@cabby.com"}, "short")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestUserServiceUpdateUser(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.UserService()
+
+	expected := tester.User
+	expected.CanAdmin = false
+
+	err := s.UpdateUser(context.Background(), expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, _ := s.User(context.Background(), tester.UserEmail, tester.UserPassword)
+	if result.CanAdmin {
+		t.Error("Got:", result.CanAdmin, "Expected:", false)
+	}
+}
+
+func TestUserServiceDeleteUser(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.UserService()
+
+	err := s.DeleteUser(context.Background(), tester.UserEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, _ := s.User(context.Background(), tester.UserEmail, tester.UserPassword)
+	if result.Email != "" {
+		t.Error("Got:", result.Email, "Expected no user")
+	}
+
+	// collection access is deleted with the user
+	ucl, _ := s.UserCollections(context.Background(), tester.UserEmail)
+	if len(ucl.CollectionAccessList) != 0 {
+		t.Error("Got:", len(ucl.CollectionAccessList), "Expected:", 0)
+	}
+}
+
+func TestUserServiceUserCollections(t *testing.T) {
+	ds := setupPostgres(t)
+	defer ds.Close()
+	s := ds.UserService()
+
+	// creating access twice is ignored
+	ca := cabby.CollectionAccess{ID: tester.Collection.ID, CanRead: true, CanWrite: true}
+	err := s.CreateUserCollection(context.Background(), tester.UserEmail, ca)
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	result, err := s.UserCollections(context.Background(), tester.UserEmail)
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	access, ok := result.CollectionAccessList[tester.Collection.ID]
+	if !ok || !access.CanRead || !access.CanWrite {
+		t.Error("Got:", result.CollectionAccessList, "Expected:", ca)
+	}
+
+	err = s.DeleteUserCollection(context.Background(), tester.UserEmail, tester.CollectionID)
+	if err != nil {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	result, _ = s.UserCollections(context.Background(), tester.UserEmail)
+	if len(result.CollectionAccessList) != 0 {
+		t.Error("Got:", len(result.CollectionAccessList), "Expected:", 0)
+	}
+}
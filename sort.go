@@ -0,0 +1,129 @@
+package cabby
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Sort fields are the fields a listing can be read in the order of with a sort parameter
+const (
+	SortDateAdded = "date_added"
+	SortID        = "id"
+	SortModified  = "modified"
+	SortTitle     = "title"
+)
+
+// CollectionSorts lists the fields collections can be sorted on
+var CollectionSorts = []string{SortID, SortTitle}
+
+// ObjectSorts lists the fields objects and manifests can be sorted on
+var ObjectSorts = []string{SortDateAdded, SortID, SortModified}
+
+// sortFields is the default order of listings; a sort puts its field first and the rest of them break its ties
+var sortFields = []string{SortDateAdded, SortID, SortModified, SortTitle}
+
+// Sort is the order to read a listing in.  The zero sort is the default order: the date added, then id, then
+// modified; listings without a date added, like collections, start with the id.  Items that are the same in every
+// field are in the order they were stored.
+type Sort struct {
+	Field      string
+	Descending bool
+}
+
+// NewSort returns a sort given a sort parameter, a field prefixed with '-' to sort it descending.  The field has to
+// be one of the fields given.
+func NewSort(s string, fields []string) (Sort, error) {
+	if s == "" {
+		return Sort{}, nil
+	}
+
+	sort := Sort{Field: strings.TrimPrefix(s, "-"), Descending: strings.HasPrefix(s, "-")}
+	for _, f := range fields {
+		if sort.Field == f {
+			return sort, nil
+		}
+	}
+	return Sort{}, errors.New("Invalid sort, it has to be one of: " + strings.Join(fields, ", "))
+}
+
+// Fields returns the fields a listing is ordered by, the sort's field first
+func (s Sort) Fields() []string {
+	if s.Field == "" {
+		return sortFields
+	}
+
+	fields := []string{s.Field}
+	for _, f := range sortFields {
+		if f != s.Field {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// IsZero returns whether the sort is the default order
+func (s Sort) IsZero() bool {
+	return s.Field == "" && !s.Descending
+}
+
+// Less returns whether an item is before another in the sort's order
+func (s Sort) Less(a, b SortKey) bool {
+	for _, f := range s.Fields() {
+		if c := a.compare(b, f); c != 0 {
+			return (c < 0) != s.Descending
+		}
+	}
+	return (a.Row < b.Row) != s.Descending
+}
+
+func (s Sort) String() string {
+	if s.Descending {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// SortKey is what an item in a listing is sorted on; fields a listing doesn't have are left empty.  Row is where the
+// item was stored, which orders items that are the same in every field.
+type SortKey struct {
+	DateAdded time.Time
+	ID        string
+	Modified  string
+	Title     string
+	Row       int64
+}
+
+func (k SortKey) compare(other SortKey, field string) int {
+	switch field {
+	case SortDateAdded:
+		return compareTimes(k.DateAdded, other.DateAdded)
+	case SortID:
+		return strings.Compare(k.ID, other.ID)
+	case SortModified:
+		return compareModified(k.Modified, other.Modified)
+	case SortTitle:
+		return strings.Compare(k.Title, other.Title)
+	}
+	return 0
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+// modified times are compared as times, so versions written with a different precision are still in order
+func compareModified(a, b string) int {
+	ta, errA := time.Parse(time.RFC3339Nano, a)
+	tb, errB := time.Parse(time.RFC3339Nano, b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return compareTimes(ta, tb)
+}
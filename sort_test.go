@@ -0,0 +1,85 @@
+package cabby
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSort(t *testing.T) {
+	tests := []struct {
+		sort     string
+		expected Sort
+	}{
+		{"", Sort{}},
+		{"id", Sort{Field: SortID}},
+		{"-modified", Sort{Field: SortModified, Descending: true}},
+		{"date_added", Sort{Field: SortDateAdded}},
+	}
+
+	for _, test := range tests {
+		result, err := NewSort(test.sort, ObjectSorts)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Sort:", test.sort)
+		}
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+		if result.String() != test.sort {
+			t.Error("Got:", result.String(), "Expected:", test.sort)
+		}
+	}
+}
+
+func TestNewSortInvalid(t *testing.T) {
+	for _, s := range []string{"title", "-", "--id", "type", "id,modified"} {
+		if _, err := NewSort(s, ObjectSorts); err == nil {
+			t.Error("Got no error", "Expected an error", "Sort:", s)
+		}
+	}
+}
+
+func TestSortFields(t *testing.T) {
+	tests := []struct {
+		sort     Sort
+		expected []string
+	}{
+		{Sort{}, []string{SortDateAdded, SortID, SortModified, SortTitle}},
+		{Sort{Field: SortModified, Descending: true}, []string{SortModified, SortDateAdded, SortID, SortTitle}},
+		{Sort{Field: SortTitle}, []string{SortTitle, SortDateAdded, SortID, SortModified}},
+	}
+
+	for _, test := range tests {
+		result := test.sort.Fields()
+		if strings.Join(result, ",") != strings.Join(test.expected, ",") {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
+
+func TestSortLess(t *testing.T) {
+	added := time.Date(2018, 10, 30, 12, 3, 48, 0, time.UTC)
+	first := SortKey{DateAdded: added, ID: "malware--2", Modified: "2018-01-02T00:00:00Z", Row: 1}
+
+	tests := []struct {
+		sort     Sort
+		other    SortKey
+		expected bool
+	}{
+		{Sort{}, SortKey{DateAdded: added.Add(time.Millisecond), ID: "malware--1", Row: 2}, true},
+		{Sort{}, SortKey{DateAdded: added, ID: "malware--3", Row: 2}, true},
+		{Sort{}, SortKey{DateAdded: added, ID: "malware--2", Modified: "2018-01-01T00:00:00Z", Row: 2}, false},
+		{Sort{}, SortKey{DateAdded: added, ID: "malware--2", Modified: "2018-01-02T00:00:00.000Z", Row: 2}, true},
+		{Sort{Descending: true}, SortKey{DateAdded: added.Add(time.Millisecond), ID: "malware--1", Row: 2}, false},
+		{Sort{Field: SortID}, SortKey{DateAdded: added.Add(time.Millisecond), ID: "malware--1", Row: 2}, false},
+		{Sort{Field: SortID, Descending: true}, SortKey{ID: "malware--1", Row: 2}, true},
+		{Sort{Field: SortModified}, SortKey{ID: "malware--1", Modified: "2018-01-03T00:00:00Z", Row: 2}, true},
+		{Sort{Field: SortTitle}, SortKey{Title: "a title", Row: 2}, true},
+	}
+
+	for _, test := range tests {
+		if result := test.sort.Less(first, test.other); result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "Sort:", test.sort, "Key:", test.other)
+		}
+	}
+}
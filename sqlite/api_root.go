@@ -22,20 +22,21 @@ type APIRootService struct {
 func (s APIRootService) APIRoot(ctx context.Context, path string) (cabby.APIRoot, error) {
 	resource, action := "APIRoot", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.apiRoot(path)
+	result, err := s.apiRoot(ctx, path)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s APIRootService) apiRoot(path string) (cabby.APIRoot, error) {
-	sql := `select api_root_path, title, description, versions, max_content_length
+func (s APIRootService) apiRoot(ctx context.Context, path string) (cabby.APIRoot, error) {
+	sql := `select api_root_path, title, description, versions, max_content_length,
+					  coalesce(default_page_size, 0), coalesce(max_page_size, 0), coalesce(updated_at, '')
 				  from taxii_api_root
 				  where api_root_path = ?`
 	args := []interface{}{path}
 
 	a := cabby.APIRoot{}
 
-	rows, err := s.DB.Query(sql, args...)
+	rows, err := s.DataStore.query(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 		return a, err
@@ -43,10 +44,11 @@ func (s APIRootService) apiRoot(path string) (cabby.APIRoot, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var versions string
-		if err := rows.Scan(&a.Path, &a.Title, &a.Description, &versions, &a.MaxContentLength); err != nil {
+		var versions, updatedAt string
+		if err := rows.Scan(&a.Path, &a.Title, &a.Description, &versions, &a.MaxContentLength, &a.DefaultPageSize, &a.MaxPageSize, &updatedAt); err != nil {
 			return a, err
 		}
+		a.UpdatedAt = laterUpdate(a.UpdatedAt, updatedAt)
 		a.Versions = strings.Split(versions, ",")
 	}
 
@@ -58,31 +60,34 @@ func (s APIRootService) apiRoot(path string) (cabby.APIRoot, error) {
 func (s APIRootService) APIRoots(ctx context.Context) ([]cabby.APIRoot, error) {
 	resource, action := "APIRoots", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.apiRoots()
+	result, err := s.apiRoots(ctx)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s APIRootService) apiRoots() ([]cabby.APIRoot, error) {
-	sql := `select api_root_path, title, description, versions, max_content_length
+func (s APIRootService) apiRoots(ctx context.Context) ([]cabby.APIRoot, error) {
+	sql := `select api_root_path, title, description, versions, max_content_length,
+					  coalesce(default_page_size, 0), coalesce(max_page_size, 0), coalesce(updated_at, '')
 				  from taxii_api_root`
 	args := []interface{}{}
 
 	as := []cabby.APIRoot{}
 
-	rows, err := s.DB.Query(sql, args...)
+	rows, err := s.DataStore.query(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 		return as, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var a cabby.APIRoot
-		var versions string
+		var versions, updatedAt string
 
-		if err := rows.Scan(&a.Path, &a.Title, &a.Description, &versions, &a.MaxContentLength); err != nil {
+		if err := rows.Scan(&a.Path, &a.Title, &a.Description, &versions, &a.MaxContentLength, &a.DefaultPageSize, &a.MaxPageSize, &updatedAt); err != nil {
 			return as, err
 		}
+		a.UpdatedAt = laterUpdate(a.UpdatedAt, updatedAt)
 		a.Versions = strings.Split(versions, ",")
 
 		as = append(as, a)
@@ -99,7 +104,7 @@ func (s APIRootService) CreateAPIRoot(ctx context.Context, a cabby.APIRoot) erro
 
 	err := a.Validate()
 	if err == nil {
-		err = s.createAPIRoot(a)
+		err = s.createAPIRoot(ctx, a)
 	} else {
 		log.WithFields(log.Fields{"api_root": a, "error": err}).Error("Invalid API Root")
 	}
@@ -108,12 +113,15 @@ func (s APIRootService) CreateAPIRoot(ctx context.Context, a cabby.APIRoot) erro
 	return err
 }
 
-func (s APIRootService) createAPIRoot(a cabby.APIRoot) error {
-	sql := `insert into taxii_api_root (api_root_path, title, description, versions, max_content_length)
-					values (?, ?, ?, ?, ?)`
-	args := []interface{}{a.Path, a.Title, a.Description, strings.Join(a.Versions, ","), a.MaxContentLength}
+func (s APIRootService) createAPIRoot(ctx context.Context, a cabby.APIRoot) error {
+	sql := `insert into taxii_api_root (
+						api_root_path, title, description, versions, max_content_length, default_page_size, max_page_size
+					)
+					values (?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{
+		a.Path, a.Title, a.Description, strings.Join(a.Versions, ","), a.MaxContentLength, a.DefaultPageSize, a.MaxPageSize}
 
-	err := s.DataStore.write(sql, args...)
+	err := s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -124,16 +132,16 @@ func (s APIRootService) createAPIRoot(a cabby.APIRoot) error {
 func (s APIRootService) DeleteAPIRoot(ctx context.Context, id string) error {
 	resource, action := "APIRoot", "delete"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	err := s.deleteAPIRoot(id)
+	err := s.deleteAPIRoot(ctx, id)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return err
 }
 
-func (s APIRootService) deleteAPIRoot(path string) error {
+func (s APIRootService) deleteAPIRoot(ctx context.Context, path string) error {
 	sql := `delete from taxii_api_root where api_root_path = ?`
 	args := []interface{}{path}
 
-	_, err := s.DB.Exec(sql, args...)
+	_, err := s.DataStore.exec(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -147,7 +155,7 @@ func (s APIRootService) UpdateAPIRoot(ctx context.Context, a cabby.APIRoot) erro
 
 	err := a.Validate()
 	if err == nil {
-		err = s.updateAPIRoot(a)
+		err = s.updateAPIRoot(ctx, a)
 	} else {
 		log.WithFields(log.Fields{"api_root": a, "error": err}).Error("Invalid API Root")
 	}
@@ -156,13 +164,14 @@ func (s APIRootService) UpdateAPIRoot(ctx context.Context, a cabby.APIRoot) erro
 	return err
 }
 
-func (s APIRootService) updateAPIRoot(a cabby.APIRoot) error {
+func (s APIRootService) updateAPIRoot(ctx context.Context, a cabby.APIRoot) error {
 	sql := `update taxii_api_root
-				  set title = ?, description = ?, versions = ?, max_content_length = ?
+				  set title = ?, description = ?, versions = ?, max_content_length = ?, default_page_size = ?, max_page_size = ?
 				  where api_root_path = ?`
-	args := []interface{}{a.Title, a.Description, strings.Join(a.Versions, ","), a.MaxContentLength, a.Path}
+	args := []interface{}{
+		a.Title, a.Description, strings.Join(a.Versions, ","), a.MaxContentLength, a.DefaultPageSize, a.MaxPageSize, a.Path}
 
-	err := s.DataStore.write(sql, args...)
+	err := s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
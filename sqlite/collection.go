@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"strings"
+	"time"
 
 	// import sqlite dependency
 	_ "github.com/mattn/go-sqlite3"
@@ -22,13 +23,15 @@ type CollectionService struct {
 func (s CollectionService) Collection(ctx context.Context, apiRootPath, collectionID string) (cabby.Collection, error) {
 	resource, action := "Collection", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.collection(cabby.TakeUser(ctx).Email, apiRootPath, collectionID)
+	result, err := s.collection(ctx, cabby.TakeUser(ctx).Email, apiRootPath, collectionID)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s CollectionService) collection(user, apiRootPath, collectionID string) (cabby.Collection, error) {
-	sql := `select c.id, c.title, c.description, uc.can_read, uc.can_write, c.media_types
+func (s CollectionService) collection(ctx context.Context, user, apiRootPath, collectionID string) (cabby.Collection, error) {
+	sql := `select c.id, c.title, c.description, uc.can_read, uc.can_write, c.media_types,
+					  coalesce(c.version_policy, ''), coalesce(c.retain_versions, 0),
+						  coalesce(c.retain_window, 0), coalesce(c.expire_by, ''), coalesce(c.max_age, 0), max(coalesce(c.updated_at, ''), coalesce(uc.updated_at, '')) updated_at
 					from
 						taxii_collection c
 						inner join taxii_user_collection uc
@@ -39,7 +42,7 @@ func (s CollectionService) collection(user, apiRootPath, collectionID string) (c
 	c := cabby.Collection{}
 	var err error
 
-	rows, err := s.DB.Query(sql, args...)
+	rows, err := s.DataStore.query(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 		return c, err
@@ -47,11 +50,17 @@ func (s CollectionService) collection(user, apiRootPath, collectionID string) (c
 	defer rows.Close()
 
 	for rows.Next() {
-		var mediaTypes string
+		var mediaTypes, updatedAt string
 
-		if err := rows.Scan(&c.ID, &c.Title, &c.Description, &c.CanRead, &c.CanWrite, &mediaTypes); err != nil {
+		var retainWindow, maxAge int64
+
+		if err := rows.Scan(&c.ID, &c.Title, &c.Description, &c.CanRead, &c.CanWrite, &mediaTypes, &c.VersionPolicy,
+			&c.RetainVersions, &retainWindow, &c.ExpireBy, &maxAge, &updatedAt); err != nil {
 			return c, err
 		}
+		c.RetainWindow = time.Duration(retainWindow) * time.Second
+		c.MaxAge = time.Duration(maxAge) * time.Second
+		c.UpdatedAt = laterUpdate(c.UpdatedAt, updatedAt)
 		c.MediaTypes = strings.Split(mediaTypes, ",")
 	}
 
@@ -59,42 +68,39 @@ func (s CollectionService) collection(user, apiRootPath, collectionID string) (c
 	return c, err
 }
 
+// collectionSortColumns are the columns collections are sorted on
+var collectionSortColumns = map[string]string{cabby.SortID: "id", cabby.SortTitle: "title"}
+
 // Collections will read from the data store and return the resource
 func (s CollectionService) Collections(ctx context.Context, apiRootPath string, cr *cabby.Range) (cabby.Collections, error) {
 	resource, action := "Collections", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.collections(cabby.TakeUser(ctx).Email, apiRootPath, cr)
+	result, err := s.collections(ctx, cabby.TakeUser(ctx).Email, apiRootPath, cr)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s CollectionService) collections(user, apiRootPath string, cr *cabby.Range) (cabby.Collections, error) {
-	sql := `with data as (
-					  select id, title, description, can_read, can_write, media_types, 1 count
-					  from (
-						  select c.id, c.title, c.description, uc.can_read, uc.can_write, c.media_types
-						  from
-							  taxii_collection c
-							  inner join taxii_user_collection uc
-								  on c.id = uc.collection_id
-						  where
-						 	 uc.email = ?
-							 and c.api_root_path = ?
-					 		 and (uc.can_read = 1 or uc.can_write = 1)
-					  )
-				  )
-				  select
-					  id, title, description, can_read, can_write, media_types, (select sum(count) from data) total
-				  from data
-					$paginate`
-
-	args := []interface{}{user, apiRootPath}
-	sql, args = applyPaging(sql, cr, args)
+func (s CollectionService) collections(ctx context.Context, user, apiRootPath string, cr *cabby.Range) (cabby.Collections, error) {
+	data := selectFrom(`c.id, c.title, c.description, uc.can_read, uc.can_write, c.media_types,
+		max(coalesce(c.updated_at, ''), coalesce(uc.updated_at, '')) updated_at, 1 count`,
+		`taxii_collection c
+		inner join taxii_user_collection uc
+			on c.id = uc.collection_id`).
+		where("uc.email = ?", user).
+		where("c.api_root_path = ?", apiRootPath).
+		where("(uc.can_read = 1 or uc.can_write = 1)")
+
+	sql, args := selectFrom(`id, title, description, can_read, can_write, media_types, updated_at,
+		(select sum(count) from data) total, (select max(updated_at) from data) last_updated_at`, "data").
+		with("data", data).
+		orderBy(ordering(cr.Sort, collectionSortColumns, "id")).
+		paginate(cr).
+		build()
 
 	cs := cabby.Collections{}
 	var err error
 
-	rows, err := s.DB.Query(sql, args...)
+	rows, err := s.DataStore.query(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 		return cs, err
@@ -103,11 +109,14 @@ func (s CollectionService) collections(user, apiRootPath string, cr *cabby.Range
 
 	for rows.Next() {
 		var c cabby.Collection
-		var mediaTypes string
+		var mediaTypes, updatedAt, lastUpdatedAt string
 
-		if err := rows.Scan(&c.ID, &c.Title, &c.Description, &c.CanRead, &c.CanWrite, &mediaTypes, &cr.Total); err != nil {
+		if err := rows.Scan(
+			&c.ID, &c.Title, &c.Description, &c.CanRead, &c.CanWrite, &mediaTypes, &updatedAt, &cr.Total, &lastUpdatedAt); err != nil {
 			return cs, err
 		}
+		c.UpdatedAt = laterUpdate(c.UpdatedAt, updatedAt)
+		cs.UpdatedAt = laterUpdate(cs.UpdatedAt, lastUpdatedAt)
 		c.MediaTypes = strings.Split(mediaTypes, ",")
 		cs.Collections = append(cs.Collections, c)
 	}
@@ -120,19 +129,19 @@ func (s CollectionService) collections(user, apiRootPath string, cr *cabby.Range
 func (s CollectionService) CollectionsInAPIRoot(ctx context.Context, apiRootPath string) (cabby.CollectionsInAPIRoot, error) {
 	resource, action := "CollectionsInAPIRoot", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.collectionsInAPIRoot(apiRootPath)
+	result, err := s.collectionsInAPIRoot(ctx, apiRootPath)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s CollectionService) collectionsInAPIRoot(apiRootPath string) (cabby.CollectionsInAPIRoot, error) {
+func (s CollectionService) collectionsInAPIRoot(ctx context.Context, apiRootPath string) (cabby.CollectionsInAPIRoot, error) {
 	sql := `select c.api_root_path, c.id from taxii_collection c where c.api_root_path = ?`
 	args := []interface{}{apiRootPath}
 
 	ac := cabby.CollectionsInAPIRoot{}
 	var err error
 
-	rows, err := s.DB.Query(sql, args...)
+	rows, err := s.DataStore.query(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 		return ac, err
@@ -159,7 +168,7 @@ func (s CollectionService) CreateCollection(ctx context.Context, c cabby.Collect
 
 	err := c.Validate()
 	if err == nil {
-		err = s.createCollection(c)
+		err = s.createCollection(ctx, c)
 	} else {
 		log.WithFields(log.Fields{"collection": c, "error": err}).Error("Invalid Collection")
 	}
@@ -168,12 +177,17 @@ func (s CollectionService) CreateCollection(ctx context.Context, c cabby.Collect
 	return err
 }
 
-func (s CollectionService) createCollection(c cabby.Collection) error {
-	sql := `insert into taxii_collection (id, api_root_path, title, description, media_types)
-					values (?, ?, ?, ?, ?)`
-	args := []interface{}{c.ID.String(), c.APIRootPath, c.Title, c.Description, strings.Join(c.MediaTypes, ",")}
-
-	err := s.DataStore.write(sql, args...)
+func (s CollectionService) createCollection(ctx context.Context, c cabby.Collection) error {
+	sql := `insert into taxii_collection (
+						id, api_root_path, title, description, media_types, version_policy, retain_versions, retain_window,
+						expire_by, max_age
+					)
+					values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{
+		c.ID.String(), c.APIRootPath, c.Title, c.Description, strings.Join(c.MediaTypes, ","), c.VersionPolicy,
+		c.RetainVersions, int64(c.RetainWindow / time.Second), c.ExpireBy, int64(c.MaxAge / time.Second)}
+
+	err := s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -184,16 +198,16 @@ func (s CollectionService) createCollection(c cabby.Collection) error {
 func (s CollectionService) DeleteCollection(ctx context.Context, id string) error {
 	resource, action := "Collection", "delete"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	err := s.deleteCollection(id)
+	err := s.deleteCollection(ctx, id)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return err
 }
 
-func (s CollectionService) deleteCollection(id string) error {
+func (s CollectionService) deleteCollection(ctx context.Context, id string) error {
 	sql := `delete from taxii_collection where id = ?`
 	args := []interface{}{id}
 
-	_, err := s.DB.Exec(sql, args...)
+	_, err := s.DataStore.exec(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -207,7 +221,7 @@ func (s CollectionService) UpdateCollection(ctx context.Context, c cabby.Collect
 
 	err := c.Validate()
 	if err == nil {
-		err = s.updateCollection(c)
+		err = s.updateCollection(ctx, c)
 	} else {
 		log.WithFields(log.Fields{"collection": c, "error": err}).Error("Invalid Collection")
 	}
@@ -216,11 +230,16 @@ func (s CollectionService) UpdateCollection(ctx context.Context, c cabby.Collect
 	return err
 }
 
-func (s CollectionService) updateCollection(c cabby.Collection) error {
-	sql := `update taxii_collection set api_root_path = ?, title = ?, description = ? where id = ?`
-	args := []interface{}{c.APIRootPath, c.Title, c.Description, c.ID.String()}
+func (s CollectionService) updateCollection(ctx context.Context, c cabby.Collection) error {
+	sql := `update taxii_collection
+					set api_root_path = ?, title = ?, description = ?, version_policy = ?, retain_versions = ?, retain_window = ?,
+					  expire_by = ?, max_age = ?
+					where id = ?`
+	args := []interface{}{
+		c.APIRootPath, c.Title, c.Description, c.VersionPolicy, c.RetainVersions, int64(c.RetainWindow / time.Second),
+		c.ExpireBy, int64(c.MaxAge / time.Second), c.ID.String()}
 
-	err := s.DataStore.write(sql, args...)
+	err := s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
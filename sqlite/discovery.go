@@ -24,7 +24,7 @@ func (s DiscoveryService) CreateDiscovery(ctx context.Context, d cabby.Discovery
 
 	err := d.Validate()
 	if err == nil {
-		err = s.createDiscovery(d)
+		err = s.createDiscovery(ctx, d)
 	} else {
 		log.WithFields(log.Fields{"discovery": d, "error": err}).Error("Invalid Discovery")
 	}
@@ -33,11 +33,11 @@ func (s DiscoveryService) CreateDiscovery(ctx context.Context, d cabby.Discovery
 	return err
 }
 
-func (s DiscoveryService) createDiscovery(d cabby.Discovery) error {
+func (s DiscoveryService) createDiscovery(ctx context.Context, d cabby.Discovery) error {
 	sql := `insert into taxii_discovery (title, description, contact, default_url) values (?, ?, ?, ?)`
 	args := []interface{}{d.Title, d.Description, d.Contact, d.Default}
 
-	err := s.DataStore.write(sql, args...)
+	err := s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -48,16 +48,16 @@ func (s DiscoveryService) createDiscovery(d cabby.Discovery) error {
 func (s DiscoveryService) DeleteDiscovery(ctx context.Context) error {
 	resource, action := "Discovery", "delete"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	err := s.deleteDiscovery()
+	err := s.deleteDiscovery(ctx)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return err
 }
 
-func (s DiscoveryService) deleteDiscovery() error {
+func (s DiscoveryService) deleteDiscovery(ctx context.Context) error {
 	sql := `delete from taxii_discovery`
 	args := []interface{}{}
 
-	_, err := s.DB.Exec(sql, args...)
+	_, err := s.DataStore.exec(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -68,16 +68,17 @@ func (s DiscoveryService) deleteDiscovery() error {
 func (s DiscoveryService) Discovery(ctx context.Context) (cabby.Discovery, error) {
 	resource, action := "Discovery", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.discovery()
+	result, err := s.discovery(ctx)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s DiscoveryService) discovery() (cabby.Discovery, error) {
+func (s DiscoveryService) discovery(ctx context.Context) (cabby.Discovery, error) {
 	sql := `select td.title, td.description, td.contact, td.default_url,
 						 case
 							 when tar.api_root_path is null then 'No API Roots defined' else tar.api_root_path
-						 end discovery_path
+						 end discovery_path,
+						 max(coalesce(td.updated_at, ''), coalesce(tar.updated_at, '')) updated_at
 					 from
 						 taxii_discovery td
 						 left join taxii_api_root tar
@@ -87,7 +88,7 @@ func (s DiscoveryService) discovery() (cabby.Discovery, error) {
 	d := cabby.Discovery{}
 	var apiRoots []string
 
-	rows, err := s.DB.Query(sql, args...)
+	rows, err := s.DataStore.query(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 		return d, err
@@ -95,10 +96,11 @@ func (s DiscoveryService) discovery() (cabby.Discovery, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var apiRoot string
-		if err := rows.Scan(&d.Title, &d.Description, &d.Contact, &d.Default, &apiRoot); err != nil {
+		var apiRoot, updatedAt string
+		if err := rows.Scan(&d.Title, &d.Description, &d.Contact, &d.Default, &apiRoot, &updatedAt); err != nil {
 			return d, err
 		}
+		d.UpdatedAt = laterUpdate(d.UpdatedAt, updatedAt)
 		if apiRoot != "No API Roots defined" {
 			apiRoots = append(apiRoots, apiRoot)
 		}
@@ -116,7 +118,7 @@ func (s DiscoveryService) UpdateDiscovery(ctx context.Context, d cabby.Discovery
 
 	err := d.Validate()
 	if err == nil {
-		err = s.updateDiscovery(d)
+		err = s.updateDiscovery(ctx, d)
 	} else {
 		log.WithFields(log.Fields{"discovery": d, "error": err}).Error("Invalid Discovery")
 	}
@@ -125,12 +127,12 @@ func (s DiscoveryService) UpdateDiscovery(ctx context.Context, d cabby.Discovery
 	return err
 }
 
-func (s DiscoveryService) updateDiscovery(d cabby.Discovery) error {
+func (s DiscoveryService) updateDiscovery(ctx context.Context, d cabby.Discovery) error {
 	sql := `update taxii_discovery
 					set title = ?, description = ?, contact = ?, default_url = ?`
 	args := []interface{}{d.Title, d.Description, d.Contact, d.Default}
 
-	err := s.DataStore.write(sql, args...)
+	err := s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
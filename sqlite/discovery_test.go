@@ -147,7 +147,7 @@ func TestDiscoveryServiceDiscoveryQueryErr(t *testing.T) {
 func TestDiscoveryServiceDiscoveryNoDiscovery(t *testing.T) {
 	setupSQLite()
 	ds := testDataStore()
-	s := DiscoveryService{DB: ds.DB}
+	s := DiscoveryService{DB: ds.DB, DataStore: ds}
 
 	_, err := s.Discovery(context.Background())
 	if err != nil {
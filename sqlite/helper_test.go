@@ -2,8 +2,8 @@ package sqlite
 
 import (
 	"context"
-	"io/ioutil"
 	"os"
+	"testing"
 	"time"
 
 	cabby "github.com/pladdy/cabby2"
@@ -12,8 +12,8 @@ import (
 )
 
 const (
-	testDBPath = "testdata/tester.db"
-	schema     = "schema.sql"
+	benchmarkDBPath = "testdata/benchmark.db"
+	testDBPath      = "testdata/tester.db"
 )
 
 /* helpers */
@@ -71,6 +71,51 @@ func createObjectVersion(ds *DataStore, id, version string) {
 	}
 }
 
+// benchmarkDataStore returns a data store at the schema version given with a collection of objects in it; every
+// object has two versions.  Short benchmarks use a smaller collection than the million objects long ones do.
+func benchmarkDataStore(b *testing.B, version int) *DataStore {
+	objects := 1000000
+	if testing.Short() {
+		objects = 10000
+	}
+
+	removeDB(benchmarkDBPath)
+	ds, err := NewDataStore(benchmarkDBPath)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	if _, err := ds.DB.Exec(schemaVersionTable); err != nil {
+		b.Fatal(err)
+	}
+	for _, m := range migrations[:version] {
+		if err := ds.applyMigration(context.Background(), m); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	createUser(ds)
+	createDiscovery(ds)
+	createAPIRoot(ds)
+	createCollection(ds, tester.Collection.ID.String())
+
+	sql := `with recursive n(i) as (select 0 union all select i + 1 from n where i < ? - 1)
+	        insert into stix_objects (id, type, created, modified, object, collection_id)
+	        select id, 'malware', '2016-04-06T20:07:09.000Z', modified,
+	               json_object('type', 'malware', 'id', id, 'created', '2016-04-06T20:07:09.000Z', 'modified', modified,
+	                           'name', 'malware ' || i),
+	               ?
+	          from (select i,
+	                       printf('malware--%08d-0000-4000-8000-000000000000', i / 2) id,
+	                       case i % 2 when 0 then '2018-01-01T00:00:00.000Z' else '2018-01-02T00:00:00.000Z' end modified
+	                  from n)`
+
+	if _, err := ds.DB.Exec(sql, objects*2, tester.CollectionID); err != nil {
+		b.Fatal(err)
+	}
+	return ds
+}
+
 func createUser(ds *DataStore) {
 	err := ds.UserService().CreateUser(context.Background(), tester.User, tester.UserPassword)
 	if err != nil {
@@ -82,20 +127,10 @@ func setupSQLite() {
 	tearDownSQLite()
 	tester.Info.Println("Setting up test sqlite db:", testDBPath)
 
-	f, err := os.Open(schema)
-	if err != nil {
-		tester.Error.Fatal("Couldn't open schema file: ", err)
-	}
-
-	schema, err := ioutil.ReadAll(f)
-	if err != nil {
-		tester.Error.Fatal("Couldn't read schema file: ", err)
-	}
-
 	ds := testDataStore()
-	_, err = ds.DB.Exec(string(schema))
+	_, err := ds.Migrate(context.Background())
 	if err != nil {
-		tester.Error.Fatal("Couldn't load schema: ", err)
+		tester.Error.Fatal("Couldn't migrate schema: ", err)
 	}
 
 	createUser(ds)
@@ -115,5 +150,24 @@ func testDataStore() *DataStore {
 
 func tearDownSQLite() {
 	tester.Info.Println("Tearing down test sqlite db:", testDBPath)
-	os.Remove(testDBPath)
+	removeDB(testDBPath)
+}
+
+// removeDB removes a database and its WAL files; a WAL left behind would be replayed into the next database at the path
+func removeDB(path string) {
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		os.Remove(path + suffix)
+	}
+}
+
+// manifestEntry returns the manifest entry for the tester object; its date added is set by the data store
+func manifestEntry(ds *DataStore) cabby.ManifestEntry {
+	objects, err := ds.ObjectService().Object(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{})
+	if err != nil || len(objects) != 1 {
+		tester.Error.Fatal("Can't read tester object: ", err)
+	}
+
+	entry := tester.ManifestEntry
+	entry.DateAdded = objects[0].DateAdded.Format(cabby.TimestampFormat)
+	return entry
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"strings"
+	"time"
 
 	// import sqlite dependency
 	_ "github.com/mattn/go-sqlite3"
@@ -13,59 +14,127 @@ import (
 
 // ManifestService implements a SQLite version of the ManifestService interface
 type ManifestService struct {
-	DB *sql.DB
+	DB        *sql.DB
+	DataStore *DataStore
+}
+
+// manifestSortColumns are the columns manifest entries are sorted on; an entry's versions is the modified time of
+// its one version
+var manifestSortColumns = map[string]string{
+	cabby.SortDateAdded: "created_at",
+	cabby.SortID:        "id",
+	cabby.SortModified:  "strftime('%Y-%m-%d %H:%M:%f', modified)",
+}
+
+// IterateManifest will read from the data store and return an iterator over the resource; the range total is
+// set once Next is called
+func (s ManifestService) IterateManifest(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+	resource, action := "Manifest", "iterate"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.iterateManifest(ctx, collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ManifestService) iterateManifest(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+	// media_types omitted...should that be in this table?
+	page := collectionData("rowid, id, created_at, modified", collectionID, f)
+
+	q := selectFrom(`id, coalesce(created_at, ''), modified versions, (select updated_at from updated) last_updated_at,
+		(select coalesce(min(created_at), '') from page) first_added,
+		(select coalesce(max(created_at), '') from page) last_added, `+pageColumns, "page").
+		with("updated", collectionUpdated(collectionID))
+	sql, args := withPage(q, page, collectionID, cr, f, manifestSortColumns).build()
+
+	rows, err := s.DataStore.query(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return nil, err
+	}
+	return &manifestRows{rows: rows, cr: cr}, nil
 }
 
 // Manifest will read from the data store and return the resource
 func (s ManifestService) Manifest(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error) {
 	resource, action := "Manifest", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.manifest(collectionID, cr, f)
+	result, err := s.manifest(ctx, collectionID, cr, f)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s ManifestService) manifest(collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error) {
-	sql := `with data as (
-						select rowid, id, min(created) date_added, group_concat(modified) versions, 1 count
-						-- media_types omitted...should that be in this table?
-						from stix_objects_data
-						where
-							collection_id = ?
-							and $filter
-						group by rowid, id
-					)
-					select id, date_added, versions, (select sum(count) from data) total
-					from data
-					$paginate`
-
-	args := []interface{}{collectionID}
-
-	sql, args = applyFiltering(sql, f, args)
-	sql, args = applyPaging(sql, cr, args)
-
+func (s ManifestService) manifest(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error) {
 	m := cabby.Manifest{}
 
-	rows, err := s.DB.Query(sql, args...)
+	mr, err := s.iterateManifest(ctx, collectionID, cr, f)
 	if err != nil {
-		logSQLError(sql, args, err)
 		return m, err
 	}
-	defer rows.Close()
+	defer mr.Close()
 
-	for rows.Next() {
-		me := cabby.ManifestEntry{}
-		var versions string
+	for mr.Next() {
+		m.Objects = append(m.Objects, mr.Entry())
+	}
 
-		if err := rows.Scan(&me.ID, &me.DateAdded, &versions, &cr.Total); err != nil {
-			return m, err
-		}
+	err = mr.Err()
+	return m, err
+}
 
-		me.MediaTypes = []string{cabby.StixContentType}
-		me.Versions = strings.Split(string(versions), ",")
-		m.Objects = append(m.Objects, me)
+// manifestRows implements a cabby.ManifestIterator over sql rows
+type manifestRows struct {
+	rows       *queryRows
+	cr         *cabby.Range
+	entry      cabby.ManifestEntry
+	updatedAt  time.Time
+	firstAdded time.Time
+	lastAdded  time.Time
+	err        error
+}
+
+func (m *manifestRows) Next() bool {
+	if m.err != nil || !m.rows.Next() {
+		return false
 	}
 
-	err = rows.Err()
-	return m, err
+	m.entry = cabby.ManifestEntry{}
+	var createdAt, versions, lastUpdatedAt, firstAdded, lastAdded, nextAdded string
+	var total, remaining, nextRow int64
+
+	m.err = m.rows.Scan(&m.entry.ID, &createdAt, &versions, &lastUpdatedAt, &firstAdded, &lastAdded,
+		&total, &remaining, &nextAdded, &nextRow)
+	if m.err != nil {
+		return false
+	}
+	setPage(m.cr, total, remaining, nextAdded, nextRow)
+	m.updatedAt = laterUpdate(m.updatedAt, lastUpdatedAt)
+	m.firstAdded, m.lastAdded = sqliteTime(firstAdded), sqliteTime(lastAdded)
+
+	m.entry.DateAdded = sqliteTime(createdAt).Format(cabby.TimestampFormat)
+
+	m.entry.MediaTypes = []string{cabby.StixContentType}
+	m.entry.Versions = strings.Split(string(versions), ",")
+	return true
+}
+
+func (m *manifestRows) Entry() cabby.ManifestEntry {
+	return m.entry
+}
+
+func (m *manifestRows) UpdatedAt() time.Time {
+	return m.updatedAt
+}
+
+func (m *manifestRows) DateAdded() (time.Time, time.Time) {
+	return m.firstAdded, m.lastAdded
+}
+
+func (m *manifestRows) Err() error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.rows.Err()
+}
+
+func (m *manifestRows) Close() error {
+	return m.rows.Close()
 }
@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -14,7 +15,7 @@ func TestManifestServiceManifest(t *testing.T) {
 	ds := testDataStore()
 	s := ds.ManifestService()
 
-	expected := tester.ManifestEntry
+	expected := manifestEntry(ds)
 
 	result, err := s.Manifest(context.Background(), tester.CollectionID, &cabby.Range{}, cabby.Filter{})
 	if err != nil {
@@ -27,6 +28,41 @@ func TestManifestServiceManifest(t *testing.T) {
 	}
 }
 
+func TestManifestServiceIterateManifest(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.ManifestService()
+
+	expected := manifestEntry(ds)
+	cr := cabby.Range{First: 0, Last: 0}
+
+	entries, err := s.IterateManifest(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer entries.Close()
+
+	if !entries.Next() {
+		t.Fatal("Got:", entries.Err(), "Expected an entry")
+	}
+
+	passed := tester.CompareManifestEntry(entries.Entry(), expected)
+	if !passed {
+		t.Error("Comparison failed")
+	}
+
+	if cr.Total != 1 {
+		t.Error("Got:", cr.Total, "Expected:", 1)
+	}
+
+	if entries.Next() {
+		t.Error("Got:", entries.Entry(), "Expected no more entries")
+	}
+	if entries.Err() != nil {
+		t.Error("Got:", entries.Err(), "Expected no error")
+	}
+}
+
 func TestManifestServiceManifestFilter(t *testing.T) {
 	setupSQLite()
 	ds := testDataStore()
@@ -127,3 +163,22 @@ func TestManifestServiceManifestNoAPIRoot(t *testing.T) {
 		t.Error("Got:", err, "Expected no error")
 	}
 }
+
+/* benchmarks */
+
+func BenchmarkManifestServiceManifest(b *testing.B) {
+	for _, version := range benchmarkVersions {
+		ds := benchmarkDataStore(b, version)
+
+		b.Run(fmt.Sprintf("schema %v", version), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				cr, _ := cabby.NewRange("items 0-99")
+				if _, err := ds.ManifestService().Manifest(context.Background(), tester.CollectionID, &cr, cabby.Filter{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		ds.Close()
+	}
+	removeDB(benchmarkDBPath)
+}
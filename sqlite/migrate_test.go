@@ -0,0 +1,248 @@
+package sqlite
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestMigrations(t *testing.T) {
+	// the only tables migrations drop are copies an earlier migration kept
+	copies := map[string]bool{"stix_objects_v2": true}
+	dropTable := regexp.MustCompile(`drop table (?:if exists )?(\w+)`)
+
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Error("Got:", m.version, "Expected:", i+1, "Description:", m.description)
+		}
+		for _, drop := range dropTable.FindAllStringSubmatch(strings.ToLower(m.sql), -1) {
+			if !copies[drop[1]] {
+				t.Error("Got: drop table", drop[1], "Expected: forward only migrations", "Version:", m.version)
+			}
+		}
+	}
+}
+
+func TestDataStoreMigrate(t *testing.T) {
+	tearDownSQLite()
+	defer tearDownSQLite()
+
+	ds := testDataStore()
+	defer ds.Close()
+
+	version, err := ds.SchemaVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 0 {
+		t.Error("Got:", version, "Expected:", 0)
+	}
+
+	err = ds.VerifySchema(context.Background())
+	if err == nil {
+		t.Error("Expected an error for an unmigrated schema")
+	}
+
+	applied, err := ds.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(migrations) {
+		t.Error("Got:", applied, "Expected:", len(migrations))
+	}
+
+	err = ds.VerifySchema(context.Background())
+	if err != nil {
+		t.Error("Got:", err, "Expected: nil")
+	}
+
+	// migrating again is a no-op
+	applied, err = ds.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 0 {
+		t.Error("Got:", applied, "Expected:", 0)
+	}
+}
+
+func TestDataStoreMigratePartial(t *testing.T) {
+	tearDownSQLite()
+	defer tearDownSQLite()
+
+	ds := testDataStore()
+	defer ds.Close()
+
+	// a data store created before migrations were tracked has the initial schema and no version
+	_, err := ds.DB.Exec(migrations[0].sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := ds.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(migrations) {
+		t.Error("Got:", applied, "Expected:", len(migrations))
+	}
+
+	version, err := ds.SchemaVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Error("Got:", version, "Expected:", LatestSchemaVersion())
+	}
+}
+
+func TestDataStoreMigrateNewerSchema(t *testing.T) {
+	tearDownSQLite()
+	defer tearDownSQLite()
+
+	ds := testDataStore()
+	defer ds.Close()
+
+	_, err := ds.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ds.DB.Exec(`insert into schema_version (version, description) values (?, 'from the future')`,
+		LatestSchemaVersion()+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ds.Migrate(context.Background())
+	if err == nil {
+		t.Error("Expected an error migrating a newer schema")
+	}
+
+	err = ds.VerifySchema(context.Background())
+	if err == nil {
+		t.Error("Expected an error verifying a newer schema")
+	}
+}
+
+func TestDataStoreMigrateFailure(t *testing.T) {
+	tearDownSQLite()
+	defer tearDownSQLite()
+
+	ds := testDataStore()
+	defer ds.Close()
+
+	_, err := ds.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds.DB.Close()
+	_, err = ds.Migrate(context.Background())
+	if err == nil {
+		t.Error("Expected an error migrating a closed data store")
+	}
+}
+
+// objects written before they were keyed by collection are kept, and their versions can be added to other
+// collections after
+func TestDataStoreMigrateObjectsByCollection(t *testing.T) {
+	tearDownSQLite()
+	defer tearDownSQLite()
+
+	ds := testDataStore()
+	defer ds.Close()
+
+	if _, err := ds.DB.Exec(schemaVersionTable); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations[:2] {
+		if err := ds.applyMigration(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	o := tester.Object
+	if err := ds.ObjectService().CreateObject(context.Background(), o); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ds.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	results, _ := ds.ObjectService().Object(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{})
+	if len(results) != 1 {
+		t.Error("Got:", len(results), "Expected:", 1)
+	}
+
+	// the copy of the objects is dropped by a later migration
+	var copies int
+	err := ds.DB.QueryRow("select count(*) from sqlite_master where type = 'table' and name = 'stix_objects_v2'").Scan(&copies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copies != 0 {
+		t.Error("Got:", copies, "Expected:", 0)
+	}
+
+	o.CollectionID, _ = cabby.NewID()
+	if err := ds.ObjectService().CreateObject(context.Background(), o); err != nil {
+		t.Error("Got:", err, "Expected: nil")
+	}
+}
+
+// objects written before their expiry and counts were kept at write time are counted, and expire, once migrated
+func TestDataStoreMigrateObjectCounts(t *testing.T) {
+	tearDownSQLite()
+	defer tearDownSQLite()
+
+	ds := testDataStore()
+	defer ds.Close()
+
+	if _, err := ds.DB.Exec(schemaVersionTable); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations[:11] {
+		if err := ds.applyMigration(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createUser(ds)
+	createDiscovery(ds)
+	createAPIRoot(ds)
+	createCollection(ds, tester.CollectionID)
+
+	o := tester.Object
+	for _, modified := range []string{"2018-01-01T00:00:00.000Z", "2018-01-02T00:00:00.000Z"} {
+		_, err := ds.DB.Exec(createObjectSQL, o.ID, o.Type, o.Created, modified, o.Object, tester.CollectionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := ds.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	cr := cabby.Range{First: 0, Last: 0}
+	if _, err := ds.ObjectService().Objects(context.Background(), tester.CollectionID, &cr, cabby.Filter{}); err != nil {
+		t.Fatal(err)
+	}
+	if cr.Total != 2 {
+		t.Error("Got:", cr.Total, "Expected:", 2)
+	}
+
+	expiry := "update taxii_collection set expire_by = ?, max_age = ? where id = ?"
+	if _, err := ds.DB.Exec(expiry, cabby.ExpireByAdded, -60, tester.CollectionID); err != nil {
+		t.Fatal(err)
+	}
+	results, _ := ds.ObjectService().Objects(context.Background(), tester.CollectionID, &cabby.Range{First: -1, Last: -1}, cabby.Filter{})
+	if len(results) != 0 {
+		t.Error("Got:", len(results), "Expected:", 0)
+	}
+}
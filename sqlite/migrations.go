@@ -0,0 +1,716 @@
+package sqlite
+
+// migrations are applied in order and never edited once released; a schema change is a new migration at the end
+// of the list.  The first migration creates tables only if they don't exist so databases created before migrations
+// were tracked can be brought under version control.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "initial schema",
+		sql: `
+/* stix */
+
+create table if not exists stix_objects (
+  id            text not null,
+  type          text not null,
+  created       text not null,
+  modified      text not null,
+  object        text not null check(json_valid(object) = 1),
+  collection_id text not null,
+  created_at    text,
+  updated_at    text,
+
+  primary key (id, modified)
+);
+
+  create trigger if not exists stix_objects_ai_created_at after insert on stix_objects
+    begin
+      update stix_objects set created_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+      update stix_objects set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+    end;
+
+  create trigger if not exists stix_objects_au_updated_at after update on stix_objects
+    begin
+      update stix_objects set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+    end;
+
+  create index if not exists stix_objects_id on stix_objects (id);
+  create index if not exists stix_objects_type on stix_objects (type);
+  create index if not exists stix_objects_version on stix_objects (id, type, modified);
+
+  create view if not exists stix_objects_id_aggregate as
+    select rowid,
+           id,
+           type,
+           collection_id,
+           min(modified) first,
+           max(modified) last
+    from stix_objects
+    group by id,
+             type,
+             collection_id;
+
+  create view if not exists stix_objects_data as
+    select
+      so.rowid,
+      so.id,
+      so.type,
+      so.created,
+      so.modified,
+      so.object,
+      so.collection_id,
+      case when so.modified = sa.first and so.modified = sa.last then 'only'
+           when so.modified = sa.last then 'last'
+           when so.modified = sa.first then 'first'
+      end version,
+      so.created_at,
+      so.updated_at
+    from
+      stix_objects so
+      left join stix_objects_id_aggregate sa
+        on so.id = sa.id
+        and so.collection_id = sa.collection_id;
+
+/* taxii */
+
+create table if not exists taxii_api_root (
+  id                 integer not null primary key,
+  discovery_id       integer check(discovery_id = 1) default 1,
+  api_root_path      text    check(api_root_path != "") not null,
+  title              text    not null,
+  description        text,
+  versions           text,
+  max_content_length integer not null,
+  created_at         text,
+  updated_at         text,
+
+  unique(api_root_path) on conflict fail
+);
+
+  create trigger if not exists taxii_api_root_ai_created_at after insert on taxii_api_root
+    begin
+      update taxii_api_root set created_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+      update taxii_api_root set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+    end;
+
+  create trigger if not exists taxii_api_root_au_updated_at after update on taxii_api_root
+    begin
+      update taxii_api_root set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+    end;
+
+create table if not exists taxii_collection (
+  id            text not null primary key,
+  api_root_path text not null,
+  title         text,
+  description   text,
+  media_types   text default '',
+  created_at    text,
+  updated_at    text
+);
+
+  create trigger if not exists taxii_collection_ai_created_at after insert on taxii_collection
+    begin
+      update taxii_collection set created_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+      update taxii_collection set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+    end;
+
+  create trigger if not exists taxii_collection_au_updated_at after update on taxii_collection
+    begin
+      update taxii_collection set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+    end;
+
+create table if not exists taxii_discovery (
+  id          text check(id = 1) default 1 primary key, /* can only be one, see trigger below */
+  title       text not null,
+  description text,
+  contact     text,
+  default_url text,
+  created_at  text,
+  updated_at  text
+);
+
+  create trigger if not exists taxii_discovery_ai_created_at after insert on taxii_discovery
+    begin
+      update taxii_discovery set created_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+      update taxii_discovery set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+    end;
+
+  create trigger if not exists taxii_discovery_au_updated_at after update on taxii_discovery
+    begin
+      update taxii_discovery set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+    end;
+
+  create trigger if not exists taxii_discovery_bi_count before insert on taxii_discovery
+    begin
+      select
+        case
+          when (select count(*) from taxii_discovery) > 0
+            then raise(abort, 'Only one discovery can be defined')
+        end;
+    end;
+
+create table if not exists taxii_status (
+  id                text not null,
+  status            text not null,
+  request_timestamp text,
+  total_count       integer not null,
+  success_count     integer not null,
+  successes         text,
+  failure_count     integer not null,
+  failures          text,
+  pending_count     integer not null,
+  pendings          text,
+  /* internal */
+  created_at    text,
+  updated_at    text
+);
+
+  create trigger if not exists taxii_status_ai_created_at after insert on taxii_status
+    begin
+      update taxii_status set created_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+      update taxii_status set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+    end;
+
+  create trigger if not exists taxii_status_au_updated_at after update on taxii_status
+    begin
+      update taxii_status set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where id = new.id;
+    end;
+
+  create index if not exists taxii_status_taxii_id on taxii_status (id);
+
+create table if not exists taxii_user (
+  email      text not null primary key,
+  can_admin  integer check(can_admin in (1, 0)) default 0 not null,
+  created_at text,
+  updated_at text
+);
+
+  create trigger if not exists taxii_user_ai_created_at after insert on taxii_user
+    begin
+      update taxii_user set created_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where email = new.email;
+      update taxii_user set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where email = new.email;
+    end;
+
+  create trigger if not exists taxii_user_bi_email before insert on taxii_user
+    begin
+      select case when new.email not like '%_@__%.__%' then raise(abort, 'Invalid email address, expecting <username>@<domain>.<tld>') end;
+    end;
+
+  create trigger if not exists taxii_user_au_updated_at after update on taxii_user
+    begin
+      update taxii_user set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where email = new.email;
+    end;
+
+create table if not exists taxii_user_collection (
+  id            integer primary key not null,
+  email         text    not null,
+  collection_id text    not null,
+  can_read      integer check(can_read in (1, 0)) not null,
+  can_write     integer check(can_read in (1, 0)) not null,
+  created_at    text,
+  updated_at    text,
+
+  unique (email, collection_id) on conflict ignore,
+  foreign key (email) references taxii_user(email) on delete cascade
+);
+
+  create trigger if not exists taxii_user_collection_ai_created_at after insert on taxii_user_collection
+    begin
+      update taxii_user_collection set created_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where email = new.email;
+      update taxii_user_collection set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where email = new.email;
+    end;
+
+  create trigger if not exists taxii_user_collection_au_updated_at after update on taxii_user_collection
+    begin
+      update taxii_user_collection set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where email = new.email;
+    end;
+
+create table if not exists taxii_user_pass (
+  id         integer not null primary key,
+  email      text not null,
+  -- check password is not empty string or sha256 of empty string
+  pass       text not null check (
+               pass not in ("", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+               and length(pass) == 64
+             ),
+  created_at text,
+  updated_at text,
+
+  unique(email) on conflict ignore,
+  foreign key (email) references taxii_user(email) on delete cascade
+);
+
+  create trigger if not exists taxii_user_pass_ai_created_at after insert on taxii_user_pass
+    begin
+      update taxii_user_pass set created_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where email = new.email;
+      update taxii_user_pass set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where email = new.email;
+    end;
+
+  create trigger if not exists taxii_user_pass_au_updated_at after update on taxii_user_pass
+    begin
+      update taxii_user_pass set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where email = new.email;
+    end;
+`,
+	},
+	{
+		version:     2,
+		description: "track status owner and collection",
+		sql: `
+alter table taxii_status add column email text;
+alter table taxii_status add column collection_id text;
+
+  create index taxii_status_created_at on taxii_status (created_at);
+  create index taxii_status_email on taxii_status (email);
+  create index taxii_status_collection_id on taxii_status (collection_id);
+`,
+	},
+	{
+		version:     3,
+		description: "key objects by collection",
+		sql: `
+/* sqlite can't change a primary key; the objects are copied into a new table and the old one is kept as
+   stix_objects_v2 so no data is lost, it can be dropped once the upgrade is checked */
+
+drop view stix_objects_data;
+drop view stix_objects_id_aggregate;
+drop trigger stix_objects_ai_created_at;
+drop trigger stix_objects_au_updated_at;
+drop index stix_objects_id;
+drop index stix_objects_type;
+drop index stix_objects_version;
+
+alter table stix_objects rename to stix_objects_v2;
+
+create table stix_objects (
+  id            text not null,
+  type          text not null,
+  created       text not null,
+  modified      text not null,
+  object        text not null check(json_valid(object) = 1),
+  collection_id text not null,
+  created_at    text,
+  updated_at    text,
+
+  primary key (collection_id, id, modified)
+);
+
+insert into stix_objects (rowid, id, type, created, modified, object, collection_id, created_at, updated_at)
+  select rowid, id, type, created, modified, object, collection_id, created_at, updated_at
+  from stix_objects_v2
+  order by rowid;
+
+  create trigger stix_objects_ai_created_at after insert on stix_objects
+    begin
+      update stix_objects set created_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+        where id = new.id and collection_id = new.collection_id;
+      update stix_objects set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+        where id = new.id and collection_id = new.collection_id;
+    end;
+
+  create trigger stix_objects_au_updated_at after update on stix_objects
+    begin
+      update stix_objects set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+        where id = new.id and collection_id = new.collection_id;
+    end;
+
+  create index stix_objects_id on stix_objects (id);
+  create index stix_objects_type on stix_objects (type);
+  create index stix_objects_version on stix_objects (id, type, modified);
+
+  create view stix_objects_id_aggregate as
+    select rowid,
+           id,
+           type,
+           collection_id,
+           min(modified) first,
+           max(modified) last
+    from stix_objects
+    group by id,
+             type,
+             collection_id;
+
+  create view stix_objects_data as
+    select
+      so.rowid,
+      so.id,
+      so.type,
+      so.created,
+      so.modified,
+      so.object,
+      so.collection_id,
+      case when so.modified = sa.first and so.modified = sa.last then 'only'
+           when so.modified = sa.last then 'last'
+           when so.modified = sa.first then 'first'
+      end version,
+      so.created_at,
+      so.updated_at
+    from
+      stix_objects so
+      left join stix_objects_id_aggregate sa
+        on so.id = sa.id
+        and so.collection_id = sa.collection_id;
+`,
+	},
+	{
+		version:     4,
+		description: "set an object version's created_at once",
+		sql: `
+/* the triggers updated every version of an object when one was written; they update only the row written now.
+   created_at is when a version was added and doesn't change after */
+
+drop trigger stix_objects_ai_created_at;
+drop trigger stix_objects_au_updated_at;
+
+  create trigger stix_objects_ai_created_at after insert on stix_objects
+    begin
+      update stix_objects set created_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where rowid = new.rowid;
+      update stix_objects set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where rowid = new.rowid;
+    end;
+
+  create trigger stix_objects_au_updated_at after update on stix_objects
+    begin
+      update stix_objects set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') where rowid = new.rowid;
+    end;
+`,
+	},
+	{
+		version:     5,
+		description: "version policy per collection",
+		sql: `
+/* what's done with posted versions that are already in a collection or older than its latest version; null is the
+   default policy, reject */
+
+alter table taxii_collection add column version_policy text;
+`,
+	},
+	{
+		version:     6,
+		description: "version retention per collection",
+		sql: `
+/* how many of an object's latest versions are kept, or for how many seconds after they're modified; null keeps every
+   version */
+
+alter table taxii_collection add column retain_versions integer;
+alter table taxii_collection add column retain_window integer;
+`,
+	},
+	{
+		version:     7,
+		description: "object expiry per collection",
+		sql: `
+/* objects expire by the date their latest version was added, or by its valid_until, plus max_age seconds; null never
+   expires them */
+
+alter table taxii_collection add column expire_by text;
+alter table taxii_collection add column max_age integer;
+
+  /* the objects whose latest version has expired; every version of them is hidden from reads until it's purged */
+  create view stix_objects_expired as
+    select so.collection_id, so.id
+    from
+      stix_objects_data so
+      inner join taxii_collection c
+        on so.collection_id = c.id
+    where
+      so.version in ('last', 'only')
+      and (
+        (c.expire_by = 'added'
+         and so.created_at < strftime('%Y-%m-%d %H:%M:%f', 'now', '-' || c.max_age || ' seconds'))
+        or (c.expire_by = 'valid_until'
+            and strftime('%Y-%m-%d %H:%M:%f', json_extract(so.object, '$.valid_until'))
+              < strftime('%Y-%m-%d %H:%M:%f', 'now', '-' || coalesce(c.max_age, 0) || ' seconds'))
+      );
+`,
+	},
+	{
+		version:     8,
+		description: "object tombstones",
+		sql: `
+/* object versions deleted from a collection; clients replicating a collection read them to delete their copies */
+
+create table if not exists stix_objects_tombstone (
+  collection_id text not null,
+  id            text not null,
+  modified      text not null,
+  deleted_at    text not null default (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+  create index if not exists stix_objects_tombstone_deleted_at on stix_objects_tombstone (collection_id, deleted_at);
+`,
+	},
+	{
+		version:     9,
+		description: "object property indexes",
+		sql: `
+/* match filters query into the stored objects; the scalar properties most filtered on are indexed, matching the
+   expressions the filters use */
+
+  create index if not exists stix_objects_created_by_ref on stix_objects (collection_id, json_extract(object, '$.created_by_ref'));
+  create index if not exists stix_objects_relationship_type on stix_objects (collection_id, json_extract(object, '$.relationship_type'));
+  create index if not exists stix_objects_source_ref on stix_objects (collection_id, json_extract(object, '$.source_ref'));
+  create index if not exists stix_objects_target_ref on stix_objects (collection_id, json_extract(object, '$.target_ref'));
+  create index if not exists stix_objects_spec_version on stix_objects (collection_id, coalesce(json_extract(object, '$.spec_version'), '2.0'));
+`,
+	},
+	{
+		version:     10,
+		description: "page sizes per api root",
+		sql: `
+/* how many items a page of a listing has when a request doesn't ask for a range, and the most it can ask for; null
+   doesn't limit pages */
+
+alter table taxii_api_root add column default_page_size integer;
+alter table taxii_api_root add column max_page_size integer;
+`,
+	},
+	{
+		version:     11,
+		description: "object version flags kept at write time",
+		sql: `
+/* whether a version is an object's first, last or only version was worked out on every read by grouping every object
+   in every collection; the first and last versions of each object are kept in a table the triggers maintain, and the
+   data view looks them up by key */
+
+create table if not exists stix_objects_versions (
+  collection_id  text not null,
+  id             text not null,
+  first_modified text not null,
+  last_modified  text not null,
+
+  primary key (collection_id, id)
+);
+
+insert into stix_objects_versions (collection_id, id, first_modified, last_modified)
+  select collection_id, id, min(modified), max(modified)
+  from stix_objects
+  group by collection_id, id;
+
+  create trigger stix_objects_ai_versions after insert on stix_objects
+    begin
+      insert into stix_objects_versions (collection_id, id, first_modified, last_modified)
+        values (new.collection_id, new.id, new.modified, new.modified)
+        on conflict (collection_id, id) do update
+        set first_modified = min(first_modified, excluded.first_modified),
+            last_modified = max(last_modified, excluded.last_modified);
+    end;
+
+  create trigger stix_objects_ad_versions after delete on stix_objects
+    begin
+      delete from stix_objects_versions where collection_id = old.collection_id and id = old.id;
+      insert into stix_objects_versions (collection_id, id, first_modified, last_modified)
+        select collection_id, id, min(modified), max(modified)
+        from stix_objects
+        where collection_id = old.collection_id and id = old.id
+        group by collection_id, id;
+    end;
+
+drop view stix_objects_data;
+drop view stix_objects_id_aggregate;
+
+  create view stix_objects_data as
+    select
+      so.rowid,
+      so.id,
+      so.type,
+      so.created,
+      so.modified,
+      so.object,
+      so.collection_id,
+      case when so.modified = sv.first_modified and so.modified = sv.last_modified then 'only'
+           when so.modified = sv.last_modified then 'last'
+           when so.modified = sv.first_modified then 'first'
+      end version,
+      so.created_at,
+      so.updated_at
+    from
+      stix_objects so
+      left join stix_objects_versions sv
+        on so.collection_id = sv.collection_id
+        and so.id = sv.id;
+
+  /* reads are of a collection, by the date objects were added or by type */
+  create index if not exists stix_objects_collection_created_at on stix_objects (collection_id, created_at);
+  create index if not exists stix_objects_collection_type on stix_objects (collection_id, type);
+`,
+	},
+	{
+		version:     12,
+		description: "object expiry and counts kept at write time",
+		sql: `
+/* reads hid expired objects by working out every object's expiry in the collection, and counted a collection by
+   reading all of it, on every page.  when an object expires is kept with its versions, and how many objects and
+   versions each collection has is kept in a table, so a page reads only its own rows */
+
+alter table stix_objects_versions add column versions integer not null default 0;
+alter table stix_objects_versions add column expires_at text;
+
+update stix_objects_versions
+  set versions = (select count(*)
+                  from stix_objects so
+                  where so.collection_id = stix_objects_versions.collection_id and so.id = stix_objects_versions.id);
+
+  /* when each object's latest version expires in its collection; null never expires it */
+  create view stix_objects_expiry as
+    select
+      sv.collection_id,
+      sv.id,
+      case c.expire_by
+        when 'added' then strftime('%Y-%m-%d %H:%M:%f', so.created_at, c.max_age || ' seconds')
+        when 'valid_until' then strftime('%Y-%m-%d %H:%M:%f', json_extract(so.object, '$.valid_until'),
+                                         coalesce(c.max_age, 0) || ' seconds')
+      end expires_at
+    from
+      stix_objects_versions sv
+      inner join stix_objects so
+        on sv.collection_id = so.collection_id
+        and sv.id = so.id
+        and sv.last_modified = so.modified
+      inner join taxii_collection c
+        on sv.collection_id = c.id;
+
+update stix_objects_versions
+  set expires_at = (select e.expires_at
+                    from stix_objects_expiry e
+                    where e.collection_id = stix_objects_versions.collection_id and e.id = stix_objects_versions.id);
+
+create table if not exists stix_objects_counts (
+  collection_id text    not null primary key,
+  objects       integer not null default 0,
+  versions      integer not null default 0,
+  updated_at    text
+);
+
+insert into stix_objects_counts (collection_id, objects, versions, updated_at)
+  select
+    sv.collection_id,
+    count(*),
+    sum(sv.versions),
+    (select max(so.updated_at) from stix_objects so where so.collection_id = sv.collection_id)
+  from stix_objects_versions sv
+  group by sv.collection_id;
+
+drop trigger stix_objects_ai_versions;
+drop trigger stix_objects_ad_versions;
+
+  create trigger stix_objects_ai_versions after insert on stix_objects
+    begin
+      insert into stix_objects_versions (collection_id, id, first_modified, last_modified, versions)
+        values (new.collection_id, new.id, new.modified, new.modified, 1)
+        on conflict (collection_id, id) do update
+        set first_modified = min(first_modified, excluded.first_modified),
+            last_modified = max(last_modified, excluded.last_modified),
+            versions = versions + 1;
+      update stix_objects_versions
+        set expires_at = (select e.expires_at from stix_objects_expiry e where e.collection_id = new.collection_id and e.id = new.id)
+        where collection_id = new.collection_id and id = new.id;
+    end;
+
+  /* a version's date added is set after it's inserted, and an overwritten version can change its valid_until */
+  create trigger stix_objects_au_expiry after update of created_at, object on stix_objects
+    begin
+      update stix_objects_versions
+        set expires_at = (select e.expires_at from stix_objects_expiry e where e.collection_id = new.collection_id and e.id = new.id)
+        where collection_id = new.collection_id and id = new.id;
+    end;
+
+  create trigger stix_objects_ad_versions after delete on stix_objects
+    begin
+      delete from stix_objects_versions where collection_id = old.collection_id and id = old.id;
+      insert into stix_objects_versions (collection_id, id, first_modified, last_modified, versions)
+        select collection_id, id, min(modified), max(modified), count(*)
+        from stix_objects
+        where collection_id = old.collection_id and id = old.id
+        group by collection_id, id;
+      update stix_objects_versions
+        set expires_at = (select e.expires_at from stix_objects_expiry e where e.collection_id = old.collection_id and e.id = old.id)
+        where collection_id = old.collection_id and id = old.id;
+      update stix_objects_counts set updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+        where collection_id = old.collection_id;
+    end;
+
+  create trigger stix_objects_au_counts after update of updated_at on stix_objects
+    begin
+      insert into stix_objects_counts (collection_id, updated_at) values (new.collection_id, new.updated_at)
+        on conflict (collection_id) do update
+        set updated_at = max(coalesce(updated_at, ''), excluded.updated_at);
+    end;
+
+  create trigger stix_objects_versions_ai_counts after insert on stix_objects_versions
+    begin
+      insert into stix_objects_counts (collection_id, objects, versions) values (new.collection_id, 1, new.versions)
+        on conflict (collection_id) do update
+        set objects = objects + 1, versions = versions + excluded.versions;
+    end;
+
+  create trigger stix_objects_versions_au_counts after update of versions on stix_objects_versions
+    begin
+      update stix_objects_counts set versions = versions + new.versions - old.versions
+        where collection_id = new.collection_id;
+    end;
+
+  create trigger stix_objects_versions_ad_counts after delete on stix_objects_versions
+    begin
+      update stix_objects_counts set objects = objects - 1, versions = versions - old.versions
+        where collection_id = old.collection_id;
+    end;
+
+  /* a collection's expiry changes when its objects expire */
+  create trigger taxii_collection_au_expiry after update of expire_by, max_age on taxii_collection
+    begin
+      update stix_objects_versions
+        set expires_at = (select e.expires_at
+                          from stix_objects_expiry e
+                          where e.collection_id = stix_objects_versions.collection_id and e.id = stix_objects_versions.id)
+        where collection_id = new.id;
+    end;
+
+drop view stix_objects_expired;
+drop view stix_objects_data;
+
+  /* the objects whose latest version has expired; every version of them is hidden from reads until it's purged */
+  create view stix_objects_expired as
+    select collection_id, id
+    from stix_objects_versions
+    where expires_at < strftime('%Y-%m-%d %H:%M:%f', 'now');
+
+  create view stix_objects_data as
+    select
+      so.rowid,
+      so.id,
+      so.type,
+      so.created,
+      so.modified,
+      so.object,
+      so.collection_id,
+      case when so.modified = sv.first_modified and so.modified = sv.last_modified then 'only'
+           when so.modified = sv.last_modified then 'last'
+           when so.modified = sv.first_modified then 'first'
+      end version,
+      so.created_at,
+      so.updated_at,
+      sv.expires_at
+    from
+      stix_objects so
+      left join stix_objects_versions sv
+        on so.collection_id = sv.collection_id
+        and so.id = sv.id;
+
+  /* pages in the default order, by date added then id then modified, are read in the order of an index */
+  create index if not exists stix_objects_collection_sort
+    on stix_objects (collection_id, created_at, id, strftime('%Y-%m-%d %H:%M:%f', modified));
+
+  /* expired objects are found by collection without reading the ones that don't expire */
+  create index if not exists stix_objects_versions_expires_at on stix_objects_versions (collection_id, expires_at)
+    where expires_at is not null;
+`,
+	},
+	{
+		version:     13,
+		description: "drop the objects kept by version 3",
+		sql: `
+/* version 3 kept the objects it copied as stix_objects_v2; every database has had its objects in stix_objects since
+   then, so the copy is only taking space */
+drop table if exists stix_objects_v2;
+`,
+	},
+}
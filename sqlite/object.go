@@ -3,20 +3,23 @@ package sqlite
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
-	"errors"
+	"fmt"
+	"time"
 
 	// import sqlite dependency
 	_ "github.com/mattn/go-sqlite3"
 
 	cabby "github.com/pladdy/cabby2"
-	"github.com/pladdy/stones"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
 	createObjectSQL = `insert into stix_objects (id, type, created, modified, object, collection_id)
 				             values (?, ?, ?, ?, ?, ?)`
+	// collections that overwrite versions replace the stored version; the date it was added doesn't change
+	overwriteObjectSQL = createObjectSQL + `
+	                       on conflict (collection_id, id, modified) do update
+	                       set type = excluded.type, created = excluded.created, object = excluded.object`
 	batchBufferSize = 50
 )
 
@@ -26,49 +29,294 @@ type ObjectService struct {
 	DataStore *DataStore
 }
 
-// CreateBundle will read from the data store and return the resource
-func (s ObjectService) CreateBundle(ctx context.Context, b stones.Bundle, collectionID string, st cabby.Status, ss cabby.StatusService) {
+// CompactObjects will delete the object versions collections don't retain
+func (s ObjectService) CompactObjects(ctx context.Context) (int64, error) {
+	resource, action := "Objects", "compact"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.compactObjects(ctx, time.Now().In(time.UTC))
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ObjectService) compactObjects(ctx context.Context, now time.Time) (int64, error) {
+	collections, err := s.retainingCollections(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var compacted int64
+	for _, c := range collections {
+		versions, err := s.objectVersions(ctx, c.ID.String())
+		if err != nil {
+			return compacted, err
+		}
+
+		for id, modified := range versions {
+			for _, expired := range c.ExpiredVersions(modified, now) {
+				deleted, err := s.deleteObjectVersion(ctx, c.ID.String(), id, expired)
+				if err != nil {
+					return compacted, err
+				}
+				compacted += deleted
+			}
+		}
+	}
+	return compacted, nil
+}
+
+func (s ObjectService) deleteObjectVersion(ctx context.Context, collectionID, objectID, modified string) (int64, error) {
+	sql := `delete from stix_objects where collection_id = ? and id = ? and modified = ?`
+	args := []interface{}{collectionID, objectID, modified}
+
+	result, err := s.DataStore.exec(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// objectVersions returns the modified times of every object version in a collection by object id
+func (s ObjectService) objectVersions(ctx context.Context, collectionID string) (map[string][]string, error) {
+	sql := `select id, modified from stix_objects where collection_id = ?`
+	args := []interface{}{collectionID}
+
+	versions := map[string][]string{}
+
+	rows, err := s.DataStore.query(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return versions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, modified string
+		if err := rows.Scan(&id, &modified); err != nil {
+			return versions, err
+		}
+		versions[id] = append(versions[id], modified)
+	}
+
+	err = rows.Err()
+	return versions, err
+}
+
+// retainingCollections returns the collections that don't keep every object version
+func (s ObjectService) retainingCollections(ctx context.Context) ([]cabby.Collection, error) {
+	sql := `select id, coalesce(retain_versions, 0), coalesce(retain_window, 0) from taxii_collection
+					where coalesce(retain_versions, 0) > 0 or coalesce(retain_window, 0) > 0`
+
+	var collections []cabby.Collection
+
+	rows, err := s.DataStore.query(ctx, sql)
+	if err != nil {
+		logSQLError(sql, nil, err)
+		return collections, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c cabby.Collection
+		var retainWindow int64
+
+		if err := rows.Scan(&c.ID, &c.RetainVersions, &retainWindow); err != nil {
+			return collections, err
+		}
+		c.RetainWindow = time.Duration(retainWindow) * time.Second
+		collections = append(collections, c)
+	}
+
+	err = rows.Err()
+	return collections, err
+}
+
+// CreateBundle will write objects to the data store as they're received and update the status when done
+func (s ObjectService) CreateBundle(ctx context.Context, objects <-chan []byte, collectionID string, st cabby.Status, ss cabby.StatusService) {
 	resource, action := "Bundle", "create"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	s.createBundle(ctx, b, collectionID, st, ss)
+	s.createBundle(ctx, objects, collectionID, st, ss)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 }
 
-func (s ObjectService) createBundle(ctx context.Context, b stones.Bundle, collectionID string, st cabby.Status, ss cabby.StatusService) {
-	errs := make(chan error, len(b.Objects))
+func (s ObjectService) createBundle(ctx context.Context, objects <-chan []byte, collectionID string, st cabby.Status, ss cabby.StatusService) {
+	errs := make(chan error, batchBufferSize)
 	toWrite := make(chan interface{}, batchBufferSize)
 
-	go s.DataStore.batchWrite(createObjectSQL, toWrite, errs)
+	policy := s.versionPolicy(ctx, collectionID)
+	go s.DataStore.batchWrite(ctx, writeObjectSQL(policy), toWrite, errs)
+
+	writeFailures := make(chan []cabby.StatusFailure)
+	go collectFailures(errs, writeFailures)
+
+	// versions are resolved in the batch's transaction so they see versions written earlier in the bundle; ignored
+	// versions are only read once the batch is done
+	var failures []cabby.StatusFailure
+	var ignored []string
+	total, canceled := int64(0), int64(0)
+
+	// the channel is always drained so the sender doesn't block, even after a cancel
+	for object := range objects {
+		total++
+
+		if canceled > 0 {
+			canceled++
+			continue
+		}
+
+		if total > 1 && (total-1)%batchBufferSize == 0 {
+			toWrite <- statusProgress(st, total-1)
+
+			if cabby.StatusCanceled(ctx, st, ss) {
+				canceled++
+				log.WithFields(log.Fields{"status_id": st.ID}).Warn("Status canceled, stopping bundle")
+				continue
+			}
+		}
 
-	for _, object := range b.Objects {
-		o, err := bytesToObject(object)
+		o, err := cabby.NewObject(object)
 		if err != nil {
-			errs <- err
+			failures = append(failures, cabby.StatusFailure{ID: string(o.ID), Version: o.Modified, Message: err.Error()})
 			continue
 		}
 
 		log.WithFields(log.Fields{"id": o.ID}).Info("Sending to data store")
-		toWrite <- []interface{}{o.ID, o.Type, o.Created, o.Modified, o.Object, collectionID}
+		toWrite <- objectVersion(policy, o, collectionID, &ignored)
 	}
 	close(toWrite)
 
-	updateStatus(ctx, st, errs, ss)
+	st.TotalCount = total
+	st.Failures = append(failures, <-writeFailures...)
+	st.Successes = ignored
+	cabby.UpdateStatusCounts(ctx, st, canceled, ss)
+}
+
+// objectVersion is a batch write of an object version; the version policy is applied in the batch's transaction and
+// the ids of ignored versions are added to ignored
+func objectVersion(policy string, o cabby.Object, collectionID string, ignored *[]string) batchOperation {
+	args := []interface{}{o.ID, o.Type, o.Created, o.Modified, o.Object, collectionID}
+
+	return batchOperation{args: args, fn: func(ctx context.Context, tx *sql.Tx, stmt *sql.Stmt) error {
+		action, err := resolveVersion(ctx, tx, policy, o, collectionID)
+		if err != nil {
+			return err
+		}
+		if action == cabby.IgnoreVersion {
+			*ignored = append(*ignored, string(o.ID))
+			return nil
+		}
+
+		_, err = stmt.ExecContext(ctx, args...)
+		if err != nil {
+			log.WithFields(log.Fields{"err": err, "args": args}).Error("Failed to execute")
+		}
+		return err
+	}}
+}
+
+// statusProgress is a batch write of how many objects of a bundle have been received; it's written with the batch so
+// it doesn't wait on the writer connection the batch holds.  A failed update is logged, it isn't a failed object
+func statusProgress(st cabby.Status, received int64) batchOperation {
+	return batchOperation{fn: func(ctx context.Context, tx *sql.Tx, stmt *sql.Stmt) error {
+		sql := `update taxii_status set total_count = ?, pending_count = ? where id = ? and status = 'pending'`
+		args := []interface{}{received, received, st.ID}
+
+		_, err := tx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			logSQLError(sql, args, err)
+		}
+		return nil
+	}}
 }
 
 // CreateObject will read from the data store and return the resource
 func (s ObjectService) CreateObject(ctx context.Context, object cabby.Object) error {
 	resource, action := "Object", "create"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	err := s.createObject(object)
+	err := s.createObject(ctx, object)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return err
 }
 
-func (s ObjectService) createObject(o cabby.Object) error {
-	sql := createObjectSQL
-	args := []interface{}{o.ID, o.Type, o.Created, o.Modified, o.Object, o.CollectionID}
+// the version is resolved and written in one transaction so a version written between the two isn't missed
+func (s ObjectService) createObject(ctx context.Context, o cabby.Object) error {
+	policy := s.versionPolicy(ctx, o.CollectionID.String())
+
+	return s.DataStore.transaction(ctx, func(tx *sql.Tx) error {
+		action, err := resolveVersion(ctx, tx, policy, o, o.CollectionID.String())
+		if err != nil || action == cabby.IgnoreVersion {
+			return err
+		}
+
+		sql := writeObjectSQL(policy)
+		args := []interface{}{o.ID, o.Type, o.Created, o.Modified, o.Object, o.CollectionID}
+
+		_, err = tx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			logSQLError(sql, args, err)
+		}
+		return err
+	})
+}
+
+// DeleteObject will delete the versions of an object in the filter and record a tombstone for each
+func (s ObjectService) DeleteObject(ctx context.Context, collectionID, objectID string, f cabby.Filter) (int64, error) {
+	resource, action := "Object", "delete"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.deleteObjectVersions(ctx, collectionID, objectID, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// the versions are read before any are deleted; deleting a version changes which versions are first and last.  The
+// deletes and their tombstones are written in one transaction so a failure leaves every version in place
+func (s ObjectService) deleteObjectVersions(ctx context.Context, collectionID, objectID string, f cabby.Filter) (int64, error) {
+	versions, err := s.object(ctx, collectionID, objectID, f)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	err = s.DataStore.transaction(ctx, func(tx *sql.Tx) error {
+		for _, v := range versions {
+			count, err := deleteVersion(ctx, tx, collectionID, objectID, v.Modified)
+			if err != nil {
+				return err
+			}
+			if count <= 0 {
+				continue
+			}
+
+			if err := createTombstone(ctx, tx, collectionID, objectID, v.Modified); err != nil {
+				return err
+			}
+			deleted += count
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func deleteVersion(ctx context.Context, tx *sql.Tx, collectionID, objectID, modified string) (int64, error) {
+	sql := `delete from stix_objects where collection_id = ? and id = ? and modified = ?`
+	args := []interface{}{collectionID, objectID, modified}
 
-	err := s.DataStore.write(createObjectSQL, args...)
+	result, err := tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func createTombstone(ctx context.Context, tx *sql.Tx, collectionID, objectID, modified string) error {
+	sql := `insert into stix_objects_tombstone (collection_id, id, modified) values (?, ?, ?)`
+	args := []interface{}{collectionID, objectID, modified}
+
+	_, err := tx.ExecContext(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -79,26 +327,21 @@ func (s ObjectService) createObject(o cabby.Object) error {
 func (s ObjectService) Object(ctx context.Context, collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
 	resource, action := "Object", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.object(collectionID, objectID, f)
+	result, err := s.object(ctx, collectionID, objectID, f)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s ObjectService) object(collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
-	sql := `select id, type, created, modified, object, collection_id
-	        from stix_objects_data
-					where
-					  collection_id = ?
-						and id = ?
-						and $filter`
-
-	args := []interface{}{collectionID, objectID}
-	sql, args = applyFiltering(sql, f, args)
+func (s ObjectService) object(ctx context.Context, collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
+	sql, args := collectionData(`id, type, created, modified, object, collection_id, coalesce(created_at, ''),
+		coalesce(updated_at, '')`, collectionID, f).
+		where("id = ?", objectID).
+		build()
 
 	objects := []cabby.Object{}
 	var err error
 
-	rows, err := s.DB.Query(sql, args...)
+	rows, err := s.DataStore.query(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 		return objects, err
@@ -107,9 +350,13 @@ func (s ObjectService) object(collectionID, objectID string, f cabby.Filter) ([]
 
 	for rows.Next() {
 		var o cabby.Object
-		if err := rows.Scan(&o.ID, &o.Type, &o.Created, &o.Modified, &o.Object, &o.CollectionID); err != nil {
+		var createdAt, updatedAt string
+
+		if err := rows.Scan(&o.ID, &o.Type, &o.Created, &o.Modified, &o.Object, &o.CollectionID, &createdAt, &updatedAt); err != nil {
 			return objects, err
 		}
+		o.DateAdded = sqliteTime(createdAt)
+		o.UpdatedAt = laterUpdate(o.UpdatedAt, updatedAt)
 		objects = append(objects, o)
 	}
 
@@ -117,78 +364,348 @@ func (s ObjectService) object(collectionID, objectID string, f cabby.Filter) ([]
 	return objects, err
 }
 
+// objectSortColumns are the columns objects are sorted on; modified times are sorted as times, so versions written
+// with a different precision are still in order
+var objectSortColumns = map[string]string{
+	cabby.SortDateAdded: "created_at",
+	cabby.SortID:        "id",
+	cabby.SortModified:  "strftime('%Y-%m-%d %H:%M:%f', modified)",
+}
+
+// IterateObjects will read from the data store and return an iterator over the resource; the range total is
+// set once Next is called
+func (s ObjectService) IterateObjects(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+	resource, action := "Objects", "iterate"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.iterateObjects(ctx, collectionID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ObjectService) iterateObjects(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+	page := collectionData("rowid, id, type, created, modified, object, collection_id, created_at, updated_at",
+		collectionID, f)
+
+	q := selectFrom(`id, type, created, modified, object, collection_id, coalesce(created_at, ''),
+		coalesce(updated_at, ''),
+		(select updated_at from updated) last_updated_at,
+		(select coalesce(min(created_at), '') from page) first_added,
+		(select coalesce(max(created_at), '') from page) last_added, `+pageColumns, "page").
+		with("updated", collectionUpdated(collectionID))
+	sql, args := withPage(q, page, collectionID, cr, f, objectSortColumns).build()
+
+	rows, err := s.DataStore.query(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return nil, err
+	}
+	return &objectRows{rows: rows, cr: cr}, nil
+}
+
 // Objects will read from the data store and return the resource
 func (s ObjectService) Objects(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
 	resource, action := "Objects", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.objects(collectionID, cr, f)
+	result, err := s.objects(ctx, collectionID, cr, f)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s ObjectService) objects(collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
-	sql := `with data as (
-						select rowid, id, type, created, modified, object, collection_id, 1 count
-						from stix_objects_data
-						where
-							collection_id = ?
-							and $filter
-					)
-					select id, type, created, modified, object, collection_id, (select sum(count) from data) total
-					from data
-					$paginate`
+func (s ObjectService) objects(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error) {
+	objects := []cabby.Object{}
+
+	or, err := s.iterateObjects(ctx, collectionID, cr, f)
+	if err != nil {
+		return objects, err
+	}
+	defer or.Close()
+
+	for or.Next() {
+		objects = append(objects, or.Object())
+	}
+
+	err = or.Err()
+	return objects, err
+}
+
+// PurgeObjects will delete every version of the objects that have expired in their collections
+func (s ObjectService) PurgeObjects(ctx context.Context) (int64, error) {
+	resource, action := "Objects", "purge"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.purgeObjects(ctx)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// expired objects are read before any are deleted; deleting an object's latest version would change which version
+// the view sees as latest
+func (s ObjectService) purgeObjects(ctx context.Context) (int64, error) {
+	sql := `select collection_id, id from stix_objects_expired`
+
+	type expiredObject struct{ collectionID, id string }
+	var expired []expiredObject
+
+	rows, err := s.DataStore.query(ctx, sql)
+	if err != nil {
+		logSQLError(sql, nil, err)
+		return 0, err
+	}
+
+	for rows.Next() {
+		var e expiredObject
+		if err := rows.Scan(&e.collectionID, &e.id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		expired = append(expired, e)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, e := range expired {
+		deleted, err := s.deleteObject(ctx, e.collectionID, e.id)
+		if err != nil {
+			return purged, err
+		}
+		purged += deleted
+	}
+	return purged, nil
+}
+
+func (s ObjectService) deleteObject(ctx context.Context, collectionID, objectID string) (int64, error) {
+	sql := `delete from stix_objects where collection_id = ? and id = ?`
+	args := []interface{}{collectionID, objectID}
 
+	result, err := s.DataStore.exec(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Tombstones will read the object versions deleted from a collection; the filter's added after is compared to when
+// they were deleted
+func (s ObjectService) Tombstones(ctx context.Context, collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+	resource, action := "Tombstones", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.tombstones(ctx, collectionID, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s ObjectService) tombstones(ctx context.Context, collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+	sql := `select id, modified, deleted_at from stix_objects_tombstone where collection_id = ?`
 	args := []interface{}{collectionID}
 
-	sql, args = applyFiltering(sql, f, args)
-	sql, args = applyPaging(sql, cr, args)
+	if len(f.AddedAfter) > 0 {
+		sql += ` and deleted_at > strftime('%Y-%m-%d %H:%M:%f', ?)`
+		args = append(args, f.AddedAfter)
+	}
+	sql += ` order by deleted_at, rowid`
 
-	objects := []cabby.Object{}
-	var err error
+	tombstones := []cabby.Tombstone{}
 
-	rows, err := s.DB.Query(sql, args...)
+	rows, err := s.DataStore.query(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
-		return objects, err
+		return tombstones, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var o cabby.Object
-		if err := rows.Scan(&o.ID, &o.Type, &o.Created, &o.Modified, &o.Object, &o.CollectionID, &cr.Total); err != nil {
-			return objects, err
+		var t cabby.Tombstone
+		var deletedAt string
+
+		if err := rows.Scan(&t.ID, &t.Version, &deletedAt); err != nil {
+			return tombstones, err
 		}
-		objects = append(objects, o)
+		t.DateDeleted = sqliteTime(deletedAt).Format(cabby.TimestampFormat)
+		tombstones = append(tombstones, t)
 	}
 
 	err = rows.Err()
-	return objects, err
+	return tombstones, err
+}
+
+// Versions will read the modified times of an object's versions in a collection, earliest first; the range total is
+// set
+func (s ObjectService) Versions(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+	resource, action := "Versions", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.versions(ctx, collectionID, objectID, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+// modified times are sorted as times, so versions written with a different precision are still in order
+func (s ObjectService) versions(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+	data := collectionData("modified, 1 count", collectionID, f).
+		where("id = ?", objectID)
+
+	sql, args := selectFrom("modified, (select sum(count) from data) total", "data").
+		with("data", data).
+		orderBy("strftime('%Y-%m-%d %H:%M:%f', modified), modified").
+		paginate(cr).
+		build()
+
+	versions := []string{}
+
+	rows, err := s.DataStore.query(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return versions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var modified string
+		if err := rows.Scan(&modified, &cr.Total); err != nil {
+			return versions, err
+		}
+		versions = append(versions, modified)
+	}
+
+	err = rows.Err()
+	return versions, err
+}
+
+// objectRows implements a cabby.ObjectIterator over sql rows
+type objectRows struct {
+	rows       *queryRows
+	cr         *cabby.Range
+	object     cabby.Object
+	updatedAt  time.Time
+	firstAdded time.Time
+	lastAdded  time.Time
+	err        error
+}
+
+func (o *objectRows) Next() bool {
+	if o.err != nil || !o.rows.Next() {
+		return false
+	}
+
+	o.object = cabby.Object{}
+	var createdAt, updatedAt, lastUpdatedAt, firstAdded, lastAdded, nextAdded string
+	var total, remaining, nextRow int64
+
+	o.err = o.rows.Scan(
+		&o.object.ID, &o.object.Type, &o.object.Created, &o.object.Modified, &o.object.Object, &o.object.CollectionID,
+		&createdAt, &updatedAt, &lastUpdatedAt, &firstAdded, &lastAdded, &total, &remaining, &nextAdded, &nextRow)
+
+	setPage(o.cr, total, remaining, nextAdded, nextRow)
+	o.object.DateAdded = sqliteTime(createdAt)
+	o.object.UpdatedAt = laterUpdate(o.object.UpdatedAt, updatedAt)
+	o.updatedAt = laterUpdate(o.updatedAt, lastUpdatedAt)
+	o.firstAdded, o.lastAdded = sqliteTime(firstAdded), sqliteTime(lastAdded)
+	return o.err == nil
+}
+
+func (o *objectRows) UpdatedAt() time.Time {
+	return o.updatedAt
+}
+
+func (o *objectRows) DateAdded() (time.Time, time.Time) {
+	return o.firstAdded, o.lastAdded
+}
+
+func (o *objectRows) Object() cabby.Object {
+	return o.object
+}
+
+func (o *objectRows) Err() error {
+	if o.err != nil {
+		return o.err
+	}
+	return o.rows.Err()
+}
+
+func (o *objectRows) Close() error {
+	return o.rows.Close()
 }
 
 /* helpers */
 
-func bytesToObject(b []byte) (cabby.Object, error) {
-	var o cabby.Object
-	err := json.Unmarshal(b, &o)
+func collectFailures(errs chan error, failures chan []cabby.StatusFailure) {
+	var collected []cabby.StatusFailure
+
+	for err := range errs {
+		failure := cabby.StatusFailure{Message: err.Error()}
+
+		// an object write's args start with the object's id, type, created and modified
+		if we, ok := err.(writeError); ok {
+			if len(we.args) > 0 {
+				failure.ID = fmt.Sprintf("%v", we.args[0])
+			}
+			if len(we.args) > 3 {
+				failure.Version = fmt.Sprintf("%v", we.args[3])
+			}
+		}
+		collected = append(collected, failure)
+	}
+
+	failures <- collected
+}
+
+// resolveVersion applies a collection's version policy to an object using the versions already in the collection;
+// it reads in the writer's transaction so versions written earlier in it are seen
+func resolveVersion(ctx context.Context, tx *sql.Tx, policy string, o cabby.Object, collectionID string) (cabby.VersionAction, error) {
+	sql := `select modified, object from stix_objects where collection_id = ? and id = ?`
+	args := []interface{}{collectionID, o.ID}
+
+	rows, err := tx.QueryContext(ctx, sql, args...)
 	if err != nil {
-		return o, err
+		logSQLError(sql, args, err)
+		return cabby.WriteVersion, err
 	}
+	defer rows.Close()
 
-	if o.ID == "" {
-		err = errors.New("Invalid ID")
+	var stored []cabby.Object
+	for rows.Next() {
+		v := cabby.Object{ID: o.ID}
+		if err := rows.Scan(&v.Modified, &v.Object); err != nil {
+			return cabby.WriteVersion, err
+		}
+		stored = append(stored, v)
 	}
 
-	o.Object = b
-	return o, err
+	if err := rows.Err(); err != nil {
+		return cabby.WriteVersion, err
+	}
+	return cabby.ResolveVersion(policy, o, stored)
 }
 
-func updateStatus(ctx context.Context, st cabby.Status, errs chan error, ss cabby.StatusService) {
-	failures := int64(0)
-	for _ = range errs {
-		failures++
+// versionPolicy returns a collection's version policy; if it can't be read the default policy is used
+func (s ObjectService) versionPolicy(ctx context.Context, collectionID string) string {
+	sql := `select coalesce(version_policy, '') from taxii_collection where id = ?`
+	args := []interface{}{collectionID}
+
+	var policy string
+
+	rows, err := s.DataStore.query(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return policy
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := rows.Scan(&policy); err != nil {
+			logSQLError(sql, args, err)
+		}
 	}
+	return policy
+}
 
-	st.FailureCount = failures
-	st.SuccessCount = st.TotalCount - failures
-	ss.UpdateStatus(ctx, st)
+// writeObjectSQL returns the statement that writes objects under a version policy
+func writeObjectSQL(policy string) string {
+	if policy == cabby.VersionPolicyOverwrite {
+		return overwriteObjectSQL
+	}
+	return createObjectSQL
 }
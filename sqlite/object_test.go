@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
@@ -15,33 +16,6 @@ import (
 	"github.com/pladdy/stones"
 )
 
-func TestBytesToObjectValidJSON(t *testing.T) {
-	_, err := bytesToObject([]byte(`{
-		"type": "malware",
-		"id": "malware--31b940d4-6f7f-459a-80ea-9c1f17b5891b",
-    "created": "2016-04-06T20:07:09.000Z",
-		"modified": "2016-04-06T20:07:09.000Z",
-		"name": "Poison Ivy"}`))
-
-	if err != nil {
-		t.Error("Expected no error")
-	}
-}
-
-func TestBytesToObjectInvalidObject(t *testing.T) {
-	_, err := bytesToObject([]byte(`{"foo": "bar"}`))
-	if err == nil {
-		t.Error("Expected an error")
-	}
-}
-
-func TestBytesToObjectInvalidJSON(t *testing.T) {
-	o, err := bytesToObject([]byte(`{"foo": "bar"`))
-	if err == nil {
-		t.Error("Expected error for bundle:", o)
-	}
-}
-
 func TestObjectServiceCreateObject(t *testing.T) {
 	setupSQLite()
 	ds := testDataStore()
@@ -148,6 +122,63 @@ func TestObjectServiceObjectQueryErr(t *testing.T) {
 	}
 }
 
+func TestObjectServiceDeleteObject(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.ObjectService()
+
+	expected := tester.Object
+	collectionID := expected.CollectionID.String()
+
+	deleted, err := s.DeleteObject(context.Background(), collectionID, string(expected.ID), cabby.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Error("Got:", deleted, "Expected:", 1)
+	}
+
+	var tombstones int
+	err = ds.DB.QueryRow("select count(*) from stix_objects_tombstone where collection_id = ? and id = ?",
+		collectionID, string(expected.ID)).Scan(&tombstones)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tombstones != 1 {
+		t.Error("Got:", tombstones, "Expected:", 1)
+	}
+}
+
+func TestObjectServiceDeleteObjectRollback(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.ObjectService()
+
+	expected := tester.Object
+	collectionID := expected.CollectionID.String()
+
+	// without a tombstone table the delete fails after the version is deleted
+	_, err := ds.Writer.Exec("drop table stix_objects_tombstone")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.DeleteObject(context.Background(), collectionID, string(expected.ID), cabby.Filter{})
+	if err == nil {
+		t.Error("Got:", err, "Expected an error")
+	}
+
+	var versions int
+	err = ds.DB.QueryRow("select count(*) from stix_objects where collection_id = ? and id = ?",
+		collectionID, string(expected.ID)).Scan(&versions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions != 1 {
+		t.Error("Got:", versions, "Expected:", 1)
+	}
+}
+
 func TestObjectsServiceObjectsFilter(t *testing.T) {
 	setupSQLite()
 	ds := testDataStore()
@@ -173,6 +204,13 @@ func TestObjectsServiceObjectsFilter(t *testing.T) {
 		time.Sleep(100 * time.Millisecond)
 	}
 
+	// each version has its own date added
+	first, _ := s.Object(context.Background(), tester.CollectionID, objectID, cabby.Filter{Versions: "first"})
+	if len(first) != 1 {
+		t.Fatal("Got:", len(first), "Expected:", 1)
+	}
+	addedAfter := first[0].DateAdded.Format(time.RFC3339Nano)
+
 	tests := []struct {
 		filter          cabby.Filter
 		expectedObjects int
@@ -183,7 +221,7 @@ func TestObjectsServiceObjectsFilter(t *testing.T) {
 		{cabby.Filter{IDs: ids[0].String()}, 1},
 		{cabby.Filter{IDs: strings.Join([]string{ids[0].String(), ids[4].String(), ids[8].String()}, ",")}, 3},
 		{cabby.Filter{Versions: versions[2]}, 1},
-		{cabby.Filter{AddedAfter: versions[0]}, 5},
+		{cabby.Filter{AddedAfter: addedAfter}, 4},
 	}
 
 	for _, test := range tests {
@@ -236,6 +274,67 @@ func TestObjectsServiceObjectsRange(t *testing.T) {
 	}
 }
 
+// totals read from the counts kept at write time follow the objects in a collection as they're added, deleted and
+// expire; they're the same as the totals of filters that count the objects they match
+func TestObjectsServiceObjectsTotal(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.ObjectService()
+
+	other, _ := cabby.NewID()
+	createObject(ds, "malware--"+other.String())
+	createObjectVersion(ds, tester.ObjectID, "2018-01-01T00:00:00.000Z")
+
+	compareTotals := func(all, objects int64) {
+		t.Helper()
+		tests := []struct {
+			filter   cabby.Filter
+			expected int64
+		}{
+			{cabby.Filter{}, all},
+			{cabby.Filter{Versions: "all"}, all},
+			{cabby.Filter{Versions: "first"}, objects},
+			{cabby.Filter{Versions: "last"}, objects},
+			{cabby.Filter{Types: "malware"}, all},
+			{cabby.Filter{Types: "malware", Versions: "last"}, objects},
+		}
+
+		for _, test := range tests {
+			cr := cabby.Range{First: 0, Last: 0}
+			if _, err := s.Objects(context.Background(), tester.CollectionID, &cr, test.filter); err != nil {
+				t.Fatal("Got:", err, "Expected no error")
+			}
+			if cr.Total != test.expected {
+				t.Error("Got:", cr.Total, "Expected:", test.expected, "Filter:", test.filter)
+			}
+
+			results, _ := s.Objects(context.Background(), tester.CollectionID, &cabby.Range{First: -1, Last: -1}, test.filter)
+			if int64(len(results)) != test.expected {
+				t.Error("Got:", len(results), "Expected:", test.expected, "Filter:", test.filter)
+			}
+		}
+	}
+
+	compareTotals(3, 2)
+
+	// every object expires, then none do
+	expiry := "update taxii_collection set expire_by = ?, max_age = ? where id = ?"
+	if _, err := ds.Writer.Exec(expiry, cabby.ExpireByAdded, -60, tester.CollectionID); err != nil {
+		t.Fatal(err)
+	}
+	compareTotals(0, 0)
+
+	if _, err := ds.Writer.Exec(expiry, nil, nil, tester.CollectionID); err != nil {
+		t.Fatal(err)
+	}
+	compareTotals(3, 2)
+
+	if _, err := s.DeleteObject(context.Background(), tester.CollectionID, tester.ObjectID, cabby.Filter{Versions: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	compareTotals(2, 2)
+}
+
 func TestObjectsServiceObjectsQueryErr(t *testing.T) {
 	setupSQLite()
 	ds := testDataStore()
@@ -271,6 +370,38 @@ func TestObjectServiceObjectsInvalidID(t *testing.T) {
 	}
 }
 
+func TestObjectServiceIterateObjects(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.ObjectService()
+
+	expected := tester.Object
+	cr := cabby.Range{First: -1, Last: -1}
+
+	objects, err := s.IterateObjects(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer objects.Close()
+
+	count := 0
+	for objects.Next() {
+		count++
+
+		passed := tester.CompareObject(objects.Object(), expected)
+		if !passed {
+			t.Error("Comparison failed")
+		}
+	}
+
+	if objects.Err() != nil {
+		t.Error("Got:", objects.Err(), "Expected no error")
+	}
+	if count != 1 || cr.Total != 1 {
+		t.Error("Got:", count, cr.Total, "Expected:", 1)
+	}
+}
+
 func TestObjectServiceCreateBundle(t *testing.T) {
 	setupSQLite()
 	ds := testDataStore()
@@ -287,11 +418,11 @@ func TestObjectServiceCreateBundle(t *testing.T) {
 	}
 
 	st := tester.Status
-	osv.CreateBundle(context.Background(), bundle, tester.CollectionID, st, ssv)
+	osv.CreateBundle(context.Background(), objectsToChannel(bundle.Objects), tester.CollectionID, st, ssv)
 
 	// check objects were saved
 	for _, object := range bundle.Objects {
-		expected, _ := bytesToObject(object)
+		expected, _ := cabby.NewObject(object)
 		expected.CollectionID = tester.Collection.ID
 
 		results, _ := osv.Object(context.Background(), tester.CollectionID, string(expected.ID), cabby.Filter{})
@@ -325,7 +456,14 @@ func TestObjectServiceCreateBundleWithInvalidObject(t *testing.T) {
 	}
 
 	st := tester.Status
-	osv.CreateBundle(context.Background(), bundle, tester.CollectionID, st, ssv)
+	st.ID, _ = cabby.NewID()
+	st.Status = "pending"
+	err = ssv.CreateStatus(context.Background(), st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	osv.CreateBundle(context.Background(), objectsToChannel(bundle.Objects), tester.CollectionID, st, ssv)
 
 	// check objects were saved; use an invalid range to get all
 	result, _ := osv.Objects(context.Background(), tester.CollectionID, &cabby.Range{First: -1, Last: -1}, cabby.Filter{})
@@ -333,6 +471,91 @@ func TestObjectServiceCreateBundleWithInvalidObject(t *testing.T) {
 	if len(result) != expected {
 		t.Error("Got:", len(result), "Expected:", expected)
 	}
+
+	// the invalid object is reported in the status
+	status, _ := ssv.Status(context.Background(), st.ID.String())
+	if status.FailureCount != 1 || len(status.Failures) != 1 {
+		t.Error("Got:", status.FailureCount, status.Failures, "Expected:", 1)
+	}
+	if len(status.Failures) == 1 && status.Failures[0].Message != "Invalid ID" {
+		t.Error("Got:", status.Failures[0].Message, "Expected:", "Invalid ID")
+	}
+}
+
+func TestObjectServiceCreateBundleCanceled(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	osv := ds.ObjectService()
+	ssv := ds.StatusService()
+
+	_, err := ds.DB.Exec("delete from stix_objects")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, _ := stones.NewBundle()
+	for i := 0; i < batchBufferSize*2; i++ {
+		id, _ := stones.NewStixID("malware")
+		object := fmt.Sprintf(
+			`{"type": "malware", "id": "%s", "created": "2016-04-06T20:07:09.000Z", "modified": "2016-04-06T20:07:09.000Z"}`,
+			id.String())
+		bundle.Objects = append(bundle.Objects, []byte(object))
+	}
+
+	st, _ := cabby.NewStatus(len(bundle.Objects))
+	err = ssv.CreateStatus(context.Background(), st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ssv.CancelStatus(context.Background(), st.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	osv.CreateBundle(context.Background(), objectsToChannel(bundle.Objects), tester.CollectionID, st, ssv)
+
+	// the first batch is sent before the cancel is checked
+	result, _ := osv.Objects(context.Background(), tester.CollectionID, &cabby.Range{First: -1, Last: -1}, cabby.Filter{})
+	if len(result) != batchBufferSize {
+		t.Error("Got:", len(result), "Expected:", batchBufferSize)
+	}
+
+	status, _ := ssv.Status(context.Background(), st.ID.String())
+	if status.Status != "canceled" {
+		t.Error("Got:", status.Status, "Expected:", "canceled")
+	}
+	if status.FailureCount != int64(batchBufferSize) {
+		t.Error("Got:", status.FailureCount, "Expected:", batchBufferSize)
+	}
+}
+
+func TestCollectFailures(t *testing.T) {
+	errs := make(chan error, 10)
+	errs <- writeError{args: []interface{}{"indicator--1"}, err: errors.New("an error")}
+	errs <- writeError{
+		args: []interface{}{"indicator--2", "indicator", "2018-01-01T00:00:00.000Z", "2018-01-02T00:00:00.000Z", "{}", tester.CollectionID},
+		err:  errors.New("a write error")}
+	errs <- errors.New("another error")
+	close(errs)
+
+	failures := make(chan []cabby.StatusFailure, 1)
+	collectFailures(errs, failures)
+
+	expected := []cabby.StatusFailure{
+		{ID: "indicator--1", Message: "an error"},
+		{ID: "indicator--2", Version: "2018-01-02T00:00:00.000Z", Message: "a write error"},
+		{Message: "another error"}}
+	result := <-failures
+
+	if len(result) != len(expected) {
+		t.Fatal("Got:", len(result), "Expected:", len(expected))
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Error("Got:", result[i], "Expected:", expected[i])
+		}
+	}
 }
 
 func TestUpdateStatus(t *testing.T) {
@@ -360,12 +583,10 @@ func TestUpdateStatus(t *testing.T) {
 	}
 
 	// assume one object failed to write
-	errs := make(chan error, 10)
-	errs <- errors.New("an error")
-	close(errs)
+	expected.Failures = []cabby.StatusFailure{{ID: "indicator--1", Message: "an error"}}
 
 	// updating implies complete
-	updateStatus(context.Background(), expected, errs, ss)
+	cabby.UpdateStatusCounts(context.Background(), expected, 0, ss)
 
 	expected.FailureCount = 1
 	expected.PendingCount = 0
@@ -379,3 +600,94 @@ func TestUpdateStatus(t *testing.T) {
 		t.Error("Comparison failed")
 	}
 }
+
+func objectsToChannel(objects []json.RawMessage) <-chan []byte {
+	c := make(chan []byte, len(objects))
+	for _, o := range objects {
+		c <- o
+	}
+	close(c)
+	return c
+}
+
+/* benchmarks */
+
+// the reads query the expiry and counts the latest schema keeps at write time, so they're benchmarked on it; the
+// README has the numbers of earlier schemas
+var benchmarkVersions = []int{len(migrations)}
+
+func BenchmarkObjectServiceObjects(b *testing.B) {
+	filters := []struct {
+		name   string
+		filter cabby.Filter
+	}{
+		{"all", cabby.Filter{}},
+		{"first", cabby.Filter{Versions: "first"}},
+		{"last", cabby.Filter{Versions: "last"}},
+		{"type", cabby.Filter{Types: "malware"}},
+	}
+
+	for _, version := range benchmarkVersions {
+		ds := benchmarkDataStore(b, version)
+
+		for _, f := range filters {
+			b.Run(fmt.Sprintf("schema %v %v", version, f.name), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					cr, _ := cabby.NewRange("items 0-99")
+					if _, err := ds.ObjectService().Objects(context.Background(), tester.CollectionID, &cr, f.filter); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+		ds.Close()
+	}
+	removeDB(benchmarkDBPath)
+}
+
+// a cursored page in the middle of the collection reads its own rows, and counts the rows before it for its offset
+func BenchmarkObjectServiceObjectsCursor(b *testing.B) {
+	for _, version := range benchmarkVersions {
+		ds := benchmarkDataStore(b, version)
+
+		var added string
+		var row int64
+		err := ds.DB.QueryRow(`select created_at, rowid from stix_objects
+		                       order by created_at, rowid limit 1 offset (select count(*) / 2 from stix_objects)`).
+			Scan(&added, &row)
+		if err != nil {
+			b.Fatal(err)
+		}
+		after := cabby.Cursor{DateAdded: sqliteTime(added), Row: row}
+
+		b.Run(fmt.Sprintf("schema %v", version), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				cr := cabby.Range{Cursored: true, Limit: 100, After: after}
+				objects, err := ds.ObjectService().Objects(context.Background(), tester.CollectionID, &cr, cabby.Filter{})
+				if err != nil || len(objects) != 100 {
+					b.Fatal("Got:", len(objects), err, "Expected: 100")
+				}
+			}
+		})
+		ds.Close()
+	}
+	removeDB(benchmarkDBPath)
+}
+
+func BenchmarkObjectServiceObject(b *testing.B) {
+	for _, version := range benchmarkVersions {
+		ds := benchmarkDataStore(b, version)
+		id := "malware--00004242-0000-4000-8000-000000000000"
+
+		b.Run(fmt.Sprintf("schema %v", version), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				objects, err := ds.ObjectService().Object(context.Background(), tester.CollectionID, id, cabby.Filter{Versions: "last"})
+				if err != nil || len(objects) != 1 {
+					b.Fatal("Got:", len(objects), err, "Expected: 1")
+				}
+			}
+		})
+		ds.Close()
+	}
+	removeDB(benchmarkDBPath)
+}
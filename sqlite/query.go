@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"strings"
+
+	cabby "github.com/pladdy/cabby2"
+)
+
+// query builds a select statement.  Where predicates are and'ed together and every clause keeps its own args, so the
+// args of a built query are in the order of their placeholders however the query was composed.
+type query struct {
+	ctes       []cte
+	columns    string
+	from       string
+	conditions []predicate
+	grouping   string
+	ordering   string
+	page       *cabby.Range
+	max        int64
+}
+
+// cte is a named query in a with clause
+type cte struct {
+	name  string
+	query *query
+}
+
+// predicate is a boolean sql expression and the args for its placeholders
+type predicate struct {
+	sql  string
+	args []interface{}
+}
+
+// selectFrom returns a query of the columns from a table, view or named query
+func selectFrom(columns, from string) *query {
+	return &query{columns: columns, from: from}
+}
+
+// with names a query the query can select from; named queries are built in the order they're added
+func (q *query) with(name string, sub *query) *query {
+	q.ctes = append(q.ctes, cte{name: name, query: sub})
+	return q
+}
+
+// where adds a predicate
+func (q *query) where(sql string, args ...interface{}) *query {
+	q.conditions = append(q.conditions, predicate{sql: sql, args: args})
+	return q
+}
+
+// whereAll adds predicates
+func (q *query) whereAll(predicates ...predicate) *query {
+	q.conditions = append(q.conditions, predicates...)
+	return q
+}
+
+// filter adds a predicate for each part of an object filter that's set
+func (q *query) filter(f cabby.Filter) *query {
+	filter := Filter{f}
+	return q.whereAll(filter.predicates()...)
+}
+
+func (q *query) groupBy(columns string) *query {
+	q.grouping = columns
+	return q
+}
+
+func (q *query) orderBy(columns string) *query {
+	q.ordering = columns
+	return q
+}
+
+// limit limits the query to a number of rows; zero doesn't limit it
+func (q *query) limit(rows int64) *query {
+	q.max = rows
+	return q
+}
+
+// paginate limits the query to a range; an invalid range doesn't limit it
+func (q *query) paginate(cr *cabby.Range) *query {
+	q.page = cr
+	return q
+}
+
+// build returns the sql for the query and its args
+func (q *query) build() (string, []interface{}) {
+	var clauses []string
+	args := []interface{}{}
+
+	if len(q.ctes) > 0 {
+		var ctes []string
+		for _, c := range q.ctes {
+			sql, cteArgs := c.query.build()
+			ctes = append(ctes, c.name+" as (\n"+sql+"\n)")
+			args = append(args, cteArgs...)
+		}
+		clauses = append(clauses, "with "+strings.Join(ctes, ",\n"))
+	}
+
+	clauses = append(clauses, "select "+q.columns, "from "+q.from)
+
+	if len(q.conditions) > 0 {
+		sql, whereArgs := joinPredicates(q.conditions)
+		clauses = append(clauses, "where "+sql)
+		args = append(args, whereArgs...)
+	}
+
+	if q.grouping != "" {
+		clauses = append(clauses, "group by "+q.grouping)
+	}
+
+	if q.ordering != "" {
+		clauses = append(clauses, "order by "+q.ordering)
+	}
+
+	if q.max > 0 {
+		clauses = append(clauses, "limit ?")
+		args = append(args, q.max)
+	}
+
+	if q.page != nil {
+		r := Range{q.page}
+		if sql, pageArgs := r.QueryString(); sql != "" {
+			clauses = append(clauses, sql)
+			args = append(args, pageArgs...)
+		}
+	}
+
+	return strings.Join(clauses, "\n"), args
+}
+
+// joinPredicates ands predicates together
+func joinPredicates(predicates []predicate) (string, []interface{}) {
+	var sqls []string
+	args := []interface{}{}
+
+	for _, p := range predicates {
+		sqls = append(sqls, p.sql)
+		args = append(args, p.args...)
+	}
+	return strings.Join(sqls, "\n  and "), args
+}
@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/tester"
+)
+
+func TestQueryBuild(t *testing.T) {
+	tests := []struct {
+		query        *query
+		expectedSQL  string
+		expectedArgs []interface{}
+	}{
+		{selectFrom("id", "t"), "select id from t", []interface{}{}},
+		{selectFrom("id", "t").where("a = ?", 1).where("b = 1").where("c in (?, ?)", 2, 3),
+			"select id from t where a = ? and b = 1 and c in (?, ?)",
+			[]interface{}{1, 2, 3}},
+		{selectFrom("id, count(*)", "t").where("a = ?", 1).groupBy("id").orderBy("id desc"),
+			"select id, count(*) from t where a = ? group by id order by id desc",
+			[]interface{}{1}},
+		{selectFrom("id", "t").paginate(&cabby.Range{First: 0, Last: 9}),
+			"select id from t limit ? offset ?",
+			[]interface{}{int64(10), int64(0)}},
+		{selectFrom("id", "t").paginate(&cabby.Range{First: 5, Last: 1}), "select id from t", []interface{}{}},
+		{selectFrom("id", "page").
+			with("data", selectFrom("id", "t").where("a = ?", 1)).
+			with("page", selectFrom("*", "data").paginate(&cabby.Range{First: 0, Last: 0})).
+			where("id != ?", 2).
+			orderBy("id").
+			paginate(&cabby.Range{First: 0, Last: 4}),
+			"with data as ( select id from t where a = ? ), page as ( select * from data limit ? offset ? ) " +
+				"select id from page where id != ? order by id limit ? offset ?",
+			[]interface{}{1, int64(1), int64(0), 2, int64(5), int64(0)}},
+	}
+
+	re := regexp.MustCompile(`\s+`)
+
+	for _, test := range tests {
+		sql, args := test.query.build()
+		sql = re.ReplaceAllString(sql, " ")
+
+		if sql != test.expectedSQL {
+			t.Error("Got:", sql, "Expected:", test.expectedSQL)
+		}
+		if !reflect.DeepEqual(args, test.expectedArgs) {
+			t.Error("Got:", args, "Expected:", test.expectedArgs)
+		}
+	}
+}
+
+// every combination of filters is and'ed together in a fixed order with its args in the same order; the tester
+// object is in every filter, so every combination returns it
+func TestQueryFilterSQL(t *testing.T) {
+	addedAfter := "(strftime('%s', strftime('%Y-%m-%d %H:%M:%f', created_at)) " +
+		"+ strftime('%f', strftime('%Y-%m-%d %H:%M:%f', created_at))) * 1000 > " +
+		"(strftime('%s', strftime('%Y-%m-%d %H:%M:%f', ?)) + strftime('%f', strftime('%Y-%m-%d %H:%M:%f', ?))) * 1000"
+	modified := "(strftime('%s', strftime('%Y-%m-%d %H:%M:%f', modified)) " +
+		"+ strftime('%f', strftime('%Y-%m-%d %H:%M:%f', modified))) * 1000 = " +
+		"(strftime('%s', strftime('%Y-%m-%d %H:%M:%f', ?)) + strftime('%f', strftime('%Y-%m-%d %H:%M:%f', ?))) * 1000"
+
+	tests := []struct {
+		filter cabby.Filter
+		sql    string
+		args   []interface{}
+	}{
+		{cabby.Filter{},
+			"select id from stix_objects_data",
+			[]interface{}{}},
+		{cabby.Filter{IDs: "malware--1,indicator--2"},
+			"select id from stix_objects_data where (id = ? or id = ?)",
+			[]interface{}{"malware--1", "indicator--2"}},
+		{cabby.Filter{Types: "malware", Versions: "last"},
+			"select id from stix_objects_data where (version in ('last', 'only')) and (type = ?)",
+			[]interface{}{"malware"}},
+		{cabby.Filter{Versions: "first,2018-01-01T00:00:00.000Z"},
+			"select id from stix_objects_data where (version in ('first', 'only') or " + modified + ")",
+			[]interface{}{"2018-01-01T00:00:00Z", "2018-01-01T00:00:00Z"}},
+		{cabby.Filter{AddedAfter: "2018-01-01T00:00:00.000Z", Versions: "all"},
+			"select id from stix_objects_data where " + addedAfter + " and (1 = 1)",
+			[]interface{}{"2018-01-01T00:00:00.000Z", "2018-01-01T00:00:00.000Z"}},
+		{cabby.Filter{IDs: "malware--1", Types: "malware,indicator", Properties: map[string]string{
+			cabby.MatchCreatedByRef: "identity--1", cabby.MatchSpecVersion: "2.0"}},
+			"select id from stix_objects_data where (id = ?) and (type = ? or type = ?) " +
+				"and (json_extract(object, '$.created_by_ref') in (?)) " +
+				"and (coalesce(json_extract(object, '$.spec_version'), '2.0') in (?))",
+			[]interface{}{"malware--1", "malware", "indicator", "identity--1", "2.0"}},
+	}
+
+	for _, test := range tests {
+		sql, args := selectFrom("id", "stix_objects_data").filter(test.filter).build()
+
+		// the sql is compared without its line breaks and indentation
+		sql = strings.Join(strings.Fields(sql), " ")
+		if sql != test.sql {
+			t.Error("Got:", sql, "Expected:", test.sql, "Filter:", test.filter)
+		}
+		if !reflect.DeepEqual(args, test.args) {
+			t.Error("Got:", args, "Expected:", test.args, "Filter:", test.filter)
+		}
+	}
+}
+
+func TestQueryFilterCombinations(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	defer ds.Close()
+
+	parts := []cabby.Filter{
+		{AddedAfter: "2000-01-01T00:00:00.000Z"},
+		{Versions: "last"},
+		{IDs: tester.ObjectID + ",indicator--8e2e2d2b-17d4-4cbf-938f-98ee46b3cd3f"},
+		{Types: tester.Object.Type},
+		{Properties: map[string]string{
+			cabby.MatchCreatedByRef: "identity--f431f809-377b-45e0-aa1c-6a4751cae5ff", cabby.MatchSpecVersion: "2.0"}},
+	}
+
+	for combination := 0; combination < 1<<uint(len(parts)); combination++ {
+		var f cabby.Filter
+
+		for i, part := range parts {
+			if combination&(1<<uint(i)) == 0 {
+				continue
+			}
+			f.AddedAfter += part.AddedAfter
+			f.Versions += part.Versions
+			f.IDs += part.IDs
+			f.Types += part.Types
+			if part.Properties != nil {
+				f.Properties = part.Properties
+			}
+		}
+
+		objects, err := ds.ObjectService().Objects(context.Background(), tester.CollectionID, &cabby.Range{}, f)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Filter:", f)
+		}
+		if len(objects) != 1 {
+			t.Error("Got:", len(objects), "Expected:", 1, "Filter:", f)
+		}
+
+		manifest, err := ds.ManifestService().Manifest(context.Background(), tester.CollectionID, &cabby.Range{}, f)
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Filter:", f)
+		}
+		if len(manifest.Objects) != 1 {
+			t.Error("Got:", len(manifest.Objects), "Expected:", 1, "Filter:", f)
+		}
+	}
+}
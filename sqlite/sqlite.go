@@ -1,9 +1,11 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"regexp"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,18 +17,79 @@ import (
 )
 
 const (
-	maxWritesPerBatch = 500
+	// batchIdle is how long an open batch waits for more to write before it's committed; other writes wait for the
+	// writer connection while a batch is open
+	batchIdle = 50 * time.Millisecond
+	// defaultBusyTimeout is how long a connection waits on a locked database before it fails
+	defaultBusyTimeout = 5 * time.Second
+	// defaultReadConnections is the size of the read pool
+	defaultReadConnections = 4
+	maxWritesPerBatch      = 500
+	// sqliteTimeFormat matches the format triggers use for created_at and updated_at
+	sqliteTimeFormat = "2006-01-02 15:04:05.000"
 )
 
-// DataStore represents a SQLite database
+// DataStore represents a SQLite database; reads share a pool of connections and writes queue for a single writer
+// connection, so they wait on each other instead of failing with 'database is locked'
 type DataStore struct {
-	DB   *sql.DB
-	Path string
+	DB      *sql.DB
+	Writer  *sql.DB
+	Path    string
+	Options Options
 }
 
-// NewDataStore returns a sqliteDB
+// Options tune a data store's connections; zero values are the defaults
+type Options struct {
+	BusyTimeout     time.Duration
+	QueryTimeout    time.Duration
+	ReadConnections int
+}
+
+// NewOptions returns the options in a data store config: busy_timeout and query_timeout are durations and
+// read_connections is the size of the read pool
+func NewOptions(config map[string]string) (o Options, err error) {
+	if v := config["busy_timeout"]; v != "" {
+		if o.BusyTimeout, err = time.ParseDuration(v); err != nil || o.BusyTimeout < 0 {
+			return o, fmt.Errorf("Invalid busy timeout: %s", v)
+		}
+	}
+
+	if v := config["query_timeout"]; v != "" {
+		if o.QueryTimeout, err = time.ParseDuration(v); err != nil || o.QueryTimeout < 0 {
+			return o, fmt.Errorf("Invalid query timeout: %s", v)
+		}
+	}
+
+	if v := config["read_connections"]; v != "" {
+		if o.ReadConnections, err = strconv.Atoi(v); err != nil || o.ReadConnections < 1 {
+			return o, fmt.Errorf("Invalid read connections: %s", v)
+		}
+	}
+	return o, nil
+}
+
+func (o Options) busyTimeout() time.Duration {
+	if o.BusyTimeout == 0 {
+		return defaultBusyTimeout
+	}
+	return o.BusyTimeout
+}
+
+func (o Options) readConnections() int {
+	if o.ReadConnections == 0 {
+		return defaultReadConnections
+	}
+	return o.ReadConnections
+}
+
+// NewDataStore returns a sqliteDB with the default options
 func NewDataStore(path string) (*DataStore, error) {
-	s := DataStore{Path: path}
+	return NewDataStoreWithOptions(path, Options{})
+}
+
+// NewDataStoreWithOptions returns a sqliteDB with its connections tuned by the options
+func NewDataStoreWithOptions(path string, o Options) (*DataStore, error) {
+	s := DataStore{Path: path, Options: o}
 	if s.Path == "" {
 		return &s, errors.New("No database location specfied in config")
 	}
@@ -40,9 +103,10 @@ func (s *DataStore) APIRootService() cabby.APIRootService {
 	return APIRootService{DB: s.DB, DataStore: s}
 }
 
-// Close connection to datastore
+// Close connections to datastore
 func (s *DataStore) Close() {
 	s.DB.Close()
+	s.Writer.Close()
 }
 
 // CollectionService returns a service for collection resources
@@ -57,7 +121,7 @@ func (s *DataStore) DiscoveryService() cabby.DiscoveryService {
 
 // ManifestService returns a service for object resources
 func (s *DataStore) ManifestService() cabby.ManifestService {
-	return ManifestService{DB: s.DB}
+	return ManifestService{DB: s.DB, DataStore: s}
 }
 
 // ObjectService returns a service for object resources
@@ -65,13 +129,23 @@ func (s *DataStore) ObjectService() cabby.ObjectService {
 	return ObjectService{DB: s.DB, DataStore: s}
 }
 
-// Open connection to datastore
+// Open connections to datastore; the database is in WAL mode so readers don't wait on the writer
 func (s *DataStore) Open() (err error) {
-	// set foreign key pragma to true in connection: https://github.com/mattn/go-sqlite3#connection-string
-	s.DB, err = sql.Open("sqlite3", s.Path+"?_fk=true")
+	s.DB, err = sql.Open("sqlite3", s.connection())
 	if err != nil {
 		log.Error(err)
+		return
 	}
+	s.DB.SetMaxOpenConns(s.Options.readConnections())
+	s.DB.SetMaxIdleConns(s.Options.readConnections())
+
+	// the writer's transactions take the write lock when they begin, rather than failing to upgrade a read lock later
+	s.Writer, err = sql.Open("sqlite3", s.connection()+"&_txlock=immediate")
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	s.Writer.SetMaxOpenConns(1)
 	return
 }
 
@@ -85,71 +159,235 @@ func (s *DataStore) UserService() cabby.UserService {
 	return UserService{DB: s.DB, DataStore: s}
 }
 
-/* writer methods */
+/* helpers */
 
-func (s *DataStore) batchWrite(query string, toWrite chan interface{}, errs chan error) {
-	defer close(errs)
+// connection returns the connection string for the database; pragmas are set per connection:
+// https://github.com/mattn/go-sqlite3#connection-string
+func (s *DataStore) connection() string {
+	timeout := strconv.FormatInt(int64(s.Options.busyTimeout()/time.Millisecond), 10)
+	return s.Path + "?_fk=true&_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=" + timeout
+}
 
-	tx, stmt, err := s.writeOperation(query)
+// laterUpdate returns the later of a time and an updated_at timestamp; timestamps that can't be parsed are ignored
+func laterUpdate(t time.Time, updatedAt string) time.Time {
+	u, err := time.ParseInLocation(sqliteTimeFormat, updatedAt, time.UTC)
 	if err != nil {
-		errs <- err
-		return
+		return t
 	}
-	defer stmt.Close()
+	return cabby.LaterTime(t, u)
+}
+
+// sqliteTime parses a created_at or updated_at timestamp; a timestamp that can't be parsed is the zero time
+func sqliteTime(timestamp string) time.Time {
+	t, err := time.ParseInLocation(sqliteTimeFormat, timestamp, time.UTC)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+/* query methods */
+
+// queryContext returns a context for a query that's done when the query timeout passes, if there is one
+func (s *DataStore) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.Options.QueryTimeout > 0 {
+		return context.WithTimeout(ctx, s.Options.QueryTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// query reads from the read pool; the rows hold the query's context until they're closed
+func (s *DataStore) query(ctx context.Context, query string, args ...interface{}) (*queryRows, error) {
+	ctx, cancel := s.queryContext(ctx)
 
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &queryRows{Rows: rows, cancel: cancel}, nil
+}
+
+// queryRows are the rows of a query; closing them cancels the query's context
+type queryRows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+func (q *queryRows) Close() error {
+	defer q.cancel()
+	return q.Rows.Close()
+}
+
+/* writer methods */
+
+// exec runs a statement on the writer connection
+func (s *DataStore) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return s.Writer.ExecContext(ctx, query, args...)
+}
+
+// batchWrite writes items in transactions of up to maxWritesPerBatch; a transaction is committed early when nothing's
+// sent for batchIdle, so other writes aren't kept waiting on the writer connection while the sender is busy.  An item
+// is either the arguments to the query or a batchOperation.  The transactions last as long as the context; each write
+// in them has the query timeout
+func (s *DataStore) batchWrite(ctx context.Context, query string, toWrite chan interface{}, errs chan error) {
+	defer close(errs)
+
+	var tx *sql.Tx
+	var stmt *sql.Stmt
 	i := 0
-	for item := range toWrite {
-		args := item.([]interface{})
 
-		err := s.execute(stmt, args...)
-		if err != nil {
-			errs <- err
-			continue
+	commit := func() {
+		if tx != nil {
+			tx.Commit() // on commit a statement is closed, a new transaction is created for the next batch
 		}
+		tx, stmt, i = nil, nil, 0
+	}
+	defer commit()
 
-		i++
-		if i >= maxWritesPerBatch {
-			tx.Commit() // on commit a statement is closed, create a new transaction for next batch
-			tx, stmt, err = s.writeOperation(query)
-			if err != nil {
-				errs <- err
+	for {
+		// only an open transaction waits to be committed
+		var idle <-chan time.Time
+		if tx != nil {
+			idle = time.After(batchIdle)
+		}
+
+		select {
+		case item, ok := <-toWrite:
+			if !ok {
 				return
 			}
-			i = 0
+			args := batchArgs(item)
+
+			if tx == nil {
+				var err error
+				if tx, stmt, err = s.writeOperation(ctx, query); err != nil {
+					errs <- writeError{args: args, err: err}
+					failWrites(toWrite, errs, err)
+					return
+				}
+			}
+
+			var err error
+			if op, ok := item.(batchOperation); ok {
+				err = s.run(ctx, tx, stmt, op)
+			} else {
+				err = s.execute(ctx, stmt, args...)
+			}
+			if err != nil {
+				errs <- writeError{args: args, err: err}
+				continue
+			}
+
+			i++
+			if i >= maxWritesPerBatch {
+				commit()
+			}
+		case <-idle:
+			commit()
 		}
 	}
-	tx.Commit()
 }
 
-func (s *DataStore) write(query string, args ...interface{}) error {
-	tx, stmt, err := s.writeOperation(query)
+// failWrites drains what's left to write so senders don't block; each item fails with the given error
+func failWrites(toWrite chan interface{}, errs chan error, err error) {
+	for item := range toWrite {
+		errs <- writeError{args: batchArgs(item), err: err}
+	}
+}
+
+// batchOperation is a batch write that needs the batch's transaction, like one that reads what the batch has written;
+// its args identify it in a writeError
+type batchOperation struct {
+	args []interface{}
+	fn   func(ctx context.Context, tx *sql.Tx, stmt *sql.Stmt) error
+}
+
+func batchArgs(item interface{}) []interface{} {
+	if op, ok := item.(batchOperation); ok {
+		return op.args
+	}
+	return item.([]interface{})
+}
+
+func (s *DataStore) run(ctx context.Context, tx *sql.Tx, stmt *sql.Stmt, op batchOperation) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	return op.fn(ctx, tx, stmt)
+}
+
+// writeError is a failed batch write and the arguments it was given
+type writeError struct {
+	args []interface{}
+	err  error
+}
+
+func (e writeError) Error() string {
+	return e.err.Error()
+}
+
+// transaction runs a function in one writer transaction; it's rolled back if the function returns an error
+func (s *DataStore) transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, err := s.Writer.BeginTx(ctx, nil)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Failed to begin transaction")
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// write runs a statement in a transaction of its own; the transaction has the query timeout
+func (s *DataStore) write(ctx context.Context, query string, args ...interface{}) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	tx, stmt, err := s.writeOperation(ctx, query)
 	if err != nil {
 		log.WithFields(log.Fields{"sql": query, "error": err}).Error("error in sql")
 		return err
 	}
-	defer tx.Commit()
 	defer stmt.Close()
 
-	return s.execute(stmt, args...)
+	if err = s.execute(ctx, stmt, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
-func (s *DataStore) execute(stmt *sql.Stmt, args ...interface{}) error {
-	_, err := stmt.Exec(args...)
+func (s *DataStore) execute(ctx context.Context, stmt *sql.Stmt, args ...interface{}) error {
+	ctx, cancel := s.queryContext(ctx)
+	defer cancel()
+
+	_, err := stmt.ExecContext(ctx, args...)
 	if err != nil {
 		log.WithFields(log.Fields{"err": err, "args": args}).Error("Failed to execute")
 	}
 	return err
 }
 
-func (s *DataStore) writeOperation(query string) (tx *sql.Tx, stmt *sql.Stmt, err error) {
-	tx, err = s.DB.Begin()
+func (s *DataStore) writeOperation(ctx context.Context, query string) (tx *sql.Tx, stmt *sql.Stmt, err error) {
+	tx, err = s.Writer.BeginTx(ctx, nil)
 	if err != nil {
 		log.WithFields(log.Fields{"err": err}).Error("Failed to begin transaction")
 		return
 	}
 
-	stmt, err = tx.Prepare(query)
+	stmt, err = tx.PrepareContext(ctx, query)
 	if err != nil {
+		tx.Rollback()
 		log.WithFields(log.Fields{"err": err, "sql": query}).Error("Failed to prepare query")
 	}
 	return
@@ -162,52 +400,43 @@ type Filter struct {
 
 // QueryString will convert a filter into a query string for a service query
 func (f *Filter) QueryString() (q string, args []interface{}) {
-	var filters []string
+	return joinPredicates(f.predicates())
+}
 
+// predicates returns a predicate for each part of the filter that's set; they're always in the same order: added
+// after, versions, ids, types, then the match properties
+func (f *Filter) predicates() (predicates []predicate) {
 	if len(f.AddedAfter) > 0 {
-		filter, newArgs := filterAddedAfter(f.AddedAfter)
-		if filter != "" {
-			filters = append(filters, filter)
-			args = append(args, newArgs...)
-		}
+		predicates = append(predicates, newPredicate(filterAddedAfter(f.AddedAfter)))
 	}
 
 	if len(f.Versions) > 0 {
-		filter, newArgs := filterVersion(f.Versions)
-		if filter != "" {
-			filters = append(filters, filter)
-			args = append(args, newArgs...)
-		}
+		predicates = append(predicates, newPredicate(filterVersion(f.Versions)))
 	}
 
-	for field, raws := range filterMapFieldToRawStrings(f) {
-		if len(raws) > 0 {
-			filter, newArgs := filterCreator(raws, field)
-			filters = append(filters, filter)
-			args = append(args, newArgs...)
-		}
+	if len(f.IDs) > 0 {
+		predicates = append(predicates, newPredicate(filterCreator(f.IDs, "id")))
 	}
 
-	return strings.Join(filters, " and "), args
-}
-
-/* filtering helpers */
-
-func applyFiltering(sql string, f cabby.Filter, args []interface{}) (string, []interface{}) {
-	filter := Filter{f}
-	qs, filterArgs := filter.QueryString()
+	if len(f.Types) > 0 {
+		predicates = append(predicates, newPredicate(filterCreator(f.Types, "type")))
+	}
 
-	if len(qs) > 0 {
-		sql = strings.Replace(sql, "$filter", qs, -1)
-	} else {
-		sql = strings.Replace(sql, "$filter", "", -1)
+	for _, property := range cabby.MatchProperties {
+		if raw := f.Properties[property]; len(raw) > 0 {
+			predicates = append(predicates, newPredicate(filterProperty(raw, property)))
+		}
 	}
 
-	sql = filterRemoveTrailingAnd(sql)
-	args = append(args, filterArgs...)
-	return sql, args
+	return
+}
+
+func newPredicate(sql string, args []interface{}) predicate {
+	return predicate{sql: sql, args: args}
 }
 
+/* filtering helpers */
+
 func filterAddedAfter(addedAfter string) (string, []interface{}) {
 	filter := `(strftime('%s', strftime('%Y-%m-%d %H:%M:%f', created_at))
 					     + strftime('%f', strftime('%Y-%m-%d %H:%M:%f', created_at))) * 1000 >
@@ -229,21 +458,31 @@ func filterCreator(raw, field string) (filter string, args []interface{}) {
 	return filter + strings.Join(ors, " or ") + ")", args
 }
 
-func filterMapFieldToRawStrings(f *Filter) map[string]string {
-	return map[string]string{
-		"id":   f.IDs,
-		"type": f.Types}
-}
-
-func filterRemoveTrailingAnd(sql string) string {
-	lines := strings.Split(sql, "\n")
-	re := regexp.MustCompile(`and\s*$`)
-
-	for i := 0; i < len(lines); i++ {
-		lines[i] = re.ReplaceAllString(lines[i], "")
+// filterProperty matches a property of the stored object to any of the raw values; labels and external ids match any
+// element of their arrays
+func filterProperty(raw, property string) (filter string, args []interface{}) {
+	var params []string
+	for _, v := range strings.Split(raw, ",") {
+		params = append(params, "?")
+		args = append(args, v)
+	}
+	in := "in (" + strings.Join(params, ", ") + ")"
+
+	switch property {
+	case cabby.MatchConfidence:
+		filter = "cast(json_extract(object, '$.confidence') as text) " + in
+	case cabby.MatchExternalID:
+		filter = `exists (select 1 from json_each(object, '$.external_references')
+		                  where json_extract(value, '$.external_id') ` + in + ")"
+	case cabby.MatchLabels:
+		filter = "exists (select 1 from json_each(object, '$.labels') where value " + in + ")"
+	case cabby.MatchSpecVersion:
+		filter = "coalesce(json_extract(object, '$.spec_version'), '" + cabby.DefaultSpecVersion + "') " + in
+	default:
+		filter = "json_extract(object, '$." + property + "') " + in
 	}
 
-	return strings.Join(lines, "\n")
+	return "(" + filter + ")", args
 }
 
 func filterVersion(rawVersion string) (filter string, args []interface{}) {
@@ -261,20 +500,22 @@ func filterVersion(rawVersion string) (filter string, args []interface{}) {
 			ors = append(ors, versionFilterSQL)
 			args = append(args, []interface{}{t.Format(time.RFC3339Nano), t.Format(time.RFC3339Nano)}...)
 		} else {
-			switch v {
-			case "all":
-				ors = append(ors, "1 = 1")
-			case "first":
-				ors = append(ors, "version in ('first', 'only')")
-			default:
-				ors = append(ors, "version in ('last', 'only')")
-			}
+			ors = append(ors, filterVersionPlaces(v))
 		}
 	}
 
 	return "(" + strings.Join(ors, " or ") + ")", args
 }
 
+// filterVersionPlaces matches the versions a version keyword is for by their place among an object's versions
+func filterVersionPlaces(keyword string) string {
+	places := cabby.VersionPlaces(keyword)
+	if places == nil {
+		return "1 = 1"
+	}
+	return "version in ('" + strings.Join(places, "', '") + "')"
+}
+
 func logSQLError(sql string, args []interface{}, err error) {
 	log.WithFields(log.Fields{"error": err, "sql": sql, "args": args}).Error("Error in sql")
 }
@@ -284,27 +525,142 @@ type Range struct {
 	*cabby.Range
 }
 
-// QueryString returns sql for paginating a range of data
-func (r *Range) QueryString() (q string, args []interface{}) {
-	if r.Valid() {
-		q = "limit ? offset ?"
-		args = []interface{}{(r.Last - r.First) + 1, r.First}
+// unexpired is the predicate of data whose object hasn't expired in its collection
+const unexpired = "(expires_at is null or expires_at >= strftime('%Y-%m-%d %H:%M:%f', 'now'))"
+
+// collectionData returns a query of columns of the unexpired data in a collection that a filter matches
+func collectionData(columns, collectionID string, f cabby.Filter) *query {
+	return selectFrom(columns, "stix_objects_data").
+		where("collection_id = ?", collectionID).
+		where(unexpired).
+		filter(f)
+}
+
+// collectionCount returns a query counting the unexpired data in a collection that a filter and predicates match.
+// Only filters on first and last versions need each object's versions; other data is counted from the objects'
+// indexes, less the data of the objects that have expired, which is looked up by the objects' key so only the expired
+// objects are read.
+func collectionCount(collectionID string, f cabby.Filter, predicates ...predicate) *query {
+	for _, v := range strings.Split(f.Versions, ",") {
+		if _, err := time.Parse(time.RFC3339Nano, v); err != nil && v != "" && v != "all" {
+			return collectionData("count(*) n", collectionID, f).whereAll(predicates...)
+		}
 	}
-	return
+
+	matched := selectFrom("count(*) n", "stix_objects").
+		where("collection_id = ?", collectionID).
+		filter(f).
+		whereAll(predicates...)
+	expired := selectFrom("count(*) n", "stix_objects indexed by sqlite_autoindex_stix_objects_1").
+		where("collection_id = ?", collectionID).
+		where("id in (select id from stix_objects_expired where collection_id = ?)", collectionID).
+		filter(f).
+		whereAll(predicates...)
+
+	return selectFrom("n - (select n from expired) n", "matched").with("matched", matched).with("expired", expired)
+}
+
+// collectionTotal returns a query of how much unexpired data in a collection a filter matches.  Filtering on all,
+// first or last versions and nothing else reads the counts kept at write time, less those of expired objects; other
+// filters count the data they match.
+func collectionTotal(collectionID string, f cabby.Filter) *query {
+	counted := f.AddedAfter == "" && f.IDs == "" && f.Types == ""
+	for _, raw := range f.Properties {
+		counted = counted && raw == ""
+	}
+
+	count, expired := "", ""
+	switch f.Versions {
+	case "", "all":
+		count, expired = "versions", "sum(sv.versions)"
+	case "first", "last":
+		count, expired = "objects", "count(*)"
+	}
+
+	if !counted || count == "" {
+		return collectionCount(collectionID, f)
+	}
+
+	return selectFrom(`c.`+count+` - (select coalesce(`+expired+`, 0)
+		from stix_objects_versions sv
+		where sv.collection_id = c.collection_id and sv.expires_at < strftime('%Y-%m-%d %H:%M:%f', 'now')) n`,
+		"stix_objects_counts c").
+		where("c.collection_id = ?", collectionID)
+}
+
+// collectionUpdated returns a query of when a collection's data was last written
+func collectionUpdated(collectionID string) *query {
+	return selectFrom("coalesce(updated_at, '') updated_at", "stix_objects_counts").
+		where("collection_id = ?", collectionID)
+}
+
+// pageColumns position a page of data: how much data there is, how much of it is after the range's cursor, and the
+// cursor of the page's last row
+const pageColumns = `(select n from total) total, (select n from total) - (select n from before) remaining,
+	(select created_at from page order by created_at desc, rowid desc limit 1) next_added,
+	(select rowid from page order by created_at desc, rowid desc limit 1) next_row`
+
+// withPage adds the named queries a page of a collection's data is read from: page is the data in the range, total is
+// how much data the filter matches and before is how much of it is before a cursored range's cursor.  A page in the
+// order data was added is read from an index and stops at the end of the range.  A cursored page is in the order it
+// was added; other pages are in the order of the range's sort, given the columns of the data to sort on.
+func withPage(q *query, page *query, collectionID string, cr *cabby.Range, f cabby.Filter, columns map[string]string) *query {
+	before := selectFrom("0 n", "total")
+
+	if !cr.Cursored {
+		order := ordering(cr.Sort, columns, "rowid")
+		return q.with("page", page.orderBy(order).paginate(cr)).with("total", collectionTotal(collectionID, f)).
+			with("before", before).orderBy(order)
+	}
+
+	if !cr.After.IsZero() {
+		added := cr.After.DateAdded.UTC().Format(sqliteTimeFormat)
+		page.where("created_at >= ? and (created_at > ? or rowid > ?)", added, added, cr.After.Row)
+		before = collectionCount(collectionID, f,
+			newPredicate("created_at <= ? and (created_at < ? or rowid <= ?)", []interface{}{added, added, cr.After.Row}))
+	}
+
+	return q.with("page", page.orderBy("created_at, rowid").limit(cr.Limit)).
+		with("total", collectionTotal(collectionID, f)).with("before", before).orderBy("created_at, rowid")
 }
 
-/* pagination helpers */
+// ordering returns the order by columns of a sort given the columns of each field it can sort on; the row column
+// orders rows that are the same in every other column
+func ordering(s cabby.Sort, columns map[string]string, row string) string {
+	direction := ""
+	if s.Descending {
+		direction = " desc"
+	}
 
-func applyPaging(sql string, cr *cabby.Range, args []interface{}) (newSQL string, newArgs []interface{}) {
-	r := Range{cr}
-	qs, pageArgs := r.QueryString()
+	var terms []string
+	seen := map[string]bool{}
+	for _, f := range s.Fields() {
+		if column, ok := columns[f]; ok {
+			seen[column] = true
+			terms = append(terms, column+direction)
+		}
+	}
+
+	if !seen[row] {
+		terms = append(terms, row+direction)
+	}
+	return strings.Join(terms, ", ")
+}
 
-	if len(pageArgs) > 0 {
-		sql = strings.Replace(sql, "$paginate", qs, -1)
-	} else {
-		sql = strings.Replace(sql, "$paginate", "", -1)
+// setPage sets the total of a range, and where a cursored range is in the data read
+func setPage(cr *cabby.Range, total, remaining int64, nextAdded string, nextRow int64) {
+	if !cr.Cursored {
+		cr.Total = total
+		return
 	}
+	cr.SetPage(total, remaining, cabby.Cursor{DateAdded: sqliteTime(nextAdded), Row: nextRow})
+}
 
-	args = append(args, pageArgs...)
-	return sql, args
+// QueryString returns sql for paginating a range of data
+func (r *Range) QueryString() (q string, args []interface{}) {
+	if r.Valid() {
+		q = "limit ? offset ?"
+		args = []interface{}{(r.Last - r.First) + 1, r.First}
+	}
+	return
 }
@@ -1,12 +1,82 @@
 package sqlite
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"regexp"
+	"sync"
 	"testing"
+	"time"
 
 	cabby "github.com/pladdy/cabby2"
+	"github.com/pladdy/cabby2/datastoretest"
+	"github.com/pladdy/cabby2/tester"
 )
 
+func TestLaterUpdate(t *testing.T) {
+	earlier := time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	tests := []struct {
+		t         time.Time
+		updatedAt string
+		expected  time.Time
+	}{
+		{time.Time{}, later.Format(sqliteTimeFormat), later},
+		{earlier, later.Format(sqliteTimeFormat), later},
+		{later, earlier.Format(sqliteTimeFormat), later},
+		{earlier, "", earlier},
+		{earlier, "invalid", earlier},
+	}
+
+	for _, test := range tests {
+		result := laterUpdate(test.t, test.updatedAt)
+		if !result.Equal(test.expected) {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
+
+func TestServicesUpdatedAt(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	ctx := cabby.WithUser(context.Background(), tester.User)
+
+	d, _ := ds.DiscoveryService().Discovery(ctx)
+	a, _ := ds.APIRootService().APIRoot(ctx, tester.APIRootPath)
+	c, _ := ds.CollectionService().Collection(ctx, tester.APIRootPath, tester.CollectionID)
+	cs, _ := ds.CollectionService().Collections(ctx, tester.APIRootPath, &cabby.Range{First: -1, Last: -1})
+
+	objects, err := ds.ObjectService().IterateObjects(ctx, tester.CollectionID, &cabby.Range{First: -1, Last: -1}, cabby.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer objects.Close()
+	objects.Next()
+
+	entries, err := ds.ManifestService().IterateManifest(ctx, tester.CollectionID, &cabby.Range{First: -1, Last: -1}, cabby.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer entries.Close()
+	entries.Next()
+
+	for name, updatedAt := range map[string]time.Time{
+		"discovery":   d.UpdatedAt,
+		"api root":    a.UpdatedAt,
+		"collection":  c.UpdatedAt,
+		"collections": cs.UpdatedAt,
+		"object":      objects.Object().UpdatedAt,
+		"objects":     objects.UpdatedAt(),
+		"manifest":    entries.UpdatedAt(),
+	} {
+		if updatedAt.IsZero() {
+			t.Error("Expected an update time for:", name)
+		}
+	}
+}
+
 func TestNewDataStore(t *testing.T) {
 	_, err := NewDataStore("temp.db")
 
@@ -23,6 +93,189 @@ func TestNewDataStoreNoPath(t *testing.T) {
 	}
 }
 
+func TestNewOptions(t *testing.T) {
+	tests := []struct {
+		config   map[string]string
+		expected Options
+		err      bool
+	}{
+		{map[string]string{}, Options{}, false},
+		{map[string]string{"busy_timeout": "10s", "read_connections": "8"}, Options{BusyTimeout: 10 * time.Second, ReadConnections: 8}, false},
+		{map[string]string{"query_timeout": "30s"}, Options{QueryTimeout: 30 * time.Second}, false},
+		{map[string]string{"query_timeout": "-1s"}, Options{}, true},
+		{map[string]string{"busy_timeout": "forever"}, Options{}, true},
+		{map[string]string{"busy_timeout": "-1s"}, Options{}, true},
+		{map[string]string{"read_connections": "many"}, Options{}, true},
+		{map[string]string{"read_connections": "0"}, Options{}, true},
+	}
+
+	for _, test := range tests {
+		result, err := NewOptions(test.config)
+		if (err != nil) != test.err {
+			t.Error("Got:", err, "Expected an error:", test.err, "Config:", test.config)
+		}
+		if !test.err && result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
+
+func TestNewDataStoreWithOptions(t *testing.T) {
+	tearDownSQLite()
+	defer tearDownSQLite()
+
+	ds, err := NewDataStoreWithOptions(testDBPath, Options{BusyTimeout: 2 * time.Second, ReadConnections: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ds.Close()
+
+	for name, db := range map[string]*sql.DB{"reader": ds.DB, "writer": ds.Writer} {
+		var mode string
+		var timeout int
+		if err := db.QueryRow("pragma journal_mode").Scan(&mode); err != nil || mode != "wal" {
+			t.Error("Got:", mode, err, "Expected: wal", "Connection:", name)
+		}
+		if err := db.QueryRow("pragma busy_timeout").Scan(&timeout); err != nil || timeout != 2000 {
+			t.Error("Got:", timeout, err, "Expected: 2000", "Connection:", name)
+		}
+	}
+
+	if ds.DB.Stats().MaxOpenConnections != 3 {
+		t.Error("Got:", ds.DB.Stats().MaxOpenConnections, "Expected: 3")
+	}
+	if ds.Writer.Stats().MaxOpenConnections != 1 {
+		t.Error("Got:", ds.Writer.Stats().MaxOpenConnections, "Expected: 1")
+	}
+}
+
+// bundles are ingested while objects are read and written; every write succeeds, nothing fails on a locked database
+func TestDataStoreConcurrentIngestAndReads(t *testing.T) {
+	setupSQLite()
+	defer tearDownSQLite()
+
+	ds := testDataStore()
+	defer ds.Close()
+	ctx := context.Background()
+
+	bundles, objectsPerBundle := 4, 3*batchBufferSize
+	done := make(chan struct{})
+	errs := make(chan error, 100)
+
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				cr := cabby.Range{First: 0, Last: 99}
+				if _, err := ds.ObjectService().Objects(ctx, tester.CollectionID, &cr, cabby.Filter{}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+	statuses := make(chan cabby.Status, bundles)
+	for b := 0; b < bundles; b++ {
+		writers.Add(1)
+		go func(b int) {
+			defer writers.Done()
+
+			st, _ := cabby.NewStatus(objectsPerBundle)
+			if err := ds.StatusService().CreateStatus(ctx, st); err != nil {
+				errs <- err
+				return
+			}
+
+			objects := make(chan []byte, objectsPerBundle)
+			for i := 0; i < objectsPerBundle; i++ {
+				id := fmt.Sprintf("malware--%08d-%04d-4000-8000-000000000000", i, b)
+				objects <- []byte(`{"type": "malware", "id": "` + id + `", "created": "2016-04-06T20:07:09.000Z",
+				                    "modified": "2016-04-06T20:07:09.000Z", "name": "malware"}`)
+			}
+			close(objects)
+
+			ds.ObjectService().CreateBundle(ctx, objects, tester.CollectionID, st, ds.StatusService())
+			statuses <- st
+		}(b)
+	}
+
+	writers.Add(1)
+	go func() {
+		defer writers.Done()
+		for i := 0; i < 50; i++ {
+			o := tester.Object
+			o.Modified = fmt.Sprintf("2018-01-01T00:00:%02d.000Z", i)
+			if err := ds.ObjectService().CreateObject(ctx, o); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	writers.Wait()
+	close(done)
+	readers.Wait()
+	close(errs)
+	close(statuses)
+
+	for err := range errs {
+		t.Error("Got:", err, "Expected no error")
+	}
+
+	for st := range statuses {
+		result, err := ds.StatusService().Status(ctx, st.ID.String())
+		if err != nil || result.SuccessCount != int64(objectsPerBundle) || result.Status != "complete" {
+			t.Error("Got:", result, err, "Expected:", objectsPerBundle, "successes")
+		}
+	}
+}
+
+// a canceled context cancels reads and writes
+func TestDataStoreCanceledContext(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	defer ds.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ds.ObjectService().Objects(ctx, tester.CollectionID, &cabby.Range{}, cabby.Filter{}); err == nil {
+		t.Error("Expected an error reading objects")
+	}
+	if err := ds.ObjectService().CreateObject(ctx, tester.Object); err == nil {
+		t.Error("Expected an error writing an object")
+	}
+	if _, err := ds.StatusService().PurgeStatuses(ctx, time.Now()); err == nil {
+		t.Error("Expected an error purging statuses")
+	}
+}
+
+func TestDataStoreQueryTimeout(t *testing.T) {
+	setupSQLite()
+	defer tearDownSQLite()
+
+	ds, err := NewDataStoreWithOptions(testDBPath, Options{QueryTimeout: time.Nanosecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ds.Close()
+
+	_, err = ds.ObjectService().Objects(context.Background(), tester.CollectionID, &cabby.Range{}, cabby.Filter{})
+	if err != context.DeadlineExceeded {
+		t.Error("Got:", err, "Expected:", context.DeadlineExceeded)
+	}
+}
+
 func TestDataStoreClose(t *testing.T) {
 	s, err := NewDataStore("temp.db")
 
@@ -33,6 +286,22 @@ func TestDataStoreClose(t *testing.T) {
 	s.Close()
 }
 
+func TestDataStoreConformance(t *testing.T) {
+	datastoretest.Run(t, func(t *testing.T) cabby.DataStore {
+		removeDB(testDBPath)
+
+		ds, err := NewDataStore(testDBPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ds.Migrate(context.Background()); err != nil {
+			t.Fatal("Couldn't migrate schema: ", err)
+		}
+		return ds
+	})
+	removeDB(testDBPath)
+}
+
 func TestSQLiteBatchWriteSmall(t *testing.T) {
 	setupSQLite()
 	ds := testDataStore()
@@ -43,7 +312,7 @@ func TestSQLiteBatchWriteSmall(t *testing.T) {
 	sql := `insert into taxii_collection (id, api_root_path, title, description, media_types)
 					values (?, ?, ?, ?, ?)`
 
-	go ds.batchWrite(sql, toWrite, errs)
+	go ds.batchWrite(context.Background(), sql, toWrite, errs)
 	toWrite <- []interface{}{"test", "test api root", "test collection", "this is a test collection", "media type"}
 	close(toWrite)
 
@@ -72,7 +341,7 @@ func TestSQLiteBatchWriteExecuteLarge(t *testing.T) {
 	sql := `insert into taxii_collection (id, api_root_path, title, description)
 					values (?, ?, ?, ?)`
 
-	go ds.batchWrite(sql, toWrite, errs)
+	go ds.batchWrite(context.Background(), sql, toWrite, errs)
 
 	recordsToWrite := 1000
 	for i := 0; i <= recordsToWrite; i++ {
@@ -97,7 +366,7 @@ func TestSQLiteBatchWriteWriteOperationError(t *testing.T) {
 	toWrite := make(chan interface{}, 10)
 	errs := make(chan error, 10)
 
-	go ds.batchWrite("fail", toWrite, errs)
+	go ds.batchWrite(context.Background(), "fail", toWrite, errs)
 	toWrite <- []interface{}{"fail"}
 	close(toWrite)
 
@@ -121,7 +390,7 @@ func TestSQLiteBatchWriteExecuteError(t *testing.T) {
 	sql := `insert into taxii_collection (id, api_root_path, title, description)
 					values (?, ?, ?, ?)`
 
-	go ds.batchWrite(sql, toWrite, errs)
+	go ds.batchWrite(context.Background(), sql, toWrite, errs)
 
 	for i := 0; i <= maxWritesPerBatch; i++ {
 		if i == maxWritesPerBatch {
@@ -149,7 +418,7 @@ func TestSQLiteBatchWriteCommitError(t *testing.T) {
 	toWrite := make(chan interface{}, 10)
 	errs := make(chan error, 10)
 
-	go ds.batchWrite("insert into stix_objects (id, object) values (?, ?)", toWrite, errs)
+	go ds.batchWrite(context.Background(), "insert into stix_objects (id, object) values (?, ?)", toWrite, errs)
 	toWrite <- []interface{}{"fail"}
 	close(toWrite)
 
@@ -167,7 +436,7 @@ func TestDataStoreWriteError(t *testing.T) {
 	setupSQLite()
 	ds := testDataStore()
 
-	err := ds.write("this is not a valid query")
+	err := ds.write(context.Background(), "this is not a valid query")
 	if err == nil {
 		t.Error("Expected an error")
 	}
@@ -180,7 +449,7 @@ func TestDataStoreExecuteError(t *testing.T) {
 	tx, _ := ds.DB.Begin()
 	stmt, _ := tx.Prepare("select * from stix_objects")
 
-	err := ds.execute(stmt, "fail")
+	err := ds.execute(context.Background(), stmt, "fail")
 	if err == nil {
 		t.Error("Expected an error")
 	}
@@ -190,13 +459,13 @@ func TestDataStoreWriteOperationError(t *testing.T) {
 	ds := testDataStore()
 
 	ds.Open()
-	_, _, err := ds.writeOperation("this is not a valid query")
+	_, _, err := ds.writeOperation(context.Background(), "this is not a valid query")
 	if err == nil {
 		t.Error("Expected an error")
 	}
 
 	ds.Close()
-	_, _, err = ds.writeOperation("this is not a valid query")
+	_, _, err = ds.writeOperation(context.Background(), "this is not a valid query")
 	if err == nil {
 		t.Error("Expected an error")
 	}
@@ -249,6 +518,25 @@ func TestFilterQueryString(t *testing.T) {
 		{cabby.Filter{Versions: "all"},
 			"(1 = 1)",
 			[]interface{}{}},
+		{cabby.Filter{Properties: map[string]string{cabby.MatchCreatedByRef: "identity--1,identity--2"}},
+			"(json_extract(object, '$.created_by_ref') in (?, ?))",
+			[]interface{}{"identity--1", "identity--2"}},
+		{cabby.Filter{Properties: map[string]string{cabby.MatchConfidence: "80"}},
+			"(cast(json_extract(object, '$.confidence') as text) in (?))",
+			[]interface{}{"80"}},
+		{cabby.Filter{Properties: map[string]string{cabby.MatchLabels: "malicious-activity"}},
+			"(exists (select 1 from json_each(object, '$.labels') where value in (?)))",
+			[]interface{}{"malicious-activity"}},
+		{cabby.Filter{Properties: map[string]string{cabby.MatchExternalID: "CVE-2016-1234"}},
+			`(exists (select 1 from json_each(object, '$.external_references')
+			  where json_extract(value, '$.external_id') in (?)))`,
+			[]interface{}{"CVE-2016-1234"}},
+		{cabby.Filter{Properties: map[string]string{cabby.MatchSpecVersion: "2.0"}},
+			"(coalesce(json_extract(object, '$.spec_version'), '2.0') in (?))",
+			[]interface{}{"2.0"}},
+		{cabby.Filter{Properties: map[string]string{cabby.MatchTargetRef: "malware--1", cabby.MatchSourceRef: "indicator--1"}},
+			"(json_extract(object, '$.source_ref') in (?)) and (json_extract(object, '$.target_ref') in (?))",
+			[]interface{}{"indicator--1", "malware--1"}},
 	}
 
 	for _, test := range tests {
@@ -290,3 +578,24 @@ func TestRangeQueryString(t *testing.T) {
 		t.Error("Got:", len(args), "Expected:", expectedArgs)
 	}
 }
+
+func TestOrdering(t *testing.T) {
+	columns := map[string]string{cabby.SortDateAdded: "created_at", cabby.SortID: "id", cabby.SortTitle: "title"}
+
+	tests := []struct {
+		sort     cabby.Sort
+		row      string
+		expected string
+	}{
+		{cabby.Sort{}, "rowid", "created_at, id, title, rowid"},
+		{cabby.Sort{Field: cabby.SortID, Descending: true}, "rowid", "id desc, created_at desc, title desc, rowid desc"},
+		{cabby.Sort{Field: cabby.SortModified}, "rowid", "created_at, id, title, rowid"},
+		{cabby.Sort{Field: cabby.SortTitle}, "id", "title, created_at, id"},
+	}
+
+	for _, test := range tests {
+		if result := ordering(test.sort, columns, test.row); result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
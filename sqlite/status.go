@@ -3,6 +3,9 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
 
 	// import sqlite dependency
 	_ "github.com/mattn/go-sqlite3"
@@ -17,44 +20,95 @@ type StatusService struct {
 	DataStore *DataStore
 }
 
+// CancelStatus will mark a pending status as canceled
+func (s StatusService) CancelStatus(ctx context.Context, statusID string) error {
+	resource, action := "Status", "cancel"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	err := s.cancelStatus(ctx, statusID)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return err
+}
+
+func (s StatusService) cancelStatus(ctx context.Context, statusID string) error {
+	sql := `update taxii_status set status = 'canceled' where id = ? and status = 'pending'`
+	args := []interface{}{statusID}
+
+	result, err := s.DataStore.exec(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return err
+	}
+
+	canceled, err := result.RowsAffected()
+	if err == nil && canceled == 0 {
+		err = errors.New("No pending status found for this id")
+	}
+	return err
+}
+
 // CreateStatus will read from the data store and return the resource
 func (s StatusService) CreateStatus(ctx context.Context, status cabby.Status) error {
 	resource, action := "Status", "create"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	err := s.createStatus(status)
+	err := s.createStatus(ctx, status)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return err
 }
 
-func (s StatusService) createStatus(st cabby.Status) error {
-	sql := `insert into taxii_status (id, status, total_count, success_count, failure_count, pending_count)
-					values (?, ?, ?, ?, ?, ?)`
-	args := []interface{}{st.ID, st.Status, st.TotalCount, st.SuccessCount, st.FailureCount, st.PendingCount}
+func (s StatusService) createStatus(ctx context.Context, st cabby.Status) error {
+	sql := `insert into taxii_status (
+						id, status, total_count, success_count, failure_count, pending_count, email, collection_id
+					)
+					values (?, ?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{
+		st.ID, st.Status, st.TotalCount, st.SuccessCount, st.FailureCount, st.PendingCount, st.User, st.CollectionID}
 
-	err := s.DataStore.write(sql, args...)
+	err := s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
 	return err
 }
 
+// PurgeStatuses will delete statuses created before a given time that are no longer pending
+func (s StatusService) PurgeStatuses(ctx context.Context, before time.Time) (int64, error) {
+	resource, action := "Statuses", "purge"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.purgeStatuses(ctx, before)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s StatusService) purgeStatuses(ctx context.Context, before time.Time) (int64, error) {
+	sql := `delete from taxii_status where created_at < ? and status != 'pending'`
+	args := []interface{}{before.In(time.UTC).Format(sqliteTimeFormat)}
+
+	result, err := s.DataStore.exec(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // Status will read from the data store and return the resource
 func (s StatusService) Status(ctx context.Context, statusID string) (cabby.Status, error) {
 	resource, action := "Status", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.status(statusID)
+	result, err := s.status(ctx, statusID)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s StatusService) status(statusID string) (cabby.Status, error) {
-	sql := `select id, status, total_count, success_count, pending_count, failure_count
+func (s StatusService) status(ctx context.Context, statusID string) (cabby.Status, error) {
+	sql := `select id, status, total_count, success_count, pending_count, failure_count, coalesce(failures, ''),
+					  coalesce(successes, ''), coalesce(email, ''), coalesce(collection_id, ''), created_at
 					from taxii_status where id = ?`
 
 	st := cabby.Status{}
 	var err error
 
-	rows, err := s.DB.Query(sql, statusID)
+	rows, err := s.DataStore.query(ctx, sql, statusID)
 	if err != nil {
 		log.WithFields(log.Fields{"sql": sql, "error": err}).Error("error in sql")
 		return st, err
@@ -62,28 +116,92 @@ func (s StatusService) status(statusID string) (cabby.Status, error) {
 	defer rows.Close()
 
 	for rows.Next() {
+		var failures, successes string
+
 		if err := rows.Scan(
-			&st.ID, &st.Status, &st.TotalCount, &st.SuccessCount, &st.PendingCount, &st.FailureCount); err != nil {
+			&st.ID, &st.Status, &st.TotalCount, &st.SuccessCount, &st.PendingCount, &st.FailureCount, &failures,
+			&successes, &st.User, &st.CollectionID, &st.CreatedAt); err != nil {
 			return st, err
 		}
+
+		if len(failures) > 0 {
+			if err := json.Unmarshal([]byte(failures), &st.Failures); err != nil {
+				return st, err
+			}
+		}
+
+		if len(successes) > 0 {
+			if err := json.Unmarshal([]byte(successes), &st.Successes); err != nil {
+				return st, err
+			}
+		}
 	}
 
 	err = rows.Err()
 	return st, err
 }
 
+// Statuses will read from the data store and return the resource
+func (s StatusService) Statuses(ctx context.Context, cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+	resource, action := "Statuses", "read"
+	start := cabby.LogServiceStart(ctx, resource, action)
+	result, err := s.statuses(ctx, cr, f)
+	cabby.LogServiceEnd(ctx, resource, action, start)
+	return result, err
+}
+
+func (s StatusService) statuses(ctx context.Context, cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+	filter := StatusFilter{f}
+	data := selectFrom(`id, status, total_count, success_count, pending_count, failure_count,
+		coalesce(email, '') email, coalesce(collection_id, '') collection_id, created_at, rowid, 1 count`, "taxii_status").
+		whereAll(filter.predicates()...)
+
+	sql, args := selectFrom(`id, status, total_count, success_count, pending_count, failure_count,
+		email, collection_id, created_at, (select sum(count) from data) total`, "data").
+		with("data", data).
+		orderBy("created_at desc, rowid desc").
+		paginate(cr).
+		build()
+
+	statuses := []cabby.Status{}
+	var err error
+
+	rows, err := s.DataStore.query(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+		return statuses, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st cabby.Status
+		if err := rows.Scan(
+			&st.ID, &st.Status, &st.TotalCount, &st.SuccessCount, &st.PendingCount, &st.FailureCount,
+			&st.User, &st.CollectionID, &st.CreatedAt, &cr.Total); err != nil {
+			return statuses, err
+		}
+		statuses = append(statuses, st)
+	}
+
+	err = rows.Err()
+	return statuses, err
+}
+
 // UpdateStatus will read from the data store and return the resource
 func (s StatusService) UpdateStatus(ctx context.Context, status cabby.Status) error {
 	resource, action := "Status", "update"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	err := s.updateStatus(status)
+	err := s.updateStatus(ctx, status)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return err
 }
 
-func (s StatusService) updateStatus(st cabby.Status) error {
+func (s StatusService) updateStatus(ctx context.Context, st cabby.Status) error {
+	// a canceled status stays canceled
 	sql := `update taxii_status
-          set status = ?, total_count = ?, success_count = ?, failure_count = ?, pending_count = ?
+          set status = case when status = 'canceled' then status else ? end,
+              total_count = ?, success_count = ?, failure_count = ?, failures = ?, successes = ?,
+              pending_count = ?
           where id = ?`
 
 	st.PendingCount = st.TotalCount - st.SuccessCount - st.FailureCount
@@ -93,5 +211,63 @@ func (s StatusService) updateStatus(st cabby.Status) error {
 		st.Status = "complete"
 	}
 
-	return s.DataStore.write(sql, st.Status, st.TotalCount, st.SuccessCount, st.FailureCount, st.PendingCount, st.ID)
+	failures, err := json.Marshal(st.Failures)
+	if err != nil {
+		return err
+	}
+
+	successes, err := json.Marshal(st.Successes)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{
+		st.Status, st.TotalCount, st.SuccessCount, st.FailureCount, string(failures), string(successes), st.PendingCount,
+		st.ID}
+
+	err = s.DataStore.write(ctx, sql, args...)
+	if err != nil {
+		logSQLError(sql, args, err)
+	}
+	return err
+}
+
+// StatusFilter implementation for SQLite
+type StatusFilter struct {
+	cabby.StatusFilter
+}
+
+// QueryString will convert a status filter into a query string for a status query
+func (f *StatusFilter) QueryString() (q string, args []interface{}) {
+	return joinPredicates(f.predicates())
+}
+
+// predicates returns a predicate for each part of the status filter that's set
+func (f *StatusFilter) predicates() (predicates []predicate) {
+	fields := []struct {
+		column string
+		value  string
+	}{
+		{"email", f.User},
+		{"collection_id", f.CollectionID},
+		{"status", f.Status},
+	}
+
+	for _, field := range fields {
+		if len(field.value) > 0 {
+			predicates = append(predicates, predicate{sql: field.column + " = ?", args: []interface{}{field.value}})
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, f.CreatedAfter); err == nil {
+		predicates = append(predicates,
+			predicate{sql: "created_at > ?", args: []interface{}{t.In(time.UTC).Format(sqliteTimeFormat)}})
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, f.CreatedBefore); err == nil {
+		predicates = append(predicates,
+			predicate{sql: "created_at < ?", args: []interface{}{t.In(time.UTC).Format(sqliteTimeFormat)}})
+	}
+
+	return
 }
@@ -3,10 +3,74 @@ package sqlite
 import (
 	"context"
 	"testing"
+	"time"
 
+	cabby "github.com/pladdy/cabby2"
 	"github.com/pladdy/cabby2/tester"
 )
 
+func TestStatusServiceCancelStatus(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.StatusService()
+
+	test := tester.Status
+	test.Status = "pending"
+
+	err := s.CreateStatus(context.Background(), test)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.CancelStatus(context.Background(), test.ID.String())
+	if err != nil {
+		t.Error("Got:", err)
+	}
+
+	result, err := s.Status(context.Background(), test.ID.String())
+	if err != nil {
+		t.Error("Got:", err)
+	}
+
+	if result.Status != "canceled" {
+		t.Error("Got:", result.Status, "Expected:", "canceled")
+	}
+
+	// updates don't undo a cancel
+	test.FailureCount = test.TotalCount
+	err = s.UpdateStatus(context.Background(), test)
+	if err != nil {
+		t.Error("Got:", err)
+	}
+
+	result, _ = s.Status(context.Background(), test.ID.String())
+	if result.Status != "canceled" {
+		t.Error("Got:", result.Status, "Expected:", "canceled")
+	}
+
+	// it can't be canceled twice
+	err = s.CancelStatus(context.Background(), test.ID.String())
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestStatusServiceCancelStatusQueryErr(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.StatusService()
+
+	_, err := ds.DB.Exec("drop table taxii_status")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.CancelStatus(context.Background(), tester.StatusID)
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
 func TestStatusServiceCreateStatus(t *testing.T) {
 	setupSQLite()
 	ds := testDataStore()
@@ -48,6 +112,65 @@ func TestStatusServiceCreateStatusFail(t *testing.T) {
 	}
 }
 
+func TestStatusServicePurgeStatuses(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.StatusService()
+
+	statuses := []struct {
+		status    string
+		createdAt string
+	}{
+		{"complete", "2018-01-01 00:00:00.000"},
+		{"canceled", "2018-01-01 00:00:00.000"},
+		{"pending", "2018-01-01 00:00:00.000"},
+		{"complete", time.Now().In(time.UTC).Format(sqliteTimeFormat)},
+	}
+
+	for _, st := range statuses {
+		test := tester.Status
+		test.ID, _ = cabby.NewID()
+		test.Status = st.status
+
+		err := s.CreateStatus(context.Background(), test)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ds.DB.Exec("update taxii_status set created_at = ? where id = ?", st.createdAt, test.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	purged, err := s.PurgeStatuses(context.Background(), time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Error("Got:", err)
+	}
+
+	// pending statuses aren't purged
+	expected := int64(2)
+	if purged != expected {
+		t.Error("Got:", purged, "Expected:", expected)
+	}
+}
+
+func TestStatusServicePurgeStatusesQueryErr(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.StatusService()
+
+	_, err := ds.DB.Exec("drop table taxii_status")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.PurgeStatuses(context.Background(), time.Now())
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
 func TestStatusServiceStatus(t *testing.T) {
 	setupSQLite()
 	ds := testDataStore()
@@ -89,6 +212,113 @@ func TestStatusServiceStatusQueryErr(t *testing.T) {
 	}
 }
 
+func TestStatusServiceStatuses(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.StatusService()
+
+	otherCollection, _ := cabby.NewID()
+
+	statuses := []struct {
+		user         string
+		collectionID string
+		status       string
+	}{
+		{tester.UserEmail, tester.CollectionID, "pending"},
+		{tester.UserEmail, tester.CollectionID, "complete"},
+		{tester.UserEmail, otherCollection.String(), "complete"},
+		{"other@cabby.com", otherCollection.String(), "complete"},
+	}
+
+	for _, st := range statuses {
+		test := tester.Status
+		test.ID, _ = cabby.NewID()
+		test.User = st.user
+		test.CollectionID = st.collectionID
+		test.Status = st.status
+
+		err := s.CreateStatus(context.Background(), test)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hourAgo := time.Now().Add(-time.Hour).Format(time.RFC3339Nano)
+	hourFromNow := time.Now().Add(time.Hour).Format(time.RFC3339Nano)
+
+	tests := []struct {
+		filter   cabby.StatusFilter
+		expected int
+	}{
+		{cabby.StatusFilter{}, 4},
+		{cabby.StatusFilter{User: tester.UserEmail}, 3},
+		{cabby.StatusFilter{CollectionID: otherCollection.String()}, 2},
+		{cabby.StatusFilter{Status: "complete"}, 3},
+		{cabby.StatusFilter{User: tester.UserEmail, CollectionID: tester.CollectionID, Status: "pending"}, 1},
+		{cabby.StatusFilter{CreatedAfter: hourAgo}, 4},
+		{cabby.StatusFilter{CreatedAfter: hourFromNow}, 0},
+		{cabby.StatusFilter{CreatedBefore: hourAgo}, 0},
+		{cabby.StatusFilter{CreatedAfter: "invalid"}, 4},
+	}
+
+	for _, test := range tests {
+		cr := cabby.Range{First: -1, Last: -1}
+		results, err := s.Statuses(context.Background(), &cr, test.filter)
+		if err != nil {
+			t.Error("Got:", err)
+		}
+
+		if len(results) != test.expected {
+			t.Error("Got:", len(results), "Expected:", test.expected, "Filter:", test.filter)
+		}
+	}
+}
+
+func TestStatusServiceStatusesRange(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.StatusService()
+
+	for i := 0; i < 5; i++ {
+		test := tester.Status
+		test.ID, _ = cabby.NewID()
+
+		err := s.CreateStatus(context.Background(), test)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cr := cabby.Range{First: 0, Last: 1}
+	results, err := s.Statuses(context.Background(), &cr, cabby.StatusFilter{})
+	if err != nil {
+		t.Error("Got:", err)
+	}
+
+	if len(results) != 2 {
+		t.Error("Got:", len(results), "Expected:", 2)
+	}
+	if cr.Total != 5 {
+		t.Error("Got:", cr.Total, "Expected:", 5)
+	}
+}
+
+func TestStatusServiceStatusesQueryErr(t *testing.T) {
+	setupSQLite()
+	ds := testDataStore()
+	s := ds.StatusService()
+
+	_, err := ds.DB.Exec("drop table taxii_status")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Statuses(context.Background(), &cabby.Range{First: -1, Last: -1}, cabby.StatusFilter{})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
 func TestStatusServiceUpdateStatus(t *testing.T) {
 	setupSQLite()
 	ds := testDataStore()
@@ -2,9 +2,7 @@ package sqlite
 
 import (
 	"context"
-	"crypto/sha256"
 	"database/sql"
-	"fmt"
 
 	// import sqlite dependency
 	_ "github.com/mattn/go-sqlite3"
@@ -13,8 +11,6 @@ import (
 	cabby "github.com/pladdy/cabby2"
 )
 
-const minPasswordLength = 8
-
 // UserService implements a SQLite version of the servce
 type UserService struct {
 	DB        *sql.DB
@@ -26,9 +22,9 @@ func (s UserService) CreateUser(ctx context.Context, user cabby.User, password s
 	resource, action := "User", "create"
 	start := cabby.LogServiceStart(ctx, resource, action)
 
-	err := validateUserPasswordCombo(user, password)
+	err := cabby.ValidateUserPassword(user, password)
 	if err == nil {
-		err = s.createUser(user, password)
+		err = s.createUser(ctx, user, password)
 	} else {
 		log.WithFields(log.Fields{"error": err, "password": password, "user": user}).Error("Invalid user and/or password")
 	}
@@ -37,20 +33,20 @@ func (s UserService) CreateUser(ctx context.Context, user cabby.User, password s
 	return err
 }
 
-func (s UserService) createUser(u cabby.User, password string) error {
+func (s UserService) createUser(ctx context.Context, u cabby.User, password string) error {
 	sql := `insert into taxii_user (email, can_admin) values (?, ?)`
 	args := []interface{}{u.Email, u.CanAdmin}
 
-	err := s.DataStore.write(sql, args...)
+	err := s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 		return err
 	}
 
 	sql = `insert into taxii_user_pass (email, pass) values (?, ?)`
-	args = []interface{}{u.Email, hash(password)}
+	args = []interface{}{u.Email, cabby.HashPassword(password)}
 
-	err = s.DataStore.write(sql, args...)
+	err = s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -62,9 +58,9 @@ func (s UserService) CreateUserCollection(ctx context.Context, user string, ca c
 	resource, action := "UserCollection", "create"
 	start := cabby.LogServiceStart(ctx, resource, action)
 
-	err := validateUserCollection(user, ca)
+	err := cabby.ValidateUserCollection(user, ca)
 	if err == nil {
-		err = s.createUserCollection(user, ca)
+		err = s.createUserCollection(ctx, user, ca)
 	} else {
 		log.WithFields(log.Fields{"error": err, "collection_access": ca, "user": user}).Error("Invalid user and/or collection")
 	}
@@ -73,12 +69,12 @@ func (s UserService) CreateUserCollection(ctx context.Context, user string, ca c
 	return err
 }
 
-func (s UserService) createUserCollection(user string, ca cabby.CollectionAccess) error {
+func (s UserService) createUserCollection(ctx context.Context, user string, ca cabby.CollectionAccess) error {
 	sql := `insert into taxii_user_collection (email, collection_id, can_read, can_write)
 				  values (?, ?, ?, ?)`
 	args := []interface{}{user, ca.ID.String(), ca.CanRead, ca.CanWrite}
 
-	err := s.DataStore.write(sql, args...)
+	err := s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -89,16 +85,16 @@ func (s UserService) createUserCollection(user string, ca cabby.CollectionAccess
 func (s UserService) DeleteUserCollection(ctx context.Context, user, id string) error {
 	resource, action := "UserCollection", "delete"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	err := s.deleteUserCollection(user, id)
+	err := s.deleteUserCollection(ctx, user, id)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return err
 }
 
-func (s UserService) deleteUserCollection(user, id string) error {
+func (s UserService) deleteUserCollection(ctx context.Context, user, id string) error {
 	sql := `delete from taxii_user_collection where email = ? and collection_id = ?`
 	args := []interface{}{user, id}
 
-	_, err := s.DB.Exec(sql, args...)
+	_, err := s.DataStore.exec(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -109,23 +105,23 @@ func (s UserService) deleteUserCollection(user, id string) error {
 func (s UserService) DeleteUser(ctx context.Context, user string) error {
 	resource, action := "User", "delete"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	err := s.deleteUser(user)
+	err := s.deleteUser(ctx, user)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return err
 }
 
-func (s UserService) deleteUser(user string) error {
+func (s UserService) deleteUser(ctx context.Context, user string) error {
 	sql := `delete from taxii_user where email = ?`
 	args := []interface{}{user}
 
-	_, err := s.DB.Exec(sql, args...)
+	_, err := s.DataStore.exec(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 		return err
 	}
 
 	sql = `delete from taxii_user_pass where email = ?`
-	_, err = s.DB.Exec(sql, args...)
+	_, err = s.DataStore.exec(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -139,7 +135,7 @@ func (s UserService) UpdateUser(ctx context.Context, user cabby.User) error {
 
 	err := user.Validate()
 	if err == nil {
-		err = s.updateUser(user)
+		err = s.updateUser(ctx, user)
 	} else {
 		log.WithFields(log.Fields{"error": err, "user": user}).Error("Invalid user")
 	}
@@ -148,11 +144,11 @@ func (s UserService) UpdateUser(ctx context.Context, user cabby.User) error {
 	return err
 }
 
-func (s UserService) updateUser(u cabby.User) error {
+func (s UserService) updateUser(ctx context.Context, u cabby.User) error {
 	sql := `update taxii_user set can_admin = ? where email = ?`
 	args := []interface{}{u.CanAdmin, u.Email}
 
-	err := s.DataStore.write(sql, args...)
+	err := s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -164,9 +160,9 @@ func (s UserService) UpdateUserCollection(ctx context.Context, user string, ca c
 	resource, action := "UserCollection", "update"
 	start := cabby.LogServiceStart(ctx, resource, action)
 
-	err := validateUserCollection(user, ca)
+	err := cabby.ValidateUserCollection(user, ca)
 	if err == nil {
-		err = s.updateUserCollection(user, ca)
+		err = s.updateUserCollection(ctx, user, ca)
 	} else {
 		log.WithFields(log.Fields{"error": err, "collection_access": ca, "user": user}).Error("Invalid user and/or collection")
 	}
@@ -175,11 +171,11 @@ func (s UserService) UpdateUserCollection(ctx context.Context, user string, ca c
 	return err
 }
 
-func (s UserService) updateUserCollection(user string, ca cabby.CollectionAccess) error {
-	sql := `update taxii_user_collection set can_read = ?, can_write = ? where email = ?`
-	args := []interface{}{ca.CanRead, ca.CanWrite, user}
+func (s UserService) updateUserCollection(ctx context.Context, user string, ca cabby.CollectionAccess) error {
+	sql := `update taxii_user_collection set can_read = ?, can_write = ? where email = ? and collection_id = ?`
+	args := []interface{}{ca.CanRead, ca.CanWrite, user, ca.ID.String()}
 
-	err := s.DataStore.write(sql, args...)
+	err := s.DataStore.write(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 	}
@@ -190,23 +186,23 @@ func (s UserService) updateUserCollection(user string, ca cabby.CollectionAccess
 func (s UserService) User(ctx context.Context, user, password string) (cabby.User, error) {
 	resource, action := "User", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.user(user, password)
+	result, err := s.user(ctx, user, password)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s UserService) user(user, password string) (cabby.User, error) {
+func (s UserService) user(ctx context.Context, user, password string) (cabby.User, error) {
 	sql := `select tu.email, tu.can_admin
           from
             taxii_user tu
             inner join taxii_user_pass tup
               on tu.email = tup.email
           where tu.email = ? and tup.pass = ?`
-	args := []interface{}{user, hash(password)}
+	args := []interface{}{user, cabby.HashPassword(password)}
 
 	u := cabby.User{}
 
-	rows, err := s.DB.Query(sql, args...)
+	rows, err := s.DataStore.query(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 		return u, err
@@ -227,12 +223,12 @@ func (s UserService) user(user, password string) (cabby.User, error) {
 func (s UserService) UserCollections(ctx context.Context, user string) (cabby.UserCollectionList, error) {
 	resource, action := "UserCollectionList", "read"
 	start := cabby.LogServiceStart(ctx, resource, action)
-	result, err := s.userCollections(user)
+	result, err := s.userCollections(ctx, user)
 	cabby.LogServiceEnd(ctx, resource, action, start)
 	return result, err
 }
 
-func (s UserService) userCollections(user string) (cabby.UserCollectionList, error) {
+func (s UserService) userCollections(ctx context.Context, user string) (cabby.UserCollectionList, error) {
 	sql := `select tuc.collection_id, tuc.can_read, tuc.can_write
 					from
 						taxii_user tu
@@ -243,7 +239,7 @@ func (s UserService) userCollections(user string) (cabby.UserCollectionList, err
 
 	ucl := cabby.UserCollectionList{Email: user, CollectionAccessList: map[cabby.ID]cabby.CollectionAccess{}}
 
-	rows, err := s.DB.Query(sql, args...)
+	rows, err := s.DataStore.query(ctx, sql, args...)
 	if err != nil {
 		logSQLError(sql, args, err)
 		return ucl, err
@@ -260,34 +256,3 @@ func (s UserService) userCollections(user string) (cabby.UserCollectionList, err
 
 	return ucl, nil
 }
-
-/* helpers */
-
-func hash(password string) string {
-	return fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
-}
-
-func validatePassword(password string) (err error) {
-	if len(password) < minPasswordLength {
-		return fmt.Errorf("Password length is too small, minimum length of characters is %d", minPasswordLength)
-	}
-	return
-}
-
-func validateUserPasswordCombo(user cabby.User, password string) error {
-	err := user.Validate()
-	if err != nil {
-		return err
-	}
-	return validatePassword(password)
-}
-
-func validateUserCollection(user string, ca cabby.CollectionAccess) error {
-	if user == "" {
-		return fmt.Errorf("User undefined")
-	}
-	if ca.ID.IsEmpty() {
-		return fmt.Errorf("Invalid collection ID")
-	}
-	return nil
-}
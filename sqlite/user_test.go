@@ -454,60 +454,3 @@ func TestUserServiceUserCollectionsQueryErr(t *testing.T) {
 		t.Error("Got:", err, "Expected an error")
 	}
 }
-
-func TestHash(t *testing.T) {
-	tests := []struct {
-		raw  string
-		hash string
-	}{
-		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
-		{"bloop", "bdf0ff3f50f492bd0fb261caf097829138f472dd0ab3b504fe0f01e8c8225664"},
-	}
-
-	for _, test := range tests {
-		result := hash(test.raw)
-		if result != test.hash {
-			t.Error("Got:", result, "Expected:", test.hash)
-		}
-	}
-}
-
-func TestValidatePassword(t *testing.T) {
-	tests := []struct {
-		password    string
-		expectError bool
-	}{
-		{"", true},
-		{"12345678", false},
-	}
-
-	for _, test := range tests {
-		result := validatePassword(test.password)
-
-		if test.expectError && result == nil {
-			t.Error("Got:", result, "Expected:", test.expectError)
-		}
-	}
-}
-
-func TestValidateUserCollection(t *testing.T) {
-	newID, _ := cabby.NewID()
-
-	tests := []struct {
-		user        string
-		ca          cabby.CollectionAccess
-		expectError bool
-	}{
-		{"", cabby.CollectionAccess{}, true},
-		{"foo", cabby.CollectionAccess{}, true},
-		{"foo", cabby.CollectionAccess{ID: newID}, false},
-	}
-
-	for _, test := range tests {
-		result := validateUserCollection(test.user, test.ca)
-
-		if test.expectError && result == nil {
-			t.Error("Got:", result, "Expected:", test.expectError)
-		}
-	}
-}
@@ -0,0 +1,20 @@
+package cabby
+
+import "context"
+
+// StatusCanceled returns whether a status has been canceled since it was read; a status that can't be read isn't
+func StatusCanceled(ctx context.Context, st Status, ss StatusService) bool {
+	current, err := ss.Status(ctx, st.ID.String())
+	if err != nil {
+		return false
+	}
+	return current.Status == "canceled"
+}
+
+// UpdateStatusCounts counts the successes and failures of a status and saves it; objects that weren't written due to
+// cancellation count as failures but aren't listed
+func UpdateStatusCounts(ctx context.Context, st Status, canceled int64, ss StatusService) {
+	st.FailureCount = canceled + int64(len(st.Failures))
+	st.SuccessCount = st.TotalCount - st.FailureCount
+	ss.UpdateStatus(ctx, st)
+}
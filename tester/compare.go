@@ -25,6 +25,11 @@ func CompareAPIRoot(result, expected cabby.APIRoot) bool {
 	if result.MaxContentLength != expected.MaxContentLength {
 		Error.Println("Got:", result.MaxContentLength, "Expected:", expected.MaxContentLength)
 	}
+	if result.DefaultPageSize != expected.DefaultPageSize || result.MaxPageSize != expected.MaxPageSize {
+		Error.Println("Got:", result.DefaultPageSize, result.MaxPageSize,
+			"Expected:", expected.DefaultPageSize, expected.MaxPageSize)
+		passed = false
+	}
 
 	return passed
 }
@@ -57,6 +62,26 @@ func CompareCollection(result, expected cabby.Collection) bool {
 		Error.Println("Got:", strings.Join(result.MediaTypes, ","), "Expected:", strings.Join(expected.MediaTypes, ","))
 		passed = false
 	}
+	if result.VersionPolicy != expected.VersionPolicy {
+		Error.Println("Got:", result.VersionPolicy, "Expected:", expected.VersionPolicy)
+		passed = false
+	}
+	if result.RetainVersions != expected.RetainVersions {
+		Error.Println("Got:", result.RetainVersions, "Expected:", expected.RetainVersions)
+		passed = false
+	}
+	if result.RetainWindow != expected.RetainWindow {
+		Error.Println("Got:", result.RetainWindow, "Expected:", expected.RetainWindow)
+		passed = false
+	}
+	if result.ExpireBy != expected.ExpireBy {
+		Error.Println("Got:", result.ExpireBy, "Expected:", expected.ExpireBy)
+		passed = false
+	}
+	if result.MaxAge != expected.MaxAge {
+		Error.Println("Got:", result.MaxAge, "Expected:", expected.MaxAge)
+		passed = false
+	}
 
 	return passed
 }
@@ -230,6 +255,11 @@ func compareFailures(result, expected cabby.Status, passed bool) bool {
 		passed = false
 	}
 
+	if len(result.Failures) != len(expected.Failures) {
+		Error.Println("Got:", result.Failures, "Expected:", expected.Failures)
+		return false
+	}
+
 	for i := 0; i < len(result.Failures); i++ {
 		if result.Failures[i] != expected.Failures[i] {
 			Error.Println("Got:", result.Failures[i], "Expected:", expected.Failures[i])
@@ -22,7 +22,7 @@ const (
 	// Port for testing server
 	Port = 1234
 	// StatusID for status tests
-	StatusID = "5abf4004-4f7f-459a-2eea-9c14af7b58abc"
+	StatusID = "5abf4004-4f7f-459a-2eea-9c14af7b58ab"
 	// UserEmail for tests
 	UserEmail = "test@cabby.com"
 	// UserPassword for tests
@@ -83,6 +83,8 @@ var (
 	Objects = []cabby.Object{object()}
 	// Status mock
 	Status = status()
+	// Tombstone mock
+	Tombstone = cabby.Tombstone{ID: ObjectID, Version: objectCreated, DateDeleted: objectCreated}
 	// User mock
 	User = cabby.User{
 		Email:                UserEmail,
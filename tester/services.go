@@ -2,9 +2,9 @@ package tester
 
 import (
 	"context"
+	"time"
 
 	cabby "github.com/pladdy/cabby2"
-	"github.com/pladdy/stones"
 )
 
 /* DataStore */
@@ -174,9 +174,60 @@ func (s DiscoveryService) UpdateDiscovery(ctx context.Context, d cabby.Discovery
 	return s.UpdateDiscoveryFn(ctx, d)
 }
 
+// ManifestIterator is a mock implementation that iterates over Entries; Error is returned by Err, Updated by UpdatedAt and
+// AddedFirst and AddedLast by DateAdded
+type ManifestIterator struct {
+	Entries    []cabby.ManifestEntry
+	Error      error
+	Updated    time.Time
+	AddedFirst time.Time
+	AddedLast  time.Time
+	i          int
+}
+
+// Next is a mock implementation
+func (m *ManifestIterator) Next() bool {
+	if m.Error != nil || m.i >= len(m.Entries) {
+		return false
+	}
+	m.i++
+	return true
+}
+
+// Entry is a mock implementation
+func (m *ManifestIterator) Entry() cabby.ManifestEntry {
+	return m.Entries[m.i-1]
+}
+
+// Err is a mock implementation
+func (m *ManifestIterator) Err() error {
+	return m.Error
+}
+
+// Close is a mock implementation
+func (m *ManifestIterator) Close() error {
+	return nil
+}
+
+// UpdatedAt is a mock implementation
+func (m *ManifestIterator) UpdatedAt() time.Time {
+	return m.Updated
+}
+
+// DateAdded is a mock implementation
+func (m *ManifestIterator) DateAdded() (time.Time, time.Time) {
+	return m.AddedFirst, m.AddedLast
+}
+
 // ManifestService is a mock implementation
 type ManifestService struct {
-	ManifestFn func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error)
+	IterateManifestFn func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error)
+	ManifestFn        func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.Manifest, error)
+}
+
+// IterateManifest is a mock implementation
+func (s ManifestService) IterateManifest(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ManifestIterator, error) {
+	return s.IterateManifestFn(ctx, collectionID, cr, f)
 }
 
 // Manifest is a mock implementation
@@ -184,18 +235,74 @@ func (s ManifestService) Manifest(ctx context.Context, collectionID string, cr *
 	return s.ManifestFn(ctx, collectionID, cr, f)
 }
 
+// ObjectIterator is a mock implementation that iterates over Objects; Error is returned by Err, Updated by UpdatedAt and
+// AddedFirst and AddedLast by DateAdded
+type ObjectIterator struct {
+	Objects    []cabby.Object
+	Error      error
+	Updated    time.Time
+	AddedFirst time.Time
+	AddedLast  time.Time
+	i          int
+}
+
+// Next is a mock implementation
+func (o *ObjectIterator) Next() bool {
+	if o.Error != nil || o.i >= len(o.Objects) {
+		return false
+	}
+	o.i++
+	return true
+}
+
+// Object is a mock implementation
+func (o *ObjectIterator) Object() cabby.Object {
+	return o.Objects[o.i-1]
+}
+
+// Err is a mock implementation
+func (o *ObjectIterator) Err() error {
+	return o.Error
+}
+
+// Close is a mock implementation
+func (o *ObjectIterator) Close() error {
+	return nil
+}
+
+// UpdatedAt is a mock implementation
+func (o *ObjectIterator) UpdatedAt() time.Time {
+	return o.Updated
+}
+
+// DateAdded is a mock implementation
+func (o *ObjectIterator) DateAdded() (time.Time, time.Time) {
+	return o.AddedFirst, o.AddedLast
+}
+
 // ObjectService is a mock implementation
 type ObjectService struct {
 	MaxContentLength int64
-	CreateBundleFn   func(ctx context.Context, b stones.Bundle, collectionID string, s cabby.Status, ss cabby.StatusService)
+	CompactObjectsFn func(ctx context.Context) (int64, error)
+	CreateBundleFn   func(ctx context.Context, objects <-chan []byte, collectionID string, s cabby.Status, ss cabby.StatusService)
 	CreateObjectFn   func(ctx context.Context, object cabby.Object) error
+	DeleteObjectFn   func(ctx context.Context, collectionID, objectID string, f cabby.Filter) (int64, error)
+	IterateObjectsFn func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error)
 	ObjectFn         func(ctx context.Context, collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error)
 	ObjectsFn        func(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) ([]cabby.Object, error)
+	PurgeObjectsFn   func(ctx context.Context) (int64, error)
+	TombstonesFn     func(ctx context.Context, collectionID string, f cabby.Filter) ([]cabby.Tombstone, error)
+	VersionsFn       func(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error)
+}
+
+// CompactObjects is a mock implementation
+func (s ObjectService) CompactObjects(ctx context.Context) (int64, error) {
+	return s.CompactObjectsFn(ctx)
 }
 
 // CreateBundle is a mock implementation
-func (s ObjectService) CreateBundle(ctx context.Context, b stones.Bundle, collectionID string, st cabby.Status, ss cabby.StatusService) {
-	s.CreateBundleFn(ctx, b, collectionID, st, ss)
+func (s ObjectService) CreateBundle(ctx context.Context, objects <-chan []byte, collectionID string, st cabby.Status, ss cabby.StatusService) {
+	s.CreateBundleFn(ctx, objects, collectionID, st, ss)
 }
 
 // CreateObject is a mock implementation
@@ -203,6 +310,16 @@ func (s ObjectService) CreateObject(ctx context.Context, object cabby.Object) er
 	return s.CreateObjectFn(ctx, object)
 }
 
+// DeleteObject is a mock implementation
+func (s ObjectService) DeleteObject(ctx context.Context, collectionID, objectID string, f cabby.Filter) (int64, error) {
+	return s.DeleteObjectFn(ctx, collectionID, objectID, f)
+}
+
+// IterateObjects is a mock implementation
+func (s ObjectService) IterateObjects(ctx context.Context, collectionID string, cr *cabby.Range, f cabby.Filter) (cabby.ObjectIterator, error) {
+	return s.IterateObjectsFn(ctx, collectionID, cr, f)
+}
+
 // Object is a mock implementation
 func (s ObjectService) Object(ctx context.Context, collectionID, objectID string, f cabby.Filter) ([]cabby.Object, error) {
 	return s.ObjectFn(ctx, collectionID, objectID, f)
@@ -213,11 +330,34 @@ func (s ObjectService) Objects(ctx context.Context, collectionID string, cr *cab
 	return s.ObjectsFn(ctx, collectionID, cr, f)
 }
 
+// PurgeObjects is a mock implementation
+func (s ObjectService) PurgeObjects(ctx context.Context) (int64, error) {
+	return s.PurgeObjectsFn(ctx)
+}
+
+// Tombstones is a mock implementation
+func (s ObjectService) Tombstones(ctx context.Context, collectionID string, f cabby.Filter) ([]cabby.Tombstone, error) {
+	return s.TombstonesFn(ctx, collectionID, f)
+}
+
+// Versions is a mock implementation
+func (s ObjectService) Versions(ctx context.Context, collectionID, objectID string, cr *cabby.Range, f cabby.Filter) ([]string, error) {
+	return s.VersionsFn(ctx, collectionID, objectID, cr, f)
+}
+
 // StatusService is a mock implementation
 type StatusService struct {
-	CreateStatusFn func(ctx context.Context, status cabby.Status) error
-	StatusFn       func(ctx context.Context, statusID string) (cabby.Status, error)
-	UpdateStatusFn func(ctx context.Context, status cabby.Status) error
+	CancelStatusFn  func(ctx context.Context, statusID string) error
+	CreateStatusFn  func(ctx context.Context, status cabby.Status) error
+	PurgeStatusesFn func(ctx context.Context, before time.Time) (int64, error)
+	StatusFn        func(ctx context.Context, statusID string) (cabby.Status, error)
+	StatusesFn      func(ctx context.Context, cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error)
+	UpdateStatusFn  func(ctx context.Context, status cabby.Status) error
+}
+
+// CancelStatus is a mock implementation
+func (s StatusService) CancelStatus(ctx context.Context, statusID string) error {
+	return s.CancelStatusFn(ctx, statusID)
 }
 
 // CreateStatus is a mock implementation
@@ -225,11 +365,21 @@ func (s StatusService) CreateStatus(ctx context.Context, status cabby.Status) er
 	return s.CreateStatusFn(ctx, status)
 }
 
+// PurgeStatuses is a mock implementation
+func (s StatusService) PurgeStatuses(ctx context.Context, before time.Time) (int64, error) {
+	return s.PurgeStatusesFn(ctx, before)
+}
+
 // Status is a mock implementation
 func (s StatusService) Status(ctx context.Context, statusID string) (cabby.Status, error) {
 	return s.StatusFn(ctx, statusID)
 }
 
+// Statuses is a mock implementation
+func (s StatusService) Statuses(ctx context.Context, cr *cabby.Range, f cabby.StatusFilter) ([]cabby.Status, error) {
+	return s.StatusesFn(ctx, cr, f)
+}
+
 // UpdateStatus is a mock implementation
 func (s StatusService) UpdateStatus(ctx context.Context, status cabby.Status) error {
 	return s.UpdateStatusFn(ctx, status)
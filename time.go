@@ -0,0 +1,21 @@
+package cabby
+
+import "time"
+
+// LaterTime returns the later of two times
+func LaterTime(t, u time.Time) time.Time {
+	if u.After(t) {
+		return u
+	}
+	return t
+}
+
+// sameMillisecond returns whether a timestamp is the time at millisecond precision, the precision data stores keep;
+// a timestamp that can't be parsed isn't
+func sameMillisecond(timestamp string, t time.Time) bool {
+	m, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return false
+	}
+	return m.Truncate(time.Millisecond).Equal(t.Truncate(time.Millisecond))
+}
@@ -0,0 +1,29 @@
+package cabby
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLaterTime(t *testing.T) {
+	earlier := time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	tests := []struct {
+		t        time.Time
+		u        time.Time
+		expected time.Time
+	}{
+		{time.Time{}, later, later},
+		{earlier, later, later},
+		{later, earlier, later},
+		{earlier, time.Time{}, earlier},
+	}
+
+	for _, test := range tests {
+		result := LaterTime(test.t, test.u)
+		if !result.Equal(test.expected) {
+			t.Error("Got:", result, "Expected:", test.expected)
+		}
+	}
+}
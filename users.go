@@ -0,0 +1,42 @@
+package cabby
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// MinPasswordLength is the fewest characters a user's password can have
+const MinPasswordLength = 8
+
+// HashPassword returns the hash of a password that data stores keep in place of it
+func HashPassword(password string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
+}
+
+// ValidatePassword returns an error if a password is too short
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("Password length is too small, minimum length of characters is %d", MinPasswordLength)
+	}
+	return nil
+}
+
+// ValidateUserPassword returns an error if a user or its password isn't valid
+func ValidateUserPassword(user User, password string) error {
+	err := user.Validate()
+	if err != nil {
+		return err
+	}
+	return ValidatePassword(password)
+}
+
+// ValidateUserCollection returns an error if a user's access to a collection doesn't name the user or collection
+func ValidateUserCollection(user string, ca CollectionAccess) error {
+	if user == "" {
+		return fmt.Errorf("User undefined")
+	}
+	if ca.ID.IsEmpty() {
+		return fmt.Errorf("Invalid collection ID")
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cabby
+
+import "testing"
+
+func TestHashPassword(t *testing.T) {
+	tests := []struct {
+		raw  string
+		hash string
+	}{
+		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"bloop", "bdf0ff3f50f492bd0fb261caf097829138f472dd0ab3b504fe0f01e8c8225664"},
+	}
+
+	for _, test := range tests {
+		result := HashPassword(test.raw)
+		if result != test.hash {
+			t.Error("Got:", result, "Expected:", test.hash)
+		}
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		password    string
+		expectError bool
+	}{
+		{"", true},
+		{"1234567", true},
+		{"12345678", false},
+	}
+
+	for _, test := range tests {
+		result := ValidatePassword(test.password)
+
+		if test.expectError != (result != nil) {
+			t.Error("Got:", result, "Expected error:", test.expectError)
+		}
+	}
+}
+
+func TestValidateUserPassword(t *testing.T) {
+	tests := []struct {
+		user        User
+		password    string
+		expectError bool
+	}{
+		{User{Email: "foo"}, "12345678", true},
+		{User{Email: "no@no.no"}, "", true},
+		{User{Email: "no@no.no"}, "12345678", false},
+	}
+
+	for _, test := range tests {
+		result := ValidateUserPassword(test.user, test.password)
+
+		if test.expectError != (result != nil) {
+			t.Error("Got:", result, "Expected error:", test.expectError)
+		}
+	}
+}
+
+func TestValidateUserCollection(t *testing.T) {
+	newID, _ := NewID()
+
+	tests := []struct {
+		user        string
+		ca          CollectionAccess
+		expectError bool
+	}{
+		{"", CollectionAccess{}, true},
+		{"foo", CollectionAccess{}, true},
+		{"foo", CollectionAccess{ID: newID}, false},
+	}
+
+	for _, test := range tests {
+		result := ValidateUserCollection(test.user, test.ca)
+
+		if test.expectError != (result != nil) {
+			t.Error("Got:", result, "Expected error:", test.expectError)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package cabby
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Version policies decide what's done with a posted object version that's already in a collection or that's older
+// than the latest version there; a collection without a policy rejects them
+const (
+	VersionPolicyReject          = "reject"
+	VersionPolicyIgnoreIdentical = "ignore-identical"
+	VersionPolicyOverwrite       = "overwrite"
+)
+
+// ValidVersionPolicy returns whether a collection can use the policy
+func ValidVersionPolicy(policy string) bool {
+	switch policy {
+	case "", VersionPolicyReject, VersionPolicyIgnoreIdentical, VersionPolicyOverwrite:
+		return true
+	}
+	return false
+}
+
+// VersionAction is what a data store does with a posted object version
+type VersionAction int
+
+const (
+	// WriteVersion adds the version to the collection
+	WriteVersion VersionAction = iota
+	// IgnoreVersion leaves the collection as is; the content is already there
+	IgnoreVersion
+	// OverwriteVersion replaces the stored version with the posted one
+	OverwriteVersion
+)
+
+// ResolveVersion decides what's done with an object version given the versions of the object already in the
+// collection; the error says why a version is rejected.
+//
+//   - reject: versions that exist or are older than the latest version are rejected
+//   - ignore-identical: versions with the same content as the stored version, or as the latest version apart from
+//     modified, are ignored; versions that exist with different content are rejected
+//   - overwrite: versions that exist are replaced and older versions are added
+func ResolveVersion(policy string, o Object, stored []Object) (VersionAction, error) {
+	var latest *Object
+
+	for i := range stored {
+		if sameVersion(stored[i].Modified, o.Modified) {
+			return resolveExistingVersion(policy, o, stored[i])
+		}
+		if latest == nil || laterVersion(stored[i].Modified, latest.Modified) {
+			latest = &stored[i]
+		}
+	}
+
+	if latest == nil {
+		return WriteVersion, nil
+	}
+
+	switch policy {
+	case VersionPolicyIgnoreIdentical:
+		if sameContent(o.Object, latest.Object, "modified") {
+			return IgnoreVersion, nil
+		}
+	case VersionPolicyOverwrite:
+	default:
+		if laterVersion(latest.Modified, o.Modified) {
+			return WriteVersion, fmt.Errorf(
+				"Object %s version %s is older than the latest version %s", o.ID, o.Modified, latest.Modified)
+		}
+	}
+	return WriteVersion, nil
+}
+
+func resolveExistingVersion(policy string, o, existing Object) (VersionAction, error) {
+	identical := sameContent(o.Object, existing.Object)
+
+	switch policy {
+	case VersionPolicyIgnoreIdentical:
+		if identical {
+			return IgnoreVersion, nil
+		}
+		return WriteVersion, fmt.Errorf("Object %s version %s already exists with different content", o.ID, o.Modified)
+	case VersionPolicyOverwrite:
+		if identical {
+			return IgnoreVersion, nil
+		}
+		return OverwriteVersion, nil
+	}
+	return WriteVersion, fmt.Errorf("Object %s version %s already exists", o.ID, o.Modified)
+}
+
+// ExpiredVersions returns the versions of an object the collection doesn't retain, given the modified times of all
+// its versions.  The first and latest versions are always kept so match[version]=first and last don't change, and
+// versions with a modified time that doesn't parse are kept under a window.
+func (c Collection) ExpiredVersions(versions []string, now time.Time) []string {
+	if c.RetainVersions <= 0 && c.RetainWindow <= 0 {
+		return nil
+	}
+
+	sorted := append([]string{}, versions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return laterVersion(sorted[j], sorted[i]) })
+
+	expired := []string{}
+	for i, version := range sorted {
+		newer := len(sorted) - 1 - i
+		if i == 0 || newer == 0 {
+			continue
+		}
+
+		if c.RetainVersions > 0 && newer < c.RetainVersions {
+			continue
+		}
+
+		if c.RetainWindow > 0 {
+			modified, err := time.Parse(time.RFC3339Nano, version)
+			if err != nil || !modified.Before(now.Add(-c.RetainWindow)) {
+				continue
+			}
+		}
+
+		expired = append(expired, version)
+	}
+	return expired
+}
+
+// SortVersions sorts the modified times of an object's versions, earliest first
+func SortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool { return laterVersion(versions[j], versions[i]) })
+}
+
+// laterVersion returns whether modified time a is after b; times that don't parse are compared as strings
+func laterVersion(a, b string) bool {
+	at, errA := time.Parse(time.RFC3339Nano, a)
+	bt, errB := time.Parse(time.RFC3339Nano, b)
+
+	if errA != nil || errB != nil {
+		return a > b
+	}
+	return at.After(bt)
+}
+
+// sameVersion returns whether modified times a and b are the same time, however they're formatted
+func sameVersion(a, b string) bool {
+	at, errA := time.Parse(time.RFC3339Nano, a)
+	bt, errB := time.Parse(time.RFC3339Nano, b)
+
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return at.Equal(bt)
+}
+
+// sameContent compares objects as JSON so formatting and property order don't matter; the ignored properties
+// aren't compared
+func sameContent(a, b []byte, ignore ...string) bool {
+	var am, bm map[string]interface{}
+
+	if json.Unmarshal(a, &am) != nil || json.Unmarshal(b, &bm) != nil {
+		return bytes.Equal(a, b)
+	}
+
+	for _, property := range ignore {
+		delete(am, property)
+		delete(bm, property)
+	}
+	return reflect.DeepEqual(am, bm)
+}
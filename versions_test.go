@@ -0,0 +1,172 @@
+package cabby
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func versionOf(modified, name string) Object {
+	return Object{
+		Type:     "malware",
+		Modified: modified,
+		Object: []byte(fmt.Sprintf(
+			`{"type": "malware", "id": "malware--1", "modified": "%s", "name": "%s"}`, modified, name))}
+}
+
+func TestValidVersionPolicy(t *testing.T) {
+	tests := []struct {
+		policy   string
+		expected bool
+	}{
+		{"", true},
+		{VersionPolicyReject, true},
+		{VersionPolicyIgnoreIdentical, true},
+		{VersionPolicyOverwrite, true},
+		{"keep", false},
+	}
+
+	for _, test := range tests {
+		result := ValidVersionPolicy(test.policy)
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "Policy:", test.policy)
+		}
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	older, latest := "2016-04-06T20:03:48.000Z", "2017-04-06T20:03:48.000Z"
+	stored := []Object{versionOf(older, "poison"), versionOf(latest, "ivy")}
+
+	tests := []struct {
+		policy      string
+		object      Object
+		expected    VersionAction
+		expectError bool
+	}{
+		// nothing stored
+		{VersionPolicyReject, versionOf(latest, "ivy"), WriteVersion, false},
+		// newer versions are added
+		{"", versionOf("2018-04-06T20:03:48.000Z", "oak"), WriteVersion, false},
+		{VersionPolicyReject, versionOf("2018-04-06T20:03:48.000Z", "oak"), WriteVersion, false},
+		{VersionPolicyIgnoreIdentical, versionOf("2018-04-06T20:03:48.000Z", "oak"), WriteVersion, false},
+		{VersionPolicyOverwrite, versionOf("2018-04-06T20:03:48.000Z", "oak"), WriteVersion, false},
+		// existing versions
+		{"", versionOf(latest, "ivy"), WriteVersion, true},
+		{VersionPolicyReject, versionOf(latest, "ivy"), WriteVersion, true},
+		{VersionPolicyIgnoreIdentical, versionOf(latest, "ivy"), IgnoreVersion, false},
+		{VersionPolicyIgnoreIdentical, versionOf(latest, "oak"), WriteVersion, true},
+		{VersionPolicyOverwrite, versionOf(latest, "ivy"), IgnoreVersion, false},
+		{VersionPolicyOverwrite, versionOf(latest, "oak"), OverwriteVersion, false},
+		{VersionPolicyReject, versionOf("2017-04-06T20:03:48Z", "ivy"), WriteVersion, true},
+		// out of order versions
+		{VersionPolicyReject, versionOf("2016-05-06T20:03:48.000Z", "oak"), WriteVersion, true},
+		{VersionPolicyIgnoreIdentical, versionOf("2016-05-06T20:03:48.000Z", "oak"), WriteVersion, false},
+		{VersionPolicyOverwrite, versionOf("2016-05-06T20:03:48.000Z", "oak"), WriteVersion, false},
+		// same content as the latest version under a new modified time
+		{VersionPolicyReject, versionOf("2018-04-06T20:03:48.000Z", "ivy"), WriteVersion, false},
+		{VersionPolicyIgnoreIdentical, versionOf("2018-04-06T20:03:48.000Z", "ivy"), IgnoreVersion, false},
+		{VersionPolicyOverwrite, versionOf("2018-04-06T20:03:48.000Z", "ivy"), WriteVersion, false},
+	}
+
+	for i, test := range tests {
+		versions := stored
+		if i == 0 {
+			versions = nil
+		}
+
+		result, err := ResolveVersion(test.policy, test.object, versions)
+		if test.expectError {
+			if err == nil {
+				t.Error("Got:", err, "Expected an error", "Test:", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Error("Got:", err, "Expected no error", "Test:", i)
+		}
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "Test:", i)
+		}
+	}
+}
+
+func TestSameContent(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		ignore   []string
+		expected bool
+	}{
+		{`{"name": "ivy", "modified": "1"}`, `{"modified":"1","name":"ivy"}`, nil, true},
+		{`{"name": "ivy", "modified": "1"}`, `{"name": "ivy", "modified": "2"}`, nil, false},
+		{`{"name": "ivy", "modified": "1"}`, `{"name": "ivy", "modified": "2"}`, []string{"modified"}, true},
+		{`{"name": "ivy"}`, `{"name": "oak"}`, nil, false},
+		{`not json`, `not json`, nil, true},
+	}
+
+	for _, test := range tests {
+		result := sameContent([]byte(test.a), []byte(test.b), test.ignore...)
+		if result != test.expected {
+			t.Error("Got:", result, "Expected:", test.expected, "A:", test.a, "B:", test.b)
+		}
+	}
+}
+
+func TestCollectionExpiredVersions(t *testing.T) {
+	now := time.Date(2018, 4, 6, 20, 3, 48, 0, time.UTC)
+	versions := []string{
+		"2018-04-06T18:03:48.000Z",
+		"2018-04-01T20:03:48.000Z",
+		"2018-04-06T19:03:48.000Z",
+		"2018-03-01T20:03:48.000Z",
+		"2018-04-05T20:03:48.000Z",
+	}
+
+	tests := []struct {
+		collection Collection
+		expected   []string
+	}{
+		{Collection{}, nil},
+		{Collection{RetainVersions: 2}, []string{"2018-04-01T20:03:48.000Z", "2018-04-05T20:03:48.000Z"}},
+		{Collection{RetainVersions: 3}, []string{"2018-04-01T20:03:48.000Z"}},
+		{Collection{RetainVersions: 1}, []string{
+			"2018-04-01T20:03:48.000Z", "2018-04-05T20:03:48.000Z", "2018-04-06T18:03:48.000Z"}},
+		{Collection{RetainVersions: 10}, []string{}},
+		{Collection{RetainWindow: 3 * time.Hour}, []string{"2018-04-01T20:03:48.000Z", "2018-04-05T20:03:48.000Z"}},
+		{Collection{RetainWindow: 30 * time.Minute}, []string{
+			"2018-04-01T20:03:48.000Z", "2018-04-05T20:03:48.000Z", "2018-04-06T18:03:48.000Z"}},
+	}
+
+	for i, test := range tests {
+		result := test.collection.ExpiredVersions(versions, now)
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Error("Got:", result, "Expected:", test.expected, "Test:", i)
+		}
+	}
+}
+
+func TestCollectionExpiredVersionsKeepsFirstAndLast(t *testing.T) {
+	c := Collection{RetainVersions: 1}
+
+	result := c.ExpiredVersions([]string{"2018-04-06T20:03:48.000Z", "2016-04-06T20:03:48.000Z"}, time.Now())
+	if len(result) != 0 {
+		t.Error("Got:", result, "Expected no expired versions")
+	}
+
+	result = c.ExpiredVersions([]string{"2018-04-06T20:03:48.000Z"}, time.Now())
+	if len(result) != 0 {
+		t.Error("Got:", result, "Expected no expired versions")
+	}
+}
+
+func TestSortVersions(t *testing.T) {
+	versions := []string{"2018-01-02T00:00:00Z", "2018-01-01T00:00:00.000Z", "2018-01-01T12:00:00.5Z"}
+	expected := []string{"2018-01-01T00:00:00.000Z", "2018-01-01T12:00:00.5Z", "2018-01-02T00:00:00Z"}
+
+	SortVersions(versions)
+	if !reflect.DeepEqual(versions, expected) {
+		t.Error("Got:", versions, "Expected:", expected)
+	}
+}